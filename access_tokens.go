@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+const revokedAccessTokenPurgeInterval = time.Hour
+
+var errAccessTokenRevoked = errors.New("access token has been revoked")
+
+// parseJWT checks an access token's signature and expiry like auth.ParseJWT,
+// and also that it hasn't been revoked since it was issued, on its own or
+// by its user revoking every session
+func (cfg *apiConfig) parseJWT(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := auth.ParseJWT(token, cfg.config.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := cfg.db.GetAccessTokenStatus(ctx, database.GetAccessTokenStatusParams{
+		Jti:    claims.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status.Revoked || claims.TokenVersion != status.TokenVersion {
+		return nil, errAccessTokenRevoked
+	}
+	return claims, nil
+}
+
+// purgeExpiredRevokedAccessTokens forgets revocations for tokens that have
+// since expired on their own
+func (cfg *apiConfig) purgeExpiredRevokedAccessTokens(ctx context.Context) error {
+	_, err := cfg.db.DeleteExpiredRevokedAccessTokens(ctx)
+	return err
+}
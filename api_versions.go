@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The API is served under /api/v1, /api/v2 and so on. The original
+// unversioned /api paths stay as aliases, answered by the version the client
+// names in the API-Version header, or by defaultAPIVersion so existing
+// clients keep working.
+const (
+	apiVersionHeader  = "API-Version"
+	defaultAPIVersion = 1
+)
+
+// apiVersion is the route table of one version of the API. Patterns are
+// relative to the version's prefix, like "GET /chirps/{chirpID}".
+type apiVersion struct {
+	version int
+	// patterns keeps registration order, so routes are mounted the same
+	// way every time
+	patterns []string
+	routes   map[string]http.Handler
+}
+
+func newAPIVersion(version int) *apiVersion {
+	return &apiVersion{version: version, routes: map[string]http.Handler{}}
+}
+
+// Handle registers handler for pattern, replacing the one inherited from
+// the previous version if there is one
+func (v *apiVersion) Handle(pattern string, handler http.Handler) {
+	if _, ok := v.routes[pattern]; !ok {
+		v.patterns = append(v.patterns, pattern)
+	}
+	v.routes[pattern] = handler
+}
+
+func (v *apiVersion) HandleFunc(pattern string, handler http.HandlerFunc) {
+	v.Handle(pattern, handler)
+}
+
+// next starts the following version with every route of this one, so a
+// breaking change only has to re-register the routes it changes
+func (v *apiVersion) next() *apiVersion {
+	next := newAPIVersion(v.version + 1)
+	for _, pattern := range v.patterns {
+		next.Handle(pattern, v.routes[pattern])
+	}
+	return next
+}
+
+// mountAPI registers every version's routes under its prefix, and the
+// unversioned aliases of all of them
+func mountAPI(mux *http.ServeMux, versions ...*apiVersion) {
+	byNumber := map[int]*apiVersion{}
+	var aliases []string
+	seen := map[string]bool{}
+	for _, v := range versions {
+		byNumber[v.version] = v
+		for _, pattern := range v.patterns {
+			method, path, _ := strings.Cut(pattern, " ")
+			mux.Handle(fmt.Sprintf("%s /api/v%d%s", method, v.version, path), withAPIVersion(v.version, v.routes[pattern]))
+			if !seen[pattern] {
+				seen[pattern] = true
+				aliases = append(aliases, pattern)
+			}
+		}
+	}
+
+	for _, pattern := range aliases {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.Handle(method+" /api"+path, negotiateAPIVersion(pattern, byNumber))
+	}
+}
+
+func withAPIVersion(version int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(apiVersionHeader, strconv.Itoa(version))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// negotiateAPIVersion serves an unversioned path with the route of the
+// requested version. Versions can be asked for as "2" or "v2".
+func negotiateAPIVersion(pattern string, versions map[int]*apiVersion) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", apiVersionHeader)
+
+		version := defaultAPIVersion
+		if requested := r.Header.Get(apiVersionHeader); requested != "" {
+			var err error
+			version, err = strconv.Atoi(strings.TrimPrefix(strings.ToLower(requested), "v"))
+			if err != nil || versions[version] == nil {
+				respondWithError(w, 400, fmt.Sprintf("Unsupported API version %q", requested))
+				return
+			}
+		}
+
+		handler, ok := versions[version].routes[pattern]
+		if !ok {
+			respondWithError(w, 404, fmt.Sprintf("Not available in API version %d", version))
+			return
+		}
+		withAPIVersion(version, handler).ServeHTTP(w, r)
+	})
+}
+
+var apiVersionPrefix = regexp.MustCompile(`/api/v[0-9]+/`)
+
+// unversionedRoute maps a path or mux pattern under /api/vN to its
+// unversioned alias, like "GET /api/v1/chirps" to "GET /api/chirps", so
+// per-route rules apply to every version
+func unversionedRoute(route string) string {
+	if loc := apiVersionPrefix.FindStringIndex(route); loc != nil {
+		return route[:loc[0]] + "/api/" + route[loc[1]:]
+	}
+	return route
+}
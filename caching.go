@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/cache"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// cacheKeyPrefix namespaces Chirpy's keys on a shared cache server
+	cacheKeyPrefix = "chirpy:"
+	// Cached copies are dropped on writes; the TTLs bound how stale view
+	// counts and anything a missed invalidation leaves behind can get
+	chirpCacheTTL   = time.Minute
+	profileCacheTTL = 5 * time.Minute
+	// A user's For You ranking is also what keeps its pages consistent, so
+	// it isn't dropped when new chirps arrive; they show up once it expires
+	forYouCacheTTL = 2 * time.Minute
+)
+
+func chirpCacheKey(id uuid.UUID) string {
+	return "chirp:" + id.String()
+}
+
+func profileCacheKey(handle string) string {
+	return "profile:" + handle
+}
+
+func forYouCacheKey(userID uuid.UUID) string {
+	return "for-you:" + userID.String()
+}
+
+// cached returns the value under key, calling load and storing its result
+// on a miss. Cache failures are logged and fall back to load, so an outage
+// only costs speed.
+func cached[T any](ctx context.Context, c cache.Cache, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+
+	data, ok, err := c.Get(ctx, key)
+	if err != nil {
+		log.Printf("Cache read of %s failed: %v", key, err)
+	}
+	if ok {
+		var value T
+		if json.Unmarshal(data, &value) == nil {
+			return value, nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	data, err = json.Marshal(value)
+	if err == nil {
+		err = c.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		log.Printf("Cache write of %s failed: %v", key, err)
+	}
+	return value, nil
+}
+
+// invalidate drops cached copies after a write. A failure is logged, and
+// the copy expires with its TTL.
+func (cfg *apiConfig) invalidate(ctx context.Context, keys ...string) {
+	if cfg.cache == nil || len(keys) == 0 {
+		return
+	}
+	err := cfg.cache.Delete(ctx, keys...)
+	if err != nil {
+		log.Printf("Cache invalidation of %v failed: %v", keys, err)
+	}
+}
+
+// getChirp is GetChirpByID through the cache, for reads on behalf of
+// viewerID that can live with a view count up to chirpCacheTTL old. The
+// cached copy is shared by every viewer, so whether its author is hidden
+// from this one is checked on each read, as sql.ErrNoRows.
+func (cfg *apiConfig) getChirp(ctx context.Context, id uuid.UUID, viewerID uuid.NullUUID) (database.Chirp, error) {
+	dbChirp, err := cached(ctx, cfg.cache, chirpCacheKey(id), chirpCacheTTL, func() (database.Chirp, error) {
+		return cfg.db.GetChirpByID(ctx, id)
+	})
+	if err != nil {
+		return dbChirp, err
+	}
+	hidden, err := cfg.db.IsChirpAuthorHidden(ctx, database.IsChirpAuthorHiddenParams{
+		AuthorID: dbChirp.UserID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if hidden {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return dbChirp, nil
+}
+
+// invalidateChirps drops the cached copies of chirps that changed, skipping
+// uuid.Nil so an absent parent can be passed as is
+func (cfg *apiConfig) invalidateChirps(ctx context.Context, ids ...uuid.UUID) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != uuid.Nil {
+			keys = append(keys, chirpCacheKey(id))
+		}
+	}
+	cfg.invalidate(ctx, keys...)
+}
+
+// getProfile is the public profile of the user with handle, through the
+// cache
+func (cfg *apiConfig) getProfile(ctx context.Context, handle string) (PublicUser, error) {
+	return cached(ctx, cfg.cache, profileCacheKey(handle), profileCacheTTL, func() (PublicUser, error) {
+		dbUser, err := cfg.db.GetUserByHandle(ctx, optionalString(handle))
+		if err != nil {
+			return PublicUser{}, err
+		}
+		return publicUsersFromDB([]database.User{dbUser})[0], nil
+	})
+}
+
+// invalidateProfiles drops the cached profiles under handles, skipping
+// users without one
+func (cfg *apiConfig) invalidateProfiles(ctx context.Context, handles ...sql.NullString) {
+	keys := make([]string, 0, len(handles))
+	for _, handle := range handles {
+		if handle.Valid {
+			keys = append(keys, profileCacheKey(handle.String))
+		}
+	}
+	cfg.invalidate(ctx, keys...)
+}
+
+// invalidateForYou drops the cached For You rankings of users whose follows,
+// mutes, blocks or settings changed
+func (cfg *apiConfig) invalidateForYou(ctx context.Context, userIDs ...uuid.UUID) {
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, forYouCacheKey(userID))
+	}
+	cfg.invalidate(ctx, keys...)
+}
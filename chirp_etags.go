@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// chirpsETag is a weak ETag for a response listing chirps, changing when
+// any of them is added, removed, edited, liked or replied to. View counts
+// are left out, since every read bumps them and they don't need to be
+// current. extra covers anything else in the response, like a cursor.
+func chirpsETag(chirps []Chirp, extra ...string) string {
+	hash := sha256.New()
+	for _, chirp := range chirps {
+		hash.Write(chirp.ID[:])
+		binary.Write(hash, binary.BigEndian, chirp.UpdatedAt.UnixNano())
+		binary.Write(hash, binary.BigEndian, chirp.LikeCount)
+		binary.Write(hash, binary.BigEndian, chirp.ReplyCount)
+	}
+	for _, value := range extra {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// respondIfNotModified sets etag on the response and answers 304 if the
+// client already has it, reporting whether it did. Clients are asked to
+// revalidate rather than reuse responses blindly.
+func respondIfNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !etagMatches(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(304)
+	return true
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison GET requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// chirpViewFlushInterval is how often buffered views are written out. They
+// are flushed once more on shutdown; views are only lost if the process is
+// killed, which is acceptable for a counter.
+const chirpViewFlushInterval = 5 * time.Second
+
+// recordChirpViews counts a view of every chirp about to be shown
+func (cfg *apiConfig) recordChirpViews(chirps []Chirp) {
+	for _, chirp := range chirps {
+		cfg.chirpViews.Add(chirp.ID)
+	}
+}
+
+// flushChirpViews writes buffered views to the database in a single batch,
+// keeping them for the next run if the write fails
+func (cfg *apiConfig) flushChirpViews(ctx context.Context) error {
+	pending := cfg.chirpViews.Drain()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	params := database.AddChirpViewsParams{}
+	for id, count := range pending {
+		params.Ids = append(params.Ids, id)
+		params.Counts = append(params.Counts, count)
+	}
+	err := cfg.db.AddChirpViews(ctx, params)
+	if err != nil {
+		cfg.chirpViews.Restore(pending)
+		return err
+	}
+	return nil
+}
+
+type ChirpStats struct {
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	ViewCount  int64     `json:"view_count"`
+	LikeCount  int32     `json:"like_count"`
+	ReplyCount int32     `json:"reply_count"`
+}
+
+// handlerGetChirpStats reports engagement for a chirp to its author
+func (cfg *apiConfig) handlerGetChirpStats(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if dbChirp.UserID != userID {
+		respondWithError(w, 403, "You can only view stats for your own chirps")
+		return
+	}
+
+	respondWithJSON(w, 200, ChirpStats{
+		ChirpID:    dbChirp.ID,
+		ViewCount:  dbChirp.ViewCount,
+		LikeCount:  dbChirp.LikeCount,
+		ReplyCount: dbChirp.ReplyCount,
+	})
+}
+
+type ChirpAnalytics struct {
+	ChirpCount int64 `json:"chirp_count"`
+	ViewCount  int64 `json:"view_count"`
+	LikeCount  int64 `json:"like_count"`
+	ReplyCount int64 `json:"reply_count"`
+}
+
+// handlerGetMyAnalytics totals the engagement on all of the user's
+// published chirps
+func (cfg *apiConfig) handlerGetMyAnalytics(w http.ResponseWriter, r *http.Request) {
+	totals, err := cfg.db.GetChirpTotalsForUser(r.Context(), authUserID(r))
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve analytics")
+		return
+	}
+
+	respondWithJSON(w, 200, ChirpAnalytics{
+		ChirpCount: totals.ChirpCount,
+		ViewCount:  totals.ViewCount,
+		LikeCount:  totals.LikeCount,
+		ReplyCount: totals.ReplyCount,
+	})
+}
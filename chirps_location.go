@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/geo"
+	"github.com/google/uuid"
+)
+
+const (
+	nearbyDefaultRadius = 1000.0
+	nearbyMaxRadius     = 50000.0
+	nearbyMaxResults    = 100
+	// nearbyCandidateLimit bounds how many chirps are read from the covering
+	// cells before filtering by exact distance
+	nearbyCandidateLimit = 1000
+	placeMaxLength       = 100
+)
+
+var errInvalidLocation = errors.New("lat and lon must be given together and be valid coordinates")
+
+type ChirpLocation struct {
+	Latitude  *float64 `json:"lat,omitempty"`
+	Longitude *float64 `json:"lon,omitempty"`
+	Place     string   `json:"place,omitempty"`
+}
+
+// chirpLocation holds the location columns stored on a chirp
+type chirpLocation struct {
+	Latitude  sql.NullFloat64
+	Longitude sql.NullFloat64
+	Geohash   sql.NullString
+	Place     sql.NullString
+}
+
+// chirpLocationFromDB returns the public location of a chirp, or nil if it
+// has none
+func chirpLocationFromDB(dbChirp database.Chirp) *ChirpLocation {
+	if !dbChirp.Latitude.Valid && !dbChirp.Place.Valid {
+		return nil
+	}
+	location := &ChirpLocation{Place: dbChirp.Place.String}
+	if dbChirp.Latitude.Valid && dbChirp.Longitude.Valid {
+		location.Latitude = &dbChirp.Latitude.Float64
+		location.Longitude = &dbChirp.Longitude.Float64
+	}
+	return location
+}
+
+// resolveChirpLocation validates the location sent with a new chirp. Nothing
+// is attached unless the author has opted in to sharing their location.
+func (cfg *apiConfig) resolveChirpLocation(ctx context.Context, userID uuid.UUID, lat, lon *float64, place string) (chirpLocation, error) {
+	if (lat == nil) != (lon == nil) || len(place) > placeMaxLength {
+		return chirpLocation{}, errInvalidLocation
+	}
+	if lat != nil {
+		err := geo.Validate(*lat, *lon)
+		if err != nil {
+			return chirpLocation{}, errInvalidLocation
+		}
+	}
+	if lat == nil && place == "" {
+		return chirpLocation{}, nil
+	}
+
+	dbUser, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return chirpLocation{}, err
+	}
+	if !dbUser.ShareLocation {
+		return chirpLocation{}, nil
+	}
+
+	location := chirpLocation{Place: optionalString(place)}
+	if lat != nil {
+		location.Latitude = sql.NullFloat64{Float64: *lat, Valid: true}
+		location.Longitude = sql.NullFloat64{Float64: *lon, Valid: true}
+		location.Geohash = optionalString(geo.Encode(*lat, *lon, geo.Precision))
+	}
+	return location, nil
+}
+
+func (cfg *apiConfig) handlerGetNearbyChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil || geo.Validate(lat, lon) != nil {
+		respondWithError(w, 400, "lat and lon must be valid coordinates")
+		return
+	}
+
+	radius := nearbyDefaultRadius
+	if value := query.Get("radius"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 || parsed > nearbyMaxRadius {
+			respondWithError(w, 400, "radius must be between 1 and 50000 meters")
+			return
+		}
+		radius = parsed
+	}
+
+	dbChirps, err := cfg.db.GetChirpsInCells(r.Context(), database.GetChirpsInCellsParams{
+		Prefixes: geoPrefixPatterns(geo.CoveringCells(lat, lon, radius)),
+		ViewerID: cfg.viewerID(r),
+		Limit:    nearbyCandidateLimit,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve chirps")
+		return
+	}
+
+	// Cells overshoot the circle, so filter by exact distance, nearest first
+	type candidate struct {
+		chirp    database.Chirp
+		distance float64
+	}
+	candidates := []candidate{}
+	for _, dbChirp := range dbChirps {
+		distance := geo.Distance(lat, lon, dbChirp.Latitude.Float64, dbChirp.Longitude.Float64)
+		if distance <= radius {
+			candidates = append(candidates, candidate{chirp: dbChirp, distance: distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	if len(candidates) > nearbyMaxResults {
+		candidates = candidates[:nearbyMaxResults]
+	}
+
+	chirps := []Chirp{}
+	for _, c := range candidates {
+		chirps = append(chirps, chirpFromDB(c.chirp))
+	}
+
+	respondWithJSON(w, 200, chirps)
+}
+
+// geoPrefixPatterns turns geohash cells into LIKE patterns
+func geoPrefixPatterns(cells []string) []string {
+	patterns := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		patterns = append(patterns, cell+"%")
+	}
+	return patterns
+}
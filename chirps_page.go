@@ -0,0 +1,156 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	chirpsPageDefaultLimit = 50
+	chirpsPageMaxLimit     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor is the position of the last row on a page, by the timestamp
+// and ID it's ordered on. Clients see it only as an opaque token.
+type pageCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func encodeCursor(cursor pageCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + cursor.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+	createdAtStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return pageCursor{}, errInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtStr)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return pageCursor{}, errInvalidCursor
+	}
+	return pageCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// ChirpsPage is one page of a cursor-paginated chirp listing. NextCursor is
+// empty on the last page.
+type ChirpsPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// parsePageParams reads the optional limit and cursor query parameters,
+// writing the error response itself when it returns false
+func parsePageParams(w http.ResponseWriter, r *http.Request) (int, *pageCursor, bool) {
+	limit, ok := parsePageLimit(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	var cursor *pageCursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		decoded, err := decodeCursor(token)
+		if err != nil {
+			respondWithError(w, 400, "Invalid cursor")
+			return 0, nil, false
+		}
+		cursor = &decoded
+	}
+	return limit, cursor, true
+}
+
+// parsePageLimit reads the optional limit query parameter, writing the
+// error response itself when it returns false
+func parsePageLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limit := chirpsPageDefaultLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > chirpsPageMaxLimit {
+			respondWithError(w, 400, "limit must be between 1 and 100")
+			return 0, false
+		}
+		limit = parsed
+	}
+	return limit, true
+}
+
+// pageRows trims rows fetched with limit+1 to the page, using the extra row
+// only to learn whether another page follows. The cursor for it is taken
+// from the page's last row, and is empty on the last page.
+func pageRows[T any](rows []T, limit int, cursorOf func(T) pageCursor) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	rows = rows[:limit]
+	return rows, encodeCursor(cursorOf(rows[limit-1]))
+}
+
+// chirpsPageFromDB builds a page from rows fetched with limit+1, as pageRows
+// trims them
+func chirpsPageFromDB(dbChirps []database.Chirp, limit int) ChirpsPage {
+	dbChirps, nextCursor := pageRows(dbChirps, limit, func(last database.Chirp) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := ChirpsPage{Chirps: []Chirp{}, NextCursor: nextCursor}
+	for _, dbChirp := range dbChirps {
+		page.Chirps = append(page.Chirps, chirpFromDB(dbChirp))
+	}
+	return page
+}
+
+// respondWithChirpsPage serves one page of chirps in the requested order
+// along with the cursor for the next page
+func (cfg *apiConfig) respondWithChirpsPage(w http.ResponseWriter, r *http.Request, authorID uuid.NullUUID, sortOrder string) {
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	var dbChirps []database.Chirp
+	var err error
+	if sortOrder == "desc" {
+		params := database.GetChirpsPageDescParams{AuthorID: authorID, ViewerID: cfg.viewerID(r), Limit: int32(limit + 1)}
+		if cursor != nil {
+			params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+			params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		}
+		dbChirps, err = cfg.db.GetChirpsPageDesc(r.Context(), params)
+	} else {
+		params := database.GetChirpsPageParams{AuthorID: authorID, ViewerID: cfg.viewerID(r), Limit: int32(limit + 1)}
+		if cursor != nil {
+			params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+			params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		}
+		dbChirps, err = cfg.db.GetChirpsPage(r.Context(), params)
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve chirps")
+		return
+	}
+
+	page := chirpsPageFromDB(dbChirps, limit)
+	if respondIfNotModified(w, r, chirpsETag(page.Chirps, page.NextCursor)) {
+		return
+	}
+	respondWithJSON(w, 200, page)
+}
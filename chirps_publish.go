@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+)
+
+// chirpPublishInterval is how often pending chirps past their undo-send
+// window are released
+const chirpPublishInterval = time.Second
+
+// publishDueChirps makes every pending chirp whose undo-send window has
+// passed visible in feeds and announces it to stream clients and webhooks
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) error {
+	published, err := cfg.db.PublishDueChirps(ctx)
+	if err != nil {
+		return err
+	}
+	for _, row := range published {
+		// The pending copy and a parent's reply count are now stale
+		cfg.invalidateChirps(ctx, row.ID, row.ParentChirpID.UUID)
+		cfg.publishChirp(ctx, database.Chirp(row))
+		cfg.emitWebhook(ctx, row.UserID, webhooks.EventChirpCreated, chirpFromDB(database.Chirp(row)))
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/sqlite"
+	"github.com/Utkarsh736/chirpy/internal/store"
+)
+
+// command is one of the binary's subcommands
+type command struct {
+	usage   string
+	summary string
+	run     func(args []string)
+}
+
+// commands are the subcommands by name. Filled in by init, since help
+// lists them.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve":        {"serve", "run the HTTP server (the default)", serve},
+		"migrate":      {"migrate", "apply pending database migrations", runMigrate},
+		"seed":         {"seed [-users n] [-follows n] [-chirps n]", "fill a dev database with fake users, follows and chirps", runSeed},
+		"create-admin": {"create-admin <email>", "create an admin account with a generated password", runCreateAdmin},
+		"promote":      {"promote [-role role] <email>", "give an existing user a role, admin by default", runPromote},
+		"purge-tokens": {"purge-tokens", "delete expired and revoked tokens", runPurgeTokens},
+		"help":         {"help", "show this help", func([]string) { printUsage() }},
+	}
+}
+
+// commandOrder is the order help lists the commands in
+var commandOrder = []string{"serve", "migrate", "seed", "create-admin", "promote", "purge-tokens", "help"}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range commandOrder {
+		cmd := commands[name]
+		fmt.Fprintf(os.Stderr, "  %-42s %s\n", cmd.usage, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nSettings are read from the environment and .env, as for the server.")
+}
+
+// newFlagSet creates the flag set of the command called name, whose usage
+// message names its arguments
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n", os.Args[0], commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// loadConfig reads the settings, exiting with every invalid one listed
+func loadConfig() *config.Config {
+	conf, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	return conf
+}
+
+// openDB connects to the database with the configured pool, exiting if it
+// can't be reached
+func openDB(conf *config.Config) *sql.DB {
+	var db *sql.DB
+	var err error
+	if conf.DatabaseDriver == config.DatabaseSQLite {
+		db, err = sqlite.Open(conf.SQLitePath)
+	} else {
+		db, err = sql.Open("postgres", conf.DatabaseURL)
+	}
+	if err != nil {
+		log.Fatal("Error opening database:", err)
+	}
+	// An in-memory database would be lost with its connections
+	if conf.SQLitePath != config.SQLiteMemory {
+		applyDBPool(db, conf.DB)
+	}
+	err = pingDB(db, conf.DatabaseURL)
+	if err != nil {
+		log.Fatal("Error connecting to database: ", err)
+	}
+	return db
+}
+
+// openCommandDB is openDB for the commands other than serve, which can't
+// reach a server's in-memory database or a demo's data
+func openCommandDB(conf *config.Config) *sql.DB {
+	if conf.SQLitePath == config.SQLiteMemory || conf.DatabaseDriver == config.DatabaseMemory {
+		log.Fatal("The data is in memory, inside the server; commands can't reach it")
+	}
+	return openDB(conf)
+}
+
+// emailArg is the single email address args must hold, exiting with the
+// command's usage otherwise
+func emailArg(flags *flag.FlagSet) string {
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	email, ok := normalizeEmail(flags.Arg(0))
+	if !ok {
+		log.Fatalf("Invalid email address %q", flags.Arg(0))
+	}
+	return email
+}
+
+func runMigrate(args []string) {
+	flags := newFlagSet("migrate")
+	flags.Parse(args)
+
+	conf := loadConfig()
+	db := openCommandDB(conf)
+	defer db.Close()
+	err := migrateDB(context.Background(), db, conf.DatabaseDriver)
+	if err != nil {
+		log.Fatal("Error migrating database: ", err)
+	}
+}
+
+func runSeed(args []string) {
+	flags := newFlagSet("seed")
+	var opts seedOptions
+	flags.IntVar(&opts.users, "users", 50, "users to create")
+	flags.IntVar(&opts.follows, "follows", 10, "users each seeded user follows")
+	flags.IntVar(&opts.chirps, "chirps", 20, "chirps each seeded user posts")
+	flags.Parse(args)
+
+	if opts.users < 0 || opts.follows < 0 || opts.chirps < 0 {
+		log.Fatal("-users, -follows and -chirps can't be negative")
+	}
+	// Never fill a real database with fake accounts
+	conf := loadConfig()
+	if conf.Platform != "dev" {
+		log.Fatal("seed only runs with PLATFORM=dev")
+	}
+
+	db := openCommandDB(conf)
+	defer db.Close()
+	err := seedDB(context.Background(), store.NewSQL(db), opts)
+	if err != nil {
+		log.Fatal("Error seeding database: ", err)
+	}
+}
+
+// runCreateAdmin creates a verified admin account. The password is
+// generated rather than taken as an argument, so it doesn't end up in
+// shell history, and is printed once.
+func runCreateAdmin(args []string) {
+	flags := newFlagSet("create-admin")
+	flags.Parse(args)
+	email := emailArg(flags)
+
+	password, err := auth.MakeRefreshToken()
+	if err != nil {
+		log.Fatal("Error generating password: ", err)
+	}
+	hashedPassword, err := auth.HashPassword(password)
+	if err != nil {
+		log.Fatal("Error hashing password: ", err)
+	}
+
+	db := openCommandDB(loadConfig())
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Fatal("Error creating admin: ", err)
+	}
+	defer tx.Rollback()
+	q := database.New(tx)
+
+	dbUser, err := q.CreateUser(ctx, database.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashedPassword,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Fatalf("An account with the email %s already exists; use promote to make it an admin", email)
+	}
+	if err != nil {
+		log.Fatal("Error creating admin: ", err)
+	}
+	// The operator vouches for the address
+	_, err = q.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{ID: dbUser.ID, Email: email})
+	if err != nil {
+		log.Fatal("Error creating admin: ", err)
+	}
+	_, err = q.SetUserRole(ctx, database.SetUserRoleParams{ID: dbUser.ID, Role: auth.RoleAdmin})
+	if err != nil {
+		log.Fatal("Error creating admin: ", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		log.Fatal("Error creating admin: ", err)
+	}
+
+	fmt.Printf("Created admin %s (%s)\nPassword: %s\nChange it after logging in.\n", email, dbUser.ID, password)
+}
+
+// runPromote changes an existing user's role. Like the admin endpoint, it
+// takes effect with the user's next access token.
+func runPromote(args []string) {
+	flags := newFlagSet("promote")
+	role := flags.String("role", auth.RoleAdmin, "role to give: user, moderator or admin")
+	flags.Parse(args)
+	email := emailArg(flags)
+	if !auth.ValidRole(*role) {
+		log.Fatal("Role must be user, moderator or admin")
+	}
+
+	db := openCommandDB(loadConfig())
+	defer db.Close()
+	ctx := context.Background()
+	q := database.New(db)
+
+	dbUser, err := q.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Fatalf("No user with the email %s", email)
+	}
+	if err != nil {
+		log.Fatal("Error looking up user: ", err)
+	}
+	_, err = q.SetUserRole(ctx, database.SetUserRoleParams{ID: dbUser.ID, Role: *role})
+	if err != nil {
+		log.Fatal("Error updating role: ", err)
+	}
+
+	fmt.Printf("%s is now %s (was %s)\n", email, *role, dbUser.Role)
+}
+
+// runPurgeTokens deletes the refresh tokens, access token revocations and
+// login links that can no longer be used. The server purges the last two
+// on its own; refresh tokens are kept until this runs.
+func runPurgeTokens(args []string) {
+	flags := newFlagSet("purge-tokens")
+	flags.Parse(args)
+
+	db := openCommandDB(loadConfig())
+	defer db.Close()
+	ctx := context.Background()
+	q := database.New(db)
+
+	for _, purge := range []struct {
+		name   string
+		delete func(context.Context) (int64, error)
+	}{
+		{"refresh tokens", q.DeleteStaleRefreshTokens},
+		{"access token revocations", q.DeleteExpiredRevokedAccessTokens},
+		{"login links", q.DeleteExpiredMagicLinkTokens},
+	} {
+		deleted, err := purge.delete(ctx)
+		if err != nil {
+			log.Fatalf("Error purging %s: %v", purge.name, err)
+		}
+		fmt.Printf("Deleted %d %s\n", deleted, purge.name)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	dataExportPending  = "pending"
+	dataExportBuilding = "building"
+	dataExportReady    = "ready"
+	dataExportFailed   = "failed"
+)
+
+const (
+	dataExportInterval = 5 * time.Second
+	dataExportBatch    = 5
+	// dataExportStaleAfter is how long an export can be building before
+	// another instance takes it over from a worker that must have died
+	dataExportStaleAfter = 10 * time.Minute
+
+	// dataExportRetention is how long an archive can be downloaded, and
+	// how long until the user can ask for a fresh one
+	dataExportRetention     = 7 * 24 * time.Hour
+	dataExportPurgeInterval = time.Hour
+)
+
+type DataExport struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	// DownloadURL is set once the archive is ready
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func dataExportFromDB(dbExport database.DataExport) DataExport {
+	export := DataExport{
+		ID:        dbExport.ID,
+		CreatedAt: dbExport.CreatedAt,
+		Status:    dbExport.Status,
+		Error:     dbExport.Error.String,
+	}
+	if dbExport.CompletedAt.Valid {
+		export.CompletedAt = &dbExport.CompletedAt.Time
+	}
+	if dbExport.ExpiresAt.Valid {
+		export.ExpiresAt = &dbExport.ExpiresAt.Time
+	}
+	if dbExport.Status == dataExportReady {
+		export.DownloadURL = dataExportURL(dbExport.ID) + "/download"
+	}
+	return export
+}
+
+func dataExportURL(id uuid.UUID) string {
+	return "/api/users/me/export/" + id.String()
+}
+
+// dataExportLike is a chirp the user liked, in their archive
+type dataExportLike struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// buildDataExport is a ZIP of everything stored about userID, one JSON
+// file per kind of data
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	dbUser, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbChirps, err := cfg.db.GetChirpsForExport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbLikes, err := cfg.db.GetChirpLikesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbFollowers, err := cfg.db.GetFollowers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbFollowing, err := cfg.db.GetFollowing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	hashtags, err := cfg.db.GetFollowedHashtags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	dbSessions, err := cfg.db.GetActiveSessionsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+	likes := []dataExportLike{}
+	for _, dbLike := range dbLikes {
+		likes = append(likes, dataExportLike{ChirpID: dbLike.ChirpID, CreatedAt: dbLike.CreatedAt})
+	}
+	if hashtags == nil {
+		hashtags = []string{}
+	}
+	sessions := []Session{}
+	for _, dbSession := range dbSessions {
+		sessions = append(sessions, sessionFromDB(dbSession))
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	now := time.Now()
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", userFromDB(dbUser)},
+		{"chirps.json", chirps},
+		{"likes.json", likes},
+		{"followers.json", publicUsersFromDB(dbFollowers)},
+		{"following.json", publicUsersFromDB(dbFollowing)},
+		{"hashtags.json", hashtags},
+		{"sessions.json", sessions},
+	}
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildDataExports builds the archives users have asked for
+func (cfg *apiConfig) buildDataExports(ctx context.Context) error {
+	dbExports, err := cfg.db.ClaimDataExports(ctx, database.ClaimDataExportsParams{
+		StaleBefore: time.Now().Add(-dataExportStaleAfter),
+		MaxExports:  dataExportBatch,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, dbExport := range dbExports {
+		expiresAt := sql.NullTime{Time: time.Now().Add(dataExportRetention), Valid: true}
+		archive, err := cfg.buildDataExport(ctx, dbExport.UserID)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Left building for whichever instance runs next
+				return ctx.Err()
+			}
+			log.Printf("Failed to build data export %s: %v", dbExport.ID, err)
+			err = cfg.db.FailDataExport(ctx, database.FailDataExportParams{
+				ID:        dbExport.ID,
+				Error:     sql.NullString{String: "Failed to build the export", Valid: true},
+				ExpiresAt: expiresAt,
+			})
+		} else {
+			err = cfg.db.CompleteDataExport(ctx, database.CompleteDataExportParams{
+				ID:        dbExport.ID,
+				Archive:   archive,
+				ExpiresAt: expiresAt,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeExpiredDataExports drops archives past their retention
+func (cfg *apiConfig) purgeExpiredDataExports(ctx context.Context) error {
+	_, err := cfg.db.DeleteExpiredDataExports(ctx)
+	return err
+}
+
+// handlerDataExport returns the user's current export, asking for a new
+// one if there's none under way or ready. It answers 202 until the archive
+// can be downloaded.
+func (cfg *apiConfig) handlerDataExport(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbExport, err := cfg.db.GetCurrentDataExportForUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		dbExport, err = cfg.db.CreateDataExport(r.Context(), userID)
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to start data export")
+		return
+	}
+
+	respondWithDataExport(w, dbExport)
+}
+
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, r *http.Request) {
+	dbExport, ok := cfg.authorizeDataExport(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithDataExport(w, dbExport)
+}
+
+func (cfg *apiConfig) handlerDownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	dbExport, ok := cfg.authorizeDataExport(w, r)
+	if !ok {
+		return
+	}
+	if dbExport.Status != dataExportReady {
+		respondWithError(w, 409, "Data export isn't ready")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export-`+dbExport.CreatedAt.Format(time.DateOnly)+`.zip"`)
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(dbExport.Archive)
+}
+
+// respondWithDataExport answers 200 for a finished export and 202 with
+// its status URL for one still being built
+func respondWithDataExport(w http.ResponseWriter, dbExport database.DataExport) {
+	code := http.StatusOK
+	if dbExport.Status == dataExportPending || dbExport.Status == dataExportBuilding {
+		w.Header().Set("Location", dataExportURL(dbExport.ID))
+		code = http.StatusAccepted
+	}
+	respondWithJSON(w, code, dataExportFromDB(dbExport))
+}
+
+// authorizeDataExport loads the export named in the path if it belongs to
+// the user; anyone else's is reported as not found. It writes the error
+// response itself when it returns false.
+func (cfg *apiConfig) authorizeDataExport(w http.ResponseWriter, r *http.Request) (database.DataExport, bool) {
+	exportID, err := uuid.Parse(r.PathValue("exportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid export ID")
+		return database.DataExport{}, false
+	}
+
+	dbExport, err := cfg.db.GetDataExport(r.Context(), exportID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dbExport.UserID != authUserID(r)) {
+		respondWithError(w, 404, "Data export not found")
+		return database.DataExport{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve data export")
+		return database.DataExport{}, false
+	}
+
+	return dbExport, true
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+)
+
+// dbConnectTimeout bounds the connectivity check at startup
+const dbConnectTimeout = 10 * time.Second
+
+// applyDBPool sets how many connections db keeps to the database and for
+// how long
+func applyDBPool(db *sql.DB, pool config.DBPool) {
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+}
+
+// pingDB checks the database is reachable, so a bad DB_URL fails at startup
+// instead of on the first request. The error names the database without
+// its password.
+func pingDB(db *sql.DB, dbURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dbConnectTimeout)
+	defer cancel()
+
+	err := db.PingContext(ctx)
+	if err == nil {
+		return nil
+	}
+	target := "the database in DB_URL"
+	if parsed, parseErr := url.Parse(dbURL); parseErr == nil && parsed.Host != "" {
+		target = parsed.Redacted()
+	}
+	return fmt.Errorf("can't reach %s (is it running, and is DB_URL right?): %w", target, err)
+}
+
+// warmDBPool opens the pool's idle connections ahead of traffic, so the
+// first requests don't each pay for a connection handshake
+func warmDBPool(ctx context.Context, db *sql.DB, pool config.DBPool) error {
+	conns := make([]*sql.Conn, 0, pool.MaxIdleConns)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	// Holding every connection until the end makes the pool open new ones
+	// rather than handing back the same one
+	for range pool.MaxIdleConns {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	return nil
+}
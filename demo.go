@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/store"
+)
+
+// demoSeed is the data a demo starts with
+var demoSeed = seedOptions{users: 20, follows: 5, chirps: 5}
+
+// startDemo readies a PLATFORM=demo server, whose data is kept in maps by
+// a store.Memory rather than in a database. Secrets that weren't set are
+// made up, since nothing signed with them outlives the data, and the store
+// is seeded so there's something to look at.
+func startDemo(ctx context.Context, conf *config.Config, db store.Store) error {
+	for _, secret := range []*string{&conf.JWTSecret, &conf.PolkaKey} {
+		if *secret != "" {
+			continue
+		}
+		value, err := auth.MakeRefreshToken()
+		if err != nil {
+			return err
+		}
+		*secret = value
+	}
+
+	log.Print("Running a demo: data is kept in memory and lost when the server stops")
+	return seedDB(ctx, db, demoSeed)
+}
@@ -0,0 +1,51 @@
+package main
+
+import "github.com/Utkarsh736/chirpy/internal/mail"
+
+const (
+	mailWorkers   = 2
+	mailQueueSize = 100
+)
+
+// Outgoing email templates
+var (
+	verifyEmailTemplate = mail.MustTemplate("verify_email",
+		"Verify your Chirpy email address",
+		`Confirm this address by opening the link below within 24 hours:
+
+{{.Link}}
+
+If you didn't sign up for Chirpy, you can ignore this email.
+`)
+	magicLinkTemplate = mail.MustTemplate("magic_link",
+		"Your Chirpy login link",
+		`Sign in to Chirpy by opening the link below within 15 minutes:
+
+{{.Link}}
+
+The link works once. If you didn't ask to sign in, you can ignore this email.
+`)
+	passwordResetTemplate = mail.MustTemplate("password_reset",
+		"Choose a new Chirpy password",
+		`An administrator has reset your Chirpy password, and you've been signed
+out everywhere. Sign in by opening the link below within 15 minutes, then
+choose a new password:
+
+{{.Link}}
+
+The link works once. If it has expired, ask for a new login link from the
+sign-in page.
+`)
+	newLoginTemplate = mail.MustTemplate("new_login",
+		"New sign-in to your Chirpy account",
+		`Your Chirpy account was just signed in to from a device or network it
+hasn't been used from before:
+
+When:       {{.Time}}
+IP address: {{.IPAddress}}
+Device:     {{.UserAgent}}
+
+If this was you, you can ignore this email. If it wasn't, change your
+password and sign out every session from your account settings.
+`)
+)
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/experiments"
+	"github.com/google/uuid"
+)
+
+// Experiment event kinds
+const (
+	experimentExposure   = "exposure"
+	experimentConversion = "conversion"
+)
+
+// experimentEventNameMaxLength caps the names clients give conversions
+const experimentEventNameMaxLength = 64
+
+// activeExperiments are the A/B experiments users are split between. Each
+// is measured by comparing its variants' conversions, recorded when users
+// do what the change is meant to encourage, against their exposures,
+// recorded when the variant changed what the user saw.
+var activeExperiments = []experiments.Experiment{
+	{
+		Key:         forYouRankingExperiment,
+		Description: "For You feed ranked with a shorter half-life",
+		Variants:    []experiments.Variant{{Name: "control", Weight: 1}, {Name: "fresh", Weight: 1}},
+	},
+}
+
+// ExperimentAssignment is the variant of an experiment a user is in
+type ExperimentAssignment struct {
+	Key         string `json:"key"`
+	Description string `json:"description,omitempty"`
+	Variant     string `json:"variant"`
+}
+
+// ExperimentVariant is one arm of an experiment and its share of users
+type ExperimentVariant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// ExperimentResult counts one kind of event in one variant. Name tells
+// conversions apart; exposures have none.
+type ExperimentResult struct {
+	Variant string `json:"variant"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name,omitempty"`
+	Events  int64  `json:"events"`
+	Users   int64  `json:"users"`
+}
+
+// ExperimentResults is an experiment with the events recorded in it so far
+type ExperimentResults struct {
+	Key         string              `json:"key"`
+	Description string              `json:"description,omitempty"`
+	Variants    []ExperimentVariant `json:"variants"`
+	Results     []ExperimentResult  `json:"results"`
+}
+
+// experimentVariant returns the variant of the experiment with key that
+// userID is in, and records their exposure to it, for a handler about to
+// show them that variant. It returns "" when no such experiment is running.
+// A failure to record the exposure is logged rather than failing the
+// request.
+func (cfg *apiConfig) experimentVariant(r *http.Request, userID uuid.UUID, key string) string {
+	experiment, ok := cfg.experiments.Get(key)
+	if !ok {
+		return ""
+	}
+	variant := experiment.Assign(userID)
+	if variant == "" {
+		return ""
+	}
+	err := cfg.db.CreateExperimentEvent(r.Context(), database.CreateExperimentEventParams{
+		ExperimentKey: key,
+		Variant:       variant,
+		UserID:        userID,
+		Kind:          experimentExposure,
+	})
+	if err != nil {
+		logRequestf(r, "Failed to record exposure of user %s to experiment %s: %v", userID, key, err)
+	}
+	return variant
+}
+
+// handlerGetExperiments lists the caller's variant in each running
+// experiment. Listing isn't an exposure; clients record one when they show
+// the user something that depends on the variant.
+func (cfg *apiConfig) handlerGetExperiments(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	assignments := []ExperimentAssignment{}
+	for _, experiment := range cfg.experiments.All() {
+		variant := experiment.Assign(userID)
+		if variant == "" {
+			continue
+		}
+		assignments = append(assignments, ExperimentAssignment{
+			Key:         experiment.Key,
+			Description: experiment.Description,
+			Variant:     variant,
+		})
+	}
+
+	respondWithJSON(w, 200, assignments)
+}
+
+// handlerRecordExperimentEvent records an exposure or a named conversion
+// for the caller in the variant of the experiment they're assigned, which
+// the client doesn't get to choose
+func (cfg *apiConfig) handlerRecordExperimentEvent(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	}
+
+	userID := authUserID(r)
+
+	experiment, ok := cfg.experiments.Get(r.PathValue("key"))
+	variant := experiment.Assign(userID)
+	if !ok || variant == "" {
+		respondWithError(w, 404, "Experiment not found")
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	switch params.Kind {
+	case experimentExposure:
+		params.Name = ""
+	case experimentConversion:
+		if params.Name == "" || len(params.Name) > experimentEventNameMaxLength {
+			respondWithError(w, 400, "Conversions need a name of at most 64 characters")
+			return
+		}
+	default:
+		respondWithError(w, 400, "kind must be exposure or conversion")
+		return
+	}
+
+	err = cfg.db.CreateExperimentEvent(r.Context(), database.CreateExperimentEventParams{
+		ExperimentKey: experiment.Key,
+		Variant:       variant,
+		UserID:        userID,
+		Kind:          params.Kind,
+		Name:          params.Name,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to record experiment event")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetExperimentResults reports an experiment's variants with the
+// exposures and conversions recorded in each, for an admin to compare
+func (cfg *apiConfig) handlerGetExperimentResults(w http.ResponseWriter, r *http.Request) {
+	experiment, ok := cfg.experiments.Get(r.PathValue("key"))
+	if !ok {
+		respondWithError(w, 404, "Experiment not found")
+		return
+	}
+
+	rows, err := cfg.db.GetExperimentResults(r.Context(), experiment.Key)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve experiment results")
+		return
+	}
+
+	results := ExperimentResults{
+		Key:         experiment.Key,
+		Description: experiment.Description,
+		Variants:    []ExperimentVariant{},
+		Results:     []ExperimentResult{},
+	}
+	for _, variant := range experiment.Variants {
+		results.Variants = append(results.Variants, ExperimentVariant{Name: variant.Name, Weight: variant.Weight})
+	}
+	for _, row := range rows {
+		results.Results = append(results.Results, ExperimentResult{
+			Variant: row.Variant,
+			Kind:    row.Kind,
+			Name:    row.Name,
+			Events:  row.Events,
+			Users:   row.Users,
+		})
+	}
+
+	respondWithJSON(w, 200, results)
+}
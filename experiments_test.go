@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/experiments"
+)
+
+func TestExperiments(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"ADMIN_API_KEY": "admin-key"})
+	api.cfg.experiments = experiments.NewRegistry(
+		experiments.Experiment{Key: "compose_button", Description: "Bigger compose button", Variants: []experiments.Variant{{Name: "control", Weight: 1}, {Name: "big", Weight: 1}}},
+		experiments.Experiment{Key: "paused", Variants: []experiments.Variant{{Name: "control", Weight: 0}}},
+	)
+	alice := api.signUp("alice")
+
+	rec := api.do("GET", "/api/experiments", bearer(alice.Token), nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	assignments := decodeResponse[[]ExperimentAssignment](t, rec)
+	if len(assignments) != 1 || assignments[0].Key != "compose_button" {
+		t.Fatalf("Expected only the running experiment, got %+v", assignments)
+	}
+	variant := assignments[0].Variant
+	experiment, _ := api.cfg.experiments.Get("compose_button")
+	if want := experiment.Assign(alice.ID); variant != want {
+		t.Errorf("Expected variant %q, got %q", want, variant)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		body       map[string]string
+		wantStatus int
+	}{
+		{"exposure", "/api/experiments/compose_button/events", map[string]string{"kind": "exposure"}, 204},
+		{"conversion", "/api/experiments/compose_button/events", map[string]string{"kind": "conversion", "name": "chirp_posted"}, 204},
+		{"conversion without a name", "/api/experiments/compose_button/events", map[string]string{"kind": "conversion"}, 400},
+		{"unknown kind", "/api/experiments/compose_button/events", map[string]string{"kind": "click"}, 400},
+		{"unknown experiment", "/api/experiments/missing/events", map[string]string{"kind": "exposure"}, 404},
+		{"experiment without variants", "/api/experiments/paused/events", map[string]string{"kind": "exposure"}, 404},
+	}
+	for _, tt := range tests {
+		rec := api.do("POST", tt.path, bearer(alice.Token), tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+
+	rec = api.do("GET", "/admin/experiments/compose_button/results", bearer(alice.Token), nil)
+	if rec.Code != 403 {
+		t.Errorf("Expected 403 for a user, got %d", rec.Code)
+	}
+	rec = api.do("GET", "/admin/experiments/compose_button/results", "ApiKey admin-key", nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	results := decodeResponse[ExperimentResults](t, rec)
+	if len(results.Variants) != 2 || len(results.Results) != 2 {
+		t.Fatalf("Expected 2 variants and 2 result rows, got %+v", results)
+	}
+	for _, result := range results.Results {
+		if result.Variant != variant || result.Events != 1 || result.Users != 1 {
+			t.Errorf("Expected one event by alice in %q, got %+v", variant, result)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	// accountConfirmationWindow is how soon after signing in a token can
+	// confirm a destructive change to the account without the password,
+	// for users who sign in some other way
+	accountConfirmationWindow = 5 * time.Minute
+	// deactivationGracePeriod is how long a deactivated account can be
+	// reactivated before it's deleted
+	deactivationGracePeriod = 30 * 24 * time.Hour
+	// deactivatedAccountsBatch caps how many expired accounts one run of
+	// deleteDeactivatedAccounts deletes
+	deactivatedAccountsBatch    = 50
+	deactivatedAccountsInterval = time.Hour
+)
+
+// confirmAccount checks the caller is really dbUser before a destructive
+// change: either password is theirs, or their token's session was signed
+// in to within accountConfirmationWindow. Wrong passwords count towards the
+// login lockout. It writes the error response itself when it returns false.
+func (cfg *apiConfig) confirmAccount(w http.ResponseWriter, r *http.Request, dbUser database.User, password string) bool {
+	if password == "" {
+		if requestAuthFrom(r).claims.SignedInWithin(accountConfirmationWindow) {
+			return true
+		}
+		respondWithError(w, 403, "Confirm with your password, or sign in again first")
+		return false
+	}
+
+	if respondIfLocked(w, dbUser) {
+		return false
+	}
+	match, err := auth.CheckPasswordHash(password, dbUser.HashedPassword)
+	if err != nil || !match {
+		err = cfg.recordFailedLogin(r, dbUser)
+		if err != nil {
+			respondWithError(w, 500, "Failed to record login attempt")
+			return false
+		}
+		respondWithError(w, 403, "Incorrect password")
+		return false
+	}
+	return true
+}
+
+// handlerDeleteAccount permanently deletes the caller's account, as
+// deleteAccount describes
+func (cfg *apiConfig) handlerDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.decodeAccountConfirmation(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.deleteAccount(r.Context(), dbUser)
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete account")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeAccountConfirmation reads the optional {"password": ...} body of a
+// destructive account change and confirms it with confirmAccount, returning
+// the caller. It writes the error response itself when it returns false.
+func (cfg *apiConfig) decodeAccountConfirmation(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	// The body is optional for a recently signed-in token
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithBodyError(w, err)
+		return database.User{}, false
+	}
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return database.User{}, false
+	}
+	if !cfg.confirmAccount(w, r, dbUser, params.Password) {
+		return database.User{}, false
+	}
+	return dbUser, true
+}
+
+// deleteAccount permanently deletes dbUser's account, along with their
+// chirps, likes, follows, sessions and everything else they own. The counts
+// on other users' chirps they liked or replied to are brought down to
+// match.
+func (cfg *apiConfig) deleteAccount(ctx context.Context, dbUser database.User) error {
+	var changed []uuid.UUID
+	err := cfg.withTx(ctx, func(q store.Store) error {
+		liked, err := q.ReleaseChirpLikesByUser(ctx, dbUser.ID)
+		if err != nil {
+			return err
+		}
+		repliedTo, err := q.ReleaseChirpRepliesByUser(ctx, dbUser.ID)
+		if err != nil {
+			return err
+		}
+		deleted, err := q.DeleteChirpsForUser(ctx, dbUser.ID)
+		if err != nil {
+			return err
+		}
+		_, err = q.DeleteUser(ctx, dbUser.ID)
+		if err != nil {
+			return err
+		}
+		changed = slices.Concat(liked, repliedTo, deleted)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cfg.invalidateChirps(ctx, changed...)
+	cfg.invalidateProfiles(ctx, dbUser.Handle)
+
+	// Nothing links to the avatar any more; failing to remove it only
+	// wastes space
+	if dbUser.AvatarKey.Valid {
+		err = cfg.mediaStore.Delete(ctx, dbUser.AvatarKey.String)
+		if err != nil {
+			log.Printf("Failed to delete avatar %s: %v", dbUser.AvatarKey.String, err)
+		}
+	}
+	return nil
+}
+
+// handlerDeactivateAccount hides the caller's profile and chirps and signs
+// out every session. The account can be brought back with
+// handlerReactivateAccount within deactivationGracePeriod, after which
+// deleteDeactivatedAccounts deletes it.
+func (cfg *apiConfig) handlerDeactivateAccount(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.decodeAccountConfirmation(w, r)
+	if !ok {
+		return
+	}
+
+	err := cfg.withTx(r.Context(), func(q store.Store) error {
+		_, err := q.DeactivateUser(r.Context(), dbUser.ID)
+		if err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(r.Context(), dbUser.ID)
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to deactivate account")
+		return
+	}
+	cfg.invalidateAuthorContent(r.Context(), dbUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerReactivateAccount signs a deactivated user back in with their
+// email and password, bringing their profile and chirps back, as long as
+// deactivationGracePeriod hasn't run out
+func (cfg *apiConfig) handlerReactivateAccount(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	email, _ := normalizeEmail(params.Email)
+	dbUser, err := cfg.db.GetUserByEmail(r.Context(), email)
+	if err != nil {
+		respondWithError(w, 401, "Incorrect email or password")
+		return
+	}
+	if respondIfLocked(w, dbUser) {
+		return
+	}
+	match, err := auth.CheckPasswordHash(params.Password, dbUser.HashedPassword)
+	if err != nil || !match {
+		err = cfg.recordFailedLogin(r, dbUser)
+		if err != nil {
+			respondWithError(w, 500, "Failed to record login attempt")
+			return
+		}
+		respondWithError(w, 401, "Incorrect email or password")
+		return
+	}
+	if !dbUser.DeactivatedAt.Valid {
+		respondWithError(w, 409, "Account isn't deactivated")
+		return
+	}
+	if respondIfPasswordResetRequired(w, dbUser) {
+		return
+	}
+	// Checked before anything changes, or a suspended user would be shown
+	// again only to be refused the login
+	if respondIfSuspended(w, dbUser) {
+		return
+	}
+
+	dbUser, err = cfg.db.ReactivateUser(r.Context(), database.ReactivateUserParams{
+		ID:               dbUser.ID,
+		DeactivatedAfter: sql.NullTime{Time: time.Now().Add(-deactivationGracePeriod), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 410, "Account can no longer be reactivated")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to reactivate account")
+		return
+	}
+	err = cfg.db.ResetFailedLogins(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to record login attempt")
+		return
+	}
+	cfg.invalidateAuthorContent(r.Context(), dbUser)
+
+	cfg.respondWithLogin(w, r, dbUser)
+}
+
+// invalidateAuthorContent drops the cached profile and chirps of a user
+// whose visibility changed. A failure to list the chirps is logged, and
+// their copies expire with chirpCacheTTL.
+func (cfg *apiConfig) invalidateAuthorContent(ctx context.Context, dbUser database.User) {
+	cfg.invalidateProfiles(ctx, dbUser.Handle)
+	chirpIDs, err := cfg.db.GetChirpIDsByAuthor(ctx, dbUser.ID)
+	if err != nil {
+		log.Printf("Failed to list chirps of %s to invalidate: %v", dbUser.ID, err)
+		return
+	}
+	cfg.invalidateChirps(ctx, chirpIDs...)
+}
+
+// deleteDeactivatedAccounts deletes accounts left deactivated for longer
+// than deactivationGracePeriod, a batch at a time
+func (cfg *apiConfig) deleteDeactivatedAccounts(ctx context.Context) error {
+	dbUsers, err := cfg.db.GetUsersDeactivatedBefore(ctx, database.GetUsersDeactivatedBeforeParams{
+		DeactivatedBefore: sql.NullTime{Time: time.Now().Add(-deactivationGracePeriod), Valid: true},
+		MaxUsers:          deactivatedAccountsBatch,
+	})
+	if err != nil {
+		return err
+	}
+	for _, dbUser := range dbUsers {
+		err := cfg.deleteAccount(ctx, dbUser)
+		if err != nil {
+			return err
+		}
+	}
+	if len(dbUsers) > 0 {
+		log.Printf("Deleted %d deactivated accounts", len(dbUsers))
+	}
+	return nil
+}
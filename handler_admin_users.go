@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+const suspensionReasonMaxLength = 500
+
+// AdminUser is a user as admins see them, with the state of their account
+// that users themselves aren't shown
+type AdminUser struct {
+	User
+	LockedUntil           *time.Time `json:"locked_until,omitempty"`
+	FailedLoginCount      int32      `json:"failed_login_count"`
+	Suspended             bool       `json:"suspended"`
+	SuspendedAt           *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil        *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason      string     `json:"suspension_reason,omitempty"`
+	DeactivatedAt         *time.Time `json:"deactivated_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	ShadowBanned          bool       `json:"shadow_banned"`
+	// ActiveSessions is only filled in for a single user
+	ActiveSessions *int `json:"active_sessions,omitempty"`
+}
+
+func adminUserFromDB(dbUser database.User) AdminUser {
+	user := AdminUser{
+		User:                  userFromDB(dbUser),
+		FailedLoginCount:      dbUser.FailedLoginCount,
+		Suspended:             suspended(dbUser),
+		SuspensionReason:      dbUser.SuspensionReason,
+		PasswordResetRequired: dbUser.PasswordResetRequired,
+		ShadowBanned:          dbUser.ShadowBanned,
+	}
+	if dbUser.LockedUntil.Valid && dbUser.LockedUntil.Time.After(time.Now()) {
+		user.LockedUntil = &dbUser.LockedUntil.Time
+	}
+	if dbUser.SuspendedAt.Valid {
+		user.SuspendedAt = &dbUser.SuspendedAt.Time
+	}
+	if dbUser.SuspendedUntil.Valid {
+		user.SuspendedUntil = &dbUser.SuspendedUntil.Time
+	}
+	if dbUser.DeactivatedAt.Valid {
+		user.DeactivatedAt = &dbUser.DeactivatedAt.Time
+	}
+	return user
+}
+
+// UsersPage is one page of the admin user listing. NextCursor is empty on
+// the last page.
+type UsersPage struct {
+	Users      []AdminUser `json:"users"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// suspended reports whether dbUser is suspended now; a suspension with an
+// end date lapses on its own
+func suspended(dbUser database.User) bool {
+	return dbUser.SuspendedAt.Valid && (!dbUser.SuspendedUntil.Valid || dbUser.SuspendedUntil.Time.After(time.Now()))
+}
+
+// respondIfSuspended answers 403 when dbUser is suspended, and reports
+// whether it did
+func respondIfSuspended(w http.ResponseWriter, dbUser database.User) bool {
+	if !suspended(dbUser) {
+		return false
+	}
+	if dbUser.SuspendedUntil.Valid {
+		respondWithError(w, 403, "Account is suspended until "+dbUser.SuspendedUntil.Time.UTC().Format(time.RFC3339))
+		return true
+	}
+	respondWithError(w, 403, "Account is suspended")
+	return true
+}
+
+// respondIfPasswordResetRequired answers 403 when an admin has forced
+// dbUser to choose a new password, since the current one may be known to
+// someone else, and reports whether it did
+func respondIfPasswordResetRequired(w http.ResponseWriter, dbUser database.User) bool {
+	if !dbUser.PasswordResetRequired {
+		return false
+	}
+	respondWithError(w, 403, "Password reset required; sign in with the link we emailed you, or ask for a new login link")
+	return true
+}
+
+// parseOptionalBool reads a true or false query parameter, which is unset
+// when absent
+func parseOptionalBool(value string) (sql.NullBool, error) {
+	if value == "" {
+		return sql.NullBool{}, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return sql.NullBool{}, err
+	}
+	return sql.NullBool{Bool: parsed, Valid: true}, nil
+}
+
+// handlerListUsers pages through users newest first, optionally filtered
+// by ?q= (part of an email or handle), role, plan, suspended, deactivated,
+// shadow_banned and a from/to creation date range
+func (cfg *apiConfig) handlerListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, 400, "Invalid date range")
+		return
+	}
+
+	query := r.URL.Query()
+	suspendedFilter, err := parseOptionalBool(query.Get("suspended"))
+	if err != nil {
+		respondWithError(w, 400, "suspended must be true or false")
+		return
+	}
+	deactivatedFilter, err := parseOptionalBool(query.Get("deactivated"))
+	if err != nil {
+		respondWithError(w, 400, "deactivated must be true or false")
+		return
+	}
+	shadowBannedFilter, err := parseOptionalBool(query.Get("shadow_banned"))
+	if err != nil {
+		respondWithError(w, 400, "shadow_banned must be true or false")
+		return
+	}
+
+	params := database.ListUsersParams{
+		Query:        optionalString(query.Get("q")),
+		Role:         optionalString(query.Get("role")),
+		Plan:         optionalString(query.Get("plan")),
+		Suspended:    suspendedFilter,
+		Deactivated:  deactivatedFilter,
+		ShadowBanned: shadowBannedFilter,
+		CreatedFrom:  from,
+		CreatedTo:    to,
+		Limit:        int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbUsers, err := cfg.db.ListUsers(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve users")
+		return
+	}
+
+	dbUsers, nextCursor := pageRows(dbUsers, limit, func(last database.User) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := UsersPage{Users: []AdminUser{}, NextCursor: nextCursor}
+	for _, dbUser := range dbUsers {
+		page.Users = append(page.Users, adminUserFromDB(dbUser))
+	}
+
+	respondWithJSON(w, 200, page)
+}
+
+func (cfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := cfg.db.GetActiveSessionsForUser(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve user")
+		return
+	}
+
+	user := adminUserFromDB(dbUser)
+	activeSessions := len(sessions)
+	user.ActiveSessions = &activeSessions
+	respondWithJSON(w, 200, user)
+}
+
+// handlerSuspendUser stops a user signing in, indefinitely or until the
+// optional until, and signs out every session they have
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reason string     `json:"reason"`
+		Until  *time.Time `json:"until"`
+	}
+
+	dbUser, ok := cfg.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	if params.Reason == "" || len([]rune(params.Reason)) > suspensionReasonMaxLength {
+		respondWithError(w, 400, "reason must be 1-500 characters")
+		return
+	}
+	until := sql.NullTime{}
+	if params.Until != nil {
+		if !params.Until.After(time.Now()) {
+			respondWithError(w, 400, "until must be in the future")
+			return
+		}
+		until = sql.NullTime{Time: *params.Until, Valid: true}
+	}
+
+	var updated database.User
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		updated, err = suspendUser(r.Context(), q, dbUser.ID, params.Reason, until)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to suspend user")
+		return
+	}
+
+	respondWithJSON(w, 200, adminUserFromDB(updated))
+}
+
+// suspendUser suspends the user and revokes their refresh tokens; the
+// suspension itself invalidates their access tokens
+func suspendUser(ctx context.Context, q store.Store, userID uuid.UUID, reason string, until sql.NullTime) (database.User, error) {
+	dbUser, err := q.SuspendUser(ctx, database.SuspendUserParams{
+		ID:               userID,
+		SuspendedUntil:   until,
+		SuspensionReason: reason,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return dbUser, q.RevokeUserRefreshTokens(ctx, userID)
+}
+
+func (cfg *apiConfig) handlerUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := cfg.db.UnsuspendUser(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to unsuspend user")
+		return
+	}
+
+	respondWithJSON(w, 200, adminUserFromDB(updated))
+}
+
+// handlerSetShadowBan turns a user's shadow ban on or off. Their chirps stay
+// visible to themselves and by direct link, but drop out of everyone else's
+// listings, search results, timelines and live stream.
+func (cfg *apiConfig) handlerSetShadowBan(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ShadowBanned *bool `json:"shadow_banned"`
+	}
+
+	dbUser, ok := cfg.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	if params.ShadowBanned == nil {
+		respondWithError(w, 400, "shadow_banned is required")
+		return
+	}
+
+	updated, err := cfg.db.SetUserShadowBanned(r.Context(), database.SetUserShadowBannedParams{
+		ID:           dbUser.ID,
+		ShadowBanned: *params.ShadowBanned,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to update shadow ban")
+		return
+	}
+
+	respondWithJSON(w, 200, adminUserFromDB(updated))
+}
+
+// handlerForcePasswordReset signs a user out everywhere and stops them
+// signing in with their current password until they've chosen a new one.
+// They're emailed a login link to get back in and change it.
+func (cfg *apiConfig) handlerForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	dbUser, ok := cfg.adminTargetUser(w, r)
+	if !ok {
+		return
+	}
+
+	var updated database.User
+	err := cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		updated, err = q.RequirePasswordReset(r.Context(), dbUser.ID)
+		if err != nil {
+			return err
+		}
+		return q.RevokeUserRefreshTokens(r.Context(), dbUser.ID)
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to reset password")
+		return
+	}
+
+	// The reset stands either way; the user can ask for another link
+	err = cfg.sendMagicLink(r.Context(), updated.Email, passwordResetTemplate)
+	if err != nil {
+		logRequestf(r, "Sending password reset link failed: %v", err)
+	}
+
+	respondWithJSON(w, 200, adminUserFromDB(updated))
+}
+
+// adminTargetUser loads the user named in the path. It writes the error
+// response itself when it returns false.
+func (cfg *apiConfig) adminTargetUser(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return database.User{}, false
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve user")
+		return database.User{}, false
+	}
+	return dbUser, true
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+// avatarMaxBytes is the largest avatar image accepted
+const avatarMaxBytes = 2 << 20
+
+// avatarTypes maps accepted image content types to file extensions
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// mediaURL is where a stored media key is served from
+func mediaURL(key string) string {
+	if key == "" {
+		return ""
+	}
+	return "/media/" + key
+}
+
+func (cfg *apiConfig) handlerUploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	// Leave headroom for the multipart envelope around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, avatarMaxBytes+64*1024)
+	err := r.ParseMultipartForm(avatarMaxBytes)
+	if err != nil {
+		respondWithError(w, 400, "Avatar must be a multipart upload of at most 2MB")
+		return
+	}
+	file, header, err := r.FormFile("avatar")
+	if err != nil {
+		respondWithError(w, 400, "Missing avatar file")
+		return
+	}
+	defer file.Close()
+	if header.Size > avatarMaxBytes {
+		respondWithError(w, 400, "Avatar must be at most 2MB")
+		return
+	}
+
+	// Trust the bytes, not the client's declared content type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		respondWithError(w, 400, "Failed to read avatar")
+		return
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		respondWithError(w, 400, "Avatar must be a PNG, JPEG, GIF or WebP image")
+		return
+	}
+
+	// Every upload gets a fresh key so cached copies never go stale
+	key := "avatars/" + userID.String() + "/" + uuid.NewString() + ext
+	err = cfg.mediaStore.Put(r.Context(), key, contentType, io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		respondWithError(w, 500, "Failed to store avatar")
+		return
+	}
+
+	previous, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+	dbUser, err := cfg.db.SetUserAvatar(r.Context(), database.SetUserAvatarParams{
+		ID:        userID,
+		AvatarKey: optionalString(key),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to update avatar")
+		return
+	}
+	cfg.invalidateProfiles(r.Context(), dbUser.Handle)
+
+	// The old file is unreachable now; failing to remove it only wastes space
+	if previous.AvatarKey.Valid {
+		err = cfg.mediaStore.Delete(r.Context(), previous.AvatarKey.String)
+		if err != nil {
+			logRequestf(r, "Failed to delete old avatar %s: %v", previous.AvatarKey.String, err)
+		}
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	if !media.ValidKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, contentType, err := cfg.mediaStore.Get(r.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to read media")
+		return
+	}
+	defer body.Close()
+
+	// Keys are never reused, so responses can be cached indefinitely
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/profanity"
+)
+
+// bannedWordsReloadInterval is how soon changes made through another
+// server instance take effect here
+const bannedWordsReloadInterval = 5 * time.Minute
+
+// reloadBannedWords rebuilds the profanity filter from the banned_words
+// table plus, if configured, the words file, one word per line
+func (cfg *apiConfig) reloadBannedWords(ctx context.Context) error {
+	words, err := cfg.db.GetBannedWords(ctx)
+	if err != nil {
+		return err
+	}
+	if cfg.config.BannedWordsFile != "" {
+		data, err := os.ReadFile(cfg.config.BannedWordsFile)
+		if err != nil {
+			return err
+		}
+		words = append(words, strings.Split(string(data), "\n")...)
+	}
+
+	cfg.profanity.Set(words)
+	return nil
+}
+
+// respondWithBannedWords reloads the filter after a change and returns the
+// resulting list
+func (cfg *apiConfig) respondWithBannedWords(w http.ResponseWriter, r *http.Request, code int) {
+	err := cfg.reloadBannedWords(r.Context())
+	if err != nil {
+		logRequestf(r, "Reloading banned words failed: %v", err)
+		respondWithError(w, 500, "Failed to reload banned words")
+		return
+	}
+	respondWithJSON(w, code, cfg.profanity.Words())
+}
+
+func (cfg *apiConfig) handlerGetBannedWords(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, 200, cfg.profanity.Words())
+}
+
+func (cfg *apiConfig) handlerAddBannedWord(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Word string `json:"word"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	word, ok := profanity.NormalizeWord(params.Word)
+	if !ok {
+		respondWithError(w, 400, "Word must be a single word of letters and digits")
+		return
+	}
+
+	added, err := cfg.db.AddBannedWord(r.Context(), word)
+	if err != nil {
+		respondWithError(w, 500, "Failed to add banned word")
+		return
+	}
+	if added == 0 {
+		respondWithError(w, 409, "Word is already banned")
+		return
+	}
+
+	cfg.respondWithBannedWords(w, r, 201)
+}
+
+// handlerDeleteBannedWord removes a word added through the API. Words from
+// the words file stay banned until they're taken out of the file.
+func (cfg *apiConfig) handlerDeleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	word, _ := profanity.NormalizeWord(r.PathValue("word"))
+
+	deleted, err := cfg.db.DeleteBannedWord(r.Context(), word)
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete banned word")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Word is not banned")
+		return
+	}
+
+	cfg.respondWithBannedWords(w, r, 200)
+}
+
+// handlerReloadBannedWords picks up changes to the words file, or ones
+// made by another instance, without waiting for the reload job
+func (cfg *apiConfig) handlerReloadBannedWords(w http.ResponseWriter, r *http.Request) {
+	cfg.respondWithBannedWords(w, r, 200)
+}
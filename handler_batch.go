@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// batchMaxRequests caps how many sub-requests a single batch may contain
+const batchMaxRequests = 20
+
+// batchResponseWriter buffers a sub-request's response so it can be
+// embedded in the batch result
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchResponseWriter() *batchResponseWriter {
+	return &batchResponseWriter{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (bw *batchResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *batchResponseWriter) Write(data []byte) (int, error) {
+	return bw.body.Write(data)
+}
+
+func (bw *batchResponseWriter) WriteHeader(code int) {
+	bw.status = code
+}
+
+// handlerBatch executes up to batchMaxRequests API calls in order, forwarding
+// the caller's Authorization header to each of them. router should be the
+// wrapped handler rather than the bare mux, so every sub-request is rate
+// limited, timed out and size capped like a request of its own.
+func handlerBatch(router http.Handler) http.HandlerFunc {
+	type subRequest struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body"`
+	}
+	type parameters struct {
+		Requests []subRequest `json:"requests"`
+	}
+	type subResponse struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := parameters{}
+		err := decodeJSON(r, &params)
+		if err != nil {
+			respondWithBodyError(w, err)
+			return
+		}
+
+		if len(params.Requests) == 0 {
+			respondWithError(w, 400, "Batch must contain at least one request")
+			return
+		}
+		if len(params.Requests) > batchMaxRequests {
+			respondWithError(w, 400, "Batch contains too many requests")
+			return
+		}
+
+		// Build and validate every sub-request before running any of them.
+		// Routes are checked on the decoded, cleaned path the mux matches,
+		// so an escaped path can't slip a nested batch through.
+		subReqs := make([]*http.Request, 0, len(params.Requests))
+		for _, sub := range params.Requests {
+			if sub.Method == "" {
+				respondWithError(w, 400, "Invalid batch request")
+				return
+			}
+			subReq, err := http.NewRequestWithContext(
+				r.Context(),
+				strings.ToUpper(sub.Method),
+				sub.Path,
+				bytes.NewReader(sub.Body),
+			)
+			if err != nil || !strings.HasPrefix(path.Clean(subReq.URL.Path), "/api/") {
+				respondWithError(w, 400, "Invalid batch request")
+				return
+			}
+			if unversionedRoute(path.Clean(subReq.URL.Path)) == "/api/batch" {
+				respondWithError(w, 400, "Batch requests cannot be nested")
+				return
+			}
+			if len(sub.Body) > 0 {
+				subReq.Header.Set("Content-Type", "application/json")
+			}
+			subReqs = append(subReqs, subReq)
+		}
+
+		results := make([]subResponse, 0, len(subReqs))
+		for _, subReq := range subReqs {
+			// Run the sub-request as the caller
+			if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+				subReq.Header.Set("Authorization", authHeader)
+			}
+			if version := r.Header.Get(apiVersionHeader); version != "" {
+				subReq.Header.Set(apiVersionHeader, version)
+			}
+			subReq.RemoteAddr = r.RemoteAddr
+
+			bw := newBatchResponseWriter()
+			if streamingRoute(subReq.Method, subReq.URL.Path) {
+				// A stream would hold the whole batch open until it timed
+				// out, and a WebSocket can't upgrade on bw anyway
+				respondWithError(bw, 400, "Streaming routes cannot be batched")
+			} else {
+				router.ServeHTTP(bw, subReq)
+			}
+
+			result := subResponse{Status: bw.status}
+			if json.Valid(bw.body.Bytes()) {
+				result.Body = bw.body.Bytes()
+			} else if bw.body.Len() > 0 {
+				// Non-JSON bodies (e.g. the mux's 404 page) are returned as a JSON string
+				encoded, _ := json.Marshal(bw.body.String())
+				result.Body = encoded
+			}
+			results = append(results, result)
+		}
+
+		respondWithJSON(w, 200, results)
+	}
+}
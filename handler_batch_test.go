@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type batchResult struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+func TestBatch(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	batch := map[string]any{"requests": []map[string]any{
+		{"method": "POST", "path": "/api/chirps", "body": map[string]string{"body": "First"}},
+		{"method": "POST", "path": "/api/chirps", "body": map[string]string{"body": "Second"}},
+		{"method": "GET", "path": "/api/chirps?author_id=" + alice.ID.String()},
+		{"method": "GET", "path": "/api/no-such-route"},
+	}}
+	rec := api.do("POST", "/api/batch", bearer(alice.Token), batch)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	results := decodeResponse[[]batchResult](t, rec)
+	wantStatuses := []int{201, 201, 200, 404}
+	if len(results) != len(wantStatuses) {
+		t.Fatalf("Expected %d results, got %d", len(wantStatuses), len(results))
+	}
+	for i, want := range wantStatuses {
+		if results[i].Status != want {
+			t.Errorf("Expected sub-request %d to get %d, got %d: %s", i, want, results[i].Status, results[i].Body)
+		}
+	}
+	// Sub-requests run in order, as the caller
+	var chirps []Chirp
+	if err := json.Unmarshal(results[2].Body, &chirps); err != nil || len(chirps) != 2 {
+		t.Errorf("Expected both new chirps listed, got %s (%v)", results[2].Body, err)
+	}
+
+	rec = api.do("POST", "/api/batch", "", map[string]any{"requests": []map[string]any{
+		{"method": "POST", "path": "/api/chirps", "body": map[string]string{"body": "Anonymous"}},
+	}})
+	if results := decodeResponse[[]batchResult](t, rec); len(results) != 1 || results[0].Status != 401 {
+		t.Errorf("Expected the sub-request to be unauthorized without a token, got %s", rec.Body)
+	}
+}
+
+func TestBatchInvalid(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	tooMany := make([]map[string]any, batchMaxRequests+1)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"method": "GET", "path": "/api/chirps"}
+	}
+	tests := []struct {
+		name     string
+		requests []map[string]any
+	}{
+		{"empty", nil},
+		{"too many", tooMany},
+		{"outside the API", []map[string]any{{"method": "GET", "path": "/admin/metrics"}}},
+		{"no method", []map[string]any{{"path": "/api/chirps"}}},
+		{"nested", []map[string]any{{"method": "POST", "path": "/api/batch"}}},
+		{"nested under a version", []map[string]any{{"method": "POST", "path": "/api/v1/batch"}}},
+		{"nested with an escaped path", []map[string]any{{"method": "POST", "path": "/api/%62atch"}}},
+		{"nested with an unclean path", []map[string]any{{"method": "POST", "path": "/api/chirps/../batch"}}},
+		{"outside the API with an unclean path", []map[string]any{{"method": "GET", "path": "/api/../admin/metrics"}}},
+	}
+	for _, tt := range tests {
+		rec := api.do("POST", "/api/batch", bearer(alice.Token), map[string]any{"requests": tt.requests})
+		if rec.Code != 400 {
+			t.Errorf("%s: expected 400, got %d: %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestBatchRateLimit(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"API_READ_RATE_LIMIT": "3/1m"})
+	alice := api.signUp("alice")
+
+	// Each sub-request takes a token of its own
+	requests := make([]map[string]any, 5)
+	for i := range requests {
+		requests[i] = map[string]any{"method": "GET", "path": "/api/chirps"}
+	}
+	rec := api.do("POST", "/api/batch", bearer(alice.Token), map[string]any{"requests": requests})
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	results := decodeResponse[[]batchResult](t, rec)
+	for i, result := range results {
+		want := 200
+		if i >= 3 {
+			want = 429
+		}
+		if result.Status != want {
+			t.Errorf("Expected sub-request %d to get %d, got %d", i, want, result.Status)
+		}
+	}
+}
+
+func TestBatchStreams(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	batch := map[string]any{"requests": []map[string]any{
+		{"method": "GET", "path": "/api/chirps/stream"},
+		{"method": "GET", "path": "/api/v1/chirps/stream"},
+		{"method": "GET", "path": "/api/stream"},
+		{"method": "GET", "path": "/api/v1/stream"},
+		{"method": "GET", "path": "/api/chirps"},
+	}}
+	rec := api.do("POST", "/api/batch", bearer(alice.Token), batch)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	results := decodeResponse[[]batchResult](t, rec)
+	wantStatuses := []int{400, 400, 400, 400, 200}
+	if len(results) != len(wantStatuses) {
+		t.Fatalf("Expected %d results, got %d", len(wantStatuses), len(results))
+	}
+	for i, want := range wantStatuses {
+		if results[i].Status != want {
+			t.Errorf("Expected sub-request %d to get %d, got %d: %s", i, want, results[i].Status, results[i].Body)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, blockedID, ok := cfg.authorizeBlock(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.GetUserByID(r.Context(), blockedID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	// Blocking twice is a no-op
+	err = cfg.db.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to block user")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), blockerID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, blockedID, ok := cfg.authorizeBlock(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to unblock user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "User is not blocked")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), blockerID, blockedID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeBlock returns the caller and the target user ID, writing the
+// error response itself when it returns false
+func (cfg *apiConfig) authorizeBlock(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	blockerID := authUserID(r)
+
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if blockedID == blockerID {
+		respondWithError(w, 400, "You can't block yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return blockerID, blockedID, true
+}
+
+func (cfg *apiConfig) handlerGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbUsers, err := cfg.db.GetBlockedUsers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve blocked users")
+		return
+	}
+
+	respondWithJSON(w, 200, publicUsersFromDB(dbUsers))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.authorizeChirpAction(w, r)
+	if !ok {
+		return
+	}
+
+	// Bookmarking twice is a no-op
+	err := cfg.db.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to bookmark chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.authorizeChirpAction(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to remove bookmark")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Bookmark not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks lists the caller's bookmarks, most recently saved
+// first. The cursor tracks when a chirp was bookmarked, not when it was posted.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.GetBookmarksParams{UserID: userID, Limit: int32(limit + 1)}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	rows, err := cfg.db.GetBookmarks(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve bookmarks")
+		return
+	}
+
+	rows, nextCursor := pageRows(rows, limit, func(last database.GetBookmarksRow) pageCursor {
+		return pageCursor{CreatedAt: last.BookmarkedAt, ID: last.Chirp.ID}
+	})
+	page := ChirpsPage{Chirps: []Chirp{}, NextCursor: nextCursor}
+	for _, row := range rows {
+		page.Chirps = append(page.Chirps, chirpFromDB(row.Chirp))
+	}
+
+	respondWithJSON(w, 200, page)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/entitlements"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+// handlerEditChirp replaces the body of one of the user's chirps within
+// their plan's edit window, refreshing its hashtags and mentions and
+// dropping translations of the old body
+func (cfg *apiConfig) handlerEditChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	userID := authUserID(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	resolver, author, err := cfg.loadEntitlements(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to load entitlements")
+		return
+	}
+	if !checkFeature(w, resolver, author, entitlements.EditChirps) {
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if dbChirp.UserID != userID {
+		respondWithError(w, 403, "You can only edit your own chirps")
+		return
+	}
+	if time.Since(dbChirp.CreatedAt) > resolver.EditWindow(author) {
+		respondWithError(w, 403, "This chirp can no longer be edited")
+		return
+	}
+
+	if !checkChirpLength(w, resolver, author, params.Body) {
+		return
+	}
+	cleanedBody := cfg.profanity.Clean(params.Body)
+
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		dbChirp, err = q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			ID:   chirpID,
+			Body: cleanedBody,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteChirpTranslations(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteChirpHashtags(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if tags := chirptext.Hashtags(dbChirp.Body); len(tags) > 0 {
+			err = q.CreateChirpHashtags(r.Context(), database.CreateChirpHashtagsParams{
+				ChirpID: chirpID,
+				Tags:    tags,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = q.DeleteMentions(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		if handles := chirptext.Mentions(dbChirp.Body); len(handles) > 0 {
+			return q.CreateMentions(r.Context(), database.CreateMentionsParams{
+				ChirpID: chirpID,
+				Handles: handles,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to edit chirp")
+		return
+	}
+	cfg.invalidateChirps(r.Context(), chirpID)
+
+	respondWithJSON(w, 200, chirpFromDB(dbChirp))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	// sseResumeLimit caps how many missed chirps are replayed on reconnect
+	sseResumeLimit = 100
+)
+
+// chirpEventID identifies a chirp in the live stream by its publication
+// order, so a Last-Event-ID can be resumed from
+func chirpEventID(dbChirp database.Chirp) string {
+	return encodeCursor(pageCursor{CreatedAt: dbChirp.PublishedAt.Time, ID: dbChirp.ID})
+}
+
+// handlerChirpsStream emits a Server-Sent Event for every newly published
+// chirp. Reconnecting clients send Last-Event-ID and first receive up to
+// sseResumeLimit chirps they missed.
+func (cfg *apiConfig) handlerChirpsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, 500, "Streaming unsupported")
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost
+	sub := cfg.streamHub.Subscribe(uuid.Nil)
+	defer sub.Close()
+
+	var missed []database.Chirp
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		// An unrecognised ID just means there's nothing to replay
+		if cursor, err := decodeCursor(lastEventID); err == nil {
+			var err error
+			missed, err = cfg.db.GetChirpsPublishedAfter(r.Context(), database.GetChirpsPublishedAfterParams{
+				PublishedAt: cursor.CreatedAt,
+				ID:          cursor.ID,
+				Limit:       sseResumeLimit,
+			})
+			if err != nil {
+				respondWithError(w, 500, "Failed to retrieve missed chirps")
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stops reverse proxies such as nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+
+	replayed := map[string]bool{}
+	for _, dbChirp := range missed {
+		id := chirpEventID(dbChirp)
+		replayed[id] = true
+		if writeSSE(w, id, streamEventChirp, chirpFromDB(dbChirp)) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-cfg.shuttingDown:
+			// Clients reconnect with Last-Event-ID to another instance
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if event.Type != streamEventChirp || replayed[event.ID] {
+				continue
+			}
+			if writeSSE(w, event.ID, event.Type, event.Data) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			// Comment lines keep idle connections from being timed out
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeSSE writes a single event in text/event-stream format
+func writeSSE(w http.ResponseWriter, id, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, payload)
+	return err
+}
@@ -0,0 +1,19 @@
+package main
+
+import "net/http"
+
+// handlerGetConfig reports the server's limits so clients can validate
+// input before sending it
+func (cfg *apiConfig) handlerGetConfig(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		// MaxChirpLength is the limit without the long chirps perk; a
+		// signed-in user's own limit is in their entitlements
+		MaxChirpLength    int `json:"max_chirp_length"`
+		PasswordMinLength int `json:"password_min_length"`
+	}
+
+	respondWithJSON(w, 200, response{
+		MaxChirpLength:    cfg.config.MaxChirpLength,
+		PasswordMinLength: cfg.passwordPolicy.MinLength,
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+const dmMaxLength = 1000
+
+type Conversation struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	With      PublicUser `json:"with"`
+}
+
+type ConversationsPage struct {
+	Conversations []Conversation `json:"conversations"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+type MessagesPage struct {
+	Messages   []Message `json:"messages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+func messageFromDB(dbMessage database.Message) Message {
+	return Message{
+		ID:             dbMessage.ID,
+		CreatedAt:      dbMessage.CreatedAt,
+		ConversationID: dbMessage.ConversationID,
+		SenderID:       dbMessage.SenderID,
+		Body:           dbMessage.Body,
+	}
+}
+
+// handlerSendDM sends a message to another user, starting the conversation
+// between them if this is the first one
+func (cfg *apiConfig) handlerSendDM(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	senderID := authUserID(r)
+
+	recipientID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	if recipientID == senderID {
+		respondWithError(w, 400, "You can't message yourself")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	body := strings.TrimSpace(params.Body)
+	if body == "" {
+		respondWithError(w, 400, "Message body is required")
+		return
+	}
+	if len([]rune(body)) > dmMaxLength {
+		respondWithError(w, 400, "Message is too long")
+		return
+	}
+
+	_, err = cfg.db.GetUserByID(r.Context(), recipientID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	// A block in either direction stops messaging
+	blocked, err := cfg.db.IsBlockedEitherWay(r.Context(), database.IsBlockedEitherWayParams{
+		UserID:  senderID,
+		OtherID: recipientID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to send message")
+		return
+	}
+	if blocked {
+		respondWithError(w, 403, "You can't message this user")
+		return
+	}
+
+	var dbMessage database.Message
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		dbConversation, err := q.UpsertConversation(r.Context(), database.UpsertConversationParams{
+			SenderID:    senderID,
+			RecipientID: recipientID,
+		})
+		if err != nil {
+			return err
+		}
+		dbMessage, err = q.CreateMessage(r.Context(), database.CreateMessageParams{
+			ConversationID: dbConversation.ID,
+			SenderID:       senderID,
+			Body:           body,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to send message")
+		return
+	}
+
+	respondWithJSON(w, 201, messageFromDB(dbMessage))
+}
+
+// handlerGetConversations lists the caller's conversations, most recently
+// active first
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.GetConversationsForUserParams{UserID: userID, Limit: int32(limit + 1)}
+	if cursor != nil {
+		params.BeforeUpdatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	rows, err := cfg.db.GetConversationsForUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve conversations")
+		return
+	}
+
+	rows, nextCursor := pageRows(rows, limit, func(last database.GetConversationsForUserRow) pageCursor {
+		return pageCursor{CreatedAt: last.Conversation.UpdatedAt, ID: last.Conversation.ID}
+	})
+	page := ConversationsPage{Conversations: []Conversation{}, NextCursor: nextCursor}
+	for _, row := range rows {
+		page.Conversations = append(page.Conversations, Conversation{
+			ID:        row.Conversation.ID,
+			CreatedAt: row.Conversation.CreatedAt,
+			UpdatedAt: row.Conversation.UpdatedAt,
+			With:      publicUsersFromDB([]database.User{row.User})[0],
+		})
+	}
+
+	respondWithJSON(w, 200, page)
+}
+
+// handlerGetMessages pages through a conversation the caller is part of,
+// newest message first
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid conversation ID")
+		return
+	}
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Other users' conversations look the same as missing ones
+	_, err = cfg.db.GetConversationForUser(r.Context(), database.GetConversationForUserParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Conversation not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve conversation")
+		return
+	}
+
+	params := database.GetMessagesParams{ConversationID: conversationID, Limit: int32(limit + 1)}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbMessages, err := cfg.db.GetMessages(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve messages")
+		return
+	}
+
+	dbMessages, nextCursor := pageRows(dbMessages, limit, func(last database.Message) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := MessagesPage{Messages: []Message{}, NextCursor: nextCursor}
+	for _, dbMessage := range dbMessages {
+		page.Messages = append(page.Messages, messageFromDB(dbMessage))
+	}
+
+	respondWithJSON(w, 200, page)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDirectMessages(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	bob := api.signUp("bob")
+	carol := api.signUp("carol")
+
+	send := func(from testUser, to uuid.UUID, body string) int {
+		return api.do("POST", "/api/dm/"+to.String(), bearer(from.Token), map[string]string{"body": body}).Code
+	}
+	for _, body := range []string{"One", "Two", "Three"} {
+		if code := send(alice, bob.ID, body); code != 201 {
+			t.Fatalf("Expected 201 sending, got %d", code)
+		}
+	}
+	if code := send(bob, alice.ID, "Four"); code != 201 {
+		t.Fatalf("Expected 201 replying, got %d", code)
+	}
+
+	tests := []struct {
+		name       string
+		to         uuid.UUID
+		body       string
+		wantStatus int
+	}{
+		{"to themselves", alice.ID, "Hi", 400},
+		{"empty", bob.ID, "  ", 400},
+		{"unknown recipient", uuid.New(), "Hi", 404},
+	}
+	for _, tt := range tests {
+		if code := send(alice, tt.to, tt.body); code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.wantStatus, code)
+		}
+	}
+
+	// Both sides see the one conversation, with the other user
+	rec := api.do("GET", "/api/dm/conversations", bearer(bob.Token), nil)
+	conversations := decodeResponse[ConversationsPage](t, rec).Conversations
+	if len(conversations) != 1 || conversations[0].With.ID != alice.ID {
+		t.Fatalf("Expected bob's conversation with alice, got %+v", conversations)
+	}
+	path := "/api/dm/conversations/" + conversations[0].ID.String() + "/messages"
+
+	// Newest first, two to a page
+	var bodies []string
+	cursor := ""
+	for range 3 {
+		rec := api.do("GET", path+"?limit=2&cursor="+cursor, bearer(alice.Token), nil)
+		if rec.Code != 200 {
+			t.Fatalf("Expected 200 listing messages, got %d: %s", rec.Code, rec.Body)
+		}
+		page := decodeResponse[MessagesPage](t, rec)
+		for _, message := range page.Messages {
+			bodies = append(bodies, message.Body)
+		}
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	want := []string{"Four", "Three", "Two", "One"}
+	if len(bodies) != len(want) || cursor != "" {
+		t.Fatalf("Expected %v over two pages, got %v", want, bodies)
+	}
+	for i := range want {
+		if bodies[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, bodies)
+			break
+		}
+	}
+
+	// Anyone else's conversations look missing
+	if rec := api.do("GET", path, bearer(carol.Token), nil); rec.Code != 404 {
+		t.Errorf("Expected 404 for someone else's conversation, got %d", rec.Code)
+	}
+}
+
+func TestDirectMessagesBlocked(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	bob := api.signUp("bob")
+
+	send := func(from testUser, to uuid.UUID) int {
+		return api.do("POST", "/api/dm/"+to.String(), bearer(from.Token), map[string]string{"body": "Hi"}).Code
+	}
+
+	if rec := api.do("POST", "/api/users/"+alice.ID.String()+"/block", bearer(bob.Token), nil); rec.Code != 204 {
+		t.Fatalf("Expected 204 blocking, got %d: %s", rec.Code, rec.Body)
+	}
+	if code := send(alice, bob.ID); code != 403 {
+		t.Errorf("Expected 403 messaging someone who blocked you, got %d", code)
+	}
+	if code := send(bob, alice.ID); code != 403 {
+		t.Errorf("Expected 403 messaging someone you blocked, got %d", code)
+	}
+
+	if rec := api.do("DELETE", "/api/users/"+alice.ID.String()+"/block", bearer(bob.Token), nil); rec.Code != 204 {
+		t.Fatalf("Expected 204 unblocking, got %d: %s", rec.Code, rec.Body)
+	}
+	if code := send(alice, bob.ID); code != 201 {
+		t.Errorf("Expected 201 once unblocked, got %d", code)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+// loadEntitlements resolves a user's plan, admin overrides and the global
+// feature flags
+func (cfg *apiConfig) loadEntitlements(ctx context.Context, userID uuid.UUID) (entitlements.Resolver, entitlements.User, error) {
+	dbPlan, err := cfg.db.GetPlanForUser(ctx, userID)
+	if err != nil {
+		return entitlements.Resolver{}, entitlements.User{}, err
+	}
+	planFeatures, err := cfg.db.GetPlanFeatures(ctx, dbPlan.ID)
+	if err != nil {
+		return entitlements.Resolver{}, entitlements.User{}, err
+	}
+	dbOverrides, err := cfg.db.GetEntitlementOverrides(ctx, userID)
+	if err != nil {
+		return entitlements.Resolver{}, entitlements.User{}, err
+	}
+	dbFlags, err := cfg.db.GetFeatureFlags(ctx)
+	if err != nil {
+		return entitlements.Resolver{}, entitlements.User{}, err
+	}
+
+	user := entitlements.User{
+		Plan: entitlements.Plan{
+			ID:                 dbPlan.ID,
+			MaxChirpLength:     int(dbPlan.MaxChirpLength),
+			RateLimitPerMinute: int(dbPlan.RateLimitPerMinute),
+			EditWindow:         time.Duration(dbPlan.EditWindowSeconds) * time.Second,
+			UndoWindow:         time.Duration(dbPlan.UndoWindowSeconds) * time.Second,
+			Features:           map[entitlements.Feature]bool{},
+		},
+		Overrides: map[entitlements.Feature]bool{},
+	}
+	for _, feature := range planFeatures {
+		user.Plan.Features[entitlements.Feature(feature)] = true
+	}
+	for _, override := range dbOverrides {
+		user.Overrides[entitlements.Feature(override.Feature)] = override.Enabled
+	}
+
+	resolver := entitlements.Resolver{
+		Flags:              map[entitlements.Feature]bool{},
+		BaseMaxChirpLength: cfg.config.MaxChirpLength,
+	}
+	for _, flag := range dbFlags {
+		resolver.Flags[entitlements.Feature(flag.Feature)] = flag.Enabled
+	}
+
+	return resolver, user, nil
+}
+
+// featureDescriptions name features in the errors for users who can't use
+// them
+var featureDescriptions = map[entitlements.Feature]string{
+	entitlements.LongChirps: "Longer chirps",
+	entitlements.EditChirps: "Editing chirps",
+	entitlements.Analytics:  "Analytics",
+	entitlements.GiftRed:    "Gifting Chirpy Red",
+}
+
+// checkFeature reports whether user can use feature, writing the error
+// response itself when they can't: 402 if their plan lacks it, so
+// upgrading would help, or 403 if it's been withheld from them
+func checkFeature(w http.ResponseWriter, resolver entitlements.Resolver, user entitlements.User, feature entitlements.Feature) bool {
+	if resolver.Can(user, feature) {
+		return true
+	}
+	if resolver.Withheld(user, feature) {
+		respondWithError(w, 403, featureDescriptions[feature]+" isn't available on your account")
+		return false
+	}
+	respondWithError(w, 402, featureDescriptions[feature]+" needs Chirpy Red")
+	return false
+}
+
+// requireFeature only lets through users who can use feature. It goes
+// inside middlewareAuth.
+func (cfg *apiConfig) requireFeature(feature entitlements.Feature, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resolver, user, err := cfg.loadEntitlements(r.Context(), authUserID(r))
+		if err != nil {
+			respondWithError(w, 500, "Failed to load entitlements")
+			return
+		}
+		if !checkFeature(w, resolver, user, feature) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// checkChirpLength reports whether user may post body, writing the error
+// response itself when they can't. Free users get a 402 for a chirp that's
+// over their limit, since longer ones come with Chirpy Red.
+func checkChirpLength(w http.ResponseWriter, resolver entitlements.Resolver, user entitlements.User, body string) bool {
+	limit := resolver.MaxChirpLength(user)
+	if len(body) <= limit {
+		return true
+	}
+	if !resolver.Can(user, entitlements.LongChirps) && !resolver.Withheld(user, entitlements.LongChirps) {
+		respondWithError(w, 402, fmt.Sprintf("Chirps over %d characters need Chirpy Red", limit))
+		return false
+	}
+	respondWithError(w, 400, "Chirp is too long")
+	return false
+}
+
+type FeatureFlag struct {
+	Feature   string    `json:"feature"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (cfg *apiConfig) handlerGetFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	dbFlags, err := cfg.db.GetFeatureFlags(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve feature flags")
+		return
+	}
+
+	flags := []FeatureFlag{}
+	for _, dbFlag := range dbFlags {
+		flags = append(flags, FeatureFlag{
+			Feature:   dbFlag.Feature,
+			Enabled:   dbFlag.Enabled,
+			UpdatedAt: dbFlag.UpdatedAt,
+		})
+	}
+
+	respondWithJSON(w, 200, flags)
+}
+
+func (cfg *apiConfig) handlerSetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	feature := r.PathValue("feature")
+	if !entitlements.IsKnown(feature) {
+		respondWithError(w, 404, "Unknown feature")
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	dbFlag, err := cfg.db.UpsertFeatureFlag(r.Context(), database.UpsertFeatureFlagParams{
+		Feature: feature,
+		Enabled: params.Enabled,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to update feature flag")
+		return
+	}
+
+	respondWithJSON(w, 200, FeatureFlag{
+		Feature:   dbFlag.Feature,
+		Enabled:   dbFlag.Enabled,
+		UpdatedAt: dbFlag.UpdatedAt,
+	})
+}
+
+func (cfg *apiConfig) handlerGetUserEntitlements(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Plan           string          `json:"plan"`
+		Features       map[string]bool `json:"features"`
+		Overrides      map[string]bool `json:"overrides"`
+		MaxChirpLength int             `json:"max_chirp_length"`
+		EditWindowSecs int             `json:"edit_window_seconds"`
+		UndoWindowSecs int             `json:"undo_window_seconds"`
+		RateLimit      int             `json:"rate_limit_per_minute"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	resolver, user, err := cfg.loadEntitlements(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	resp := response{
+		Plan:           user.Plan.ID,
+		Features:       map[string]bool{},
+		Overrides:      map[string]bool{},
+		MaxChirpLength: resolver.MaxChirpLength(user),
+		EditWindowSecs: int(resolver.EditWindow(user).Seconds()),
+		UndoWindowSecs: int(resolver.UndoWindow(user).Seconds()),
+		RateLimit:      resolver.RateLimitPerMinute(user),
+	}
+	for _, feature := range entitlements.KnownFeatures {
+		resp.Features[string(feature)] = resolver.Can(user, feature)
+	}
+	for feature, enabled := range user.Overrides {
+		resp.Overrides[string(feature)] = enabled
+	}
+
+	respondWithJSON(w, 200, resp)
+}
+
+func (cfg *apiConfig) handlerSetEntitlementOverride(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	feature := r.PathValue("feature")
+	if !entitlements.IsKnown(feature) {
+		respondWithError(w, 404, "Unknown feature")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	_, err = cfg.db.UpsertEntitlementOverride(r.Context(), database.UpsertEntitlementOverrideParams{
+		UserID:  userID,
+		Feature: feature,
+		Enabled: params.Enabled,
+	})
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerDeleteEntitlementOverride(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteEntitlementOverride(r.Context(), database.DeleteEntitlementOverrideParams{
+		UserID:  userID,
+		Feature: r.PathValue("feature"),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete override")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Override not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// exportPageSize is how many rows an export reads from the database at a
+// time. Each page is flushed to the client before the next is read, so an
+// export of any size only ever holds one page.
+const exportPageSize = 500
+
+// parseDateRange reads the optional from/to query parameters. Both accept
+// either a date (2006-01-02) or a full RFC 3339 timestamp; to is exclusive.
+func parseDateRange(r *http.Request) (sql.NullTime, sql.NullTime, error) {
+	from, err := parseDateParam(r.URL.Query().Get("from"))
+	if err != nil {
+		return sql.NullTime{}, sql.NullTime{}, err
+	}
+	to, err := parseDateParam(r.URL.Query().Get("to"))
+	if err != nil {
+		return sql.NullTime{}, sql.NullTime{}, err
+	}
+	return from, to, nil
+}
+
+func parseDateParam(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, value)
+		if err != nil {
+			return sql.NullTime{}, err
+		}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// startCSVExport writes the download headers and returns a CSV writer
+// wrapping the response
+func startCSVExport(w http.ResponseWriter, filename string) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	return csv.NewWriter(w)
+}
+
+// csvText makes user-written text safe to open in a spreadsheet, which
+// would run a cell starting with one of these as a formula
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// flushCSVExport sends the rows writer holds on to the client
+func flushCSVExport(w http.ResponseWriter, writer *csv.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	// Not every writer can flush, and then the rows go out as it fills up
+	http.NewResponseController(w).Flush()
+	return nil
+}
+
+// abortCSVExport ends an export whose next page couldn't be read. The 200
+// has gone out already, so the connection is cut rather than leaving the
+// client a file that looks complete.
+func abortCSVExport(name string, err error) {
+	log.Printf("Exporting %s failed partway: %v", name, err)
+	panic(http.ErrAbortHandler)
+}
+
+func (cfg *apiConfig) handlerExportChirps(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, 400, "Invalid date range")
+		return
+	}
+
+	arg := database.ExportChirpsParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+		Limit:       exportPageSize,
+	}
+	dbChirps, err := cfg.db.ExportChirps(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, 500, "Failed to export chirps")
+		return
+	}
+
+	writer := startCSVExport(w, "chirps.csv")
+	writer.Write([]string{"id", "created_at", "updated_at", "user_id", "body"})
+	for {
+		for _, dbChirp := range dbChirps {
+			writer.Write([]string{
+				dbChirp.ID.String(),
+				dbChirp.CreatedAt.Format(time.RFC3339),
+				dbChirp.UpdatedAt.Format(time.RFC3339),
+				dbChirp.UserID.String(),
+				csvText(dbChirp.Body),
+			})
+		}
+		if flushCSVExport(w, writer) != nil || len(dbChirps) < exportPageSize {
+			return
+		}
+
+		last := dbChirps[len(dbChirps)-1]
+		arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+		dbChirps, err = cfg.db.ExportChirps(r.Context(), arg)
+		if err != nil {
+			abortCSVExport("chirps", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerExportUsers(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, 400, "Invalid date range")
+		return
+	}
+
+	arg := database.ExportUsersParams{
+		CreatedFrom: from,
+		CreatedTo:   to,
+		Limit:       exportPageSize,
+	}
+	dbUsers, err := cfg.db.ExportUsers(r.Context(), arg)
+	if err != nil {
+		respondWithError(w, 500, "Failed to export users")
+		return
+	}
+
+	// Emails and password hashes are deliberately left out of the export
+	writer := startCSVExport(w, "users.csv")
+	writer.Write([]string{"id", "created_at", "updated_at", "is_chirpy_red", "plan"})
+	for {
+		for _, dbUser := range dbUsers {
+			writer.Write([]string{
+				dbUser.ID.String(),
+				dbUser.CreatedAt.Format(time.RFC3339),
+				dbUser.UpdatedAt.Format(time.RFC3339),
+				strconv.FormatBool(dbUser.IsChirpyRed),
+				dbUser.Plan,
+			})
+		}
+		if flushCSVExport(w, writer) != nil || len(dbUsers) < exportPageSize {
+			return
+		}
+
+		last := dbUsers[len(dbUsers)-1]
+		arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+		dbUsers, err = cfg.db.ExportUsers(r.Context(), arg)
+		if err != nil {
+			abortCSVExport("users", err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+func TestExportChirpsPages(t *testing.T) {
+	api := newTestAPI(t, nil)
+	admin := api.promote(api.signUp("admin"), "admin", auth.RoleAdmin)
+
+	// One more than a page, so the export has to read a second one
+	now := time.Now().UTC()
+	for i := range exportPageSize + 1 {
+		_, err := api.cfg.db.CreateChirp(context.Background(), database.CreateChirpParams{
+			Body:        "Chirp " + strconv.Itoa(i),
+			UserID:      admin.ID,
+			PublishAt:   now,
+			PublishedAt: sql.NullTime{Time: now, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := api.do("GET", "/admin/export/chirps.csv", bearer(admin.Token), nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !rec.Flushed {
+		t.Error("Expected the export flushed as it went")
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a CSV file, got %v", err)
+	}
+	if len(records) != exportPageSize+2 {
+		t.Fatalf("Expected a header and %d chirps, got %d rows", exportPageSize+1, len(records))
+	}
+	seen := map[string]bool{}
+	for _, record := range records[1:] {
+		if seen[record[0]] {
+			t.Fatalf("Expected each chirp once, got %s again", record[0])
+		}
+		seen[record[0]] = true
+	}
+}
+
+func TestExportChirpsHiddenAuthors(t *testing.T) {
+	api := newTestAPI(t, nil)
+	admin := api.promote(api.signUp("admin"), "admin", auth.RoleAdmin)
+	bob := api.signUp("bob")
+	api.createChirp(admin, "Still public")
+	api.createChirp(bob, "Shadow-banned")
+	_, err := api.cfg.db.SetUserShadowBanned(context.Background(), database.SetUserShadowBannedParams{ID: bob.ID, ShadowBanned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := api.do("GET", "/admin/export/chirps.csv", bearer(admin.Token), nil)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a CSV file, got %v", err)
+	}
+	if len(records) != 2 || records[1][4] != "Still public" {
+		t.Errorf("Expected only the public chirp exported, got %v", records)
+	}
+}
+
+func TestExportChirpsFormulas(t *testing.T) {
+	api := newTestAPI(t, nil)
+	admin := api.promote(api.signUp("admin"), "admin", auth.RoleAdmin)
+	bodies := map[string]string{
+		"=1+1":       "'=1+1",
+		"+1":         "'+1",
+		"-1":         "'-1",
+		"@SUM(A1)":   "'@SUM(A1)",
+		"Just words": "Just words",
+	}
+	for body := range bodies {
+		api.createChirp(admin, body)
+	}
+
+	rec := api.do("GET", "/admin/export/chirps.csv", bearer(admin.Token), nil)
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected a CSV file, got %v", err)
+	}
+	if len(records) != len(bodies)+1 {
+		t.Fatalf("Expected a header and %d chirps, got %d rows", len(bodies), len(records))
+	}
+	want := map[string]bool{}
+	for _, cell := range bodies {
+		want[cell] = true
+	}
+	for _, record := range records[1:] {
+		if !want[record[4]] {
+			t.Errorf("Expected the body escaped for a spreadsheet, got %q", record[4])
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// PublicUser is what other users can see about someone; it never includes
+// their email
+type PublicUser struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+	AvatarURL   string    `json:"avatar_url"`
+}
+
+func publicUsersFromDB(dbUsers []database.User) []PublicUser {
+	users := []PublicUser{}
+	for _, dbUser := range dbUsers {
+		users = append(users, PublicUser{
+			ID:          dbUser.ID,
+			CreatedAt:   dbUser.CreatedAt,
+			Handle:      dbUser.Handle.String,
+			DisplayName: dbUser.DisplayName,
+			Bio:         dbUser.Bio,
+			Location:    dbUser.Location,
+			Website:     dbUser.Website,
+			AvatarURL:   mediaURL(dbUser.AvatarKey.String),
+		})
+	}
+	return users
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.authorizeFollow(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.GetUserByID(r.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	// Following twice is a no-op
+	created, err := cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to follow user")
+		return
+	}
+	if created > 0 {
+		cfg.invalidateForYou(r.Context(), followerID)
+		cfg.emitWebhook(r.Context(), followeeID, webhooks.EventUserFollowed, userFollowedEvent{
+			FollowerID: followerID,
+			FolloweeID: followeeID,
+		})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followerID, followeeID, ok := cfg.authorizeFollow(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to unfollow user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Not following user")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), followerID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeFollow returns the caller and the target user ID, writing the
+// error response itself when it returns false
+func (cfg *apiConfig) authorizeFollow(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	followerID := authUserID(r)
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if followeeID == followerID {
+		respondWithError(w, 400, "You can't follow yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return followerID, followeeID, true
+}
+
+// handlerGetFollowList serves GET /api/users/{userID}/followers and
+// /following; they share one pattern so /api/users/by-handle/{handle} can be
+// registered alongside them
+func (cfg *apiConfig) handlerGetFollowList(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	var dbUsers []database.User
+	switch r.PathValue("relation") {
+	case "followers":
+		dbUsers, err = cfg.db.GetFollowers(r.Context(), userID)
+	case "following":
+		dbUsers, err = cfg.db.GetFollowing(r.Context(), userID)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve users")
+		return
+	}
+
+	respondWithJSON(w, 200, publicUsersFromDB(dbUsers))
+}
+
+func (cfg *apiConfig) handlerGetUserByHandle(w http.ResponseWriter, r *http.Request) {
+	handle, ok := normalizeHandle(r.PathValue("handle"))
+	if !ok {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	profile, err := cfg.getProfile(r.Context(), handle)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	respondWithJSON(w, 200, profile)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/ranking"
+	"github.com/google/uuid"
+)
+
+const (
+	// forYouCandidateLimit caps how many chirps each source offers the ranker
+	forYouCandidateLimit = 100
+	// forYouRecommendationWindow is how far back recommended chirps go
+	forYouRecommendationWindow = 3 * 24 * time.Hour
+	// forYouRankingExperiment tries other rankings on a share of feeds
+	forYouRankingExperiment = "for_you_ranking"
+)
+
+// forYouRankers are the rankings feeds in a variant of the For You ranking
+// experiment get in place of cfg.feedRanker
+var forYouRankers = map[string]ranking.FeedRanker{
+	"fresh": ranking.Fresh,
+}
+
+// A For You cursor is the position in the ranked feed where the next page
+// starts. Clients see it only as an opaque token.
+func encodeForYouCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeForYouCursor(token string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errInvalidCursor
+	}
+	return offset, nil
+}
+
+// rankForYou gathers the user's candidate chirps and ranks them with
+// ranker. Recommendations are left out when the user has turned
+// them off, leaving only the accounts they follow.
+func (cfg *apiConfig) rankForYou(ctx context.Context, user database.User, ranker ranking.FeedRanker) ([]uuid.UUID, error) {
+	candidates := []ranking.Candidate{}
+	add := func(dbChirps []database.Chirp, source ranking.Source) {
+		for _, dbChirp := range dbChirps {
+			candidates = append(candidates, ranking.Candidate{
+				ChirpID:   dbChirp.ID,
+				AuthorID:  dbChirp.UserID,
+				CreatedAt: dbChirp.CreatedAt,
+				Likes:     int(dbChirp.LikeCount),
+				Replies:   int(dbChirp.ReplyCount),
+				Source:    source,
+			})
+		}
+	}
+
+	following, err := cfg.db.GetTimeline(ctx, database.GetTimelineParams{
+		FollowerID: user.ID,
+		Limit:      forYouCandidateLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	add(following, ranking.Following)
+
+	if user.Recommendations {
+		since := time.Now().Add(-forYouRecommendationWindow)
+		network, err := cfg.db.GetNetworkChirps(ctx, database.GetNetworkChirpsParams{
+			ViewerID: user.ID,
+			Since:    since,
+			Limit:    forYouCandidateLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		add(network, ranking.Network)
+
+		tagged, err := cfg.db.GetFollowedHashtagChirps(ctx, database.GetFollowedHashtagChirpsParams{
+			ViewerID: user.ID,
+			Since:    since,
+			Limit:    forYouCandidateLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		add(tagged, ranking.Hashtag)
+	}
+
+	ranked := ranker.Rank(candidates, time.Now())
+	ids := make([]uuid.UUID, 0, len(ranked))
+	for _, candidate := range ranked {
+		ids = append(ids, candidate.ChirpID)
+	}
+	return ids, nil
+}
+
+// handlerGetForYou returns the caller's For You feed: chirps from the
+// accounts they follow blended with popular chirps from further out in
+// their network and from hashtags they follow. The ranking is cached per
+// user so paging through it is consistent; each page's chirps are loaded
+// fresh, so ones deleted since are left out. Users in a variant of the For
+// You ranking experiment get that variant's ranking.
+func (cfg *apiConfig) handlerGetForYou(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parsePageLimit(w, r)
+	if !ok {
+		return
+	}
+	offset := 0
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		var err error
+		offset, err = decodeForYouCursor(token)
+		if err != nil {
+			respondWithError(w, 400, "Invalid cursor")
+			return
+		}
+	}
+
+	user, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	ranker := cfg.feedRanker
+	if variantRanker, ok := forYouRankers[cfg.experimentVariant(r, user.ID, forYouRankingExperiment)]; ok {
+		ranker = variantRanker
+	}
+	ranked, err := cached(r.Context(), cfg.cache, forYouCacheKey(user.ID), forYouCacheTTL, func() ([]uuid.UUID, error) {
+		return cfg.rankForYou(r.Context(), user, ranker)
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve feed")
+		return
+	}
+
+	page := ChirpsPage{Chirps: []Chirp{}}
+	ids := ranked[min(offset, len(ranked)):min(offset+limit, len(ranked))]
+	if offset+limit < len(ranked) {
+		page.NextCursor = encodeForYouCursor(offset + limit)
+	}
+	if len(ids) > 0 {
+		dbChirps, err := cfg.db.GetChirpsByIDs(r.Context(), database.GetChirpsByIDsParams{
+			Ids:      ids,
+			ViewerID: uuid.NullUUID{UUID: user.ID, Valid: true},
+		})
+		if err != nil {
+			respondWithError(w, 500, "Failed to retrieve feed")
+			return
+		}
+		byID := map[uuid.UUID]database.Chirp{}
+		for _, dbChirp := range dbChirps {
+			byID[dbChirp.ID] = dbChirp
+		}
+		for _, id := range ids {
+			if dbChirp, ok := byID[id]; ok {
+				page.Chirps = append(page.Chirps, chirpFromDB(dbChirp))
+			}
+		}
+	}
+
+	if respondIfNotModified(w, r, chirpsETag(page.Chirps, page.NextCursor)) {
+		return
+	}
+	cfg.recordChirpViews(page.Chirps)
+	respondWithJSON(w, 200, page)
+}
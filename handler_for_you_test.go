@@ -0,0 +1,163 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/cache"
+	"github.com/google/uuid"
+)
+
+func forYouIDs(t *testing.T, api *testAPI, user testUser, query string) []uuid.UUID {
+	t.Helper()
+	rec := api.do("GET", "/api/feed/for-you"+query, bearer(user.Token), nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	ids := []uuid.UUID{}
+	for _, chirp := range decodeResponse[ChirpsPage](t, rec).Chirps {
+		ids = append(ids, chirp.ID)
+	}
+	return ids
+}
+
+func TestForYou(t *testing.T) {
+	api := newTestAPI(t, nil)
+	api.cfg.cache = cache.NewLRU(100)
+	alice := api.signUp("alice")
+	bob := api.signUp("bob")
+	carol := api.signUp("carol")
+	dave := api.signUp("dave")
+	erin := api.signUp("erin")
+
+	// alice follows bob, who follows carol, and alice follows #golang
+	for _, follow := range []struct{ follower, followee testUser }{{alice, bob}, {bob, carol}} {
+		rec := api.do("POST", "/api/users/"+follow.followee.ID.String()+"/follow", bearer(follow.follower.Token), nil)
+		if rec.Code != 204 {
+			t.Fatalf("Expected 204 following, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+	rec := api.do("POST", "/api/hashtags/GoLang/follow", bearer(alice.Token), nil)
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 following a hashtag, got %d: %s", rec.Code, rec.Body)
+	}
+
+	own := api.createChirp(alice, "My own chirp")
+	followed := api.createChirp(bob, "From someone alice follows")
+	network := api.createChirp(carol, "From someone bob follows")
+	tagged := api.createChirp(dave, "Loving #golang today")
+	stranger := api.createChirp(erin, "Nobody alice knows")
+	rec = api.do("POST", "/api/chirps/"+network.ID.String()+"/like", bearer(erin.Token), nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 liking, got %d: %s", rec.Code, rec.Body)
+	}
+
+	feed := forYouIDs(t, api, alice, "")
+	for _, want := range []uuid.UUID{followed.ID, network.ID, tagged.ID} {
+		if !slices.Contains(feed, want) {
+			t.Errorf("Expected %v in the feed, got %v", want, feed)
+		}
+	}
+	for _, unwanted := range []uuid.UUID{own.ID, stranger.ID} {
+		if slices.Contains(feed, unwanted) {
+			t.Errorf("Expected %v left out of the feed, got %v", unwanted, feed)
+		}
+	}
+
+	// Pages walk the cached ranking in order
+	paged := []uuid.UUID{}
+	query := "?limit=2"
+	for range len(feed) {
+		rec := api.do("GET", "/api/feed/for-you"+query, bearer(alice.Token), nil)
+		page := decodeResponse[ChirpsPage](t, rec)
+		for _, chirp := range page.Chirps {
+			paged = append(paged, chirp.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query = "?limit=2&cursor=" + page.NextCursor
+	}
+	if !slices.Equal(paged, feed) {
+		t.Errorf("Expected pages to add up to %v, got %v", feed, paged)
+	}
+	rec = api.do("GET", "/api/feed/for-you?cursor=nope", bearer(alice.Token), nil)
+	if rec.Code != 400 {
+		t.Errorf("Expected 400 for a bad cursor, got %d", rec.Code)
+	}
+
+	// Deleted chirps drop out of the cached ranking's pages
+	rec = api.do("DELETE", "/api/chirps/"+tagged.ID.String(), bearer(dave.Token), nil)
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 deleting, got %d: %s", rec.Code, rec.Body)
+	}
+	if feed := forYouIDs(t, api, alice, ""); slices.Contains(feed, tagged.ID) {
+		t.Errorf("Expected the deleted chirp left out, got %v", feed)
+	}
+
+	// Muting drops the cached ranking
+	rec = api.do("POST", "/api/users/"+carol.ID.String()+"/mute", bearer(alice.Token), nil)
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 muting, got %d: %s", rec.Code, rec.Body)
+	}
+	if feed := forYouIDs(t, api, alice, ""); slices.Contains(feed, network.ID) {
+		t.Errorf("Expected the muted user's chirp left out, got %v", feed)
+	}
+
+	// Opting out leaves only the accounts alice follows
+	rec = api.do("PUT", "/api/users/me/settings", bearer(alice.Token), map[string]bool{"recommendations": false})
+	if rec.Code != 200 || decodeResponse[User](t, rec).Recommendations {
+		t.Fatalf("Expected recommendations turned off, got %d: %s", rec.Code, rec.Body)
+	}
+	if feed := forYouIDs(t, api, alice, ""); !slices.Equal(feed, []uuid.UUID{followed.ID}) {
+		t.Errorf("Expected only the followed chirp, got %v", feed)
+	}
+}
+
+func TestFollowHashtag(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	tests := []struct {
+		name       string
+		method     string
+		tag        string
+		wantStatus int
+	}{
+		{"follow", "POST", "golang", 204},
+		{"follow again", "POST", "%23GoLang", 204},
+		{"follow another", "POST", "sqlite", 204},
+		{"invalid", "POST", "123", 400},
+		{"unfollow", "DELETE", "sqlite", 204},
+		{"unfollow again", "DELETE", "sqlite", 404},
+	}
+	for _, tt := range tests {
+		rec := api.do(tt.method, "/api/hashtags/"+tt.tag+"/follow", bearer(alice.Token), nil)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+
+	rec := api.do("GET", "/api/users/me/hashtags", bearer(alice.Token), nil)
+	if tags := decodeResponse[[]string](t, rec); !slices.Equal(tags, []string{"golang"}) {
+		t.Errorf("Expected [golang], got %v", tags)
+	}
+}
+
+func TestForYouRankingExperiment(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"ADMIN_API_KEY": "admin-key"})
+	alice := api.signUp("alice")
+
+	forYouIDs(t, api, alice, "")
+
+	rec := api.do("GET", "/admin/experiments/"+forYouRankingExperiment+"/results", "ApiKey admin-key", nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	results := decodeResponse[ExperimentResults](t, rec).Results
+	experiment, _ := api.cfg.experiments.Get(forYouRankingExperiment)
+	want := experiment.Assign(alice.ID)
+	if len(results) != 1 || results[0].Variant != want || results[0].Kind != experimentExposure || results[0].Users != 1 {
+		t.Errorf("Expected one exposure in %q, got %+v", want, results)
+	}
+}
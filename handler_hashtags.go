@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+// hashtagTimelineLimit caps how many of a tag's newest chirps are returned
+const hashtagTimelineLimit = 100
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" || len(tag) > chirptext.MaxHashtagLength {
+		respondWithError(w, 400, "Invalid hashtag")
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByHashtag(r.Context(), database.GetChirpsByHashtagParams{
+		Tag:      tag,
+		ViewerID: cfg.viewerID(r),
+		Limit:    hashtagTimelineLimit,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve chirps")
+		return
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+
+	respondWithJSON(w, 200, chirps)
+}
+
+// followedHashtag is the hashtag in the request path, writing the error
+// response itself when it returns false
+func followedHashtag(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tag := chirptext.NormalizeHashtag(r.PathValue("tag"))
+	if !chirptext.ValidHashtag(tag) {
+		respondWithError(w, 400, "Invalid hashtag")
+		return "", false
+	}
+	return tag, true
+}
+
+// handlerFollowHashtag adds a hashtag to the ones the For You feed
+// recommends chirps from
+func (cfg *apiConfig) handlerFollowHashtag(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+	tag, ok := followedHashtag(w, r)
+	if !ok {
+		return
+	}
+
+	// Following twice is a no-op
+	created, err := cfg.db.CreateHashtagFollow(r.Context(), database.CreateHashtagFollowParams{
+		UserID: userID,
+		Tag:    tag,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to follow hashtag")
+		return
+	}
+	if created > 0 {
+		cfg.invalidateForYou(r.Context(), userID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowHashtag(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+	tag, ok := followedHashtag(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteHashtagFollow(r.Context(), database.DeleteHashtagFollowParams{
+		UserID: userID,
+		Tag:    tag,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to unfollow hashtag")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Not following hashtag")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetFollowedHashtags lists the caller's followed hashtags, most
+// recently followed first
+func (cfg *apiConfig) handlerGetFollowedHashtags(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	tags, err := cfg.db.GetFollowedHashtags(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve hashtags")
+		return
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	respondWithJSON(w, 200, tags)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds each dependency check, so a hung database
+// fails the probe instead of stalling it
+const healthCheckTimeout = 2 * time.Second
+
+var errMigrationsBehind = errors.New("migrations not applied")
+
+// pinger is implemented by caches that talk to a server
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+	// Checks maps each dependency to "ok" or what's wrong with it. Errors
+	// are logged rather than returned, since they name internal hosts.
+	Checks map[string]string `json:"checks"`
+}
+
+// handlerHealthz is the liveness probe: it reports whether the database,
+// and Redis when it's the cache, can be reached
+func (cfg *apiConfig) handlerHealthz(w http.ResponseWriter, r *http.Request) {
+	respondWithHealth(w, cfg.dependencyChecks(r))
+}
+
+// handlerReadyz is the readiness probe: on top of the dependencies being
+// up, the schema has to be migrated to this build's latest version and the
+// connection pool warmed, so traffic isn't sent to an instance that would
+// fail or stall on it
+func (cfg *apiConfig) handlerReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := cfg.dependencyChecks(r)
+	if checks["database"] == "ok" {
+		checks["migrations"] = checkResult(r, "migrations", cfg.checkMigrations(r.Context()))
+	}
+	checks["pool"] = "ok"
+	if !cfg.poolWarm.Load() {
+		checks["pool"] = "warming up"
+	}
+	respondWithHealth(w, checks)
+}
+
+func (cfg *apiConfig) dependencyChecks(r *http.Request) map[string]string {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]string{}
+	// A demo's data is in memory, with no database to check
+	if cfg.sqlDB != nil {
+		checks["database"] = checkResult(r, "database", cfg.sqlDB.PingContext(ctx))
+	}
+	if cache, ok := cfg.cache.(pinger); ok {
+		checks["cache"] = checkResult(r, "cache", cache.Ping(ctx))
+	}
+	return checks
+}
+
+// checkMigrations compares the version goose recorded against the newest
+// migration. A version is applied if its most recent goose row says so.
+// The query runs on both Postgres and SQLite.
+func (cfg *apiConfig) checkMigrations(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	latest, err := latestMigration(cfg.config.DatabaseDriver)
+	if err != nil {
+		return err
+	}
+	var version int64
+	err = cfg.sqlDB.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version versions
+		WHERE is_applied AND id = (
+			SELECT MAX(id) FROM goose_db_version
+			WHERE version_id = versions.version_id
+		)`).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("%w: at version %d, expected %d", errMigrationsBehind, version, latest)
+	}
+	return nil
+}
+
+func checkResult(r *http.Request, name string, err error) string {
+	if err == nil {
+		return "ok"
+	}
+	logRequestf(r, "Health check of %s failed: %s", name, err)
+	if errors.Is(err, errMigrationsBehind) {
+		return "pending"
+	}
+	return "unavailable"
+}
+
+func respondWithHealth(w http.ResponseWriter, checks map[string]string) {
+	status := healthResponse{Status: "ok", Checks: checks}
+	code := 200
+	for _, result := range checks {
+		if result != "ok" {
+			status.Status = "unavailable"
+			code = 503
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, code, status)
+}
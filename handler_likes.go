@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.authorizeChirpAction(w, r)
+	if !ok {
+		return
+	}
+
+	// Liking twice is a no-op, so only a new like bumps the count
+	var dbChirp database.Chirp
+	err := cfg.withTx(r.Context(), func(q store.Store) error {
+		created, err := q.CreateChirpLike(r.Context(), database.CreateChirpLikeParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			return err
+		}
+		dbChirp, err = q.AdjustChirpLikeCount(r.Context(), database.AdjustChirpLikeCountParams{
+			ID:    chirpID,
+			Delta: int32(created),
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to like chirp")
+		return
+	}
+	cfg.invalidateChirps(r.Context(), chirpID)
+
+	cfg.publishLikes(dbChirp)
+	respondWithJSON(w, 200, chirpFromDB(dbChirp))
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	userID, chirpID, ok := cfg.authorizeChirpAction(w, r)
+	if !ok {
+		return
+	}
+
+	var dbChirp database.Chirp
+	err := cfg.withTx(r.Context(), func(q store.Store) error {
+		deleted, err := q.DeleteChirpLike(r.Context(), database.DeleteChirpLikeParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err != nil || deleted == 0 {
+			return err
+		}
+		dbChirp, err = q.AdjustChirpLikeCount(r.Context(), database.AdjustChirpLikeCountParams{
+			ID:    chirpID,
+			Delta: -1,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to unlike chirp")
+		return
+	}
+	cfg.invalidateChirps(r.Context(), chirpID)
+	if dbChirp.ID != uuid.Nil {
+		cfg.publishLikes(dbChirp)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeChirpAction returns the caller and the chirp, checking that it exists
+// and is published, writing the error response itself when it returns false
+func (cfg *apiConfig) authorizeChirpAction(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID := authUserID(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	dbChirp, err := cfg.getChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil || !dbChirp.PublishedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, chirpID, true
+}
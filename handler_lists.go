@@ -0,0 +1,299 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	listNameMaxLength        = 25
+	listDescriptionMaxLength = 100
+)
+
+type List struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+}
+
+func listFromDB(dbList database.List) List {
+	return List{
+		ID:          dbList.ID,
+		CreatedAt:   dbList.CreatedAt,
+		UpdatedAt:   dbList.UpdatedAt,
+		OwnerID:     dbList.OwnerID,
+		Name:        dbList.Name,
+		Description: dbList.Description,
+		Private:     dbList.IsPrivate,
+	}
+}
+
+// listParams is the body accepted when creating or updating a list
+type listParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
+// decodeListParams reads and validates a list body, writing the error
+// response itself when it returns false
+func decodeListParams(w http.ResponseWriter, r *http.Request) (listParams, bool) {
+	params := listParams{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return listParams{}, false
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	params.Description = strings.TrimSpace(params.Description)
+	if params.Name == "" || len([]rune(params.Name)) > listNameMaxLength {
+		respondWithError(w, 400, "List name must be between 1 and 25 characters")
+		return listParams{}, false
+	}
+	if len([]rune(params.Description)) > listDescriptionMaxLength {
+		respondWithError(w, 400, "List description is too long")
+		return listParams{}, false
+	}
+	return params, true
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	params, ok := decodeListParams(w, r)
+	if !ok {
+		return
+	}
+
+	dbList, err := cfg.db.CreateList(r.Context(), database.CreateListParams{
+		OwnerID:     userID,
+		Name:        params.Name,
+		Description: params.Description,
+		IsPrivate:   params.Private,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to create list")
+		return
+	}
+
+	respondWithJSON(w, 201, listFromDB(dbList))
+}
+
+// handlerGetMyLists returns every list the caller owns, private ones included
+func (cfg *apiConfig) handlerGetMyLists(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbLists, err := cfg.db.GetListsByOwner(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve lists")
+		return
+	}
+
+	lists := []List{}
+	for _, dbList := range dbLists {
+		lists = append(lists, listFromDB(dbList))
+	}
+
+	respondWithJSON(w, 200, lists)
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, false)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, 200, listFromDB(dbList))
+}
+
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, true)
+	if !ok {
+		return
+	}
+
+	params, ok := decodeListParams(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := cfg.db.UpdateList(r.Context(), database.UpdateListParams{
+		ID:          dbList.ID,
+		Name:        params.Name,
+		Description: params.Description,
+		IsPrivate:   params.Private,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to update list")
+		return
+	}
+
+	respondWithJSON(w, 200, listFromDB(updated))
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, true)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteList(r.Context(), dbList.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, false)
+	if !ok {
+		return
+	}
+
+	dbUsers, err := cfg.db.GetListMembers(r.Context(), dbList.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve list members")
+		return
+	}
+
+	respondWithJSON(w, 200, publicUsersFromDB(dbUsers))
+}
+
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, true)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	_, err = cfg.db.GetUserByID(r.Context(), memberID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	// Adding an existing member is a no-op
+	err = cfg.db.AddListMember(r.Context(), database.AddListMemberParams{
+		ListID: dbList.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to add list member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, true)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	deleted, err := cfg.db.RemoveListMember(r.Context(), database.RemoveListMemberParams{
+		ListID: dbList.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to remove list member")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "User is not a member of this list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetListChirps serves a timeline built only from the list members'
+// chirps, newest first
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, r *http.Request) {
+	dbList, ok := cfg.authorizeList(w, r, false)
+	if !ok {
+		return
+	}
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.GetListTimelineParams{
+		ListID:   dbList.ID,
+		ViewerID: uuid.NullUUID{UUID: authUserID(r), Valid: true},
+		Limit:    int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbChirps, err := cfg.db.GetListTimeline(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve list timeline")
+		return
+	}
+
+	page := chirpsPageFromDB(dbChirps, limit)
+	cfg.recordChirpViews(page.Chirps)
+	respondWithJSON(w, 200, page)
+}
+
+// authorizeList loads the list named in the path for the caller. Private lists are hidden from everyone but their owner, and
+// requireOwner restricts the action to the owner outright. It writes the
+// error response itself when it returns false.
+func (cfg *apiConfig) authorizeList(w http.ResponseWriter, r *http.Request, requireOwner bool) (database.List, bool) {
+	userID := authUserID(r)
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid list ID")
+		return database.List{}, false
+	}
+
+	dbList, err := cfg.db.GetListByID(r.Context(), listID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "List not found")
+		return database.List{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve list")
+		return database.List{}, false
+	}
+
+	isOwner := dbList.OwnerID == userID
+	if dbList.IsPrivate && !isOwner {
+		respondWithError(w, 404, "List not found")
+		return database.List{}, false
+	}
+	if requireOwner && !isOwner {
+		respondWithError(w, 403, "You can only modify your own lists")
+		return database.List{}, false
+	}
+
+	return dbList, true
+}
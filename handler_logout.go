@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+// handlerLogout revokes the access token it's called with and, if one is
+// given, the refresh token from the same login
+func (cfg *apiConfig) handlerLogout(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// Get and validate JWT
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	// Any token may revoke itself, whatever its scopes
+	claims, err := cfg.parseJWT(r.Context(), token)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	// The body is optional
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	auditUserID := uuid.NullUUID{UUID: userID, Valid: true}
+	if params.RefreshToken != "" {
+		// Only the caller's own refresh tokens can be revoked here
+		revoked, err := cfg.db.RevokeRefreshTokenForUser(r.Context(), database.RevokeRefreshTokenForUserParams{
+			Token:  params.RefreshToken,
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, 500, "Failed to revoke refresh token")
+			return
+		}
+		if revoked > 0 {
+			cfg.audit(r, auditRefreshTokenRevoked, auditUserID, "logout")
+		}
+	}
+
+	if claims.ID != "" {
+		err = cfg.db.RevokeAccessToken(r.Context(), database.RevokeAccessTokenParams{
+			Jti:       claims.ID,
+			UserID:    userID,
+			ExpiresAt: claims.ExpiresAt.Time,
+		})
+		if err != nil {
+			respondWithError(w, 500, "Failed to revoke access token")
+			return
+		}
+		cfg.audit(r, auditAccessTokenRevoked, auditUserID, "logout")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRevokeAllSessions signs the user out everywhere: every refresh token
+// is revoked and bumping the token version invalidates every access token,
+// including the one making the request
+func (cfg *apiConfig) handlerRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	err := cfg.withTx(r.Context(), func(q store.Store) error {
+		err := q.RevokeUserRefreshTokens(r.Context(), userID)
+		if err != nil {
+			return err
+		}
+		return q.IncrementUserTokenVersion(r.Context(), userID)
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to revoke sessions")
+		return
+	}
+	cfg.audit(r, auditSessionsRevoked, uuid.NullUUID{UUID: userID, Valid: true}, "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/mail"
+	"github.com/Utkarsh736/chirpy/internal/store"
+)
+
+const (
+	magicLinkTTL = 15 * time.Minute
+	// magicLinkResendInterval stops the endpoint being used to flood an
+	// inbox
+	magicLinkResendInterval = time.Minute
+	magicLinkPurgeInterval  = 10 * time.Minute
+)
+
+// handlerRequestMagicLink emails a sign-in link to the address given. The
+// response is the same whether or not the address has an account, so it
+// can't be used to find out who has signed up.
+func (cfg *apiConfig) handlerRequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	email, ok := normalizeEmail(params.Email)
+	if !ok {
+		respondWithError(w, 400, "Invalid email address")
+		return
+	}
+
+	recent, err := cfg.db.MagicLinkSentSince(r.Context(), database.MagicLinkSentSinceParams{
+		Email:     email,
+		CreatedAt: time.Now().Add(-magicLinkResendInterval),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to send login link")
+		return
+	}
+	if recent {
+		respondWithError(w, 429, "A login link was sent recently, please check your email")
+		return
+	}
+
+	err = cfg.sendMagicLink(r.Context(), email, magicLinkTemplate)
+	if err != nil {
+		logRequestf(r, "Sending login link failed: %v", err)
+		respondWithError(w, 500, "Failed to send login link")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendMagicLink emails a login link to email in template, which is given
+// the link as .Link
+func (cfg *apiConfig) sendMagicLink(ctx context.Context, email string, template *mail.Template) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.db.CreateMagicLinkToken(ctx, database.CreateMagicLinkTokenParams{
+		TokenHash: auth.HashToken(token),
+		Email:     email,
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := template.Render(email, map[string]string{
+		"Link": cfg.config.BaseURL + "/api/login/magic/verify?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, msg)
+}
+
+// handlerVerifyMagicLink exchanges a login link for the same tokens as
+// handlerLogin, creating the account on first use
+func (cfg *apiConfig) handlerVerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, 400, "token is required")
+		return
+	}
+
+	dbToken, err := cfg.db.ConsumeMagicLinkToken(r.Context(), auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && time.Now().After(dbToken.ExpiresAt)) {
+		respondWithError(w, 401, "Invalid or expired login link")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to sign in")
+		return
+	}
+
+	// Opening the link proves the address, so it also counts as verifying it
+	var dbUser database.User
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		dbUser, err = claimUserByEmail(r.Context(), q, dbToken.Email)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to sign in")
+		return
+	}
+
+	cfg.respondWithLogin(w, r, dbUser)
+}
+
+// purgeExpiredMagicLinks drops login links that were never opened
+func (cfg *apiConfig) purgeExpiredMagicLinks(ctx context.Context) error {
+	_, err := cfg.db.DeleteExpiredMagicLinkTokens(ctx)
+	return err
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+// mentionsLimit caps how many of the newest mentioning chirps are returned
+const mentionsLimit = 100
+
+func (cfg *apiConfig) handlerGetMyMentions(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbChirps, err := cfg.db.GetMentionsForUser(r.Context(), database.GetMentionsForUserParams{
+		UserID: userID,
+		Limit:  mentionsLimit,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve mentions")
+		return
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+
+	respondWithJSON(w, 200, chirps)
+}
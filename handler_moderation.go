@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+// Report statuses; a report is open until a moderator resolves it
+const (
+	reportStatusOpen     = "open"
+	reportStatusResolved = "resolved"
+)
+
+const (
+	moderationActionChirpDeleted = "chirp_deleted"
+	notificationChirpRemoved     = "chirp.removed"
+)
+
+// reportDecisions maps each action a moderator can take on a report to the
+// decision recorded for it
+var reportDecisions = map[string]string{
+	"dismiss":        "dismissed",
+	"delete_chirp":   "chirp_deleted",
+	"suspend_author": "author_suspended",
+}
+
+// errAuthorOutranksModerator means a report's author has at least the role
+// of the moderator trying to suspend them
+var errAuthorOutranksModerator = errors.New("author's role is not below the moderator's")
+
+// ReportsPage is one page of the moderation queue. NextCursor is empty on
+// the last page.
+type ReportsPage struct {
+	Reports    []ChirpReport `json:"reports"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ModeratedChirp is a chirp as moderators see it, deleted or not
+type ModeratedChirp struct {
+	Chirp
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func moderatedChirpFromDB(dbChirp database.Chirp) ModeratedChirp {
+	chirp := ModeratedChirp{Chirp: chirpFromDB(dbChirp)}
+	if dbChirp.DeletedAt.Valid {
+		chirp.DeletedAt = &dbChirp.DeletedAt.Time
+	}
+	return chirp
+}
+
+// ModerationAction is an entry in the moderation log. ModeratorID is empty
+// for actions taken with the admin API key.
+type ModerationAction struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID *uuid.UUID `json:"moderator_id,omitempty"`
+	Action      string     `json:"action"`
+	ChirpID     uuid.UUID  `json:"chirp_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Reason      string     `json:"reason"`
+}
+
+func moderationActionFromDB(dbAction database.ModerationAction) ModerationAction {
+	action := ModerationAction{
+		ID:        dbAction.ID,
+		CreatedAt: dbAction.CreatedAt,
+		Action:    dbAction.Action,
+		ChirpID:   dbAction.ChirpID,
+		UserID:    dbAction.UserID,
+		Reason:    dbAction.Reason,
+	}
+	if dbAction.ModeratorID.Valid {
+		action.ModeratorID = &dbAction.ModeratorID.UUID
+	}
+	return action
+}
+
+// ModerationActionsPage is one page of the moderation log. NextCursor is
+// empty on the last page.
+type ModerationActionsPage struct {
+	Actions    []ModerationAction `json:"actions"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// ReportContext is a report with what a moderator needs to decide on it:
+// the chirp, the chirp it replies to, its author and every report against
+// it
+type ReportContext struct {
+	Report  ChirpReport     `json:"report"`
+	Chirp   ModeratedChirp  `json:"chirp"`
+	Parent  *ModeratedChirp `json:"parent,omitempty"`
+	Author  AdminUser       `json:"author"`
+	Reports []ChirpReport   `json:"reports"`
+}
+
+// handlerListReports pages through reports oldest first, the open ones
+// unless ?status= says otherwise, optionally filtered by ?reason=
+func (cfg *apiConfig) handlerListReports(w http.ResponseWriter, r *http.Request) {
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = reportStatusOpen
+	case reportStatusOpen, reportStatusResolved:
+	default:
+		respondWithError(w, 400, "status must be open or resolved")
+		return
+	}
+
+	params := database.ListChirpReportsParams{
+		Status: optionalString(status),
+		Reason: optionalString(r.URL.Query().Get("reason")),
+		Limit:  int32(limit + 1),
+	}
+	if cursor != nil {
+		params.AfterCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbReports, err := cfg.db.ListChirpReports(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve reports")
+		return
+	}
+
+	dbReports, nextCursor := pageRows(dbReports, limit, func(last database.ChirpReport) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := ReportsPage{Reports: []ChirpReport{}, NextCursor: nextCursor}
+	for _, dbReport := range dbReports {
+		page.Reports = append(page.Reports, chirpReportFromDB(dbReport))
+	}
+
+	respondWithJSON(w, 200, page)
+}
+
+func (cfg *apiConfig) handlerGetReport(w http.ResponseWriter, r *http.Request) {
+	dbReport, ok := cfg.moderatedReport(w, r)
+	if !ok {
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpForModeration(r.Context(), dbReport.ChirpID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve chirp")
+		return
+	}
+	dbAuthor, err := cfg.db.GetUserByID(r.Context(), dbChirp.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve author")
+		return
+	}
+	dbReports, err := cfg.db.GetChirpReportsForChirp(r.Context(), dbChirp.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve reports")
+		return
+	}
+
+	reportContext := ReportContext{
+		Report:  chirpReportFromDB(dbReport),
+		Chirp:   moderatedChirpFromDB(dbChirp),
+		Author:  adminUserFromDB(dbAuthor),
+		Reports: []ChirpReport{},
+	}
+	if dbChirp.ParentChirpID.Valid {
+		dbParent, err := cfg.db.GetChirpForModeration(r.Context(), dbChirp.ParentChirpID.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, 500, "Failed to retrieve chirp")
+			return
+		}
+		if err == nil {
+			parent := moderatedChirpFromDB(dbParent)
+			reportContext.Parent = &parent
+		}
+	}
+	for _, dbReport := range dbReports {
+		reportContext.Reports = append(reportContext.Reports, chirpReportFromDB(dbReport))
+	}
+
+	respondWithJSON(w, 200, reportContext)
+}
+
+// handlerResolveReport acts on an open report: dismissing it, deleting the
+// chirp or suspending its author (indefinitely, or until the optional
+// until). The decision resolves every open report against the chirp.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Action string     `json:"action"`
+		Note   string     `json:"note"`
+		Until  *time.Time `json:"until"`
+	}
+
+	dbReport, ok := cfg.moderatedReport(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	decision, ok := reportDecisions[params.Action]
+	if !ok {
+		respondWithError(w, 400, "action must be dismiss, delete_chirp or suspend_author")
+		return
+	}
+	if len([]rune(params.Note)) > suspensionReasonMaxLength {
+		respondWithError(w, 400, "note must be at most 500 characters")
+		return
+	}
+	until := sql.NullTime{}
+	if params.Until != nil {
+		if params.Action != "suspend_author" {
+			respondWithError(w, 400, "until only applies to suspend_author")
+			return
+		}
+		if !params.Until.After(time.Now()) {
+			respondWithError(w, 400, "until must be in the future")
+			return
+		}
+		until = sql.NullTime{Time: *params.Until, Valid: true}
+	}
+	if dbReport.Status != reportStatusOpen {
+		respondWithError(w, 409, "Report is already resolved")
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirpForModeration(r.Context(), dbReport.ChirpID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve chirp")
+		return
+	}
+
+	var resolved []database.ChirpReport
+	var deleted database.Chirp
+	var notification database.Notification
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		resolved, err = q.ResolveChirpReports(r.Context(), database.ResolveChirpReportsParams{
+			ChirpID:      dbChirp.ID,
+			Decision:     decision,
+			DecisionNote: params.Note,
+			DecidedBy:    staffUserID(r),
+		})
+		if err != nil || len(resolved) == 0 {
+			return err
+		}
+
+		reason := params.Note
+		if reason == "" {
+			reason = "Reported for " + dbReport.Reason
+		}
+		switch params.Action {
+		case "delete_chirp":
+			if dbChirp.DeletedAt.Valid {
+				return nil
+			}
+			deleted, notification, err = takeDownChirp(r.Context(), q, dbChirp, staffUserID(r), reason)
+			// Deleted since it was read; the reports are resolved all the same
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		case "suspend_author":
+			// Locked so a promotion can't slip in between the check and
+			// the suspension. Only admins can suspend their peers.
+			author, err := q.GetUserByIDForUpdate(r.Context(), dbChirp.UserID)
+			if err != nil {
+				return err
+			}
+			role := staffRole(r)
+			if role != auth.RoleAdmin && auth.HasRole(author.Role, role) {
+				return errAuthorOutranksModerator
+			}
+			_, err = suspendUser(r.Context(), q, dbChirp.UserID, reason, until)
+			return err
+		}
+		return nil
+	})
+	if errors.Is(err, errAuthorOutranksModerator) {
+		respondWithError(w, 403, "Only an admin can suspend a moderator or admin")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to resolve report")
+		return
+	}
+	// Another moderator got there first
+	if len(resolved) == 0 {
+		respondWithError(w, 409, "Report is already resolved")
+		return
+	}
+
+	if deleted.DeletedAt.Valid {
+		cfg.chirpDeleted(r.Context(), deleted)
+		cfg.publishNotification(notification)
+	}
+
+	report := chirpReportFromDB(dbReport)
+	for _, dbResolved := range resolved {
+		if dbResolved.ID == dbReport.ID {
+			report = chirpReportFromDB(dbResolved)
+		}
+	}
+	respondWithJSON(w, 200, report)
+}
+
+// handlerTakeDownChirp deletes someone else's chirp for a moderator or
+// admin, with the reason they give in the body. handlerDeleteChirp hands
+// over to it once it's checked the caller's role.
+func (cfg *apiConfig) handlerTakeDownChirp(w http.ResponseWriter, r *http.Request, dbChirp database.Chirp) {
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithBodyError(w, err)
+		return
+	}
+	params.Reason = strings.TrimSpace(params.Reason)
+	if params.Reason == "" || len([]rune(params.Reason)) > suspensionReasonMaxLength {
+		respondWithError(w, 400, "reason must be 1-500 characters to delete someone else's chirp")
+		return
+	}
+
+	var deleted database.Chirp
+	var notification database.Notification
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		deleted, notification, err = takeDownChirp(r.Context(), q, dbChirp, uuid.NullUUID{UUID: authUserID(r), Valid: true}, params.Reason)
+		return err
+	})
+	// Deleted since it was read
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete chirp")
+		return
+	}
+	cfg.chirpDeleted(r.Context(), deleted)
+	cfg.publishNotification(notification)
+	cfg.audit(r, auditAdminAction, uuid.NullUUID{UUID: dbChirp.UserID, Valid: true}, "took down chirp "+dbChirp.ID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// takeDownChirp soft-deletes a chirp on a moderator's behalf, records it in
+// the moderation log and leaves its author a notification with the reason.
+// Like softDeleteChirp it returns the chirp as deleted, or sql.ErrNoRows if
+// it already was. Once committed, chirpDeleted should follow and the
+// notification be published.
+func takeDownChirp(ctx context.Context, q store.Store, dbChirp database.Chirp, moderatorID uuid.NullUUID, reason string) (database.Chirp, database.Notification, error) {
+	deleted, err := softDeleteChirp(ctx, q, dbChirp.ID)
+	if err != nil {
+		return database.Chirp{}, database.Notification{}, err
+	}
+	_, err = q.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: moderatorID,
+		Action:      moderationActionChirpDeleted,
+		ChirpID:     dbChirp.ID,
+		UserID:      dbChirp.UserID,
+		Reason:      reason,
+	})
+	if err != nil {
+		return database.Chirp{}, database.Notification{}, err
+	}
+	notification, err := q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  dbChirp.UserID,
+		Kind:    notificationChirpRemoved,
+		Message: "A moderator removed your chirp: " + reason,
+	})
+	return deleted, notification, err
+}
+
+// handlerListModerationActions pages through the moderation log newest
+// first, optionally for one author (?user_id=) or moderator
+// (?moderator_id=)
+func (cfg *apiConfig) handlerListModerationActions(w http.ResponseWriter, r *http.Request) {
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.ListModerationActionsParams{Limit: int32(limit + 1)}
+	for name, filter := range map[string]*uuid.NullUUID{"user_id": &params.UserID, "moderator_id": &params.ModeratorID} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, 400, "Invalid "+name)
+			return
+		}
+		*filter = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbActions, err := cfg.db.ListModerationActions(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve moderation log")
+		return
+	}
+
+	dbActions, nextCursor := pageRows(dbActions, limit, func(last database.ModerationAction) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := ModerationActionsPage{Actions: []ModerationAction{}, NextCursor: nextCursor}
+	for _, dbAction := range dbActions {
+		page.Actions = append(page.Actions, moderationActionFromDB(dbAction))
+	}
+
+	respondWithJSON(w, 200, page)
+}
+
+// moderatedReport loads the report named in the path. It writes the error
+// response itself when it returns false.
+func (cfg *apiConfig) moderatedReport(w http.ResponseWriter, r *http.Request) (database.ChirpReport, bool) {
+	reportID, err := uuid.Parse(r.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid report ID")
+		return database.ChirpReport{}, false
+	}
+
+	dbReport, err := cfg.db.GetChirpReport(r.Context(), reportID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Report not found")
+		return database.ChirpReport{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve report")
+		return database.ChirpReport{}, false
+	}
+	return dbReport, true
+}
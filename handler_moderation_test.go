@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// promote gives user role and signs them in again, since changing a role
+// invalidates their access tokens
+func (api *testAPI) promote(user testUser, handle, role string) testUser {
+	api.t.Helper()
+	_, err := api.cfg.db.SetUserRole(context.Background(), database.SetUserRoleParams{ID: user.ID, Role: role})
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	rec := api.do("POST", "/api/login", "", map[string]string{"email": handle + "@example.com", "password": testPassword})
+	if rec.Code != 200 {
+		api.t.Fatalf("Expected 200 signing in %s, got %d: %s", handle, rec.Code, rec.Body)
+	}
+	user.Token = decodeResponse[struct {
+		Token string `json:"token"`
+	}](api.t, rec).Token
+	return user
+}
+
+func TestResolveReportSuspendAuthor(t *testing.T) {
+	api := newTestAPI(t, nil)
+	moderator := api.promote(api.signUp("mod"), "mod", auth.RoleModerator)
+	admin := api.promote(api.signUp("admin"), "admin", auth.RoleAdmin)
+	alice := api.signUp("alice")
+	reporter := api.signUp("reporter")
+
+	report := func(author testUser) uuid.UUID {
+		t.Helper()
+		chirp := api.createChirp(author, "Reported")
+		rec := api.do("POST", "/api/chirps/"+chirp.ID.String()+"/report", bearer(reporter.Token), map[string]string{"reason": "spam"})
+		if rec.Code != 201 {
+			t.Fatalf("Expected 201 reporting, got %d: %s", rec.Code, rec.Body)
+		}
+		return decodeResponse[ChirpReport](t, rec).ID
+	}
+	suspend := func(moderator testUser, reportID uuid.UUID) int {
+		return api.do("POST", "/admin/reports/"+reportID.String()+"/resolve", bearer(moderator.Token), map[string]string{"action": "suspend_author"}).Code
+	}
+
+	adminReport := report(admin)
+	if code := suspend(moderator, adminReport); code != 403 {
+		t.Errorf("Expected 403 when a moderator suspends an admin, got %d", code)
+	}
+	dbAdmin, err := api.cfg.db.GetUserByID(context.Background(), admin.ID)
+	if err != nil || dbAdmin.SuspendedAt.Valid {
+		t.Errorf("Expected the admin left unsuspended, got %+v (%v)", dbAdmin, err)
+	}
+	rec := api.do("GET", "/admin/reports/"+adminReport.String(), bearer(moderator.Token), nil)
+	if got := decodeResponse[ReportContext](t, rec); got.Report.Status != reportStatusOpen {
+		t.Errorf("Expected the report left open, got %q", got.Report.Status)
+	}
+
+	if code := suspend(moderator, report(api.promote(api.signUp("mod2"), "mod2", auth.RoleModerator))); code != 403 {
+		t.Errorf("Expected 403 when a moderator suspends another moderator, got %d", code)
+	}
+	if code := suspend(moderator, report(alice)); code != 200 {
+		t.Errorf("Expected 200 when a moderator suspends a user, got %d", code)
+	}
+	if code := suspend(admin, adminReport); code != 200 {
+		t.Errorf("Expected 200 when an admin suspends an admin, got %d", code)
+	}
+}
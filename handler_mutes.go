@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, mutedID, ok := cfg.authorizeMute(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.GetUserByID(r.Context(), mutedID)
+	if err != nil {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+
+	// Muting twice is a no-op
+	err = cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to mute user")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), muterID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, mutedID, ok := cfg.authorizeMute(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to unmute user")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "User is not muted")
+		return
+	}
+	cfg.invalidateForYou(r.Context(), muterID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeMute returns the caller and the target user ID, writing the
+// error response itself when it returns false
+func (cfg *apiConfig) authorizeMute(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	muterID := authUserID(r)
+
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	if mutedID == muterID {
+		respondWithError(w, 400, "You can't mute yourself")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return muterID, mutedID, true
+}
+
+func (cfg *apiConfig) handlerGetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbUsers, err := cfg.db.GetMutedUsers(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve muted users")
+		return
+	}
+
+	respondWithJSON(w, 200, publicUsersFromDB(dbUsers))
+}
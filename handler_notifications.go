@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbNotifications, err := cfg.db.GetNotificationsForUser(r.Context(), database.GetNotificationsForUserParams{
+		UserID: userID,
+		Limit:  50,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve notifications")
+		return
+	}
+
+	notifications := []Notification{}
+	for _, dbNotification := range dbNotifications {
+		notification := Notification{
+			ID:        dbNotification.ID,
+			CreatedAt: dbNotification.CreatedAt,
+			Kind:      dbNotification.Kind,
+			Message:   dbNotification.Message,
+		}
+		if dbNotification.ReadAt.Valid {
+			notification.ReadAt = &dbNotification.ReadAt.Time
+		}
+		notifications = append(notifications, notification)
+	}
+
+	respondWithJSON(w, 200, notifications)
+}
+
+func (cfg *apiConfig) handlerMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	err := cfg.db.MarkNotificationsRead(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to update notifications")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
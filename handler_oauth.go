@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/oauth"
+	"github.com/Utkarsh736/chirpy/internal/store"
+)
+
+const (
+	oauthStateCookie = "oauth_state"
+	// oauthStateMaxAge is how long the user has to approve the login at the
+	// provider, in seconds
+	oauthStateMaxAge = 10 * 60
+)
+
+// oauthRedirectURI is the callback registered with the provider
+func (cfg *apiConfig) oauthRedirectURI(provider string) string {
+	return cfg.config.BaseURL + "/api/oauth/" + provider + "/callback"
+}
+
+// handlerOAuthLogin sends the browser to the provider, remembering a random
+// state in a cookie so the callback can prove it belongs to this browser
+func (cfg *apiConfig) handlerOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.oauthProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, 404, "Unknown OAuth provider")
+		return
+	}
+
+	state, err := oauth.NewState()
+	if err != nil {
+		respondWithError(w, 500, "Failed to start OAuth login")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Value:    state,
+		Path:     "/api/oauth/" + provider.Name,
+		MaxAge:   oauthStateMaxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(cfg.config.BaseURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, provider.AuthCodeURL(state, cfg.oauthRedirectURI(provider.Name)), http.StatusFound)
+}
+
+// handlerOAuthCallback completes the code exchange and signs in the local
+// user linked to the provider account, linking or creating one by verified
+// email on first use
+func (cfg *apiConfig) handlerOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := cfg.oauthProviders[r.PathValue("provider")]
+	if !ok {
+		respondWithError(w, 404, "Unknown OAuth provider")
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		respondWithError(w, 400, "OAuth login was not approved")
+		return
+	}
+
+	cookie, err := r.Cookie(oauthStateCookie)
+	if err != nil || query.Get("state") == "" ||
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		respondWithError(w, 400, "Invalid OAuth state")
+		return
+	}
+	// The state is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:   oauthStateCookie,
+		Path:   "/api/oauth/" + provider.Name,
+		MaxAge: -1,
+	})
+
+	accessToken, err := provider.Exchange(r.Context(), query.Get("code"), cfg.oauthRedirectURI(provider.Name))
+	if err != nil {
+		logRequestf(r, "OAuth code exchange with %s failed: %v", provider.Name, err)
+		respondWithError(w, 502, "Failed to complete OAuth login")
+		return
+	}
+	identity, err := provider.Identity(r.Context(), accessToken)
+	if err != nil {
+		logRequestf(r, "Fetching %s identity failed: %v", provider.Name, err)
+		respondWithError(w, 502, "Failed to complete OAuth login")
+		return
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		respondWithError(w, 403, "The provider account has no verified email address")
+		return
+	}
+
+	var dbUser database.User
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		dbUser, err = linkOAuthUser(r.Context(), q, provider.Name, identity)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to sign in")
+		return
+	}
+
+	cfg.respondWithLogin(w, r, dbUser)
+}
+
+// linkOAuthUser finds the local user for identity, creating or linking one
+// by email the first time the provider account is seen
+func linkOAuthUser(ctx context.Context, q store.Store, provider string, identity oauth.Identity) (database.User, error) {
+	dbUser, err := q.GetOAuthIdentityUser(ctx, database.GetOAuthIdentityUserParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		return dbUser, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	dbUser, err = claimUserByEmail(ctx, q, identity.Email)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	err = q.CreateOAuthIdentity(ctx, database.CreateOAuthIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+		UserID:   dbUser.ID,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return dbUser, nil
+}
+
+// claimUserByEmail returns the account for an address its owner has just
+// proved control of, creating one if there isn't one yet
+func claimUserByEmail(ctx context.Context, q store.Store, email string) (database.User, error) {
+	// Providers don't always lowercase addresses
+	email = strings.ToLower(strings.TrimSpace(email))
+	dbUser, err := q.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		hashedPassword, err := unusablePasswordHash()
+		if err != nil {
+			return database.User{}, err
+		}
+		dbUser, err = q.CreateUser(ctx, database.CreateUserParams{
+			Email:          email,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+	case err != nil:
+		return database.User{}, err
+	case !dbUser.EmailVerified:
+		// Whoever registered this address never proved they own it, so they
+		// may not be the person now signing in. Lock them out rather than
+		// handing over an account they can still get into.
+		hashedPassword, err := unusablePasswordHash()
+		if err != nil {
+			return database.User{}, err
+		}
+		err = q.SetUserPassword(ctx, database.SetUserPasswordParams{
+			ID:             dbUser.ID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+		err = q.RevokeUserRefreshTokens(ctx, dbUser.ID)
+		if err != nil {
+			return database.User{}, err
+		}
+		err = q.IncrementUserTokenVersion(ctx, dbUser.ID)
+		if err != nil {
+			return database.User{}, err
+		}
+	}
+
+	return q.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{
+		ID:    dbUser.ID,
+		Email: dbUser.Email,
+	})
+}
+
+// unusablePasswordHash hashes a random password nobody knows, for accounts
+// that sign in without one. The user can set a real password through
+// PUT /api/users.
+func unusablePasswordHash() (string, error) {
+	password, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return auth.HashPassword(password)
+}
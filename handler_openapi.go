@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/openapi"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+)
+
+// routeAuth is how a route identifies its caller
+type routeAuth int
+
+const (
+	authNone routeAuth = iota
+	// authBearer takes an access token or a scoped token
+	authBearer
+	// authRefresh takes a refresh token in place of the access token
+	authRefresh
+	authPolka
+	authStripe
+)
+
+// routeDoc describes one API route for the OpenAPI document. Paths and
+// their parameters come from the route table, so only what can't be read
+// off a pattern lives here.
+type routeDoc struct {
+	summary string
+	auth    routeAuth
+	// scopes are what a scoped token needs; full access tokens have them all
+	scopes []string
+	query  []string
+	// request is the JSON body; upload names a multipart file field instead
+	request      any
+	upload       string
+	optionalBody bool
+	// status is the success status, 200 when unset
+	status int
+	// response is the JSON body, nil when there's none
+	response any
+	// contentType is set for responses that aren't JSON
+	contentType string
+}
+
+var pageQuery = []string{"limit", "cursor"}
+
+// apiDocs is keyed like the route table. A route missing here is still in
+// the document, just without a body or summary, and is logged at startup.
+var apiDocs = map[string]routeDoc{
+	"GET /config": {summary: "Server limits for client-side validation", response: struct {
+		MaxChirpLength    int `json:"max_chirp_length"`
+		PasswordMinLength int `json:"password_min_length"`
+	}{}},
+
+	"POST /users": {summary: "Create an account", status: 201, response: User{}, request: struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Handle   string `json:"handle,omitempty"`
+	}{}},
+	"PUT /users": {summary: "Change email, password or handle", auth: authBearer, response: User{}, request: struct {
+		Email    string  `json:"email"`
+		Password string  `json:"password"`
+		Handle   *string `json:"handle,omitempty"`
+	}{}},
+	"DELETE /users/me": {summary: "Permanently delete your account and everything in it, confirmed by your password or a sign-in in the last 5 minutes", auth: authBearer, status: 204, request: struct {
+		Password string `json:"password,omitempty"`
+	}{}},
+	"POST /users/me/deactivate": {summary: "Hide your profile and chirps and sign out everywhere; the account is deleted unless reactivated within 30 days", auth: authBearer, status: 204, request: struct {
+		Password string `json:"password,omitempty"`
+	}{}},
+	"POST /users/reactivate": {summary: "Bring back a deactivated account within its grace period and sign in", response: loginResponse{}, request: struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{}},
+	"GET /verify-email":         {summary: "Confirm an email address with the emailed token", query: []string{"token"}, response: User{}},
+	"POST /verify-email/resend": {summary: "Send the verification email again", auth: authBearer, status: 204},
+	"POST /login": {summary: "Sign in with email and password", response: loginResponse{}, request: struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{}},
+	"POST /login/magic": {summary: "Email a one-time login link", status: 202, request: struct {
+		Email string `json:"email"`
+	}{}},
+	"GET /login/magic/verify":        {summary: "Exchange a login link's token for tokens", query: []string{"token"}, response: loginResponse{}},
+	"GET /oauth/{provider}/login":    {summary: "Redirect to Google or GitHub to sign in", status: 302},
+	"GET /oauth/{provider}/callback": {summary: "Finish an OAuth sign-in", query: []string{"code", "state"}, response: loginResponse{}},
+
+	"POST /webauthn/register/begin":               {summary: "Start registering a passkey", auth: authBearer, response: protocol.PublicKeyCredentialCreationOptions{}},
+	"POST /webauthn/register/finish":              {summary: "Save the passkey the browser created", auth: authBearer, status: 201, request: webauthnRegistrationParams{}, response: Passkey{}},
+	"POST /webauthn/login/begin":                  {summary: "Start signing in with a passkey", response: protocol.PublicKeyCredentialRequestOptions{}},
+	"POST /webauthn/login/finish":                 {summary: "Sign in with the passkey's assertion", request: protocol.CredentialAssertionResponse{}, response: loginResponse{}},
+	"GET /webauthn/credentials":                   {summary: "List your passkeys", auth: authBearer, response: []Passkey{}},
+	"DELETE /webauthn/credentials/{credentialID}": {summary: "Remove a passkey", auth: authBearer, status: 204},
+
+	"POST /refresh": {summary: "Get a new access token", auth: authRefresh, response: struct {
+		Token string `json:"token"`
+	}{}},
+	"POST /revoke": {summary: "Revoke a refresh token", auth: authRefresh, status: 204},
+	"POST /logout": {summary: "Sign out, revoking the access token and optionally a refresh token", auth: authBearer, status: 204, optionalBody: true, request: struct {
+		RefreshToken string `json:"refresh_token,omitempty"`
+	}{}},
+	"POST /users/me/revoke-all":    {summary: "Sign out every session", auth: authBearer, status: 204},
+	"GET /sessions":                {summary: "List active sessions", auth: authBearer, response: []Session{}},
+	"GET /users/me/logins":         {summary: "Your sign-ins, newest first", auth: authBearer, response: LoginsPage{}},
+	"DELETE /sessions/{sessionID}": {summary: "End a session", auth: authBearer, status: 204},
+	"POST /tokens": {summary: "Create a token limited to some scopes", auth: authBearer, status: 201, request: struct {
+		Scopes           []string `json:"scopes"`
+		ExpiresInSeconds int      `json:"expires_in_seconds,omitempty"`
+	}{}, response: struct {
+		Token     string    `json:"token"`
+		Scopes    []string  `json:"scopes"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}},
+
+	"POST /polka/webhooks":  {summary: "Polka subscription events", auth: authPolka, status: 204, request: polkaEvent{}},
+	"POST /stripe/webhooks": {summary: "Stripe billing events", auth: authStripe, request: json.RawMessage{}},
+	"POST /stripe/checkout": {summary: "Start a Stripe checkout for a plan", auth: authBearer, status: 201, request: struct {
+		Plan string `json:"plan"`
+	}{}, response: struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}{}},
+	"GET /plans": {summary: "List plans and their prices", response: []Plan{}},
+	"POST /redeem": {summary: "Redeem a promo code", auth: authBearer, response: User{}, request: struct {
+		Code string `json:"code"`
+	}{}},
+
+	"GET /users/me/export":                     {summary: "Your current data export, starting one if there's none under way or ready (202 until it's built)", auth: authBearer, response: DataExport{}},
+	"GET /users/me/export/{exportID}":          {summary: "The status of one of your data exports", auth: authBearer, response: DataExport{}},
+	"GET /users/me/export/{exportID}/download": {summary: "Download a ready data export as a ZIP of JSON files", auth: authBearer, contentType: "application/zip"},
+
+	"GET /users/me/subscription": {summary: "Your plan and its history", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: struct {
+		Plan      string                     `json:"plan"`
+		Status    string                     `json:"status"`
+		ExpiresAt *time.Time                 `json:"expires_at"`
+		History   []SubscriptionHistoryEvent `json:"history"`
+	}{}},
+	"PUT /users/me/settings": {summary: "Change privacy and recommendation settings or handle", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, response: User{}, request: struct {
+		ShareLocation   *bool   `json:"share_location,omitempty"`
+		Recommendations *bool   `json:"recommendations,omitempty"`
+		Handle          *string `json:"handle,omitempty"`
+	}{}},
+	"PATCH /users/me/profile": {summary: "Edit your public profile", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, response: User{}, request: struct {
+		DisplayName *string `json:"display_name,omitempty"`
+		Bio         *string `json:"bio,omitempty"`
+		Location    *string `json:"location,omitempty"`
+		Website     *string `json:"website,omitempty"`
+	}{}},
+	"POST /users/me/avatar":          {summary: "Upload an avatar image", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, upload: "avatar", response: User{}},
+	"GET /users/me/analytics":        {summary: "Engagement across all your chirps (Chirpy Red)", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, response: ChirpAnalytics{}},
+	"GET /users/me/mentions":         {summary: "Chirps that mention you", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, response: []Chirp{}},
+	"GET /users/me/mutes":            {summary: "Users you muted", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []PublicUser{}},
+	"GET /users/me/blocks":           {summary: "Users you blocked", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []PublicUser{}},
+	"GET /users/me/hashtags":         {summary: "Hashtags you follow", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []string{}},
+	"POST /users/{userID}/gift":      {summary: "Gift Chirpy Red to another user, once per paid billing period", auth: authBearer, status: 204},
+	"POST /users/{userID}/follow":    {summary: "Follow a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"DELETE /users/{userID}/follow":  {summary: "Unfollow a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"POST /users/{userID}/mute":      {summary: "Mute a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"DELETE /users/{userID}/mute":    {summary: "Unmute a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"POST /users/{userID}/block":     {summary: "Block a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"DELETE /users/{userID}/block":   {summary: "Unblock a user", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"GET /users/{userID}/{relation}": {summary: "A user's followers or who they follow (relation is followers or following)", response: []PublicUser{}},
+	"GET /users/by-handle/{handle}":  {summary: "A user's public profile", response: PublicUser{}},
+
+	"POST /chirps": {summary: "Post a chirp or a reply", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 201, response: Chirp{}, request: struct {
+		Body          string     `json:"body"`
+		Latitude      *float64   `json:"lat,omitempty"`
+		Longitude     *float64   `json:"lon,omitempty"`
+		Place         string     `json:"place,omitempty"`
+		ParentChirpID *uuid.UUID `json:"parent_chirp_id,omitempty"`
+	}{}},
+	"GET /chirps":           {summary: "List chirps, as a page when limit or cursor is given", query: []string{"author_id", "sort", "limit", "cursor"}, response: []Chirp{}},
+	"GET /chirps/stream":    {summary: "Server-sent events for new chirps", contentType: "text/event-stream"},
+	"GET /chirps/nearby":    {summary: "Chirps posted near a point", query: []string{"lat", "lon", "radius"}, response: []Chirp{}},
+	"GET /chirps/{chirpID}": {summary: "Get a chirp", response: Chirp{}},
+	"PUT /chirps/{chirpID}": {summary: "Edit your chirp within your plan's edit window (Chirpy Red)", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, response: Chirp{}, request: struct {
+		Body string `json:"body"`
+	}{}},
+	"DELETE /chirps/{chirpID}": {summary: "Delete your chirp, or as a moderator anyone's with a reason", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 204, optionalBody: true, request: struct {
+		Reason string `json:"reason,omitempty"`
+	}{}},
+	"POST /chirps/{chirpID}/translate": {summary: "Translate a chirp", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, query: []string{"to"}, response: struct {
+		ChirpID        uuid.UUID `json:"chirp_id"`
+		Body           string    `json:"body"`
+		Language       string    `json:"language"`
+		SourceLanguage string    `json:"source_language"`
+	}{}},
+	"GET /chirps/{chirpID}/replies":     {summary: "Replies to a chirp", response: []Chirp{}},
+	"GET /chirps/{chirpID}/stats":       {summary: "Engagement on your chirp (Chirpy Red)", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, response: ChirpStats{}},
+	"POST /chirps/{chirpID}/like":       {summary: "Like a chirp", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, response: Chirp{}},
+	"DELETE /chirps/{chirpID}/like":     {summary: "Unlike a chirp", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 204},
+	"POST /chirps/{chirpID}/bookmark":   {summary: "Bookmark a chirp", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 204},
+	"DELETE /chirps/{chirpID}/bookmark": {summary: "Remove a bookmark", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 204},
+	"GET /bookmarks":                    {summary: "Your bookmarked chirps", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, query: pageQuery, response: ChirpsPage{}},
+	"POST /chirps/{chirpID}/report": {summary: "Report someone else's chirp to the moderators", auth: authBearer, scopes: []string{auth.ScopeChirpsWrite}, status: 201, response: ChirpReport{}, request: struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details,omitempty"`
+	}{}},
+
+	"POST /lists":                             {summary: "Create a list", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 201, request: listParams{}, response: List{}},
+	"GET /lists":                              {summary: "Your lists", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []List{}},
+	"GET /lists/{listID}":                     {summary: "Get a list", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: List{}},
+	"PUT /lists/{listID}":                     {summary: "Update a list", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, request: listParams{}, response: List{}},
+	"DELETE /lists/{listID}":                  {summary: "Delete a list", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"GET /lists/{listID}/members":             {summary: "Members of a list", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []PublicUser{}},
+	"PUT /lists/{listID}/members/{userID}":    {summary: "Add a user to a list", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"DELETE /lists/{listID}/members/{userID}": {summary: "Remove a user from a list", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"GET /lists/{listID}/chirps":              {summary: "Chirps by a list's members", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, query: pageQuery, response: ChirpsPage{}},
+
+	"POST /dm/{userID}": {summary: "Send a direct message", auth: authBearer, scopes: []string{auth.ScopeMessagesWrite}, status: 201, response: Message{}, request: struct {
+		Body string `json:"body"`
+	}{}},
+	"GET /dm/conversations":                           {summary: "Your conversations", auth: authBearer, scopes: []string{auth.ScopeMessagesRead}, query: pageQuery, response: ConversationsPage{}},
+	"GET /dm/conversations/{conversationID}/messages": {summary: "Messages in a conversation", auth: authBearer, scopes: []string{auth.ScopeMessagesRead}, query: pageQuery, response: MessagesPage{}},
+
+	"GET /hashtags/{tag}/chirps":    {summary: "Chirps with a hashtag", response: []Chirp{}},
+	"POST /hashtags/{tag}/follow":   {summary: "Follow a hashtag, for the For You feed", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"DELETE /hashtags/{tag}/follow": {summary: "Unfollow a hashtag", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+	"GET /search":                   {summary: "Full-text search of chirps", query: []string{"q", "author_id", "limit", "cursor"}, response: ChirpsPage{}},
+	"GET /timeline":                 {summary: "Chirps from users you follow", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, query: pageQuery, response: ChirpsPage{}},
+	"GET /feed/for-you":             {summary: "Chirps from users you follow blended with recommendations, best first", auth: authBearer, scopes: []string{auth.ScopeChirpsRead}, query: pageQuery, response: ChirpsPage{}},
+	"GET /stream":                   {summary: "WebSocket of chirps and likes from users you follow", auth: authBearer, scopes: []string{auth.ScopeChirpsRead, auth.ScopeUsersRead}, query: []string{"access_token"}, status: 101},
+
+	"GET /experiments": {summary: "Your variant in each running A/B experiment", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []ExperimentAssignment{}},
+	"POST /experiments/{key}/events": {summary: "Record an exposure or a named conversion in your variant of an experiment", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204, request: struct {
+		Kind string `json:"kind"`
+		Name string `json:"name,omitempty"`
+	}{}},
+
+	"GET /notifications":       {summary: "Your notifications", auth: authBearer, scopes: []string{auth.ScopeUsersRead}, response: []Notification{}},
+	"POST /notifications/read": {summary: "Mark every notification read", auth: authBearer, scopes: []string{auth.ScopeUsersWrite}, status: 204},
+
+	"POST /webhooks":                        {summary: "Register a URL for signed event webhooks; the secret is only returned here", auth: authBearer, request: webhookEndpointParams{}, status: 201, response: WebhookEndpoint{}},
+	"GET /webhooks":                         {summary: "Your webhook endpoints", auth: authBearer, response: []WebhookEndpoint{}},
+	"GET /webhooks/{endpointID}":            {summary: "One of your webhook endpoints", auth: authBearer, response: WebhookEndpoint{}},
+	"PUT /webhooks/{endpointID}":            {summary: "Change a webhook endpoint's URL, events or whether it's active", auth: authBearer, request: webhookEndpointParams{}, response: WebhookEndpoint{}},
+	"DELETE /webhooks/{endpointID}":         {summary: "Remove a webhook endpoint", auth: authBearer, status: 204},
+	"GET /webhooks/{endpointID}/deliveries": {summary: "Recent deliveries to one of your webhook endpoints", auth: authBearer, response: []WebhookDelivery{}},
+
+	"POST /batch": {summary: "Run several API requests in one round trip", request: struct {
+		Requests []struct {
+			Method string          `json:"method"`
+			Path   string          `json:"path"`
+			Body   json.RawMessage `json:"body,omitempty"`
+		} `json:"requests"`
+	}{}, response: []struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}{}},
+}
+
+// apiError is the body of every error response
+type apiError struct {
+	Error     string       `json:"error"`
+	Details   []fieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+var pathParam = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+// buildOpenAPI documents every route of v
+func buildOpenAPI(v *apiVersion) *openapi.Document {
+	doc := openapi.New("Chirpy API", strconv.Itoa(v.version))
+	doc.Servers = []openapi.Server{{URL: fmt.Sprintf("/api/v%d", v.version)}}
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		"bearerAuth":      {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "An access token from signing in, or a scoped token from POST /tokens"},
+		"refreshToken":    {Type: "http", Scheme: "bearer", Description: "A refresh token from signing in"},
+		"polkaKey":        {Type: "apiKey", In: "header", Name: "Authorization", Description: `"ApiKey " followed by the Polka key. When a signing secret is configured, a Polka-Signature header of "t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">" is required as well`},
+		"stripeSignature": {Type: "apiKey", In: "header", Name: "Stripe-Signature", Description: "Stripe's signature of the payload"},
+	}
+	errorResponse := openapi.Response{
+		Description: "Error",
+		Content:     map[string]openapi.MediaType{"application/json": {Schema: doc.Define("Error", apiError{})}},
+	}
+
+	for _, pattern := range v.patterns {
+		method, path, _ := strings.Cut(pattern, " ")
+		route, ok := apiDocs[pattern]
+		if !ok {
+			log.Printf("OpenAPI: %s isn't documented", pattern)
+		}
+
+		op := &openapi.Operation{
+			Summary:   route.summary,
+			Tags:      []string{strings.Split(path, "/")[1]},
+			Responses: map[string]openapi.Response{"default": errorResponse},
+		}
+		for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: match[1], In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}})
+		}
+		for _, name := range route.query {
+			op.Parameters = append(op.Parameters, openapi.Parameter{Name: name, In: "query", Schema: &openapi.Schema{Type: "string"}})
+		}
+
+		switch route.auth {
+		case authBearer:
+			op.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}}
+			if len(route.scopes) > 0 {
+				op.Description = "Scoped tokens need " + strings.Join(route.scopes, ", ")
+			}
+		case authRefresh:
+			op.Security = []openapi.SecurityRequirement{{"refreshToken": {}}}
+		case authPolka:
+			op.Security = []openapi.SecurityRequirement{{"polkaKey": {}}}
+		case authStripe:
+			op.Security = []openapi.SecurityRequirement{{"stripeSignature": {}}}
+		}
+
+		switch {
+		case route.request != nil:
+			op.RequestBody = &openapi.RequestBody{
+				Required: !route.optionalBody,
+				Content:  map[string]openapi.MediaType{"application/json": {Schema: doc.SchemaOf(route.request)}},
+			}
+		case route.upload != "":
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content: map[string]openapi.MediaType{"multipart/form-data": {Schema: &openapi.Schema{
+					Type:       "object",
+					Properties: map[string]*openapi.Schema{route.upload: {Type: "string", Format: "binary"}},
+					Required:   []string{route.upload},
+				}}},
+			}
+		}
+
+		status := route.status
+		if status == 0 {
+			status = 200
+		}
+		success := openapi.Response{Description: http.StatusText(status)}
+		switch {
+		case route.response != nil:
+			success.Content = map[string]openapi.MediaType{"application/json": {Schema: doc.SchemaOf(route.response)}}
+		case route.contentType != "":
+			success.Content = map[string]openapi.MediaType{route.contentType: {Schema: &openapi.Schema{Type: "string"}}}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		doc.Add(method, pathParam.ReplaceAllString(path, "{$1}"), op)
+	}
+	return doc
+}
+
+// handlerOpenAPI serves v's OpenAPI document, built once from the routes
+// registered so far
+func handlerOpenAPI(v *apiVersion) http.HandlerFunc {
+	spec, err := json.Marshal(buildOpenAPI(v))
+	if err != nil {
+		log.Fatal("Error building the OpenAPI document: ", err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the document
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>Chirpy API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({url: "/api/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+func handlerSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+const (
+	planFree    = "free"
+	planRed     = "red"
+	planRedPlus = "red_plus"
+)
+
+// planRank orders plans from least to most generous
+var planRank = map[string]int{
+	planFree:    0,
+	planRed:     1,
+	planRedPlus: 2,
+}
+
+type Plan struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	MaxChirpLength     int32  `json:"max_chirp_length"`
+	RateLimitPerMinute int32  `json:"rate_limit_per_minute"`
+	EditWindowSeconds  int32  `json:"edit_window_seconds"`
+	UndoWindowSeconds  int32  `json:"undo_window_seconds"`
+}
+
+func planFromDB(dbPlan database.Plan) Plan {
+	return Plan{
+		ID:                 dbPlan.ID,
+		Name:               dbPlan.Name,
+		MaxChirpLength:     dbPlan.MaxChirpLength,
+		RateLimitPerMinute: dbPlan.RateLimitPerMinute,
+		EditWindowSeconds:  dbPlan.EditWindowSeconds,
+		UndoWindowSeconds:  dbPlan.UndoWindowSeconds,
+	}
+}
+
+func (cfg *apiConfig) handlerGetPlans(w http.ResponseWriter, r *http.Request) {
+	dbPlans, err := cfg.db.GetPlans(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve plans")
+		return
+	}
+
+	plans := []Plan{}
+	for _, dbPlan := range dbPlans {
+		plans = append(plans, planFromDB(dbPlan))
+	}
+
+	respondWithJSON(w, 200, plans)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+// Profile field limits, in characters
+const (
+	displayNameMaxLength = 50
+	bioMaxLength         = 160
+	locationMaxLength    = 30
+	websiteMaxLength     = 100
+)
+
+// profileField trims a PATCH field; nil means "leave unchanged" and an empty
+// string clears the field
+func profileField(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: strings.TrimSpace(*value), Valid: true}
+}
+
+// validWebsite accepts empty values and absolute http(s) URLs
+func validWebsite(value string) bool {
+	if value == "" {
+		return true
+	}
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+
+	userID := authUserID(r)
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	update := database.UpdateUserProfileParams{
+		ID:          userID,
+		DisplayName: profileField(params.DisplayName),
+		Bio:         profileField(params.Bio),
+		Location:    profileField(params.Location),
+		Website:     profileField(params.Website),
+	}
+
+	limits := []struct {
+		name  string
+		value sql.NullString
+		max   int
+	}{
+		{"display_name", update.DisplayName, displayNameMaxLength},
+		{"bio", update.Bio, bioMaxLength},
+		{"location", update.Location, locationMaxLength},
+		{"website", update.Website, websiteMaxLength},
+	}
+	for _, limit := range limits {
+		if utf8.RuneCountInString(limit.value.String) > limit.max {
+			respondWithError(w, 400, limit.name+" is too long")
+			return
+		}
+	}
+	if !validWebsite(update.Website.String) {
+		respondWithError(w, 400, "website must be an http or https URL")
+		return
+	}
+
+	dbUser, err := cfg.db.UpdateUserProfile(r.Context(), update)
+	if err != nil {
+		respondWithError(w, 500, "Failed to update profile")
+		return
+	}
+	cfg.invalidateProfiles(r.Context(), dbUser.Handle)
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/entitlements"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+// giftPeriod is how much Chirpy Red a single gift grants
+const giftPeriod = 30 * 24 * time.Hour
+
+var (
+	errPromoAlreadyRedeemed = errors.New("promo code already redeemed")
+	errPromoUnavailable     = errors.New("promo code invalid or expired")
+	errGiftUnavailable      = errors.New("no gift left this billing period")
+)
+
+type PromoCode struct {
+	Code            string     `json:"code"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Plan            string     `json:"plan"`
+	DurationDays    int32      `json:"duration_days"`
+	MaxRedemptions  int32      `json:"max_redemptions"`
+	RedemptionCount int32      `json:"redemption_count"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+}
+
+func promoCodeFromDB(dbCode database.PromoCode) PromoCode {
+	code := PromoCode{
+		Code:            dbCode.Code,
+		CreatedAt:       dbCode.CreatedAt,
+		Plan:            dbCode.Plan,
+		DurationDays:    dbCode.DurationDays,
+		MaxRedemptions:  dbCode.MaxRedemptions,
+		RedemptionCount: dbCode.RedemptionCount,
+	}
+	if dbCode.ExpiresAt.Valid {
+		code.ExpiresAt = &dbCode.ExpiresAt.Time
+	}
+	return code
+}
+
+// generatePromoCode returns a random, easy-to-type code like "K7QX-3MPA-9TRD"
+func generatePromoCode() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	raw := make([]byte, 12)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, c := range raw {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(alphabet[int(c)%len(alphabet)])
+	}
+	return b.String(), nil
+}
+
+func (cfg *apiConfig) handlerCreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code           string     `json:"code"`
+		Plan           string     `json:"plan"`
+		DurationDays   int32      `json:"duration_days"`
+		MaxRedemptions int32      `json:"max_redemptions"`
+		ExpiresAt      *time.Time `json:"expires_at"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	if params.Plan == "" {
+		params.Plan = planRed
+	}
+	if params.Plan == planFree || params.DurationDays < 1 {
+		respondWithError(w, 400, "Promo codes must grant a paid plan for at least one day")
+		return
+	}
+	if params.MaxRedemptions == 0 {
+		params.MaxRedemptions = 1
+	}
+	if params.MaxRedemptions < 0 {
+		respondWithError(w, 400, "Invalid max redemptions")
+		return
+	}
+
+	params.Code = strings.ToUpper(strings.TrimSpace(params.Code))
+	if params.Code == "" {
+		params.Code, err = generatePromoCode()
+		if err != nil {
+			respondWithError(w, 500, "Failed to generate promo code")
+			return
+		}
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: params.ExpiresAt.UTC(), Valid: true}
+	}
+
+	dbCode, err := cfg.db.CreatePromoCode(r.Context(), database.CreatePromoCodeParams{
+		Code:           params.Code,
+		Plan:           params.Plan,
+		DurationDays:   params.DurationDays,
+		MaxRedemptions: params.MaxRedemptions,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, 400, "Failed to create promo code")
+		return
+	}
+
+	respondWithJSON(w, 201, promoCodeFromDB(dbCode))
+}
+
+func (cfg *apiConfig) handlerGetPromoCodes(w http.ResponseWriter, r *http.Request) {
+	dbCodes, err := cfg.db.GetPromoCodes(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve promo codes")
+		return
+	}
+
+	codes := []PromoCode{}
+	for _, dbCode := range dbCodes {
+		codes = append(codes, promoCodeFromDB(dbCode))
+	}
+
+	respondWithJSON(w, 200, codes)
+}
+
+func (cfg *apiConfig) handlerRedeemPromoCode(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Code string `json:"code"`
+	}
+
+	userID := authUserID(r)
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	code := strings.ToUpper(strings.TrimSpace(params.Code))
+
+	// Record the redemption, claim a use and grant the plan atomically so a
+	// failure never burns a code without granting anything
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		_, err := q.CreatePromoRedemption(r.Context(), database.CreatePromoRedemptionParams{
+			Code:   code,
+			UserID: userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errPromoAlreadyRedeemed
+		}
+		if store.IsForeignKeyViolation(err) {
+			// Unknown codes fail the foreign key
+			return errPromoUnavailable
+		}
+		if err != nil {
+			return err
+		}
+
+		promo, err := q.ClaimPromoCode(r.Context(), code)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errPromoUnavailable
+		}
+		if err != nil {
+			return err
+		}
+
+		return upgradeChirpyRed(r.Context(), q, subscriptionChange{
+			UserID:         userID,
+			Plan:           promo.Plan,
+			Period:         time.Duration(promo.DurationDays) * 24 * time.Hour,
+			KeepHigherPlan: true,
+			Kind:           subscriptionEventPromo,
+			Source:         subscriptionSourcePromo,
+			PromoCode:      sql.NullString{String: promo.Code, Valid: true},
+		})
+	})
+	if errors.Is(err, errPromoAlreadyRedeemed) {
+		respondWithError(w, 409, "Promo code already redeemed")
+		return
+	}
+	if errors.Is(err, errPromoUnavailable) {
+		respondWithError(w, 400, "Promo code is invalid or expired")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to redeem promo code")
+		return
+	}
+
+	dbUser, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve user")
+		return
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
+
+func (cfg *apiConfig) handlerGiftChirpyRed(w http.ResponseWriter, r *http.Request) {
+	gifterID := authUserID(r)
+
+	recipientID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+	if recipientID == gifterID {
+		respondWithError(w, 400, "You cannot gift yourself")
+		return
+	}
+
+	// Only Red subscribers can gift
+	resolver, gifter, err := cfg.loadEntitlements(r.Context(), gifterID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to load entitlements")
+		return
+	}
+	if !checkFeature(w, resolver, gifter, entitlements.GiftRed) {
+		return
+	}
+
+	// The gift is spent, granted and announced together, so a failure
+	// partway leaves the gifter able to try again without gifting twice
+	var dbNotification database.Notification
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		claimed, err := q.ClaimSubscriptionGift(r.Context(), gifterID)
+		if err != nil {
+			return err
+		}
+		if claimed == 0 {
+			return errGiftUnavailable
+		}
+
+		err = upgradeChirpyRed(r.Context(), q, subscriptionChange{
+			UserID:         recipientID,
+			Plan:           planRed,
+			Period:         giftPeriod,
+			KeepHigherPlan: true,
+			Kind:           subscriptionEventGifted,
+			Source:         subscriptionSourceGift,
+			ActorUserID:    uuid.NullUUID{UUID: gifterID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		dbNotification, err = q.CreateNotification(r.Context(), database.CreateNotificationParams{
+			UserID:  recipientID,
+			Kind:    notificationSubscriptionGifted,
+			Message: "Someone gifted you a month of Chirpy Red!",
+		})
+		return err
+	})
+	if errors.Is(err, errGiftUnavailable) {
+		respondWithError(w, 409, "You've already gifted Chirpy Red this billing period")
+		return
+	}
+	if errors.Is(err, errUserNotFound) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to gift Chirpy Red")
+		return
+	}
+	cfg.publishNotification(dbNotification)
+
+	w.WriteHeader(http.StatusNoContent)
+}
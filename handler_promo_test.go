@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGiftChirpyRed(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	bob := api.signUp("bob")
+	carol := api.signUp("carol")
+
+	gift := func(from testUser, to uuid.UUID) int {
+		return api.do("POST", "/api/users/"+to.String()+"/gift", bearer(from.Token), nil).Code
+	}
+
+	if code := gift(alice, bob.ID); code != 402 {
+		t.Errorf("Expected 402 gifting on the free plan, got %d", code)
+	}
+
+	rec := api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, polkaUpgrade("evt_1", alice.ID))
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 upgrading, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// A recipient that doesn't exist doesn't use up the gift
+	if code := gift(alice, uuid.New()); code != 404 {
+		t.Errorf("Expected 404 for an unknown recipient, got %d", code)
+	}
+	if code := gift(alice, bob.ID); code != 204 {
+		t.Fatalf("Expected 204 gifting, got %d", code)
+	}
+	notifications, err := api.cfg.db.GetNotificationsForUser(context.Background(), database.GetNotificationsForUserParams{UserID: bob.ID, Limit: 10})
+	if err != nil || len(notifications) != 1 || notifications[0].Kind != notificationSubscriptionGifted {
+		t.Errorf("Expected bob notified of the gift, got %v (%v)", notifications, err)
+	}
+
+	// One gift per paid period, and a gifted subscription doesn't come with
+	// one of its own
+	if code := gift(alice, carol.ID); code != 409 {
+		t.Errorf("Expected 409 gifting twice in a period, got %d", code)
+	}
+	if code := gift(bob, alice.ID); code != 409 {
+		t.Errorf("Expected 409 gifting from a gifted subscription, got %d", code)
+	}
+
+	rec = api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, fmt.Sprintf(`{"id":"evt_2","event":"user.renewed","data":{"user_id":%q}}`, alice.ID))
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 renewing, got %d: %s", rec.Code, rec.Body)
+	}
+	if code := gift(alice, carol.ID); code != 204 {
+		t.Errorf("Expected 204 gifting after a renewal, got %d", code)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handlerPurgeDeletedChirps permanently removes soft-deleted chirps, optionally
+// only those deleted before ?before= (a date or RFC 3339 timestamp)
+func (cfg *apiConfig) handlerPurgeDeletedChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Purged int64 `json:"purged"`
+	}
+
+	before, err := parseDateParam(r.URL.Query().Get("before"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid before date")
+		return
+	}
+
+	purged, err := cfg.db.PurgeDeletedChirps(r.Context(), before)
+	if err != nil {
+		respondWithError(w, 500, "Failed to purge chirps")
+		return
+	}
+
+	respondWithJSON(w, 200, response{Purged: purged})
+}
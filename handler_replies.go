@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerGetChirpReplies(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+
+	dbChirp, err := cfg.getChirp(r.Context(), chirpID, cfg.viewerID(r))
+	if err != nil || !dbChirp.PublishedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+
+	dbReplies, err := cfg.db.GetChirpReplies(r.Context(), database.GetChirpRepliesParams{
+		ParentChirpID: uuid.NullUUID{UUID: chirpID, Valid: true},
+		ViewerID:      cfg.viewerID(r),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve replies")
+		return
+	}
+
+	replies := []Chirp{}
+	for _, dbReply := range dbReplies {
+		replies = append(replies, chirpFromDB(dbReply))
+	}
+
+	if respondIfNotModified(w, r, chirpsETag(replies)) {
+		return
+	}
+	respondWithJSON(w, 200, replies)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const reportDetailsMaxLength = 1000
+
+// reportReasons are what a chirp can be reported for. Anything else needs
+// details, under other.
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "self_harm", "misinformation", "other"}
+
+type ChirpReport struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ReporterID uuid.UUID `json:"reporter_id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+	Status     string    `json:"status"`
+	// The decision fields are set once a moderator resolves the report
+	Decision     string     `json:"decision,omitempty"`
+	DecisionNote string     `json:"decision_note,omitempty"`
+	DecidedBy    *uuid.UUID `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+}
+
+func chirpReportFromDB(dbReport database.ChirpReport) ChirpReport {
+	report := ChirpReport{
+		ID:           dbReport.ID,
+		CreatedAt:    dbReport.CreatedAt,
+		UpdatedAt:    dbReport.UpdatedAt,
+		ReporterID:   dbReport.ReporterID,
+		ChirpID:      dbReport.ChirpID,
+		Reason:       dbReport.Reason,
+		Details:      dbReport.Details,
+		Status:       dbReport.Status,
+		Decision:     dbReport.Decision.String,
+		DecisionNote: dbReport.DecisionNote,
+	}
+	if dbReport.DecidedBy.Valid {
+		report.DecidedBy = &dbReport.DecidedBy.UUID
+	}
+	if dbReport.DecidedAt.Valid {
+		report.DecidedAt = &dbReport.DecidedAt.Time
+	}
+	return report
+}
+
+// handlerReportChirp flags someone else's chirp for moderators, with one of
+// reportReasons and optional details. A user can only have one open report
+// against a chirp.
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	userID := authUserID(r)
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	if !slices.Contains(reportReasons, params.Reason) {
+		respondWithError(w, 400, "reason must be one of "+strings.Join(reportReasons, ", "))
+		return
+	}
+	params.Details = strings.TrimSpace(params.Details)
+	if len([]rune(params.Details)) > reportDetailsMaxLength {
+		respondWithError(w, 400, "details must be at most 1000 characters")
+		return
+	}
+	if params.Reason == "other" && params.Details == "" {
+		respondWithError(w, 400, "details are required when reason is other")
+		return
+	}
+
+	dbChirp, err := cfg.getChirp(r.Context(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil || !dbChirp.PublishedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+	if dbChirp.UserID == userID {
+		respondWithError(w, 400, "You can't report your own chirp")
+		return
+	}
+
+	dbReport, err := cfg.db.CreateChirpReport(r.Context(), database.CreateChirpReportParams{
+		ReporterID: userID,
+		ChirpID:    chirpID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "You've already reported this chirp")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to report chirp")
+		return
+	}
+
+	respondWithJSON(w, 201, chirpReportFromDB(dbReport))
+}
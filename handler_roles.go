@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// requireRole only lets through requests from users with at least role,
+// going by the role in their access token. The admin API key, when
+// configured, counts as an admin so scripts and the first admin can get in.
+// Changing a role invalidates the user's access tokens, so a demotion takes
+// effect straight away. Signed-in callers are available to next through
+// staffUserID, and every change is recorded in the audit log.
+func (cfg *apiConfig) requireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	next = cfg.auditAdminRequests(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiKey, err := auth.GetAPIKey(r.Header); err == nil {
+			if cfg.config.AdminAPIKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.config.AdminAPIKey)) != 1 {
+				respondWithError(w, 401, "Unauthorized")
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+		claims, err := cfg.parseJWT(r.Context(), token)
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+		// Privileged endpoints never take tokens issued to other clients
+		if claims.Scopes != nil {
+			respondWithError(w, 403, "Token lacks the required scope")
+			return
+		}
+		if !auth.HasRole(claims.Role, role) {
+			respondWithError(w, 403, "Forbidden")
+			return
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), requestAuthKey, &requestAuth{
+			claims: claims,
+			userID: userID,
+		})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// staffUserID is the admin or moderator requireRole let through, or none
+// for the admin API key
+func staffUserID(r *http.Request) uuid.NullUUID {
+	return callerID(r)
+}
+
+// staffRole is the role requireRole let the caller through with. The admin
+// API key counts as an admin.
+func staffRole(r *http.Request) string {
+	ra, ok := r.Context().Value(requestAuthKey).(*requestAuth)
+	if !ok {
+		return auth.RoleAdmin
+	}
+	return ra.claims.Role
+}
+
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role string `json:"role"`
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+	if !auth.ValidRole(params.Role) {
+		respondWithError(w, 400, "Role must be user, moderator or admin")
+		return
+	}
+
+	dbUser, err := cfg.db.SetUserRole(r.Context(), database.SetUserRoleParams{
+		ID:   userID,
+		Role: params.Role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to update role")
+		return
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const searchQueryMaxLength = 200
+
+// handlerSearchChirps runs a full-text search over published chirps, newest
+// first. q accepts web-search syntax: quoted phrases, OR and -excluded terms.
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		respondWithError(w, 400, "q is required")
+		return
+	}
+	if len(query) > searchQueryMaxLength {
+		respondWithError(w, 400, "q is too long")
+		return
+	}
+
+	// Parse optional author_id filter
+	authorID := uuid.NullUUID{}
+	if authorIDStr := r.URL.Query().Get("author_id"); authorIDStr != "" {
+		parsed, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			respondWithError(w, 400, "Invalid author ID")
+			return
+		}
+		authorID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.SearchChirpsParams{
+		Query:    query,
+		AuthorID: authorID,
+		ViewerID: cfg.viewerID(r),
+		Limit:    int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbChirps, err := cfg.db.SearchChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to search chirps")
+		return
+	}
+
+	respondWithJSON(w, 200, chirpsPageFromDB(dbChirps, limit))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const sessionUserAgentMaxLength = 512
+
+// Session is a login, represented by its refresh token
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+}
+
+func sessionFromDB(dbToken database.RefreshToken) Session {
+	session := Session{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		ExpiresAt: dbToken.ExpiresAt,
+		UserAgent: dbToken.UserAgent,
+		IPAddress: dbToken.IpAddress,
+	}
+	if dbToken.LastUsedAt.Valid {
+		session.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return session
+}
+
+// sessionUserAgent is the request's User-Agent, trimmed to a sane length
+func sessionUserAgent(r *http.Request) string {
+	userAgent := []rune(r.UserAgent())
+	if len(userAgent) > sessionUserAgentMaxLength {
+		userAgent = userAgent[:sessionUserAgentMaxLength]
+	}
+	return string(userAgent)
+}
+
+// clientIP is the address the request came from. Forwarding headers are
+// ignored since anyone can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbTokens, err := cfg.db.GetActiveSessionsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve sessions")
+		return
+	}
+
+	sessions := make([]Session, 0, len(dbTokens))
+	for _, dbToken := range dbTokens {
+		sessions = append(sessions, sessionFromDB(dbToken))
+	}
+	respondWithJSON(w, 200, sessions)
+}
+
+// handlerDeleteSession revokes one session's refresh token. Access tokens
+// it has already handed out run until they expire; revoke-all ends those
+// too.
+func (cfg *apiConfig) handlerDeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid session ID")
+		return
+	}
+
+	revoked, err := cfg.db.RevokeSession(r.Context(), database.RevokeSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to revoke session")
+		return
+	}
+	if revoked == 0 {
+		respondWithError(w, 404, "Session not found")
+		return
+	}
+	cfg.audit(r, auditRefreshTokenRevoked, uuid.NullUUID{UUID: userID, Valid: true}, "session "+sessionID.String())
+
+	w.WriteHeader(http.StatusNoContent)
+}
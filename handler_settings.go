@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+)
+
+var errHandleTaken = errors.New("handle is taken")
+
+// normalizeHandle lowercases a requested handle, dropping a leading '@', and
+// reports whether it is valid
+func normalizeHandle(raw string) (string, bool) {
+	handle := strings.ToLower(strings.TrimPrefix(raw, "@"))
+	return handle, chirptext.ValidHandle(handle)
+}
+
+// handlerUpdateSettings changes the settings present in the body and leaves
+// the rest alone
+func (cfg *apiConfig) handlerUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		ShareLocation   *bool   `json:"share_location"`
+		Recommendations *bool   `json:"recommendations"`
+		Handle          *string `json:"handle"`
+	}
+
+	userID := authUserID(r)
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil || (params.ShareLocation == nil && params.Recommendations == nil && params.Handle == nil) {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	handle := ""
+	if params.Handle != nil {
+		var ok bool
+		handle, ok = normalizeHandle(*params.Handle)
+		if !ok {
+			respondWithError(w, 400, "Handle must be 3-20 letters, digits or underscores")
+			return
+		}
+	}
+
+	previous, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	var dbUser database.User
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		if params.ShareLocation != nil {
+			dbUser, err = q.SetShareLocation(r.Context(), database.SetShareLocationParams{
+				ID:            userID,
+				ShareLocation: *params.ShareLocation,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if params.Recommendations != nil {
+			dbUser, err = q.SetRecommendations(r.Context(), database.SetRecommendationsParams{
+				ID:              userID,
+				Recommendations: *params.Recommendations,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if params.Handle != nil {
+			dbUser, err = q.SetUserHandle(r.Context(), database.SetUserHandleParams{
+				ID:     userID,
+				Handle: optionalString(handle),
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return errHandleTaken
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errHandleTaken) {
+		respondWithError(w, 409, "Handle is already taken")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to update settings")
+		return
+	}
+	cfg.invalidateProfiles(r.Context(), previous.Handle)
+	if params.Recommendations != nil {
+		cfg.invalidateForYou(r.Context(), userID)
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/stream"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamBuffer is how many events a client may fall behind before it is
+	// disconnected
+	streamBuffer       = 64
+	streamWriteTimeout = 10 * time.Second
+	streamPingInterval = 30 * time.Second
+	streamPongTimeout  = 60 * time.Second
+)
+
+// Stream event types
+const (
+	streamEventChirp        = "chirp"
+	streamEventLike         = "like"
+	streamEventNotification = "notification"
+)
+
+// streamMessage is the JSON frame written to WebSocket clients
+type streamMessage struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// ChirpLikes is the payload of a like event
+type ChirpLikes struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	LikeCount int32     `json:"like_count"`
+}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// handlerStream upgrades to a WebSocket and pushes new chirps, like counts
+// and the caller's notifications until the client disconnects. Browsers
+// can't set headers on WebSocket requests, so the access token may also be
+// passed as ?access_token=.
+func (cfg *apiConfig) handlerStream(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	// Upgrade writes its own error response
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sub := cfg.streamHub.Subscribe(userID)
+	defer sub.Close()
+
+	// Clients don't send anything, but reading is what processes pongs and
+	// notices when they go away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongTimeout))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-cfg.shuttingDown:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(streamWriteTimeout))
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow"),
+					time.Now().Add(streamWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			err := conn.WriteJSON(streamMessage{Type: event.Type, Data: event.Data})
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// publishChirp announces a newly published chirp to every connected
+// client, or only to its author's if they're shadow-banned
+func (cfg *apiConfig) publishChirp(ctx context.Context, dbChirp database.Chirp) {
+	author, err := cfg.db.GetUserByID(ctx, dbChirp.UserID)
+	if err != nil {
+		log.Printf("Failed to look up the author of chirp %s to announce it: %v", dbChirp.ID, err)
+		return
+	}
+
+	event := stream.Event{
+		ID:   chirpEventID(dbChirp),
+		Type: streamEventChirp,
+		Data: chirpFromDB(dbChirp),
+	}
+	if author.ShadowBanned {
+		event.UserID = author.ID
+	}
+	cfg.streamHub.Publish(event)
+}
+
+// publishLikes announces a chirp's new like count
+func (cfg *apiConfig) publishLikes(dbChirp database.Chirp) {
+	cfg.streamHub.Publish(stream.Event{
+		Type: streamEventLike,
+		Data: ChirpLikes{ChirpID: dbChirp.ID, LikeCount: dbChirp.LikeCount},
+	})
+}
+
+// publishNotification pushes a notification to its recipient's connections
+func (cfg *apiConfig) publishNotification(dbNotification database.Notification) {
+	cfg.streamHub.Publish(stream.Event{
+		Type:   streamEventNotification,
+		UserID: dbNotification.UserID,
+		Data: Notification{
+			ID:        dbNotification.ID,
+			CreatedAt: dbNotification.CreatedAt,
+			Kind:      dbNotification.Kind,
+			Message:   dbNotification.Message,
+		},
+	})
+}
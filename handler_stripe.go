@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v83"
+	"github.com/stripe/stripe-go/v83/webhook"
+)
+
+// stripeMaxBodyBytes bounds webhook payloads; Stripe events are well under this
+const stripeMaxBodyBytes = 64 * 1024
+
+func (cfg *apiConfig) handlerStripeCheckout(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Plan string `json:"plan"`
+	}
+	type response struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+
+	if cfg.stripeClient == nil {
+		respondWithError(w, 404, "Stripe billing is not enabled")
+		return
+	}
+
+	// The body is optional; an empty one buys the default plan
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithBodyError(w, err)
+		return
+	}
+	if params.Plan == "" {
+		params.Plan = planRed
+	}
+	priceID, ok := cfg.stripePrices[params.Plan]
+	if !ok {
+		respondWithError(w, 400, "Plan is not available through Stripe")
+		return
+	}
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	// The user ID and plan are attached to both the session and the
+	// resulting subscription so later lifecycle events can be mapped back
+	// to the user
+	sessionParams := &stripe.CheckoutSessionCreateParams{
+		Mode: stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		LineItems: []*stripe.CheckoutSessionCreateLineItemParams{{
+			Price:    stripe.String(priceID),
+			Quantity: stripe.Int64(1),
+		}},
+		ClientReferenceID: stripe.String(dbUser.ID.String()),
+		SuccessURL:        stripe.String(cfg.config.Stripe.SuccessURL),
+		CancelURL:         stripe.String(cfg.config.Stripe.CancelURL),
+		Metadata:          map[string]string{"plan": params.Plan},
+		SubscriptionData: &stripe.CheckoutSessionCreateSubscriptionDataParams{
+			Metadata: map[string]string{"user_id": dbUser.ID.String(), "plan": params.Plan},
+		},
+	}
+	// Returning users check out as the Stripe customer they already are
+	customerID, err := cfg.db.GetStripeCustomerID(r.Context(), dbUser.ID)
+	if err == nil {
+		sessionParams.Customer = stripe.String(customerID)
+	} else if errors.Is(err, sql.ErrNoRows) {
+		sessionParams.CustomerEmail = stripe.String(dbUser.Email)
+	} else {
+		respondWithError(w, 500, "Failed to retrieve Stripe customer")
+		return
+	}
+
+	session, err := cfg.stripeClient.V1CheckoutSessions.Create(r.Context(), sessionParams)
+	if err != nil {
+		respondWithError(w, 502, "Failed to create checkout session")
+		return
+	}
+
+	respondWithJSON(w, 201, response{
+		ID:  session.ID,
+		URL: session.URL,
+	})
+}
+
+func (cfg *apiConfig) handlerStripeWebhook(w http.ResponseWriter, r *http.Request) {
+	if cfg.config.Stripe.WebhookSecret == "" {
+		respondWithError(w, 404, "Stripe billing is not enabled")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, stripeMaxBodyBytes))
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	// Verify the signature over the raw body before trusting anything in it.
+	// Events must also be in the API version stripe-go is built for.
+	event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), cfg.config.Stripe.WebhookSecret)
+	if errors.Is(err, webhook.ErrNotSigned) || errors.Is(err, webhook.ErrInvalidHeader) || errors.Is(err, webhook.ErrNoValidSignature) || errors.Is(err, webhook.ErrTooOld) {
+		respondWithError(w, 401, "Invalid signature")
+		return
+	}
+	if err != nil || event.ID == "" {
+		logRequestf(r, "Rejected Stripe event: %v", err)
+		respondWithError(w, 400, "Invalid request")
+		return
+	}
+
+	dbEvent, err := cfg.recordWebhookEvent(r.Context(), webhookSourceStripe, event.ID, string(event.Type), payload)
+	if err != nil {
+		respondWithError(w, 500, "Failed to record webhook event")
+		return
+	}
+	if dbEvent.Status == webhookStatusProcessed {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), dbEvent, false)
+	if errors.Is(err, errWebhookEventProcessed) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil && !errors.Is(err, errUserNotFound) && !errors.Is(err, errUnknownPlan) {
+		// Non-2xx makes Stripe retry the delivery
+		respondWithError(w, 500, "Failed to process webhook event")
+		return
+	}
+
+	// Unknown users and plans will never succeed on retry; the failed
+	// event stays in the log for replay instead
+	w.WriteHeader(http.StatusOK)
+}
+
+// applyStripeEvent maps Stripe subscription lifecycle events onto Chirpy Red
+func applyStripeEvent(ctx context.Context, q store.Store, dbEvent database.WebhookEvent) error {
+	event := stripe.Event{}
+	err := json.Unmarshal(dbEvent.Payload, &event)
+	if err != nil {
+		return err
+	}
+	if event.Data == nil {
+		return errors.New("stripe event has no data")
+	}
+
+	webhookEventID := uuid.NullUUID{UUID: dbEvent.ID, Valid: true}
+	switch event.Type {
+	case stripe.EventTypeCheckoutSessionCompleted:
+		// Completing checkout only ties the user to their Stripe customer.
+		// The plan and its period come from the subscription events, which
+		// carry the period end and may arrive before or after this one.
+		session := stripe.CheckoutSession{}
+		err = json.Unmarshal(event.Data.Raw, &session)
+		if err != nil {
+			return err
+		}
+		userID, err := uuid.Parse(session.ClientReferenceID)
+		if err != nil {
+			return errUserNotFound
+		}
+		if session.Customer == nil || session.Customer.ID == "" {
+			return nil
+		}
+		linked, err := q.LinkStripeCustomer(ctx, database.LinkStripeCustomerParams{
+			UserID:     userID,
+			CustomerID: session.Customer.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if linked == 0 {
+			return errUserNotFound
+		}
+		return nil
+
+	case stripe.EventTypeCustomerSubscriptionCreated, stripe.EventTypeCustomerSubscriptionUpdated, stripe.EventTypeCustomerSubscriptionDeleted:
+		subscription := stripe.Subscription{}
+		err = json.Unmarshal(event.Data.Raw, &subscription)
+		if err != nil {
+			return err
+		}
+		userID, err := uuid.Parse(subscription.Metadata["user_id"])
+		if err != nil {
+			return errUserNotFound
+		}
+
+		if event.Type == stripe.EventTypeCustomerSubscriptionDeleted {
+			return downgradeChirpyRed(ctx, q, userID, dbEvent.Source, webhookEventID)
+		}
+		switch subscription.Status {
+		case stripe.SubscriptionStatusActive, stripe.SubscriptionStatusTrialing:
+			// Each item is billed for its own period; the subscription
+			// lasts until the last of them ends
+			var expiresAt *time.Time
+			if subscription.Items != nil {
+				for _, item := range subscription.Items.Data {
+					periodEnd := time.Unix(item.CurrentPeriodEnd, 0)
+					if item.CurrentPeriodEnd > 0 && (expiresAt == nil || periodEnd.After(*expiresAt)) {
+						expiresAt = &periodEnd
+					}
+				}
+			}
+			return upgradeChirpyRed(ctx, q, subscriptionChange{
+				UserID:         userID,
+				Plan:           subscription.Metadata["plan"],
+				ExpiresAt:      expiresAt,
+				Source:         dbEvent.Source,
+				WebhookEventID: webhookEventID,
+			})
+		case stripe.SubscriptionStatusCanceled, stripe.SubscriptionStatusUnpaid, stripe.SubscriptionStatusIncompleteExpired:
+			return downgradeChirpyRed(ctx, q, userID, dbEvent.Source, webhookEventID)
+		}
+	}
+	// Other event types (and transitional statuses like past_due) are ignored
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v83"
+	"github.com/stripe/stripe-go/v83/webhook"
+)
+
+const testStripeWebhookSecret = "whsec_test"
+
+func stripeSubscriptionEvent(eventID, eventType, apiVersion, status string, userID uuid.UUID, periodEnd time.Time) string {
+	return fmt.Sprintf(`{"id":%q,"object":"event","api_version":%q,"type":%q,"data":{"object":{"id":"sub_1","object":"subscription","status":%q,`+
+		`"metadata":{"user_id":%q,"plan":"red"},"items":{"object":"list","data":[{"id":"si_1","object":"subscription_item","current_period_end":%d}]}}}}`,
+		eventID, apiVersion, eventType, status, userID, periodEnd.Unix())
+}
+
+// postStripeWebhook delivers payload signed with secret, or unsigned when
+// secret is empty
+func (api *testAPI) postStripeWebhook(payload, secret string) *httptest.ResponseRecorder {
+	api.t.Helper()
+	req := httptest.NewRequest("POST", "/api/stripe/webhooks", strings.NewReader(payload))
+	req.RemoteAddr = testRemoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: []byte(payload), Secret: secret})
+		req.Header.Set("Stripe-Signature", signed.Header)
+	}
+	rec := httptest.NewRecorder()
+	api.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStripeWebhook(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"STRIPE_WEBHOOK_SECRET": testStripeWebhookSecret})
+	alice := api.signUp("alice")
+	periodEnd := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name       string
+		payload    string
+		secret     string
+		wantStatus int
+	}{
+		{"unsigned", stripeSubscriptionEvent("evt_1", "customer.subscription.updated", stripe.APIVersion, "active", alice.ID, periodEnd), "", 401},
+		{"wrong secret", stripeSubscriptionEvent("evt_1", "customer.subscription.updated", stripe.APIVersion, "active", alice.ID, periodEnd), "whsec_other", 401},
+		{"other API version", stripeSubscriptionEvent("evt_1", "customer.subscription.updated", "2024-06-20", "active", alice.ID, periodEnd), testStripeWebhookSecret, 400},
+	}
+	for _, tt := range tests {
+		rec := api.postStripeWebhook(tt.payload, tt.secret)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+	user, err := api.cfg.db.GetUserByID(context.Background(), alice.ID)
+	if err != nil || user.IsChirpyRed {
+		t.Fatalf("Expected alice still on the free plan, got %v (%v)", user.IsChirpyRed, err)
+	}
+
+	rec := api.postStripeWebhook(stripeSubscriptionEvent("evt_2", "customer.subscription.updated", stripe.APIVersion, "active", alice.ID, periodEnd), testStripeWebhookSecret)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 upgrading, got %d: %s", rec.Code, rec.Body)
+	}
+	subscription, err := api.cfg.db.GetSubscriptionByUserID(context.Background(), alice.ID)
+	if err != nil || !subscription.ExpiresAt.Equal(periodEnd) {
+		t.Fatalf("Expected a subscription until %v, got %v (%v)", periodEnd, subscription.ExpiresAt, err)
+	}
+
+	rec = api.postStripeWebhook(stripeSubscriptionEvent("evt_3", "customer.subscription.deleted", stripe.APIVersion, "canceled", alice.ID, periodEnd), testStripeWebhookSecret)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 downgrading, got %d: %s", rec.Code, rec.Body)
+	}
+	user, err = api.cfg.db.GetUserByID(context.Background(), alice.ID)
+	if err != nil || user.IsChirpyRed {
+		t.Errorf("Expected alice back on the free plan, got %v (%v)", user.IsChirpyRed, err)
+	}
+}
+
+func TestStripeCheckout(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	rec := api.do("POST", "/api/stripe/checkout", bearer(alice.Token), nil)
+	if rec.Code != 404 {
+		t.Errorf("Expected 404 without Stripe configured, got %d", rec.Code)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/checkout/sessions" || r.Header.Get("Authorization") != "Bearer sk_test" {
+			t.Errorf("Expected an authenticated checkout session request, got %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		r.ParseForm()
+		want := map[string]string{
+			"mode":                                 "subscription",
+			"line_items[0][price]":                 "price_red",
+			"client_reference_id":                  alice.ID.String(),
+			"metadata[plan]":                       "red",
+			"subscription_data[metadata][user_id]": alice.ID.String(),
+		}
+		for field, value := range want {
+			if got := r.PostForm.Get(field); got != value {
+				t.Errorf("Expected %s=%q, got %q", field, value, got)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"cs_1","object":"checkout.session","url":"https://checkout.stripe.test/cs_1"}`))
+	}))
+	defer server.Close()
+	backend := stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL:           stripe.String(server.URL),
+		LeveledLogger: &stripe.LeveledLogger{Level: stripe.LevelNull},
+	})
+	api.cfg.stripeClient = stripe.NewClient("sk_test", stripe.WithBackends(&stripe.Backends{API: backend}))
+	api.cfg.stripePrices = map[string]string{planRed: "price_red"}
+
+	rec = api.do("POST", "/api/stripe/checkout", bearer(alice.Token), map[string]string{"plan": planRedPlus})
+	if rec.Code != 400 {
+		t.Errorf("Expected 400 for a plan not sold through Stripe, got %d", rec.Code)
+	}
+	rec = api.do("POST", "/api/stripe/checkout", bearer(alice.Token), nil)
+	if rec.Code != 201 {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	session := decodeResponse[map[string]string](t, rec)
+	if session["id"] != "cs_1" || session["url"] != "https://checkout.stripe.test/cs_1" {
+		t.Errorf("Expected the checkout session, got %v", session)
+	}
+}
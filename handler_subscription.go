@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SubscriptionHistoryEvent struct {
+	ID               uuid.UUID  `json:"id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	Kind             string     `json:"kind"`
+	Source           string     `json:"source"`
+	WebhookEventType string     `json:"webhook_event_type,omitempty"`
+	GiftedBy         *uuid.UUID `json:"gifted_by,omitempty"`
+	PromoCode        string     `json:"promo_code,omitempty"`
+}
+
+func (cfg *apiConfig) handlerGetMySubscription(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Plan      string                     `json:"plan"`
+		Status    string                     `json:"status"`
+		ExpiresAt *time.Time                 `json:"expires_at"`
+		History   []SubscriptionHistoryEvent `json:"history"`
+	}
+
+	userID := authUserID(r)
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	// Users who never subscribed have no subscription row
+	resp := response{
+		Plan:    dbUser.Plan,
+		Status:  "none",
+		History: []SubscriptionHistoryEvent{},
+	}
+	dbSubscription, err := cfg.db.GetSubscriptionByUserID(r.Context(), userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "Failed to retrieve subscription")
+		return
+	}
+	if err == nil {
+		resp.Status = dbSubscription.Status
+		resp.ExpiresAt = &dbSubscription.ExpiresAt
+	}
+
+	dbEvents, err := cfg.db.GetSubscriptionEventsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve subscription history")
+		return
+	}
+	for _, dbEvent := range dbEvents {
+		event := SubscriptionHistoryEvent{
+			ID:               dbEvent.ID,
+			CreatedAt:        dbEvent.CreatedAt,
+			Kind:             dbEvent.Kind,
+			Source:           dbEvent.Source,
+			WebhookEventType: dbEvent.WebhookEventType.String,
+			PromoCode:        dbEvent.PromoCode.String,
+		}
+		if dbEvent.ActorUserID.Valid {
+			event.GiftedBy = &dbEvent.ActorUserID.UUID
+		}
+		resp.History = append(resp.History, event)
+	}
+
+	respondWithJSON(w, 200, resp)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerGetTimeline returns chirps from the users the caller follows,
+// newest first, paginated like GET /api/chirps
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.GetTimelineParams{FollowerID: userID, Limit: int32(limit + 1)}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbChirps, err := cfg.db.GetTimeline(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve timeline")
+		return
+	}
+
+	page := chirpsPageFromDB(dbChirps, limit)
+	if respondIfNotModified(w, r, chirpsETag(page.Chirps, page.NextCursor)) {
+		return
+	}
+	cfg.recordChirpViews(page.Chirps)
+	respondWithJSON(w, 200, page)
+}
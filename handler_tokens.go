@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+)
+
+const (
+	scopedTokenDefaultTTL = time.Hour
+	// Scoped tokens can't be refreshed, so a client that needs longer asks
+	// the user again
+	scopedTokenMaxTTL = 24 * time.Hour
+)
+
+// handlerCreateScopedToken issues an access token limited to the scopes
+// asked for, to hand to a third-party client. Only a full-access token can
+// issue one, so a scoped token can't be used to widen itself.
+func (cfg *apiConfig) handlerCreateScopedToken(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Scopes           []string `json:"scopes"`
+		ExpiresInSeconds int      `json:"expires_in_seconds"`
+	}
+	type response struct {
+		Token     string    `json:"token"`
+		Scopes    []string  `json:"scopes"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	if len(params.Scopes) == 0 {
+		respondWithError(w, 400, "At least one scope is required")
+		return
+	}
+	scopes := make([]string, 0, len(params.Scopes))
+	seen := map[string]bool{}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, 400, "Unknown scope: "+scope)
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiresIn := scopedTokenDefaultTTL
+	if params.ExpiresInSeconds != 0 {
+		if params.ExpiresInSeconds < 0 || params.ExpiresInSeconds > int(scopedTokenMaxTTL/time.Second) {
+			respondWithError(w, 400, "expires_in_seconds must be between 1 and 86400")
+			return
+		}
+		expiresIn = time.Duration(params.ExpiresInSeconds) * time.Second
+	}
+
+	scopedToken, err := auth.MakeJWT(dbUser.ID, dbUser.Role, dbUser.TokenVersion, cfg.config.JWTSecret, expiresIn, scopes...)
+	if err != nil {
+		respondWithError(w, 500, "Failed to create access token")
+		return
+	}
+
+	respondWithJSON(w, 201, response{
+		Token:     scopedToken,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().UTC().Add(expiresIn),
+	})
+}
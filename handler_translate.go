@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/translate"
+	"github.com/google/uuid"
+)
+
+// handlerTranslateChirp translates a chirp, caching the result. Translations
+// cost money, so anonymous callers can't trigger them.
+func (cfg *apiConfig) handlerTranslateChirp(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		ChirpID        uuid.UUID `json:"chirp_id"`
+		Body           string    `json:"body"`
+		Language       string    `json:"language"`
+		SourceLanguage string    `json:"source_language"`
+	}
+
+	if cfg.translator == nil {
+		respondWithError(w, 404, "Translation is not enabled")
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid chirp ID")
+		return
+	}
+
+	language, err := translate.NormalizeLanguage(r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid target language")
+		return
+	}
+
+	dbChirp, err := cfg.getChirp(r.Context(), chirpID, cfg.viewerID(r))
+	if err != nil || !dbChirp.PublishedAt.Valid {
+		respondWithError(w, 404, "Chirp not found")
+		return
+	}
+
+	// Serve from the cache when this chirp was already translated
+	cached, err := cfg.db.GetChirpTranslation(r.Context(), database.GetChirpTranslationParams{
+		ChirpID:  chirpID,
+		Language: language,
+	})
+	if err == nil {
+		respondWithJSON(w, 200, response{
+			ChirpID:        chirpID,
+			Body:           cached.Body,
+			Language:       cached.Language,
+			SourceLanguage: cached.SourceLanguage,
+		})
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "Failed to retrieve translation")
+		return
+	}
+
+	result, err := cfg.translator.Translate(r.Context(), dbChirp.Body, language)
+	if err != nil {
+		respondWithError(w, 502, "Translation provider failed")
+		return
+	}
+
+	dbTranslation, err := cfg.db.UpsertChirpTranslation(r.Context(), database.UpsertChirpTranslationParams{
+		ChirpID:        chirpID,
+		Language:       language,
+		Body:           result.Text,
+		SourceLanguage: result.SourceLanguage,
+		Provider:       cfg.translator.Name(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to save translation")
+		return
+	}
+
+	respondWithJSON(w, 200, response{
+		ChirpID:        chirpID,
+		Body:           dbTranslation.Body,
+		Language:       dbTranslation.Language,
+		SourceLanguage: dbTranslation.SourceLanguage,
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/translate"
+)
+
+// upperTranslator "translates" by upper-casing, counting its calls
+type upperTranslator struct {
+	calls int
+}
+
+func (t *upperTranslator) Name() string {
+	return "upper"
+}
+
+func (t *upperTranslator) Translate(ctx context.Context, text, targetLanguage string) (translate.Result, error) {
+	t.calls++
+	return translate.Result{Text: strings.ToUpper(text), SourceLanguage: "en"}, nil
+}
+
+func TestTranslateAfterEdit(t *testing.T) {
+	api := newTestAPI(t, nil)
+	translator := &upperTranslator{}
+	api.cfg.translator = translator
+	alice := api.signUp("alice")
+
+	// Editing is a Chirpy Red perk
+	rec := api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, polkaUpgrade("evt_1", alice.ID))
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 upgrading, got %d: %s", rec.Code, rec.Body)
+	}
+	chirp := api.createChirp(alice, "hello world")
+
+	translated := func() string {
+		t.Helper()
+		rec := api.do("POST", "/api/chirps/"+chirp.ID.String()+"/translate?to=de", bearer(alice.Token), nil)
+		if rec.Code != 200 {
+			t.Fatalf("Expected 200 translating, got %d: %s", rec.Code, rec.Body)
+		}
+		return decodeResponse[struct {
+			Body string `json:"body"`
+		}](t, rec).Body
+	}
+
+	for range 2 {
+		if body := translated(); body != "HELLO WORLD" {
+			t.Errorf("Expected HELLO WORLD, got %q", body)
+		}
+	}
+	if translator.calls != 1 {
+		t.Errorf("Expected the second request served from the cache, got %d calls", translator.calls)
+	}
+
+	rec = api.do("PUT", "/api/chirps/"+chirp.ID.String(), bearer(alice.Token), map[string]string{"body": "goodbye world"})
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 editing, got %d: %s", rec.Code, rec.Body)
+	}
+	if body := translated(); body != "GOODBYE WORLD" {
+		t.Errorf("Expected the edited body translated, got %q", body)
+	}
+}
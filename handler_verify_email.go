@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// sendVerificationEmail issues a fresh verification token for the user's
+// current address and mails them a link to redeem it
+func (cfg *apiConfig) sendVerificationEmail(ctx context.Context, dbUser database.User) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+	err = cfg.db.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		TokenHash: auth.HashToken(token),
+		UserID:    dbUser.ID,
+		Email:     dbUser.Email,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := verifyEmailTemplate.Render(dbUser.Email, map[string]string{
+		"Link": cfg.config.BaseURL + "/api/verify-email?token=" + url.QueryEscape(token),
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.mailer.Send(ctx, msg)
+}
+
+func (cfg *apiConfig) handlerVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, 400, "token is required")
+		return
+	}
+
+	dbToken, err := cfg.db.ConsumeEmailVerificationToken(r.Context(), auth.HashToken(token))
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && time.Now().After(dbToken.ExpiresAt)) {
+		respondWithError(w, 400, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify email")
+		return
+	}
+
+	// No rows means the user has changed address since the token was sent
+	dbUser, err := cfg.db.MarkEmailVerified(r.Context(), database.MarkEmailVerifiedParams{
+		ID:    dbToken.UserID,
+		Email: dbToken.Email,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 400, "Invalid or expired verification token")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify email")
+		return
+	}
+
+	// Any other outstanding links are now pointless
+	err = cfg.db.DeleteEmailVerificationTokens(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify email")
+		return
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
+
+func (cfg *apiConfig) handlerResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+	if dbUser.EmailVerified {
+		respondWithError(w, 409, "Email is already verified")
+		return
+	}
+
+	err = cfg.sendVerificationEmail(r.Context(), dbUser)
+	if err != nil {
+		respondWithError(w, 500, "Failed to send verification email")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
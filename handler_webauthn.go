@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+const (
+	webauthnChallengeTTL           = 5 * time.Minute
+	webauthnChallengePurgeInterval = 10 * time.Minute
+	webauthnCeremonyRegister       = "register"
+	webauthnCeremonyLogin          = "login"
+	passkeyNameMaxLength           = 50
+)
+
+type Passkey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func passkeyFromDB(dbCredential database.WebauthnCredential) Passkey {
+	passkey := Passkey{
+		ID:        base64.RawURLEncoding.EncodeToString(dbCredential.ID),
+		Name:      dbCredential.Name,
+		CreatedAt: dbCredential.CreatedAt,
+	}
+	if dbCredential.LastUsedAt.Valid {
+		passkey.LastUsedAt = &dbCredential.LastUsedAt.Time
+	}
+	return passkey
+}
+
+// newWebAuthn configures go-webauthn for the relying party in conf.
+// Passkeys are discoverable so login doesn't need the email first, and
+// each ceremony must be finished within webauthnChallengeTTL.
+func newWebAuthn(conf config.WebAuthn) (*webauthn.WebAuthn, error) {
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: webauthnChallengeTTL, TimeoutUVD: webauthnChallengeTTL}
+	return webauthn.New(&webauthn.Config{
+		RPID:                  conf.RPID,
+		RPDisplayName:         "Chirpy",
+		RPOrigins:             []string{conf.Origin},
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+}
+
+// passkeyUser is a user as go-webauthn sees them. Their ID is the user
+// handle their passkeys are made for.
+type passkeyUser struct {
+	user        database.User
+	credentials []webauthn.Credential
+}
+
+func (u passkeyUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u passkeyUser) WebAuthnDisplayName() string {
+	if u.user.DisplayName != "" {
+		return u.user.DisplayName
+	}
+	return u.user.Email
+}
+
+func (u passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// credentialFromDB is a stored passkey as go-webauthn checks assertions
+// against it. Passkeys registered before their backup eligibility was kept
+// take it from the assertion being checked.
+func credentialFromDB(dbCredential database.WebauthnCredential, assertion *protocol.ParsedCredentialAssertionData) webauthn.Credential {
+	backupEligible := dbCredential.BackupEligible.Bool
+	if !dbCredential.BackupEligible.Valid {
+		backupEligible = assertion.Response.AuthenticatorData.Flags.HasBackupEligible()
+	}
+	return webauthn.Credential{
+		ID:        dbCredential.ID,
+		PublicKey: dbCredential.PublicKey,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: backupEligible,
+			BackupState:    dbCredential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{SignCount: uint32(dbCredential.SignCount)},
+	}
+}
+
+// The ceremony options are go-webauthn's, handed straight to
+// navigator.credentials, so unlike the rest of the API they follow the
+// WebAuthn spec's camelCase. Responses are the PublicKeyCredential as
+// serialized by the browser's toJSON().
+
+type webauthnRegistrationParams struct {
+	protocol.CredentialCreationResponse
+	// Name labels the new passkey so it can be told apart later
+	Name string `json:"name"`
+}
+
+// decodeBase64URL accepts base64url with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// createWebAuthnChallenge stores the session go-webauthn started for
+// ceremony under its challenge, tied to userID when the user is already
+// known
+func (cfg *apiConfig) createWebAuthnChallenge(ctx context.Context, ceremony string, userID uuid.NullUUID, session *webauthn.SessionData) error {
+	sessionData, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return cfg.db.CreateWebAuthnChallenge(ctx, database.CreateWebAuthnChallengeParams{
+		Challenge:   session.Challenge,
+		Ceremony:    ceremony,
+		UserID:      userID,
+		ExpiresAt:   session.Expires,
+		SessionData: string(sessionData),
+	})
+}
+
+// consumeWebAuthnChallenge redeems the challenge the browser signed,
+// returning the session it was stored with. It writes the error response
+// itself when it returns false.
+func (cfg *apiConfig) consumeWebAuthnChallenge(w http.ResponseWriter, r *http.Request, challenge string, ceremony string) (database.WebauthnChallenge, webauthn.SessionData, bool) {
+	dbChallenge, err := cfg.db.ConsumeWebAuthnChallenge(r.Context(), challenge)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (dbChallenge.Ceremony != ceremony || time.Now().After(dbChallenge.ExpiresAt))) {
+		respondWithError(w, 400, "Invalid or expired challenge")
+		return database.WebauthnChallenge{}, webauthn.SessionData{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify passkey")
+		return database.WebauthnChallenge{}, webauthn.SessionData{}, false
+	}
+
+	session := webauthn.SessionData{}
+	err = json.Unmarshal([]byte(dbChallenge.SessionData), &session)
+	if err != nil {
+		// Left by a ceremony started before sessions were stored
+		respondWithError(w, 400, "Invalid or expired challenge")
+		return database.WebauthnChallenge{}, webauthn.SessionData{}, false
+	}
+	return dbChallenge, session, true
+}
+
+// purgeExpiredWebAuthnChallenges drops ceremonies that were started but
+// never finished
+func (cfg *apiConfig) purgeExpiredWebAuthnChallenges(ctx context.Context) error {
+	_, err := cfg.db.DeleteExpiredWebAuthnChallenges(ctx)
+	return err
+}
+
+func (cfg *apiConfig) handlerWebAuthnRegisterBegin(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+
+	// Stop the same authenticator being registered twice
+	dbCredentials, err := cfg.db.GetWebAuthnCredentialsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to start passkey registration")
+		return
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(dbCredentials))
+	for _, dbCredential := range dbCredentials {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: dbCredential.ID,
+		})
+	}
+
+	creation, session, err := cfg.webauthn.BeginRegistration(passkeyUser{user: dbUser}, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		respondWithError(w, 500, "Failed to start passkey registration")
+		return
+	}
+	err = cfg.createWebAuthnChallenge(r.Context(), webauthnCeremonyRegister, uuid.NullUUID{UUID: userID, Valid: true}, session)
+	if err != nil {
+		respondWithError(w, 500, "Failed to start passkey registration")
+		return
+	}
+
+	respondWithJSON(w, 200, creation.Response)
+}
+
+func (cfg *apiConfig) handlerWebAuthnRegisterFinish(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	decoder := json.NewDecoder(r.Body)
+	params := webauthnRegistrationParams{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		params.Name = "Passkey"
+	}
+	if len([]rune(params.Name)) > passkeyNameMaxLength {
+		respondWithError(w, 400, "Passkey name is too long")
+		return
+	}
+
+	parsed, err := params.CredentialCreationResponse.Parse()
+	if err != nil {
+		respondWithError(w, 400, "Invalid passkey credential")
+		return
+	}
+
+	dbChallenge, session, ok := cfg.consumeWebAuthnChallenge(w, r, parsed.Response.CollectedClientData.Challenge, webauthnCeremonyRegister)
+	if !ok {
+		return
+	}
+	if dbChallenge.UserID.UUID != userID {
+		respondWithError(w, 400, "Invalid or expired challenge")
+		return
+	}
+
+	dbUser, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+	credential, err := cfg.webauthn.CreateCredential(passkeyUser{user: dbUser}, session, parsed)
+	if err != nil {
+		respondWithError(w, 400, "Passkey registration failed")
+		return
+	}
+
+	// No rows means the credential ID is already registered
+	dbCredential, err := cfg.db.CreateWebAuthnCredential(r.Context(), database.CreateWebAuthnCredentialParams{
+		ID:             credential.ID,
+		UserID:         userID,
+		PublicKey:      credential.PublicKey,
+		SignCount:      int64(credential.Authenticator.SignCount),
+		Name:           params.Name,
+		BackupEligible: sql.NullBool{Bool: credential.Flags.BackupEligible, Valid: true},
+		BackupState:    credential.Flags.BackupState,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 409, "Passkey is already registered")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to save passkey")
+		return
+	}
+
+	respondWithJSON(w, 201, passkeyFromDB(dbCredential))
+}
+
+func (cfg *apiConfig) handlerWebAuthnLoginBegin(w http.ResponseWriter, r *http.Request) {
+	// With no allow list the browser offers any passkey for this site
+	assertion, session, err := cfg.webauthn.BeginDiscoverableLogin()
+	if err != nil {
+		respondWithError(w, 500, "Failed to start passkey login")
+		return
+	}
+	err = cfg.createWebAuthnChallenge(r.Context(), webauthnCeremonyLogin, uuid.NullUUID{}, session)
+	if err != nil {
+		respondWithError(w, 500, "Failed to start passkey login")
+		return
+	}
+
+	respondWithJSON(w, 200, assertion.Response)
+}
+
+func (cfg *apiConfig) handlerWebAuthnLoginFinish(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	params := protocol.CredentialAssertionResponse{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	parsed, err := params.Parse()
+	if err != nil {
+		respondWithError(w, 400, "Invalid passkey credential")
+		return
+	}
+
+	_, session, ok := cfg.consumeWebAuthnChallenge(w, r, parsed.Response.CollectedClientData.Challenge, webauthnCeremonyLogin)
+	if !ok {
+		return
+	}
+
+	dbCredential, err := cfg.db.GetWebAuthnCredential(r.Context(), parsed.RawID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 401, "Unknown passkey")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify passkey")
+		return
+	}
+	dbUser, err := cfg.db.GetUserByID(r.Context(), dbCredential.UserID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to sign in")
+		return
+	}
+
+	// go-webauthn checks the user handle the authenticator reports is the
+	// account the passkey was made for
+	user := passkeyUser{user: dbUser, credentials: []webauthn.Credential{credentialFromDB(dbCredential, parsed)}}
+	credential, err := cfg.webauthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		return user, nil
+	}, session, parsed)
+	if err != nil {
+		respondWithError(w, 401, "Passkey verification failed")
+		return
+	}
+	// A counter that didn't move forward means two copies of the key
+	if credential.Authenticator.CloneWarning {
+		logRequestf(r, "Passkey %s for user %s may be cloned: sign count %d after %d", params.ID, dbCredential.UserID, parsed.Response.AuthenticatorData.Counter, dbCredential.SignCount)
+		respondWithError(w, 401, "Passkey verification failed")
+		return
+	}
+
+	err = cfg.db.UpdateWebAuthnCredentialUsage(r.Context(), database.UpdateWebAuthnCredentialUsageParams{
+		ID:             dbCredential.ID,
+		SignCount:      int64(credential.Authenticator.SignCount),
+		BackupEligible: sql.NullBool{Bool: credential.Flags.BackupEligible, Valid: true},
+		BackupState:    credential.Flags.BackupState,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to verify passkey")
+		return
+	}
+
+	cfg.respondWithLogin(w, r, dbUser)
+}
+
+func (cfg *apiConfig) handlerGetPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	dbCredentials, err := cfg.db.GetWebAuthnCredentialsForUser(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve passkeys")
+		return
+	}
+
+	passkeys := make([]Passkey, 0, len(dbCredentials))
+	for _, dbCredential := range dbCredentials {
+		passkeys = append(passkeys, passkeyFromDB(dbCredential))
+	}
+	respondWithJSON(w, 200, passkeys)
+}
+
+func (cfg *apiConfig) handlerDeletePasskey(w http.ResponseWriter, r *http.Request) {
+	userID := authUserID(r)
+
+	credentialID, err := decodeBase64URL(r.PathValue("credentialID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid credential ID")
+		return
+	}
+
+	deleted, err := cfg.db.DeleteWebAuthnCredential(r.Context(), database.DeleteWebAuthnCredentialParams{
+		ID:     credentialID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete passkey")
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, 404, "Passkey not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// testAuthenticator is a software passkey holding an ES256 key
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+	flags        protocol.AuthenticatorFlags
+	origin       string
+}
+
+func newTestAuthenticator(t *testing.T, api *testAPI) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &testAuthenticator{
+		key:          key,
+		credentialID: credentialID,
+		flags:        protocol.FlagUserPresent | protocol.FlagUserVerified,
+		origin:       api.cfg.config.WebAuthn.Origin,
+	}
+}
+
+func (a *testAuthenticator) coseKey(t *testing.T) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	key, err := webauthncbor.Marshal(map[int]any{
+		1:  int(webauthncose.EllipticKey),
+		3:  int(webauthncose.AlgES256),
+		-1: int(webauthncose.P256),
+		-2: x,
+		-3: y,
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+	return key
+}
+
+func (a *testAuthenticator) authData(t *testing.T, rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	flags := a.flags
+	if attested {
+		flags |= protocol.FlagAttestedCredentialData
+	}
+	data = append(data, byte(flags))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey(t)...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) clientData(t *testing.T, ceremony, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	if err != nil {
+		t.Fatalf("Failed to encode client data: %v", err)
+	}
+	return data
+}
+
+// register answers the registration options from begin as the browser's
+// PublicKeyCredential would
+func (a *testAuthenticator) register(t *testing.T, begin []byte) map[string]any {
+	options := struct {
+		Challenge string `json:"challenge"`
+		RP        struct {
+			ID string `json:"id"`
+		} `json:"rp"`
+	}{}
+	err := json.Unmarshal(begin, &options)
+	if err != nil {
+		t.Fatalf("Failed to decode registration options: %v", err)
+	}
+
+	attestationObject, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(t, options.RP.ID, true),
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode attestation: %v", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	return map[string]any{
+		"id":    id,
+		"rawId": id,
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(a.clientData(t, "webauthn.create", options.Challenge)),
+			"attestationObject": base64.RawURLEncoding.EncodeToString(attestationObject),
+		},
+		"name": "Laptop",
+	}
+}
+
+// login signs the challenge in the login options from begin for user
+func (a *testAuthenticator) login(t *testing.T, begin []byte, user testUser) map[string]any {
+	options := struct {
+		Challenge string `json:"challenge"`
+		RPID      string `json:"rpId"`
+	}{}
+	err := json.Unmarshal(begin, &options)
+	if err != nil {
+		t.Fatalf("Failed to decode login options: %v", err)
+	}
+
+	clientDataJSON := a.clientData(t, "webauthn.get", options.Challenge)
+	authData := a.authData(t, options.RPID, false)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	id := base64.RawURLEncoding.EncodeToString(a.credentialID)
+	return map[string]any{
+		"id":    id,
+		"rawId": id,
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    base64.RawURLEncoding.EncodeToString(clientDataJSON),
+			"authenticatorData": base64.RawURLEncoding.EncodeToString(authData),
+			"signature":         base64.RawURLEncoding.EncodeToString(signature),
+			"userHandle":        base64.RawURLEncoding.EncodeToString(user.ID[:]),
+		},
+	}
+}
+
+func (api *testAPI) registerPasskey(user testUser, authenticator *testAuthenticator) int {
+	api.t.Helper()
+	rec := api.do("POST", "/api/webauthn/register/begin", bearer(user.Token), nil)
+	if rec.Code != 200 {
+		api.t.Fatalf("Expected 200 starting registration, got %d: %s", rec.Code, rec.Body)
+	}
+	rec = api.do("POST", "/api/webauthn/register/finish", bearer(user.Token), authenticator.register(api.t, rec.Body.Bytes()))
+	return rec.Code
+}
+
+func (api *testAPI) loginWithPasskey(user testUser, authenticator *testAuthenticator) int {
+	api.t.Helper()
+	rec := api.do("POST", "/api/webauthn/login/begin", "", nil)
+	if rec.Code != 200 {
+		api.t.Fatalf("Expected 200 starting login, got %d: %s", rec.Code, rec.Body)
+	}
+	rec = api.do("POST", "/api/webauthn/login/finish", "", authenticator.login(api.t, rec.Body.Bytes(), user))
+	return rec.Code
+}
+
+func TestPasskeyRegisterAndLogin(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	authenticator := newTestAuthenticator(t, api)
+
+	if code := api.registerPasskey(alice, authenticator); code != 201 {
+		t.Fatalf("Expected 201 registering, got %d", code)
+	}
+	if code := api.registerPasskey(alice, authenticator); code != 409 {
+		t.Errorf("Expected 409 registering the same passkey again, got %d", code)
+	}
+	rec := api.do("GET", "/api/webauthn/credentials", bearer(alice.Token), nil)
+	passkeys := decodeResponse[[]Passkey](t, rec)
+	if len(passkeys) != 1 || passkeys[0].Name != "Laptop" {
+		t.Fatalf("Expected one passkey called Laptop, got %+v", passkeys)
+	}
+
+	authenticator.signCount = 1
+	rec = api.do("POST", "/api/webauthn/login/begin", "", nil)
+	assertion := authenticator.login(t, rec.Body.Bytes(), alice)
+	rec = api.do("POST", "/api/webauthn/login/finish", "", assertion)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 signing in, got %d: %s", rec.Code, rec.Body)
+	}
+	if login := decodeResponse[loginResponse](t, rec); login.ID != alice.ID || login.Token == "" {
+		t.Errorf("Expected a token for alice, got %+v", login)
+	}
+
+	// Challenges are single use
+	rec = api.do("POST", "/api/webauthn/login/finish", "", assertion)
+	if rec.Code != 400 {
+		t.Errorf("Expected 400 replaying the assertion, got %d", rec.Code)
+	}
+
+	rec = api.do("DELETE", "/api/webauthn/credentials/"+passkeys[0].ID, bearer(alice.Token), nil)
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 removing the passkey, got %d: %s", rec.Code, rec.Body)
+	}
+	authenticator.signCount = 2
+	if code := api.loginWithPasskey(alice, authenticator); code != 401 {
+		t.Errorf("Expected 401 with a removed passkey, got %d", code)
+	}
+}
+
+func TestPasskeyRegisterRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*testAuthenticator)
+	}{
+		{"user not verified", func(a *testAuthenticator) { a.flags = protocol.FlagUserPresent }},
+		{"wrong origin", func(a *testAuthenticator) { a.origin = "https://evil.example" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, nil)
+			alice := api.signUp("alice")
+			authenticator := newTestAuthenticator(t, api)
+			tt.modify(authenticator)
+
+			if code := api.registerPasskey(alice, authenticator); code != 400 {
+				t.Errorf("Expected 400, got %d", code)
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*testAuthenticator)
+	}{
+		{"sign count went backwards", func(a *testAuthenticator) { a.signCount = 4 }},
+		{"user not verified", func(a *testAuthenticator) { a.flags = protocol.FlagUserPresent }},
+		{"backup eligibility changed", func(a *testAuthenticator) { a.flags |= protocol.FlagBackupEligible }},
+		{"wrong origin", func(a *testAuthenticator) { a.origin = "https://evil.example" }},
+		{"different key", func(a *testAuthenticator) { a.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, nil)
+			alice := api.signUp("alice")
+			authenticator := newTestAuthenticator(t, api)
+			authenticator.signCount = 5
+			if code := api.registerPasskey(alice, authenticator); code != 201 {
+				t.Fatalf("Expected 201 registering, got %d", code)
+			}
+
+			authenticator.signCount = 6
+			tt.modify(authenticator)
+			if code := api.loginWithPasskey(alice, authenticator); code != 401 {
+				t.Errorf("Expected 401, got %d", code)
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginRecordsBackupEligibility(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	authenticator := newTestAuthenticator(t, api)
+	authenticator.flags |= protocol.FlagBackupEligible | protocol.FlagBackupState
+
+	// Registered before backup eligibility was kept
+	_, err := api.cfg.db.CreateWebAuthnCredential(context.Background(), database.CreateWebAuthnCredentialParams{
+		ID:             authenticator.credentialID,
+		UserID:         alice.ID,
+		PublicKey:      authenticator.coseKey(t),
+		Name:           "Phone",
+		BackupEligible: sql.NullBool{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator.signCount = 1
+	if code := api.loginWithPasskey(alice, authenticator); code != 200 {
+		t.Fatalf("Expected 200 signing in, got %d", code)
+	}
+	dbCredential, err := api.cfg.db.GetWebAuthnCredential(context.Background(), authenticator.credentialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dbCredential.BackupEligible.Valid || !dbCredential.BackupEligible.Bool || !dbCredential.BackupState || dbCredential.SignCount != 1 {
+		t.Errorf("Expected the passkey's flags and sign count recorded, got %+v", dbCredential)
+	}
+}
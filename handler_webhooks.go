@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookEndpointsPerUser caps how many endpoints one user can register;
+	// admin endpoints aren't limited
+	webhookEndpointsPerUser = 10
+	webhookURLMaxLength     = 2048
+)
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	// Secret signs the deliveries, and is only returned when the endpoint
+	// is created
+	Secret string `json:"secret,omitempty"`
+}
+
+func webhookEndpointFromDB(dbEndpoint database.WebhookEndpoint) WebhookEndpoint {
+	return WebhookEndpoint{
+		ID:        dbEndpoint.ID,
+		CreatedAt: dbEndpoint.CreatedAt,
+		UpdatedAt: dbEndpoint.UpdatedAt,
+		URL:       dbEndpoint.Url,
+		Events:    dbEndpoint.Events,
+		Active:    dbEndpoint.Active,
+	}
+}
+
+// webhookEndpointParams is the body accepted when creating or updating an
+// endpoint. No events means all of them; active defaults to true.
+type webhookEndpointParams struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// decodeWebhookEndpointParams reads and validates an endpoint body,
+// writing the error response itself when it returns false
+func decodeWebhookEndpointParams(w http.ResponseWriter, r *http.Request) (webhookEndpointParams, bool) {
+	params := webhookEndpointParams{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return webhookEndpointParams{}, false
+	}
+
+	params.URL = strings.TrimSpace(params.URL)
+	endpointURL, err := url.Parse(params.URL)
+	if err != nil || (endpointURL.Scheme != "https" && endpointURL.Scheme != "http") || endpointURL.Host == "" || len(params.URL) > webhookURLMaxLength {
+		respondWithError(w, 400, "url must be an absolute http or https URL")
+		return webhookEndpointParams{}, false
+	}
+
+	if len(params.Events) == 0 {
+		params.Events = webhooks.Events
+	}
+	for _, event := range params.Events {
+		if !webhooks.ValidEvent(event) {
+			respondWithError(w, 400, "Unknown event: "+event)
+			return webhookEndpointParams{}, false
+		}
+	}
+	params.Events = slices.Clone(params.Events)
+	slices.Sort(params.Events)
+	params.Events = slices.Compact(params.Events)
+
+	if params.Active == nil {
+		active := true
+		params.Active = &active
+	}
+	return params, true
+}
+
+// webhookEndpoints serves the endpoint routes for one kind of owner: a
+// user managing their own under /api/webhooks, or admins managing the ones
+// that get every event under /admin/webhooks
+type webhookEndpoints struct {
+	cfg *apiConfig
+	// owner is the user whose endpoints the request manages, or none for
+	// admin endpoints
+	owner func(r *http.Request) uuid.NullUUID
+}
+
+func (cfg *apiConfig) userWebhookEndpoints() webhookEndpoints {
+	return webhookEndpoints{cfg: cfg, owner: func(r *http.Request) uuid.NullUUID {
+		return uuid.NullUUID{UUID: authUserID(r), Valid: true}
+	}}
+}
+
+func (cfg *apiConfig) adminWebhookEndpoints() webhookEndpoints {
+	return webhookEndpoints{cfg: cfg, owner: func(*http.Request) uuid.NullUUID {
+		return uuid.NullUUID{}
+	}}
+}
+
+func (e webhookEndpoints) handlerCreate(w http.ResponseWriter, r *http.Request) {
+	owner := e.owner(r)
+
+	params, ok := decodeWebhookEndpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	if owner.Valid {
+		count, err := e.cfg.db.CountWebhookEndpointsForUser(r.Context(), owner.UUID)
+		if err != nil {
+			respondWithError(w, 500, "Failed to create webhook endpoint")
+			return
+		}
+		if count >= webhookEndpointsPerUser {
+			respondWithError(w, 409, "Webhook endpoint limit reached")
+			return
+		}
+	}
+
+	secret, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, 500, "Failed to create webhook endpoint")
+		return
+	}
+	dbEndpoint, err := e.cfg.db.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID: owner,
+		Url:    params.URL,
+		Secret: secret,
+		Events: params.Events,
+		Active: *params.Active,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to create webhook endpoint")
+		return
+	}
+
+	endpoint := webhookEndpointFromDB(dbEndpoint)
+	endpoint.Secret = dbEndpoint.Secret
+	respondWithJSON(w, 201, endpoint)
+}
+
+func (e webhookEndpoints) handlerList(w http.ResponseWriter, r *http.Request) {
+	owner := e.owner(r)
+
+	var dbEndpoints []database.WebhookEndpoint
+	var err error
+	if owner.Valid {
+		dbEndpoints, err = e.cfg.db.GetWebhookEndpointsForUser(r.Context(), owner.UUID)
+	} else {
+		dbEndpoints, err = e.cfg.db.GetAdminWebhookEndpoints(r.Context())
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve webhook endpoints")
+		return
+	}
+
+	endpoints := []WebhookEndpoint{}
+	for _, dbEndpoint := range dbEndpoints {
+		endpoints = append(endpoints, webhookEndpointFromDB(dbEndpoint))
+	}
+
+	respondWithJSON(w, 200, endpoints)
+}
+
+func (e webhookEndpoints) handlerGet(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := e.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, 200, webhookEndpointFromDB(dbEndpoint))
+}
+
+func (e webhookEndpoints) handlerUpdate(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := e.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	params, ok := decodeWebhookEndpointParams(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := e.cfg.db.UpdateWebhookEndpoint(r.Context(), database.UpdateWebhookEndpointParams{
+		ID:     dbEndpoint.ID,
+		Url:    params.URL,
+		Events: params.Events,
+		Active: *params.Active,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to update webhook endpoint")
+		return
+	}
+
+	respondWithJSON(w, 200, webhookEndpointFromDB(updated))
+}
+
+func (e webhookEndpoints) handlerDelete(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := e.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	err := e.cfg.db.DeleteWebhookEndpoint(r.Context(), dbEndpoint.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to delete webhook endpoint")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListDeliveries lists the endpoint's recent deliveries, so its
+// owner can see what was sent and why any failed
+func (e webhookEndpoints) handlerListDeliveries(w http.ResponseWriter, r *http.Request) {
+	dbEndpoint, ok := e.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	e.cfg.respondWithWebhookDeliveries(w, r, uuid.NullUUID{UUID: dbEndpoint.ID, Valid: true})
+}
+
+// authorize loads the endpoint named in the path if it belongs to the
+// request's owner; anyone else's is reported as not found. It writes the
+// error response itself when it returns false.
+func (e webhookEndpoints) authorize(w http.ResponseWriter, r *http.Request) (database.WebhookEndpoint, bool) {
+	endpointID, err := uuid.Parse(r.PathValue("endpointID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid webhook endpoint ID")
+		return database.WebhookEndpoint{}, false
+	}
+
+	dbEndpoint, err := e.cfg.db.GetWebhookEndpoint(r.Context(), endpointID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && dbEndpoint.UserID != e.owner(r)) {
+		respondWithError(w, 404, "Webhook endpoint not found")
+		return database.WebhookEndpoint{}, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve webhook endpoint")
+		return database.WebhookEndpoint{}, false
+	}
+
+	return dbEndpoint, true
+}
+
+// chirpDeletedEvent is the data of a chirp.deleted webhook
+type chirpDeletedEvent struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+// userFollowedEvent is the data of a user.followed webhook
+type userFollowedEvent struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+}
+
+// emitWebhook queues event for the endpoints of the user it's about and
+// the admin endpoints, to be posted by deliverWebhooks. Failures are
+// logged rather than failing whatever caused the event.
+func (cfg *apiConfig) emitWebhook(ctx context.Context, userID uuid.UUID, event string, data any) {
+	eventID := uuid.New()
+	payload, err := json.Marshal(webhooks.Event{
+		ID:        eventID,
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook: %v", event, err)
+		return
+	}
+
+	_, err = cfg.db.CreateWebhookDeliveries(ctx, database.CreateWebhookDeliveriesParams{
+		EventID:   eventID,
+		EventType: event,
+		Payload:   payload,
+		UserID:    userID,
+	})
+	if err != nil {
+		log.Printf("Failed to queue %s webhook: %v", event, err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/metrics"
+)
+
+// hitCounterFlushInterval is how often counted hits are written out; a
+// crash loses at most this much
+const hitCounterFlushInterval = time.Minute
+
+// Names of the persisted counters in hit_counters
+const (
+	hitCounterFileserver = "fileserver_hits"
+	hitCounterAPI        = "api_requests"
+)
+
+// hitCounters maps each persisted counter to its name in hit_counters
+func (cfg *apiConfig) hitCounters() map[string]*metrics.Counter {
+	return map[string]*metrics.Counter{
+		hitCounterFileserver: &cfg.fileserverHits,
+		hitCounterAPI:        &cfg.apiRequests,
+	}
+}
+
+// loadHitCounters seeds the counters with the totals persisted before the
+// server started
+func (cfg *apiConfig) loadHitCounters(ctx context.Context) error {
+	rows, err := cfg.db.GetHitCounters(ctx)
+	if err != nil {
+		return err
+	}
+	counters := cfg.hitCounters()
+	for _, row := range rows {
+		if counter, ok := counters[row.Name]; ok {
+			counter.Seed(row.Count)
+		}
+	}
+	return nil
+}
+
+// flushHitCounters adds hits counted since the last flush to the persisted
+// totals, keeping any that fail for the next run
+func (cfg *apiConfig) flushHitCounters(ctx context.Context) error {
+	var firstErr error
+	for name, counter := range cfg.hitCounters() {
+		pending := counter.Drain()
+		if pending == 0 {
+			continue
+		}
+		err := cfg.db.AddHitCount(ctx, database.AddHitCountParams{
+			Name:  name,
+			Count: pending,
+		})
+		if err != nil {
+			counter.Restore(pending)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"net/http"
@@ -34,14 +35,102 @@ func CheckPasswordHash(password, hash string) (bool, error) {
 	return match, nil
 }
 
-// MakeJWT creates a new JWT token
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+// Scopes limit what an access token may be used for
+const (
+	ScopeChirpsRead    = "chirps:read"
+	ScopeChirpsWrite   = "chirps:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeMessagesRead  = "messages:read"
+	ScopeMessagesWrite = "messages:write"
+)
+
+// Scopes lists every scope a token can be issued
+var Scopes = []string{
+	ScopeChirpsRead,
+	ScopeChirpsWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+}
+
+// Roles, from least to most privileged. Each role can do everything the
+// ones before it can.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ValidRole reports whether role is one users can be given
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole reports whether role grants at least the privileges of required.
+// Unknown roles grant nothing.
+func HasRole(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// ErrInsufficientScope means the token is valid but wasn't issued the
+// scopes the request needs
+var ErrInsufficientScope = errors.New("token lacks the required scope")
+
+// Claims are the claims in a chirpy access token. A token without scopes
+// has full access; scoped tokens are limited to what they list.
+// TokenVersion is the user's token version when the token was issued, so
+// bumping it can invalidate every outstanding token at once. AuthTime is
+// when the user signed in to the session the token was issued for, which
+// refreshing carries over.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role         string           `json:"role,omitempty"`
+	TokenVersion int32            `json:"ver"`
+	Scopes       []string         `json:"scopes,omitempty"`
+	AuthTime     *jwt.NumericDate `json:"auth_time,omitempty"`
+}
+
+// ValidScope reports whether scope is one tokens can be issued
+func ValidScope(scope string) bool {
+	return hasScope(Scopes, scope)
+}
+
+// MakeJWT creates a new JWT token for a user with the given role and token
+// version who has just signed in, limited to scopes if any are given
+func MakeJWT(userID uuid.UUID, role string, tokenVersion int32, tokenSecret string, expiresIn time.Duration, scopes ...string) (string, error) {
+	return makeJWT(userID, role, tokenVersion, tokenSecret, expiresIn, time.Now(), scopes)
+}
+
+// ReissueJWT creates a new full-access JWT token for a session the user
+// signed in to at authTime, as when a refresh token is used
+func ReissueJWT(userID uuid.UUID, role string, tokenVersion int32, tokenSecret string, expiresIn time.Duration, authTime time.Time) (string, error) {
+	return makeJWT(userID, role, tokenVersion, tokenSecret, expiresIn, authTime, nil)
+}
+
+func makeJWT(userID uuid.UUID, role string, tokenVersion int32, tokenSecret string, expiresIn time.Duration, authTime time.Time, scopes []string) (string, error) {
 	// Create claims
-	claims := jwt.RegisteredClaims{
-		Issuer:    "chirpy-access",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "chirpy-access",
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+			ID:        uuid.NewString(),
+		},
+		Role:         role,
+		TokenVersion: tokenVersion,
+		Scopes:       scopes,
+		AuthTime:     jwt.NewNumericDate(authTime.UTC()),
 	}
 	
 	// Create token
@@ -56,33 +145,83 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 	return signedToken, nil
 }
 
-// ValidateJWT validates a JWT token and returns the user ID
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+// ValidateJWT validates a JWT token and returns the user ID. Scoped tokens
+// must carry every one of requiredScopes, and are refused outright when none
+// are given, so endpoints only accept them once they opt in. Both cases
+// return ErrInsufficientScope.
+func ValidateJWT(tokenString, tokenSecret string, requiredScopes ...string) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, tokenSecret)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	
+	err = claims.RequireScopes(requiredScopes...)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	
+	return claims.UserID()
+}
+
+// ParseJWT checks a token's signature and expiry and returns its claims,
+// without looking at its scopes
+func ParseJWT(tokenString, tokenSecret string) (*Claims, error) {
 	// Parse and validate token
 	token, err := jwt.ParseWithClaims(
 		tokenString,
-		&jwt.RegisteredClaims{},
+		&Claims{},
 		func(token *jwt.Token) (interface{}, error) {
 			return []byte(tokenSecret), nil
 		},
 	)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
 	
 	// Extract claims
-	claims, ok := token.Claims.(*jwt.RegisteredClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return uuid.Nil, jwt.ErrTokenInvalidClaims
+		return nil, jwt.ErrTokenInvalidClaims
 	}
 	
-	// Parse user ID from subject
-	userID, err := uuid.Parse(claims.Subject)
-	if err != nil {
-		return uuid.Nil, err
+	return claims, nil
+}
+
+// RequireScopes checks a scoped token carries every one of requiredScopes,
+// returning ErrInsufficientScope if not, as described on ValidateJWT
+func (c *Claims) RequireScopes(requiredScopes ...string) error {
+	if c.Scopes == nil {
+		return nil
 	}
-	
-	return userID, nil
+	if len(requiredScopes) == 0 {
+		return ErrInsufficientScope
+	}
+	for _, required := range requiredScopes {
+		if !hasScope(c.Scopes, required) {
+			return ErrInsufficientScope
+		}
+	}
+	return nil
+}
+
+// SignedInWithin reports whether the user signed in to the token's session
+// no longer than d ago. Tokens from before auth_time was added never have.
+func (c *Claims) SignedInWithin(d time.Duration) bool {
+	return c.AuthTime != nil && time.Since(c.AuthTime.Time) <= d
+}
+
+// UserID parses the user ID from the token's subject
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
 }
 
 // GetBearerToken extracts the Bearer token from Authorization header
@@ -119,6 +258,13 @@ func MakeRefreshToken() (string, error) {
 	return hex.EncodeToString(token), nil
 }
 
+// HashToken returns the hex-encoded SHA-256 of a token, so single-use tokens
+// such as email verification links can be stored without the token itself
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // GetAPIKey extracts the API key from Authorization header
 func GetAPIKey(headers http.Header) (string, error) {
 	authHeader := headers.Get("Authorization")
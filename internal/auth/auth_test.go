@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -9,13 +11,13 @@ import (
 
 func TestPasswordHashing(t *testing.T) {
 	password := "testpassword123"
-	
+
 	// Test hashing
 	hash, err := HashPassword(password)
 	if err != nil {
 		t.Fatalf("Failed to hash password: %v", err)
 	}
-	
+
 	// Test valid password
 	match, err := CheckPasswordHash(password, hash)
 	if err != nil {
@@ -24,7 +26,7 @@ func TestPasswordHashing(t *testing.T) {
 	if !match {
 		t.Error("Password should match hash")
 	}
-	
+
 	// Test invalid password
 	match, err = CheckPasswordHash("wrongpassword", hash)
 	if err != nil {
@@ -39,19 +41,19 @@ func TestJWTCreationAndValidation(t *testing.T) {
 	userID := uuid.New()
 	secret := "test-secret-key"
 	expiresIn := time.Hour
-	
+
 	// Create JWT
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, RoleUser, 0, secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create JWT: %v", err)
 	}
-	
+
 	// Validate JWT
 	parsedUserID, err := ValidateJWT(token, secret)
 	if err != nil {
 		t.Fatalf("Failed to validate JWT: %v", err)
 	}
-	
+
 	if parsedUserID != userID {
 		t.Errorf("Expected user ID %v, got %v", userID, parsedUserID)
 	}
@@ -61,13 +63,13 @@ func TestJWTExpiration(t *testing.T) {
 	userID := uuid.New()
 	secret := "test-secret-key"
 	expiresIn := -time.Hour // Already expired
-	
+
 	// Create expired JWT
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, RoleUser, 0, secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create JWT: %v", err)
 	}
-	
+
 	// Try to validate expired JWT
 	_, err = ValidateJWT(token, secret)
 	if err == nil {
@@ -80,13 +82,13 @@ func TestJWTWrongSecret(t *testing.T) {
 	secret := "test-secret-key"
 	wrongSecret := "wrong-secret-key"
 	expiresIn := time.Hour
-	
+
 	// Create JWT with one secret
-	token, err := MakeJWT(userID, secret, expiresIn)
+	token, err := MakeJWT(userID, RoleUser, 0, secret, expiresIn)
 	if err != nil {
 		t.Fatalf("Failed to create JWT: %v", err)
 	}
-	
+
 	// Try to validate with wrong secret
 	_, err = ValidateJWT(token, wrongSecret)
 	if err == nil {
@@ -94,3 +96,173 @@ func TestJWTWrongSecret(t *testing.T) {
 	}
 }
 
+func TestJWTScopes(t *testing.T) {
+	userID := uuid.New()
+	secret := "test-secret-key"
+
+	fullToken, err := MakeJWT(userID, RoleUser, 0, secret, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create JWT: %v", err)
+	}
+	scopedToken, err := MakeJWT(userID, RoleUser, 0, secret, time.Hour, ScopeChirpsRead, ScopeUsersRead)
+	if err != nil {
+		t.Fatalf("Failed to create JWT: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		required []string
+		wantErr  error
+	}{
+		{"full access, no scopes required", fullToken, nil, nil},
+		{"full access, scope required", fullToken, []string{ScopeChirpsWrite}, nil},
+		{"scoped, granted scope", scopedToken, []string{ScopeChirpsRead}, nil},
+		{"scoped, all granted scopes", scopedToken, []string{ScopeChirpsRead, ScopeUsersRead}, nil},
+		{"scoped, missing scope", scopedToken, []string{ScopeChirpsRead, ScopeChirpsWrite}, ErrInsufficientScope},
+		{"scoped, endpoint takes no scoped tokens", scopedToken, nil, ErrInsufficientScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, err := ValidateJWT(tt.token, secret, tt.required...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && gotID != userID {
+				t.Errorf("Expected user ID %v, got %v", userID, gotID)
+			}
+		})
+	}
+}
+
+func TestJWTRoleAndVersion(t *testing.T) {
+	token, err := MakeJWT(uuid.New(), RoleModerator, 3, "test-secret-key", time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create JWT: %v", err)
+	}
+
+	claims, err := ParseJWT(token, "test-secret-key")
+	if err != nil {
+		t.Fatalf("Failed to parse JWT: %v", err)
+	}
+	if claims.Role != RoleModerator {
+		t.Errorf("Expected role %q, got %q", RoleModerator, claims.Role)
+	}
+	if claims.TokenVersion != 3 {
+		t.Errorf("Expected token version 3, got %d", claims.TokenVersion)
+	}
+	if claims.ID == "" {
+		t.Error("Expected the token to have an ID")
+	}
+}
+
+func TestJWTAuthTime(t *testing.T) {
+	secret := "test-secret-key"
+	signedIn, err := MakeJWT(uuid.New(), RoleUser, 0, secret, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create JWT: %v", err)
+	}
+	reissued, err := ReissueJWT(uuid.New(), RoleUser, 0, secret, time.Hour, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to create JWT: %v", err)
+	}
+
+	claims, err := ParseJWT(signedIn, secret)
+	if err != nil {
+		t.Fatalf("Failed to parse JWT: %v", err)
+	}
+	if !claims.SignedInWithin(time.Minute) {
+		t.Error("Expected a new sign-in to be within a minute")
+	}
+	claims, err = ParseJWT(reissued, secret)
+	if err != nil {
+		t.Fatalf("Failed to parse JWT: %v", err)
+	}
+	if claims.SignedInWithin(time.Minute) || !claims.SignedInWithin(2*time.Hour) {
+		t.Errorf("Expected a reissued token to keep its sign-in time, got %v", claims.AuthTime)
+	}
+	if claims.Scopes != nil {
+		t.Errorf("Expected a reissued token to have full access, got %v", claims.Scopes)
+	}
+}
+
+func TestHasRole(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleModerator, true},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleUser, RoleModerator, false},
+		{"", RoleUser, false},
+		{"superuser", RoleUser, false},
+	}
+
+	for _, tt := range tests {
+		if got := HasRole(tt.role, tt.required); got != tt.want {
+			t.Errorf("HasRole(%q, %q): expected %v, got %v", tt.role, tt.required, tt.want, got)
+		}
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	if !ValidScope(ScopeMessagesWrite) {
+		t.Errorf("Expected %q to be valid", ScopeMessagesWrite)
+	}
+	if ValidScope("admin") {
+		t.Error("Expected \"admin\" to be invalid")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	hash := HashToken(token)
+	if hash == token {
+		t.Error("Expected hash to differ from token")
+	}
+	if HashToken(token) != hash {
+		t.Error("Expected hashing to be deterministic")
+	}
+	if len(hash) != 64 {
+		t.Errorf("Expected 64 hex characters, got %d", len(hash))
+	}
+}
+
+func TestGetAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{name: "ApiKey header", header: "ApiKey secret", want: "secret"},
+		{name: "Surrounding whitespace", header: "ApiKey  secret ", want: "secret"},
+		{name: "Missing header", wantErr: true},
+		{name: "Bearer token", header: "Bearer secret", wantErr: true},
+		{name: "Empty key", header: "ApiKey ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tt.header != "" {
+				headers.Set("Authorization", tt.header)
+			}
+			got, err := GetAPIKey(headers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
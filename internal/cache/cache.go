@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores short-lived copies of values that are expensive to load.
+// Callers must treat errors as misses and fall back to the source, and
+// tolerate values up to their TTL out of date.
+type Cache interface {
+	// Get returns the value stored under key, reporting whether there was one
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key until ttl passes
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys, ignoring ones that aren't stored
+	Delete(ctx context.Context, keys ...string) error
+	// Clear removes every key this cache stored
+	Clear(ctx context.Context) error
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	lru.Set(ctx, "a", []byte("1"), time.Minute)
+	lru.Set(ctx, "b", []byte("2"), time.Minute)
+	// Reading a makes b the least recently used
+	if value, ok, _ := lru.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Expected a=1, got %q ok=%v", value, ok)
+	}
+	lru.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok, _ := lru.Get(ctx, "b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+	if _, ok, _ := lru.Get(ctx, "a"); !ok {
+		t.Errorf("Expected a to survive eviction")
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", lru.Len())
+	}
+
+	// Overwriting keeps a single entry
+	lru.Set(ctx, "c", []byte("4"), time.Minute)
+	if value, _, _ := lru.Get(ctx, "c"); string(value) != "4" {
+		t.Errorf("Expected c=4, got %q", value)
+	}
+	if lru.Len() != 2 {
+		t.Errorf("Expected 2 entries after overwrite, got %d", lru.Len())
+	}
+
+	lru.Delete(ctx, "a", "missing")
+	if _, ok, _ := lru.Get(ctx, "a"); ok {
+		t.Errorf("Expected a to be deleted")
+	}
+
+	lru.Clear(ctx)
+	if lru.Len() != 0 {
+		t.Errorf("Expected an empty cache after clear, got %d entries", lru.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lru := NewLRU(10)
+	lru.now = func() time.Time { return now }
+
+	lru.Set(ctx, "chirp", []byte("x"), time.Minute)
+
+	now = now.Add(59 * time.Second)
+	if _, ok, _ := lru.Get(ctx, "chirp"); !ok {
+		t.Errorf("Expected a hit before the TTL")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok, _ := lru.Get(ctx, "chirp"); ok {
+		t.Errorf("Expected a miss once the TTL passed")
+	}
+	if lru.Len() != 0 {
+		t.Errorf("Expected the expired entry to be dropped, got %d entries", lru.Len())
+	}
+}
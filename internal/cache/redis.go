@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisMaxIdle is how many connections are kept open between commands
+	redisMaxIdle = 8
+	// redisTimeout bounds dialing and a command whose context has no
+	// deadline
+	redisTimeout = 2 * time.Second
+	// redisScanCount is how many keys Clear asks for per SCAN
+	redisScanCount = 100
+)
+
+// Redis is a cache on a Redis server. Keys are namespaced with a prefix so
+// the server can be shared.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis connects to the server at rawURL, such as
+// redis://:password@localhost:6379/0 or rediss:// for TLS, storing keys
+// under prefix
+func NewRedis(ctx context.Context, rawURL, prefix string) (*Redis, error) {
+	options, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	// go-redis would fall back to localhost
+	if parsed, _ := url.Parse(rawURL); parsed.Hostname() == "" {
+		return nil, errors.New("Redis URL has no host")
+	}
+	options.DialTimeout = redisTimeout
+	options.ReadTimeout = redisTimeout
+	options.WriteTimeout = redisTimeout
+	options.ContextTimeoutEnabled = true
+	options.MaxIdleConns = redisMaxIdle
+
+	r := &Redis{client: redis.NewClient(options), prefix: prefix}
+	// Fail at startup rather than on the first request
+	err = r.Ping(ctx)
+	if err != nil {
+		r.client.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Ping checks the server is reachable
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, r.prefix+key)
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+// Clear deletes every key under the prefix, leaving other keys on the
+// server alone
+func (r *Redis) Clear(ctx context.Context) error {
+	pattern := escapeGlob(r.prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			err = r.client.Del(ctx, keys...).Err()
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// Close closes the connections to the server
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards
+func escapeGlob(s string) string {
+	var escaped strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
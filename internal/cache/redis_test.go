@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server keeping keys in a map, recording every
+// command it receives
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	commands []string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{values: map[string]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		io.WriteString(conn, s.handle(args))
+	}
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	readLength := func(prefix byte) (int, error) {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+		if len(line) < 3 || line[0] != prefix {
+			return 0, fmt.Errorf("malformed command line %q", line)
+		}
+		return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	}
+
+	count, err := readLength('*')
+	if err != nil {
+		return nil, err
+	}
+	args := []string{}
+	for range count {
+		size, err := readLength('$')
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		_, err = io.ReadFull(reader, arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, string(arg[:size]))
+	}
+	return args, nil
+}
+
+func (s *fakeRedis) handle(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	args[0] = strings.ToUpper(args[0])
+	s.commands = append(s.commands, strings.Join(args, " "))
+
+	switch args[0] {
+	case "PING":
+		return "+PONG\r\n"
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				delete(s.values, key)
+				deleted++
+			}
+		}
+		return ":" + strconv.Itoa(deleted) + "\r\n"
+	case "SCAN":
+		pattern := strings.ReplaceAll(args[3], `\`, "")
+		var keys strings.Builder
+		matched := 0
+		for key := range s.values {
+			if ok, _ := path.Match(pattern, key); ok {
+				fmt.Fprintf(&keys, "$%d\r\n%s\r\n", len(key), key)
+				matched++
+			}
+		}
+		return fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n%s", matched, keys.String())
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func (s *fakeRedis) sawCommand(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, command := range s.commands {
+		if strings.HasPrefix(command, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRedis(t *testing.T) {
+	server, addr := startFakeRedis(t)
+	ctx := context.Background()
+
+	redis, err := NewRedis(ctx, "redis://:secret@"+addr+"/2", "chirpy:")
+	if err != nil {
+		t.Fatalf("Expected to connect, got %v", err)
+	}
+	defer redis.Close()
+	if !server.sawCommand("AUTH secret") || !server.sawCommand("SELECT 2") {
+		t.Errorf("Expected AUTH and SELECT from the URL")
+	}
+
+	_, ok, err := redis.Get(ctx, "chirp:1")
+	if err != nil || ok {
+		t.Errorf("Expected a miss, got ok=%v err=%v", ok, err)
+	}
+
+	err = redis.Set(ctx, "chirp:1", []byte("hello\r\nworld"), 90*time.Second)
+	if err != nil {
+		t.Fatalf("Expected set to succeed, got %v", err)
+	}
+	if !server.sawCommand("SET chirpy:chirp:1 hello\r\nworld ex 90") {
+		t.Errorf("Expected a prefixed SET with an expiry")
+	}
+	value, ok, err := redis.Get(ctx, "chirp:1")
+	if err != nil || !ok || string(value) != "hello\r\nworld" {
+		t.Errorf("Expected the stored value, got %q ok=%v err=%v", value, ok, err)
+	}
+
+	redis.Set(ctx, "chirp:2", []byte("x"), time.Minute)
+	server.mu.Lock()
+	server.values["other:key"] = "untouched"
+	server.mu.Unlock()
+	err = redis.Delete(ctx, "chirp:1")
+	if err != nil {
+		t.Fatalf("Expected delete to succeed, got %v", err)
+	}
+	if _, ok, _ := redis.Get(ctx, "chirp:1"); ok {
+		t.Errorf("Expected deleted key to miss")
+	}
+
+	err = redis.Clear(ctx)
+	if err != nil {
+		t.Fatalf("Expected clear to succeed, got %v", err)
+	}
+	if _, ok, _ := redis.Get(ctx, "chirp:2"); ok {
+		t.Errorf("Expected cleared key to miss")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.values["other:key"] != "untouched" {
+		t.Errorf("Expected keys outside the prefix to survive a clear")
+	}
+}
+
+func TestNewRedisInvalidURL(t *testing.T) {
+	for _, rawURL := range []string{"http://localhost", "redis://", "redis://localhost/db"} {
+		if _, err := NewRedis(context.Background(), rawURL, ""); err == nil {
+			t.Errorf("Expected error for %q, got nil", rawURL)
+		}
+	}
+}
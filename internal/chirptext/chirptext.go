@@ -0,0 +1,106 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxHashtagLength bounds stored hashtags; longer runs are ignored
+const MaxHashtagLength = 50
+
+// Handles are 3-20 letters, digits or underscores
+const (
+	MinHandleLength = 3
+	MaxHandleLength = 20
+)
+
+// A hashtag starts after whitespace or punctuation (so "a#b" and "&#39;" are
+// skipped) and must contain at least one letter, so "#1" is not a tag
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\w&])#(\w*[a-zA-Z]\w*)`)
+
+// A mention starts after whitespace or punctuation, so email addresses like
+// "me@example.com" aren't mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w+)`)
+
+var handlePattern = regexp.MustCompile(`^\w+$`)
+
+var tagPattern = regexp.MustCompile(`^\w*[a-zA-Z]\w*$`)
+
+// Hashtags returns the distinct lowercased hashtags in body, without the
+// leading '#', in order of first appearance
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		tag := strings.ToLower(match[1])
+		if len(tag) > MaxHashtagLength || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag lowercases a tag taken from a URL, dropping a leading '#'
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// Mentions returns the distinct lowercased handles mentioned in body, without
+// the leading '@', in order of first appearance
+func Mentions(body string) []string {
+	handles := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		handle := strings.ToLower(match[1])
+		if !ValidHandle(handle) || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// ValidHandle reports whether handle can be claimed by a user
+func ValidHandle(handle string) bool {
+	return len(handle) >= MinHandleLength && len(handle) <= MaxHandleLength && handlePattern.MatchString(handle)
+}
+
+// ValidHashtag reports whether tag, without its '#', is one Hashtags could
+// find in a chirp
+func ValidHashtag(tag string) bool {
+	return len(tag) <= MaxHashtagLength && tagPattern.MatchString(tag)
+}
+
+// MatchesSearch stands in for Postgres's full-text search in SearchChirps
+// where there's no Postgres. Like websearch_to_tsquery, every word and
+// "quoted phrase" in query has to be in body and none prefixed with - can
+// be, but there's no stemming: a word matches anywhere in body, ignoring
+// case.
+func MatchesSearch(body, query string) bool {
+	body = strings.ToLower(body)
+	terms := 0
+	for i, part := range strings.Split(strings.ToLower(query), `"`) {
+		// Odd parts are inside quotes
+		words := strings.Fields(part)
+		if i%2 == 1 {
+			words = []string{strings.Join(words, " ")}
+		}
+		for _, word := range words {
+			negated := false
+			if i%2 == 0 {
+				word, negated = strings.CutPrefix(word, "-")
+			}
+			if word == "" {
+				continue
+			}
+			if strings.Contains(body, word) == negated {
+				return false
+			}
+			terms++
+		}
+	}
+	return terms > 0
+}
@@ -0,0 +1,121 @@
+package chirptext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "no tags", body: "just a chirp", want: []string{}},
+		{name: "single tag", body: "learning #golang today", want: []string{"golang"}},
+		{name: "lowercased and deduplicated", body: "#Go #go #GO", want: []string{"go"}},
+		{name: "order of appearance", body: "#b then #a", want: []string{"b", "a"}},
+		{name: "punctuation before tag", body: "(#chirpy), #dev!", want: []string{"chirpy", "dev"}},
+		{name: "numbers only ignored", body: "we're #1", want: []string{}},
+		{name: "mid-word ignored", body: "email me at a#b", want: []string{}},
+		{name: "html entity ignored", body: "it&#39;s", want: []string{}},
+		{name: "too long ignored", body: "#" + strings.Repeat("a", MaxHashtagLength+1), want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	if got := NormalizeHashtag("#GoLang"); got != "golang" {
+		t.Errorf("Expected golang, got %q", got)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "no mentions", body: "hello world", want: []string{}},
+		{name: "single mention", body: "thanks @Alice!", want: []string{"alice"}},
+		{name: "deduplicated", body: "@bob and @BOB", want: []string{"bob"}},
+		{name: "email ignored", body: "write to me@example.com", want: []string{}},
+		{name: "too short ignored", body: "hi @al", want: []string{}},
+		{name: "too long ignored", body: "@" + strings.Repeat("a", MaxHandleLength+1), want: []string{}},
+		{name: "several", body: "@carol, @dave_99 meet", want: []string{"carol", "dave_99"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	valid := []string{"bob", "alice_99", strings.Repeat("a", MaxHandleLength)}
+	for _, handle := range valid {
+		if !ValidHandle(handle) {
+			t.Errorf("Expected %q to be valid", handle)
+		}
+	}
+	invalid := []string{"", "ab", "has space", "dash-ed", strings.Repeat("a", MaxHandleLength+1)}
+	for _, handle := range invalid {
+		if ValidHandle(handle) {
+			t.Errorf("Expected %q to be invalid", handle)
+		}
+	}
+}
+
+func TestValidHashtag(t *testing.T) {
+	valid := []string{"go", "go_lang", "web3", strings.Repeat("a", MaxHashtagLength)}
+	for _, tag := range valid {
+		if !ValidHashtag(tag) {
+			t.Errorf("Expected %q to be valid", tag)
+		}
+	}
+	invalid := []string{"", "123", "has space", "dash-ed", strings.Repeat("a", MaxHashtagLength+1)}
+	for _, tag := range invalid {
+		if ValidHashtag(tag) {
+			t.Errorf("Expected %q to be invalid", tag)
+		}
+	}
+}
+
+func TestMatchesSearch(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"golang", true},
+		{"GoLang", true},
+		{"golang weekend", true},
+		{"golang monday", false},
+		{`"rainy day"`, true},
+		{`"day rainy"`, false},
+		{"golang -weekend", false},
+		{"-monday", true},
+		{"", false},
+		{`""`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := MatchesSearch("Rainy day, perfect for refactoring #golang #weekend", tt.query)
+			if got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMinSize is the smallest body worth compressing; below it gzip's
+// overhead eats most of the savings
+const DefaultMinSize = 1024
+
+// compressible lists the content types that shrink under gzip. Images,
+// video and archives are already compressed.
+var compressible = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+	"application/xml":        true,
+	"image/svg+xml":          true,
+	"text/css":               true,
+	"text/csv":               true,
+	"text/html":              true,
+	"text/javascript":        true,
+	"text/plain":             true,
+	"text/xml":               true,
+}
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// Handler gzips responses from next for clients that accept it, when the
+// body is at least minSize bytes and of a compressible type
+func Handler(minSize int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || r.Header.Get("Range") != "" ||
+			r.Header.Get("Upgrade") != "" || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &writer{ResponseWriter: w, minSize: minSize, status: 200}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+// writer holds the start of the body until it knows whether to compress:
+// once minSize bytes arrive, or the handler flushes or finishes
+type writer struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	// headerWritten is set once WriteHeader has been called by the handler
+	headerWritten bool
+	decided       bool
+	buf           bytes.Buffer
+	gz            *gzip.Writer
+}
+
+func (cw *writer) WriteHeader(status int) {
+	if cw.headerWritten || cw.decided {
+		return
+	}
+	cw.status = status
+	cw.headerWritten = true
+	// Informational responses don't end the header
+	if status < 200 {
+		cw.ResponseWriter.WriteHeader(status)
+		cw.headerWritten = false
+	}
+}
+
+func (cw *writer) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf.Write(b)
+		if cw.buf.Len() < cw.minSize {
+			return len(b), nil
+		}
+		return len(b), cw.decide()
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// decide picks compressed or plain output, sends the header and writes out
+// what was held back
+func (cw *writer) decide() error {
+	cw.decided = true
+	if cw.shouldCompress() {
+		header := cw.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		cw.ResponseWriter.WriteHeader(cw.status)
+		cw.gz = gzipWriters.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+		_, err := cw.gz.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *writer) shouldCompress() bool {
+	header := cw.Header()
+	if cw.buf.Len() < cw.minSize || cw.status < 200 || cw.status == 204 ||
+		cw.status == 206 || cw.status == 304 || header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		// Sniff now, as net/http would; it can't once the body is gzipped
+		contentType = http.DetectContentType(cw.buf.Bytes())
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && compressible[mediaType]
+}
+
+// Flush sends what was held back even if it's short of minSize, for
+// handlers that stream
+func (cw *writer) Flush() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *writer) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *writer) close() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+		cw.gz.Reset(nil)
+		gzipWriters.Put(cw.gz)
+		cw.gz = nil
+	}
+}
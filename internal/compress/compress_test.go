@@ -0,0 +1,109 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	large := strings.Repeat(`{"body":"hello"}`, 200)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		status         int
+		wantGzip       bool
+	}{
+		{name: "Large JSON", acceptEncoding: "gzip, deflate", contentType: "application/json", body: large, wantGzip: true},
+		{name: "Content type with parameters", acceptEncoding: "gzip", contentType: "text/html; charset=utf-8", body: large, wantGzip: true},
+		{name: "Sniffed content type", acceptEncoding: "gzip", body: large, wantGzip: true},
+		{name: "Client without gzip", acceptEncoding: "br", contentType: "application/json", body: large},
+		{name: "Client refusing gzip", acceptEncoding: "gzip;q=0", contentType: "application/json", body: large},
+		{name: "Below threshold", acceptEncoding: "gzip", contentType: "application/json", body: `{"ok":true}`},
+		{name: "Already compressed type", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "Not modified", acceptEncoding: "gzip", contentType: "application/json", status: 304},
+		{name: "Error status", acceptEncoding: "gzip", contentType: "application/json", body: large, status: 500, wantGzip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Handler(DefaultMinSize, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				// Written in pieces to exercise buffering up to the threshold
+				for i := 0; i < len(tt.body); i += 100 {
+					io.WriteString(w, tt.body[i:min(i+100, len(tt.body))])
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/api/chirps", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			wantStatus := tt.status
+			if wantStatus == 0 {
+				wantStatus = 200
+			}
+			if rec.Code != wantStatus {
+				t.Errorf("Expected status %d, got %d", wantStatus, rec.Code)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Expected Vary: Accept-Encoding, got %q", got)
+			}
+
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Expected gzip %v, got %v", tt.wantGzip, gzipped)
+			}
+			body := rec.Body.String()
+			if gzipped {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("Expected a gzip body, got %v", err)
+				}
+				decoded, err := io.ReadAll(reader)
+				if err != nil {
+					t.Fatalf("Expected a readable gzip body, got %v", err)
+				}
+				body = string(decoded)
+			}
+			if body != tt.body {
+				t.Errorf("Expected the body intact, got %d bytes instead of %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestHandlerFlush(t *testing.T) {
+	handler := Handler(DefaultMinSize, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: hello\n\n")
+		w.(http.Flusher).Flush()
+	}))
+
+	req := httptest.NewRequest("GET", "/api/chirps/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !rec.Flushed {
+		t.Errorf("Expected the flush to reach the client")
+	}
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected event streams to be sent uncompressed")
+	}
+	if got := rec.Body.String(); got != "data: hello\n\n" {
+		t.Errorf("Expected the event, got %q", got)
+	}
+}
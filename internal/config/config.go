@@ -0,0 +1,615 @@
+// Package config loads the server's settings from the environment into one
+// typed Config, validating all of them up front so a misconfigured server
+// fails at startup with every problem listed, rather than one at a time or
+// on the first request that needs a setting
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/compress"
+	"github.com/Utkarsh736/chirpy/internal/entitlements"
+	"github.com/Utkarsh736/chirpy/internal/mail"
+	"github.com/Utkarsh736/chirpy/internal/media"
+	"github.com/Utkarsh736/chirpy/internal/password"
+	"github.com/Utkarsh736/chirpy/internal/ratelimit"
+)
+
+// Defaults of the settings that have one
+const (
+	DefaultAddr                    = ":8080"
+	DefaultTLSAddr                 = ":443"
+	DefaultHTTPRedirectAddr        = ":80"
+	DefaultAutocertCacheDir        = "certs"
+	DefaultBaseURL                 = "http://localhost:8080"
+	DefaultMediaDir                = "media"
+	DefaultDBMaxOpenConns          = 25
+	DefaultDBMaxIdleConns          = 10
+	DefaultDBConnMaxLifetime       = 30 * time.Minute
+	DefaultPolkaSignatureTolerance = 5 * time.Minute
+	DefaultReadHeaderTimeout       = 5 * time.Second
+	DefaultReadTimeout             = 15 * time.Second
+	DefaultWriteTimeout            = 30 * time.Second
+	DefaultIdleTimeout             = 2 * time.Minute
+	DefaultMaxHeaderBytes          = 64 << 10
+	DefaultRequestTimeout          = 20 * time.Second
+	DefaultMaxBodyBytes            = 1 << 20
+	DefaultStaticMaxAge            = time.Hour
+	DefaultStaticHashedMaxAge      = 365 * 24 * time.Hour
+	DefaultLoginRateLimit          = "10/1m"
+	DefaultSignupRateLimit         = "5/1h"
+	DefaultAPIReadRateLimit        = "300/1m"
+	DefaultAPIWriteRateLimit       = "60/1m"
+)
+
+// GzipOff is GzipMinSize when compression is turned off
+const GzipOff = -1
+
+// The databases DatabaseDriver can name. DatabaseMemory is no database at
+// all: a demo keeps its data in maps in the server.
+const (
+	DatabasePostgres = "postgres"
+	DatabaseSQLite   = "sqlite"
+	DatabaseMemory   = "memory"
+)
+
+// sqliteScheme starts a DB_URL naming a SQLite file
+const sqliteScheme = "sqlite:"
+
+// SQLiteMemory is SQLitePath for a database kept in memory, which is gone
+// when the server stops
+const SQLiteMemory = ":memory:"
+
+// PlatformDemo is the Platform that runs on seeded data kept in memory
+const PlatformDemo = "demo"
+
+// Config is every setting the server reads. The environment variable
+// behind each is named in its comment.
+type Config struct {
+	// DatabaseURL is the Postgres connection string, or sqlite:<path> for
+	// a SQLite database in that file, or sqlite::memory: for one in memory
+	// (DB_URL, required unless PLATFORM is demo, which ignores it)
+	DatabaseURL string
+	// DatabaseDriver is which of the two DatabaseURL names, or
+	// DatabaseMemory for a demo
+	DatabaseDriver string
+	// SQLitePath is the file of a SQLite DatabaseURL
+	SQLitePath string
+	// MigrateOnStart applies pending migrations when the server starts
+	// (MIGRATE_ON_START, default true)
+	MigrateOnStart bool
+	DB             DBPool
+
+	// Platform is "dev" for local development, which enables /admin/reset
+	// and seeding, or "demo", which serves made-up data from a database in
+	// memory in place of DB_URL's (PLATFORM, required to serve)
+	Platform string
+	// JWTSecret signs access tokens (JWT_SECRET, required to serve)
+	JWTSecret string
+	// PolkaKey authenticates Polka's webhooks (POLKA_KEY, required to serve)
+	PolkaKey string
+	// PolkaSigningSecret also requires Polka's webhooks to carry a
+	// Polka-Signature HMAC of their body (POLKA_SIGNING_SECRET, optional)
+	PolkaSigningSecret string
+	// PolkaSignatureTolerance is how far a signature's time may be from now
+	// before the delivery is taken for a replay (POLKA_SIGNATURE_TOLERANCE)
+	PolkaSignatureTolerance time.Duration
+	// AdminAPIKey counts as an admin on /admin endpoints, for scripts and
+	// for promoting the first admin (ADMIN_API_KEY, optional)
+	AdminAPIKey string
+	// BaseURL is where the server is reached from outside, without a
+	// trailing slash; links in emails point here (BASE_URL)
+	BaseURL string
+	// RequireEmailVerification stops unverified accounts from posting
+	// (REQUIRE_EMAIL_VERIFICATION)
+	RequireEmailVerification bool
+
+	Listen     Listen
+	TLS        TLS
+	Server     Server
+	RateLimits RateLimits
+	Static     Static
+	// GzipMinSize is the smallest response compressed, or GzipOff
+	// (GZIP_MIN_SIZE, a byte count or "off")
+	GzipMinSize int
+	Cache       Cache
+	Mail        Mail
+	Media       Media
+	Stripe      Stripe
+	// OAuth holds the login providers with a client ID configured, keyed
+	// by provider (OAUTH_GOOGLE_CLIENT_ID, OAUTH_GOOGLE_CLIENT_SECRET and
+	// the same for GITHUB)
+	OAuth       map[string]OAuthClient
+	WebAuthn    WebAuthn
+	Translation Translation
+	// WebhookAllowPrivate lets outgoing webhooks reach loopback and private
+	// addresses, for trying them against a local receiver
+	// (WEBHOOK_ALLOW_PRIVATE_NETWORKS)
+	WebhookAllowPrivate bool
+
+	// MaxChirpLength is the chirp limit without the long chirps perk
+	// (MAX_CHIRP_LENGTH)
+	MaxChirpLength int
+	// BannedWordsFile adds banned words, one per line, to those in the
+	// database (BANNED_WORDS_FILE, optional)
+	BannedWordsFile string
+	// PasswordMinLength is the shortest password accepted
+	// (PASSWORD_MIN_LENGTH)
+	PasswordMinLength int
+	// BreachedPasswordsFile lists passwords to refuse, one per line
+	// (BREACHED_PASSWORDS_FILE, optional)
+	BreachedPasswordsFile string
+}
+
+// DBPool is how many connections are kept to the database and for how long
+type DBPool struct {
+	MaxOpenConns    int           // DB_MAX_OPEN_CONNS
+	MaxIdleConns    int           // DB_MAX_IDLE_CONNS, at most MaxOpenConns
+	ConnMaxLifetime time.Duration // DB_CONN_MAX_LIFETIME
+}
+
+// Listen is where the server listens, before flags override it
+type Listen struct {
+	// Addr is the plain HTTP address (ADDR)
+	Addr string
+	// TLSAddr is the HTTPS address when TLS is on (TLS_ADDR)
+	TLSAddr string
+	// Port replaces the port of the address in use, "" to keep it (PORT)
+	Port string
+	// Localhost only accepts connections from this machine
+	// (BIND_LOCALHOST)
+	Localhost bool
+}
+
+// TLS is how the server terminates TLS, if at all
+type TLS struct {
+	// CertFile and KeyFile serve a certificate from disk (TLS_CERT_FILE,
+	// TLS_KEY_FILE, set together)
+	CertFile string
+	KeyFile  string
+	// AutocertDomains get certificates from Let's Encrypt instead
+	// (TLS_AUTOCERT_DOMAINS, comma-separated)
+	AutocertDomains  []string
+	AutocertCacheDir string // TLS_AUTOCERT_CACHE_DIR
+	AutocertEmail    string // TLS_AUTOCERT_EMAIL
+	// RedirectAddr redirects plain HTTP to HTTPS, "" for nowhere
+	// (HTTP_REDIRECT_ADDR, or "off")
+	RedirectAddr string
+}
+
+// Enabled reports whether the server serves HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || len(t.AutocertDomains) > 0
+}
+
+// Server bounds how long a client can hold a connection or a request. A
+// timeout of 0 is none.
+type Server struct {
+	ReadHeaderTimeout time.Duration // HTTP_READ_HEADER_TIMEOUT
+	ReadTimeout       time.Duration // HTTP_READ_TIMEOUT
+	WriteTimeout      time.Duration // HTTP_WRITE_TIMEOUT
+	IdleTimeout       time.Duration // HTTP_IDLE_TIMEOUT
+	MaxHeaderBytes    int           // HTTP_MAX_HEADER_BYTES
+	// RequestTimeout is the deadline on handler contexts, shorter than
+	// WriteTimeout (REQUEST_TIMEOUT)
+	RequestTimeout time.Duration
+	// MaxBodyBytes is the largest request body read (MAX_BODY_BYTES)
+	MaxBodyBytes int64
+}
+
+// Rate is a number of requests allowed per window
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimits are the request limits, nil when turned off. Each is set as
+// "<limit>/<window>" or "off".
+type RateLimits struct {
+	Login    *Rate // LOGIN_RATE_LIMIT, per IP
+	Signup   *Rate // SIGNUP_RATE_LIMIT, per IP
+	APIRead  *Rate // API_READ_RATE_LIMIT, per user or IP
+	APIWrite *Rate // API_WRITE_RATE_LIMIT, per user or IP
+}
+
+// Static is how long browsers cache /app files
+type Static struct {
+	MaxAge time.Duration // STATIC_MAX_AGE
+	// HashedMaxAge is for files with a content hash in their name
+	// (STATIC_HASHED_MAX_AGE)
+	HashedMaxAge time.Duration
+}
+
+// Cache is what sits in front of hot reads
+type Cache struct {
+	// RedisURL is a Redis server to cache in (REDIS_URL, optional)
+	RedisURL string
+	// MemorySize is the entries of an in-process cache used without Redis,
+	// 0 for none (MEMORY_CACHE_SIZE)
+	MemorySize int
+}
+
+// Mail is how email is sent
+type Mail struct {
+	// Sender is log, none or smtp (MAIL_SENDER)
+	Sender string
+	// SMTP is used by the smtp sender (SMTP_HOST, SMTP_PORT,
+	// SMTP_USERNAME, SMTP_PASSWORD, MAIL_FROM)
+	SMTP mail.SMTPConfig
+}
+
+// Media is where uploads are stored
+type Media struct {
+	// Store is local or s3 (MEDIA_STORE)
+	Store string
+	// Dir is the local store's directory (MEDIA_DIR)
+	Dir string
+	// S3 is the s3 store's bucket (S3_ENDPOINT, S3_REGION, S3_BUCKET,
+	// S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY)
+	S3 media.S3Config
+}
+
+// Stripe is billing through Stripe, an alternative to Polka
+type Stripe struct {
+	// SecretKey enables Stripe (STRIPE_SECRET_KEY, optional)
+	SecretKey string
+	// WebhookSecret and PriceID are required with SecretKey
+	// (STRIPE_WEBHOOK_SECRET, STRIPE_PRICE_ID)
+	WebhookSecret string
+	PriceID       string
+	// PriceIDRedPlus sells Red+ when set (STRIPE_PRICE_ID_RED_PLUS)
+	PriceIDRedPlus string
+	SuccessURL     string // STRIPE_SUCCESS_URL
+	CancelURL      string // STRIPE_CANCEL_URL
+}
+
+// Enabled reports whether Stripe billing is configured
+func (s Stripe) Enabled() bool {
+	return s.SecretKey != ""
+}
+
+// OAuthClient is the server's registration with an OAuth provider
+type OAuthClient struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthProviders are the OAuth providers that can be configured
+var OAuthProviders = []string{"google", "github"}
+
+// WebAuthn is the relying party passkeys are scoped to
+type WebAuthn struct {
+	// RPID is the passkeys' domain (WEBAUTHN_RP_ID, default BaseURL's
+	// host)
+	RPID string
+	// Origin is the page origin ceremonies run on (WEBAUTHN_ORIGIN,
+	// default BaseURL)
+	Origin string
+}
+
+// Translation is the provider translating chirps on demand
+type Translation struct {
+	// Provider is deepl or google, "" for none (TRANSLATION_PROVIDER)
+	Provider string
+	APIKey   string // TRANSLATION_API_KEY
+}
+
+// Load reads the configuration from the environment
+func Load() (*Config, error) {
+	return LoadFrom(os.Getenv)
+}
+
+// LoadFrom reads the configuration through getenv, reporting every
+// invalid setting at once
+func LoadFrom(getenv func(string) string) (*Config, error) {
+	l := &loader{getenv: getenv}
+	c := &Config{
+		DatabaseURL:    l.string("DB_URL", ""),
+		MigrateOnStart: l.bool("MIGRATE_ON_START", true),
+		DB: DBPool{
+			MaxOpenConns:    l.int("DB_MAX_OPEN_CONNS", DefaultDBMaxOpenConns, 1),
+			MaxIdleConns:    l.int("DB_MAX_IDLE_CONNS", DefaultDBMaxIdleConns, 1),
+			ConnMaxLifetime: l.duration("DB_CONN_MAX_LIFETIME", DefaultDBConnMaxLifetime),
+		},
+
+		Platform:                 l.string("PLATFORM", ""),
+		JWTSecret:                l.string("JWT_SECRET", ""),
+		PolkaKey:                 l.string("POLKA_KEY", ""),
+		PolkaSigningSecret:       l.string("POLKA_SIGNING_SECRET", ""),
+		PolkaSignatureTolerance:  l.duration("POLKA_SIGNATURE_TOLERANCE", DefaultPolkaSignatureTolerance),
+		AdminAPIKey:              l.string("ADMIN_API_KEY", ""),
+		BaseURL:                  strings.TrimSuffix(l.string("BASE_URL", DefaultBaseURL), "/"),
+		RequireEmailVerification: l.bool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		Listen: Listen{
+			Addr:      l.addr("ADDR", DefaultAddr),
+			TLSAddr:   l.addr("TLS_ADDR", DefaultTLSAddr),
+			Port:      l.port("PORT"),
+			Localhost: l.bool("BIND_LOCALHOST", false),
+		},
+		TLS: TLS{
+			CertFile:         l.string("TLS_CERT_FILE", ""),
+			KeyFile:          l.string("TLS_KEY_FILE", ""),
+			AutocertDomains:  l.list("TLS_AUTOCERT_DOMAINS"),
+			AutocertCacheDir: l.string("TLS_AUTOCERT_CACHE_DIR", DefaultAutocertCacheDir),
+			AutocertEmail:    l.string("TLS_AUTOCERT_EMAIL", ""),
+			RedirectAddr:     l.string("HTTP_REDIRECT_ADDR", DefaultHTTPRedirectAddr),
+		},
+		Server: Server{
+			ReadHeaderTimeout: l.duration("HTTP_READ_HEADER_TIMEOUT", DefaultReadHeaderTimeout),
+			ReadTimeout:       l.duration("HTTP_READ_TIMEOUT", DefaultReadTimeout),
+			WriteTimeout:      l.duration("HTTP_WRITE_TIMEOUT", DefaultWriteTimeout),
+			IdleTimeout:       l.duration("HTTP_IDLE_TIMEOUT", DefaultIdleTimeout),
+			MaxHeaderBytes:    l.int("HTTP_MAX_HEADER_BYTES", DefaultMaxHeaderBytes, 1),
+			RequestTimeout:    l.duration("REQUEST_TIMEOUT", DefaultRequestTimeout),
+			MaxBodyBytes:      int64(l.int("MAX_BODY_BYTES", DefaultMaxBodyBytes, 1)),
+		},
+		RateLimits: RateLimits{
+			Login:    l.rate("LOGIN_RATE_LIMIT", DefaultLoginRateLimit),
+			Signup:   l.rate("SIGNUP_RATE_LIMIT", DefaultSignupRateLimit),
+			APIRead:  l.rate("API_READ_RATE_LIMIT", DefaultAPIReadRateLimit),
+			APIWrite: l.rate("API_WRITE_RATE_LIMIT", DefaultAPIWriteRateLimit),
+		},
+		Static: Static{
+			MaxAge:       l.duration("STATIC_MAX_AGE", DefaultStaticMaxAge),
+			HashedMaxAge: l.duration("STATIC_HASHED_MAX_AGE", DefaultStaticHashedMaxAge),
+		},
+		GzipMinSize: GzipOff,
+		Cache: Cache{
+			RedisURL:   l.string("REDIS_URL", ""),
+			MemorySize: l.int("MEMORY_CACHE_SIZE", 0, 1),
+		},
+		Mail: Mail{
+			Sender: l.string("MAIL_SENDER", "log"),
+			SMTP: mail.SMTPConfig{
+				Host:     l.string("SMTP_HOST", ""),
+				Port:     l.int("SMTP_PORT", 0, 1),
+				Username: l.string("SMTP_USERNAME", ""),
+				Password: l.string("SMTP_PASSWORD", ""),
+				From:     l.string("MAIL_FROM", ""),
+			},
+		},
+		Media: Media{
+			Store: l.string("MEDIA_STORE", "local"),
+			Dir:   l.string("MEDIA_DIR", DefaultMediaDir),
+			S3: media.S3Config{
+				Endpoint:        l.string("S3_ENDPOINT", ""),
+				Region:          l.string("S3_REGION", ""),
+				Bucket:          l.string("S3_BUCKET", ""),
+				AccessKeyID:     l.string("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: l.string("S3_SECRET_ACCESS_KEY", ""),
+			},
+		},
+		Stripe: Stripe{
+			SecretKey:      l.string("STRIPE_SECRET_KEY", ""),
+			WebhookSecret:  l.string("STRIPE_WEBHOOK_SECRET", ""),
+			PriceID:        l.string("STRIPE_PRICE_ID", ""),
+			PriceIDRedPlus: l.string("STRIPE_PRICE_ID_RED_PLUS", ""),
+			SuccessURL:     l.string("STRIPE_SUCCESS_URL", ""),
+			CancelURL:      l.string("STRIPE_CANCEL_URL", ""),
+		},
+		OAuth: map[string]OAuthClient{},
+		WebAuthn: WebAuthn{
+			RPID:   l.string("WEBAUTHN_RP_ID", ""),
+			Origin: strings.TrimSuffix(l.string("WEBAUTHN_ORIGIN", ""), "/"),
+		},
+		Translation: Translation{
+			Provider: l.string("TRANSLATION_PROVIDER", ""),
+			APIKey:   l.string("TRANSLATION_API_KEY", ""),
+		},
+		WebhookAllowPrivate: l.bool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		MaxChirpLength:        l.int("MAX_CHIRP_LENGTH", entitlements.DefaultMaxChirpLength, 1),
+		BannedWordsFile:       l.string("BANNED_WORDS_FILE", ""),
+		PasswordMinLength:     l.int("PASSWORD_MIN_LENGTH", password.DefaultMinLength, 1),
+		BreachedPasswordsFile: l.string("BREACHED_PASSWORDS_FILE", ""),
+	}
+
+	// A demo can't be pointed at a real database by mistake
+	switch path, sqlite := strings.CutPrefix(c.DatabaseURL, sqliteScheme); {
+	case c.Platform == PlatformDemo:
+		c.DatabaseURL, c.DatabaseDriver = "", DatabaseMemory
+	case sqlite:
+		c.DatabaseDriver, c.SQLitePath = DatabaseSQLite, path
+		if path == "" {
+			l.fail("DB_URL needs a file after %s", sqliteScheme)
+		}
+	case c.DatabaseURL == "":
+		l.fail("DB_URL environment variable is not set")
+	default:
+		c.DatabaseDriver = DatabasePostgres
+	}
+	if c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		l.fail("DB_MAX_IDLE_CONNS (%d) can't exceed DB_MAX_OPEN_CONNS (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+	}
+
+	// Handlers have to give up before the connection does, or their error
+	// responses can't be written
+	if c.Server.WriteTimeout > 0 && (c.Server.RequestTimeout == 0 || c.Server.RequestTimeout >= c.Server.WriteTimeout) {
+		l.fail("REQUEST_TIMEOUT (%s) must be shorter than HTTP_WRITE_TIMEOUT (%s)", c.Server.RequestTimeout, c.Server.WriteTimeout)
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		l.fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLS.CertFile != "" && len(c.TLS.AutocertDomains) > 0 {
+		l.fail("use either TLS_CERT_FILE or TLS_AUTOCERT_DOMAINS, not both")
+	}
+	if c.TLS.RedirectAddr == "off" || !c.TLS.Enabled() {
+		c.TLS.RedirectAddr = ""
+	}
+
+	if minSize := getenv("GZIP_MIN_SIZE"); minSize != "off" {
+		c.GzipMinSize = l.int("GZIP_MIN_SIZE", compress.DefaultMinSize, 0)
+	}
+
+	if c.Mail.Sender != "log" && c.Mail.Sender != "none" && c.Mail.Sender != "smtp" {
+		l.fail("unknown MAIL_SENDER %q (expected log, none or smtp)", c.Mail.Sender)
+	}
+	if c.Media.Store != "local" && c.Media.Store != "s3" {
+		l.fail("unknown MEDIA_STORE %q (expected local or s3)", c.Media.Store)
+	}
+	if c.Stripe.Enabled() && (c.Stripe.WebhookSecret == "" || c.Stripe.PriceID == "") {
+		l.fail("STRIPE_WEBHOOK_SECRET and STRIPE_PRICE_ID must be set when STRIPE_SECRET_KEY is set")
+	}
+
+	for _, provider := range OAuthProviders {
+		prefix := "OAUTH_" + strings.ToUpper(provider)
+		if clientID := getenv(prefix + "_CLIENT_ID"); clientID != "" {
+			c.OAuth[provider] = OAuthClient{ClientID: clientID, ClientSecret: getenv(prefix + "_CLIENT_SECRET")}
+		}
+	}
+
+	// Passkeys default to the domain and origin of BASE_URL
+	baseURL, err := url.Parse(c.BaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		l.fail("invalid BASE_URL: %s", c.BaseURL)
+	} else {
+		if c.WebAuthn.RPID == "" {
+			c.WebAuthn.RPID = baseURL.Hostname()
+		}
+		if c.WebAuthn.Origin == "" {
+			c.WebAuthn.Origin = c.BaseURL
+		}
+	}
+
+	if err := errors.Join(l.errs...); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// CheckServe reports the settings that are missing for running the
+// server, which the other commands can do without. A demo makes up its
+// own secrets, since nothing it issues outlives it.
+func (c *Config) CheckServe() error {
+	var errs []error
+	for _, setting := range []struct{ env, value string }{
+		{"PLATFORM", c.Platform},
+		{"JWT_SECRET", c.JWTSecret},
+		{"POLKA_KEY", c.PolkaKey},
+	} {
+		if setting.value == "" && (c.Platform != PlatformDemo || setting.env == "PLATFORM") {
+			errs = append(errs, fmt.Errorf("%s environment variable is not set", setting.env))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loader reads settings, collecting the errors of invalid ones
+type loader struct {
+	getenv func(string) string
+	errs   []error
+}
+
+func (l *loader) fail(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+func (l *loader) invalid(env, raw string) {
+	l.fail("invalid %s: %s", env, raw)
+}
+
+func (l *loader) string(env, fallback string) string {
+	if raw := l.getenv(env); raw != "" {
+		return raw
+	}
+	return fallback
+}
+
+// int parses env as an integer of at least min
+func (l *loader) int(env string, fallback, min int) int {
+	raw := l.getenv(env)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		l.invalid(env, raw)
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) bool(env string, fallback bool) bool {
+	raw := l.getenv(env)
+	if raw == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.invalid(env, raw)
+		return fallback
+	}
+	return b
+}
+
+// duration parses env as a non-negative duration like "30s"
+func (l *loader) duration(env string, fallback time.Duration) time.Duration {
+	raw := l.getenv(env)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		l.invalid(env, raw)
+		return fallback
+	}
+	return d
+}
+
+// list splits env on commas, dropping empty entries
+func (l *loader) list(env string) []string {
+	var values []string
+	for _, value := range strings.Split(l.getenv(env), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// addr parses env as host:port or :port
+func (l *loader) addr(env, fallback string) string {
+	addr := l.string(env, fallback)
+	if _, port, err := net.SplitHostPort(addr); err != nil || !validPort(port) {
+		l.fail("invalid %s: %q isn't host:port or :port", env, addr)
+		return fallback
+	}
+	return addr
+}
+
+func (l *loader) port(env string) string {
+	port := l.getenv(env)
+	if port != "" && !validPort(port) {
+		l.invalid(env, port)
+		return ""
+	}
+	return port
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
+
+// rate parses env as "<limit>/<window>", or "off" for no limit
+func (l *loader) rate(env, fallback string) *Rate {
+	raw := l.string(env, fallback)
+	if raw == "off" {
+		return nil
+	}
+	limit, window, err := ratelimit.Parse(raw)
+	if err != nil {
+		l.fail("invalid %s: %v", env, err)
+		return nil
+	}
+	return &Rate{Limit: limit, Window: window}
+}
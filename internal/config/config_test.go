@@ -0,0 +1,192 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// env is a getenv over a fixed set of variables, with DB_URL set unless
+// overridden
+func env(vars map[string]string) func(string) string {
+	return func(name string) string {
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		if name == "DB_URL" {
+			return "postgres://localhost/chirpy"
+		}
+		return ""
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := LoadFrom(env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !c.MigrateOnStart {
+		t.Errorf("Expected migrations on start by default")
+	}
+	if c.Listen.Addr != DefaultAddr {
+		t.Errorf("Expected address %s, got %s", DefaultAddr, c.Listen.Addr)
+	}
+	if c.Server.RequestTimeout != DefaultRequestTimeout {
+		t.Errorf("Expected request timeout %s, got %s", DefaultRequestTimeout, c.Server.RequestTimeout)
+	}
+	if c.RateLimits.Login == nil || c.RateLimits.Login.Limit != 10 || c.RateLimits.Login.Window != time.Minute {
+		t.Errorf("Expected a login limit of 10/1m, got %+v", c.RateLimits.Login)
+	}
+	if c.TLS.RedirectAddr != "" {
+		t.Errorf("Expected no redirect without TLS, got %s", c.TLS.RedirectAddr)
+	}
+	if c.WebAuthn.RPID != "localhost" || c.WebAuthn.Origin != DefaultBaseURL {
+		t.Errorf("Expected passkeys for %s, got %+v", DefaultBaseURL, c.WebAuthn)
+	}
+	if len(c.OAuth) != 0 {
+		t.Errorf("Expected no OAuth providers, got %v", c.OAuth)
+	}
+	if c.DatabaseDriver != DatabasePostgres {
+		t.Errorf("Expected Postgres, got %s", c.DatabaseDriver)
+	}
+	if c.PolkaSigningSecret != "" || c.PolkaSignatureTolerance != DefaultPolkaSignatureTolerance {
+		t.Errorf("Expected unsigned webhooks with a %s tolerance, got %q %s", DefaultPolkaSignatureTolerance, c.PolkaSigningSecret, c.PolkaSignatureTolerance)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	c, err := LoadFrom(env(map[string]string{
+		"DB_URL":                         "sqlite:data/chirpy.db",
+		"MIGRATE_ON_START":               "false",
+		"BASE_URL":                       "https://chirpy.example.com/",
+		"GZIP_MIN_SIZE":                  "off",
+		"LOGIN_RATE_LIMIT":               "off",
+		"API_READ_RATE_LIMIT":            "100/1h",
+		"TLS_AUTOCERT_DOMAINS":           "chirpy.example.com, www.chirpy.example.com,",
+		"OAUTH_GITHUB_CLIENT_ID":         "id",
+		"OAUTH_GITHUB_CLIENT_SECRET":     "secret",
+		"WEBHOOK_ALLOW_PRIVATE_NETWORKS": "true",
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if c.DatabaseDriver != DatabaseSQLite || c.SQLitePath != "data/chirpy.db" {
+		t.Errorf("Expected SQLite in data/chirpy.db, got %s %s", c.DatabaseDriver, c.SQLitePath)
+	}
+	if c.MigrateOnStart {
+		t.Errorf("Expected no migrations on start")
+	}
+	if c.BaseURL != "https://chirpy.example.com" {
+		t.Errorf("Expected the base URL without its slash, got %s", c.BaseURL)
+	}
+	if c.WebAuthn.RPID != "chirpy.example.com" {
+		t.Errorf("Expected passkeys for chirpy.example.com, got %s", c.WebAuthn.RPID)
+	}
+	if c.GzipMinSize != GzipOff {
+		t.Errorf("Expected compression off, got %d", c.GzipMinSize)
+	}
+	if c.RateLimits.Login != nil {
+		t.Errorf("Expected no login limit, got %+v", c.RateLimits.Login)
+	}
+	if c.RateLimits.APIRead == nil || c.RateLimits.APIRead.Limit != 100 || c.RateLimits.APIRead.Window != time.Hour {
+		t.Errorf("Expected a read limit of 100/1h, got %+v", c.RateLimits.APIRead)
+	}
+	if len(c.TLS.AutocertDomains) != 2 || !c.TLS.Enabled() {
+		t.Errorf("Expected TLS for two domains, got %v", c.TLS.AutocertDomains)
+	}
+	if c.TLS.RedirectAddr != DefaultHTTPRedirectAddr {
+		t.Errorf("Expected a redirect on %s, got %s", DefaultHTTPRedirectAddr, c.TLS.RedirectAddr)
+	}
+	if client := c.OAuth["github"]; client.ClientID != "id" || client.ClientSecret != "secret" {
+		t.Errorf("Expected the GitHub client, got %+v", client)
+	}
+	if !c.WebhookAllowPrivate {
+		t.Errorf("Expected webhooks to private networks to be allowed")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"missing database", map[string]string{"DB_URL": ""}, "DB_URL environment variable is not set"},
+		{"sqlite without a file", map[string]string{"DB_URL": "sqlite:"}, "DB_URL needs a file after sqlite:"},
+		{"bad integer", map[string]string{"MAX_CHIRP_LENGTH": "long"}, "invalid MAX_CHIRP_LENGTH: long"},
+		{"below minimum", map[string]string{"DB_MAX_OPEN_CONNS": "0"}, "invalid DB_MAX_OPEN_CONNS: 0"},
+		{"bad bool", map[string]string{"MIGRATE_ON_START": "sometimes"}, "invalid MIGRATE_ON_START: sometimes"},
+		{"negative duration", map[string]string{"REQUEST_TIMEOUT": "-1s"}, "invalid REQUEST_TIMEOUT: -1s"},
+		{"bad tolerance", map[string]string{"POLKA_SIGNATURE_TOLERANCE": "soon"}, "invalid POLKA_SIGNATURE_TOLERANCE: soon"},
+		{"bad rate", map[string]string{"SIGNUP_RATE_LIMIT": "lots"}, "invalid SIGNUP_RATE_LIMIT"},
+		{"bad address", map[string]string{"ADDR": "8080"}, "invalid ADDR"},
+		{"bad port", map[string]string{"PORT": "99999"}, "invalid PORT: 99999"},
+		{"idle over open", map[string]string{"DB_MAX_IDLE_CONNS": "30"}, "can't exceed DB_MAX_OPEN_CONNS"},
+		{"request outlasts write", map[string]string{"REQUEST_TIMEOUT": "1m"}, "must be shorter than HTTP_WRITE_TIMEOUT"},
+		{"half a certificate", map[string]string{"TLS_CERT_FILE": "cert.pem"}, "must be set together"},
+		{"unknown media store", map[string]string{"MEDIA_STORE": "ftp"}, "unknown MEDIA_STORE"},
+		{"unknown mail sender", map[string]string{"MAIL_SENDER": "pigeon"}, "unknown MAIL_SENDER"},
+		{"incomplete stripe", map[string]string{"STRIPE_SECRET_KEY": "sk"}, "STRIPE_WEBHOOK_SECRET and STRIPE_PRICE_ID"},
+		{"bad base URL", map[string]string{"BASE_URL": "chirpy"}, "invalid BASE_URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFrom(env(tt.vars))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	_, err := LoadFrom(env(map[string]string{
+		"MAX_CHIRP_LENGTH":    "long",
+		"PASSWORD_MIN_LENGTH": "short",
+	}))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{"MAX_CHIRP_LENGTH", "PASSWORD_MIN_LENGTH"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to mention %s, got %v", want, err)
+		}
+	}
+}
+
+func TestCheckServe(t *testing.T) {
+	c, err := LoadFrom(env(map[string]string{"PLATFORM": "dev"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	err = c.CheckServe()
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "POLKA_KEY") || strings.Contains(err.Error(), "PLATFORM") {
+		t.Errorf("Expected JWT_SECRET and POLKA_KEY to be missing, got %v", err)
+	}
+
+	c.JWTSecret, c.PolkaKey = "secret", "key"
+	if err := c.CheckServe(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestLoadDemo(t *testing.T) {
+	c, err := LoadFrom(env(map[string]string{"PLATFORM": PlatformDemo, "DB_URL": ""}))
+	if err != nil {
+		t.Fatalf("Expected no error without DB_URL, got %v", err)
+	}
+	if c.DatabaseDriver != DatabaseMemory {
+		t.Errorf("Expected the data kept in memory, got %s", c.DatabaseDriver)
+	}
+	if err := c.CheckServe(); err != nil {
+		t.Errorf("Expected a demo to need no secrets, got %v", err)
+	}
+
+	// Even with a real database configured
+	c, err = LoadFrom(env(map[string]string{"PLATFORM": PlatformDemo}))
+	if err != nil || c.DatabaseDriver != DatabaseMemory || c.DatabaseURL != "" {
+		t.Errorf("Expected the data kept in memory, got %s %s (%v)", c.DatabaseDriver, c.DatabaseURL, err)
+	}
+}
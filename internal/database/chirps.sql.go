@@ -7,29 +7,130 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const addChirpViews = `-- name: AddChirpViews :exec
+UPDATE chirps
+SET view_count = chirps.view_count + views.count
+FROM (
+    SELECT unnest($1::uuid[]) AS id, unnest($2::bigint[]) AS count
+) AS views
+WHERE chirps.id = views.id
+`
+
+type AddChirpViewsParams struct {
+	Ids    []uuid.UUID
+	Counts []int64
+}
+
+// Applies a batch of buffered view counts in one statement
+func (q *Queries) AddChirpViews(ctx context.Context, arg AddChirpViewsParams) error {
+	_, err := q.db.ExecContext(ctx, addChirpViews, pq.Array(arg.Ids), pq.Array(arg.Counts))
+	return err
+}
+
+const adjustChirpLikeCount = `-- name: AdjustChirpLikeCount :one
+UPDATE chirps
+SET like_count = like_count + $1::integer
+WHERE id = $2
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at
+`
+
+type AdjustChirpLikeCountParams struct {
+	Delta int32
+	ID    uuid.UUID
+}
+
+func (q *Queries) AdjustChirpLikeCount(ctx context.Context, arg AdjustChirpLikeCountParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, adjustChirpLikeCount, arg.Delta, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const adjustChirpReplyCount = `-- name: AdjustChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = GREATEST(reply_count + $1::integer, 0)
+WHERE id = $2
+`
+
+type AdjustChirpReplyCountParams struct {
+	Delta int32
+	ID    uuid.UUID
+}
+
+func (q *Queries) AdjustChirpReplyCount(ctx context.Context, arg AdjustChirpReplyCountParams) error {
+	_, err := q.db.ExecContext(ctx, adjustChirpReplyCount, arg.Delta, arg.ID)
+	return err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, parent_chirp_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body          string
+	UserID        uuid.UUID
+	PublishAt     time.Time
+	PublishedAt   sql.NullTime
+	Latitude      sql.NullFloat64
+	Longitude     sql.NullFloat64
+	Geohash       sql.NullString
+	Place         sql.NullString
+	ParentChirpID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.PublishAt,
+		arg.PublishedAt,
+		arg.Latitude,
+		arg.Longitude,
+		arg.Geohash,
+		arg.Place,
+		arg.ParentChirpID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,27 +138,181 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
 	)
 	return i, err
 }
 
-const deleteChirp = `-- name: DeleteChirp :exec
+const deleteChirpsForUser = `-- name: DeleteChirpsForUser :many
 DELETE FROM chirps
-WHERE id = $1
+WHERE user_id = $1
+RETURNING id
 `
 
-func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, deleteChirp, id)
-	return err
+// Deletes every chirp the user has written, including soft-deleted ones,
+// returning their IDs
+func (q *Queries) DeleteChirpsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, deleteChirpsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportChirps = `-- name: ExportChirps :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors)
+    AND ($1::timestamp IS NULL OR created_at >= $1)
+    AND ($2::timestamp IS NULL OR created_at < $2)
+    AND ($3::timestamp IS NULL
+        OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ExportChirpsParams struct {
+	CreatedFrom    sql.NullTime
+	CreatedTo      sql.NullTime
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+// One page of the export, after the last row of the page before
+func (q *Queries) ExportChirps(ctx context.Context, arg ExportChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, exportChirps,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $1::uuid)
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $1::uuid)
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetAllChirpsDesc(ctx context.Context, viewerID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirpsDesc, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +326,19 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -86,8 +354,9 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE chirps.id = $1 AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self)
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -99,18 +368,291 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
 	)
 	return i, err
 }
 
-const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
+const getChirpForModeration = `-- name: GetChirpForModeration :one
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE id = $1
+`
+
+// Finds the chirp whatever its state, for moderators
+func (q *Queries) GetChirpForModeration(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForModeration, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const getChirpIDsByAuthor = `-- name: GetChirpIDsByAuthor :many
+SELECT id FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetChirpIDsByAuthor(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpIDsByAuthor, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpReplies = `-- name: GetChirpReplies :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE parent_chirp_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
 ORDER BY created_at ASC
 `
 
-func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsByAuthor, userID)
+type GetChirpRepliesParams struct {
+	ParentChirpID uuid.NullUUID
+	ViewerID      uuid.NullUUID
+}
+
+func (q *Queries) GetChirpReplies(ctx context.Context, arg GetChirpRepliesParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpReplies, arg.ParentChirpID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpTotalsForUser = `-- name: GetChirpTotalsForUser :one
+SELECT
+    COUNT(*) AS chirp_count,
+    COALESCE(SUM(view_count), 0)::bigint AS view_count,
+    COALESCE(SUM(like_count), 0)::bigint AS like_count,
+    COALESCE(SUM(reply_count), 0)::bigint AS reply_count
+FROM chirps
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+`
+
+type GetChirpTotalsForUserRow struct {
+	ChirpCount int64
+	ViewCount  int64
+	LikeCount  int64
+	ReplyCount int64
+}
+
+// Engagement across all of a user's published chirps
+func (q *Queries) GetChirpTotalsForUser(ctx context.Context, userID uuid.UUID) (GetChirpTotalsForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getChirpTotalsForUser, userID)
+	var i GetChirpTotalsForUserRow
+	err := row.Scan(
+		&i.ChirpCount,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.ReplyCount,
+	)
+	return i, err
+}
+
+const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+ORDER BY created_at ASC, id ASC
+`
+
+type GetChirpsByAuthorParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByAuthor(ctx context.Context, arg GetChirpsByAuthorParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthor, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByAuthorDesc = `-- name: GetChirpsByAuthorDesc :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+ORDER BY created_at DESC, id DESC
+`
+
+type GetChirpsByAuthorDescParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpsByAuthorDesc(ctx context.Context, arg GetChirpsByAuthorDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthorDesc, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE id = ANY($1::uuid[])
+    AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+`
+
+type GetChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// The listed chirps that are still visible to the viewer, in no particular
+// order
+func (q *Queries) GetChirpsByIDs(ctx context.Context, arg GetChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +666,19 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -137,3 +692,606 @@ func (q *Queries) GetChirpsByAuthor(ctx context.Context, userID uuid.UUID) ([]Ch
 	}
 	return items, nil
 }
+
+const getChirpsForExport = `-- name: GetChirpsForExport :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC
+`
+
+// Everything the user hasn't deleted, including chirps still pending
+func (q *Queries) GetChirpsForExport(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsForExport, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsInCells = `-- name: GetChirpsInCells :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+    AND geohash LIKE ANY($3::text[])
+ORDER BY created_at DESC
+LIMIT $1
+`
+
+type GetChirpsInCellsParams struct {
+	Limit    int32
+	ViewerID uuid.NullUUID
+	Prefixes []string
+}
+
+func (q *Queries) GetChirpsInCells(ctx context.Context, arg GetChirpsInCellsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsInCells, arg.Limit, arg.ViewerID, pq.Array(arg.Prefixes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPage = `-- name: GetChirpsPage :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $1::uuid)
+    AND ($2::uuid IS NULL OR user_id = $2)
+    AND ($3::timestamp IS NULL
+        OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type GetChirpsPageParams struct {
+	ViewerID       uuid.NullUUID
+	AuthorID       uuid.NullUUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+func (q *Queries) GetChirpsPage(ctx context.Context, arg GetChirpsPageParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPage,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPageDesc = `-- name: GetChirpsPageDesc :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $1::uuid)
+    AND ($2::uuid IS NULL OR user_id = $2)
+    AND ($3::timestamp IS NULL
+        OR (created_at, id) < ($3, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type GetChirpsPageDescParams struct {
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetChirpsPageDesc(ctx context.Context, arg GetChirpsPageDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPageDesc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsPublishedAfter = `-- name: GetChirpsPublishedAfter :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors)
+    AND (published_at, id) > ($1::timestamp, $2::uuid)
+ORDER BY published_at ASC, id ASC
+LIMIT $3
+`
+
+type GetChirpsPublishedAfterParams struct {
+	PublishedAt time.Time
+	ID          uuid.UUID
+	Limit       int32
+}
+
+// Publication order rather than creation order, since held-back chirps are
+// published after newer ones
+func (q *Queries) GetChirpsPublishedAfter(ctx context.Context, arg GetChirpsPublishedAfterParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsPublishedAfter, arg.PublishedAt, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isChirpAuthorHidden = `-- name: IsChirpAuthorHidden :one
+SELECT EXISTS (
+    SELECT 1 FROM hidden_authors
+    WHERE id = $1::uuid
+        AND (hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+)
+`
+
+type IsChirpAuthorHiddenParams struct {
+	AuthorID uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+// Whether the author's chirps are kept from the viewer, for reads of a
+// single chirp, whose cached copy is the same for every viewer
+func (q *Queries) IsChirpAuthorHidden(ctx context.Context, arg IsChirpAuthorHiddenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isChirpAuthorHidden, arg.AuthorID, arg.ViewerID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+WITH published AS (
+    UPDATE chirps
+    SET published_at = NOW()
+    WHERE published_at IS NULL AND deleted_at IS NULL AND publish_at <= NOW()
+    RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at
+), counted AS (
+    UPDATE chirps
+    SET reply_count = chirps.reply_count + replies.count
+    FROM (
+        SELECT parent_chirp_id, COUNT(*)::integer AS count
+        FROM published
+        WHERE parent_chirp_id IS NOT NULL
+        GROUP BY parent_chirp_id
+    ) AS replies
+    WHERE chirps.id = replies.parent_chirp_id
+)
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM published
+`
+
+type PublishDueChirpsRow struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Body          string
+	UserID        uuid.UUID
+	PublishAt     time.Time
+	PublishedAt   sql.NullTime
+	Latitude      sql.NullFloat64
+	Longitude     sql.NullFloat64
+	Geohash       sql.NullString
+	Place         sql.NullString
+	DeletedAt     sql.NullTime
+	LikeCount     int32
+	ParentChirpID uuid.NullUUID
+	ReplyCount    int32
+	SearchVector  interface{}
+	ViewCount     int64
+	EditedAt      sql.NullTime
+}
+
+// Replies only count towards their parent once they're published
+func (q *Queries) PublishDueChirps(ctx context.Context) ([]PublishDueChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PublishDueChirpsRow
+	for rows.Next() {
+		var i PublishDueChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at IS NOT NULL
+    AND ($1::timestamp IS NULL OR deleted_at < $1)
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const releaseChirpRepliesByUser = `-- name: ReleaseChirpRepliesByUser :many
+UPDATE chirps
+SET reply_count = reply_count - (
+    SELECT COUNT(*) FROM chirps AS replies
+    WHERE replies.parent_chirp_id = chirps.id AND replies.user_id = $1
+        AND replies.published_at IS NOT NULL AND replies.deleted_at IS NULL
+)
+WHERE user_id <> $1 AND id IN (
+    SELECT parent_chirp_id FROM chirps
+    WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+)
+RETURNING id
+`
+
+// Takes the user's published replies off the reply counts of other users'
+// chirps, for when the replies are about to be deleted with the user,
+// returning the chirps that changed
+func (q *Queries) ReleaseChirpRepliesByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, releaseChirpRepliesByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE search_vector @@ websearch_to_tsquery('english', $1)
+    AND published_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2::uuid)
+    AND ($3::uuid IS NULL OR user_id = $3)
+    AND ($4::timestamp IS NULL
+        OR (created_at, id) < ($4, $5::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type SearchChirpsParams struct {
+	Query           string
+	ViewerID        uuid.NullUUID
+	AuthorID        uuid.NullUUID
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.PublishAt,
+			&i.PublishedAt,
+			&i.Latitude,
+			&i.Longitude,
+			&i.Geohash,
+			&i.Place,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.ParentChirpID,
+			&i.ReplyCount,
+			&i.SearchVector,
+			&i.ViewCount,
+			&i.EditedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :one
+UPDATE chirps
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at
+`
+
+// Returns the chirp as deleted, so whether it was published is read under
+// the same row lock a concurrent PublishDueChirps takes
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, softDeleteChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.PublishAt,
+		&i.PublishedAt,
+		&i.Latitude,
+		&i.Longitude,
+		&i.Geohash,
+		&i.Place,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.ParentChirpID,
+		&i.ReplyCount,
+		&i.SearchVector,
+		&i.ViewCount,
+		&i.EditedAt,
+	)
+	return i, err
+}
@@ -6,33 +6,415 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AuditEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Action    string
+	UserID    uuid.NullUUID
+	ActorID   uuid.NullUUID
+	IpAddress string
+	UserAgent string
+	Details   string
+}
+
+type BannedWord struct {
+	Word      string
+	CreatedAt time.Time
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Body          string
+	UserID        uuid.UUID
+	PublishAt     time.Time
+	PublishedAt   sql.NullTime
+	Latitude      sql.NullFloat64
+	Longitude     sql.NullFloat64
+	Geohash       sql.NullString
+	Place         sql.NullString
+	DeletedAt     sql.NullTime
+	LikeCount     int32
+	ParentChirpID uuid.NullUUID
+	ReplyCount    int32
+	SearchVector  interface{}
+	ViewCount     int64
+	EditedAt      sql.NullTime
+}
+
+type ChirpHashtag struct {
+	ChirpID uuid.UUID
+	Tag     string
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpReport struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ReporterID   uuid.UUID
+	ChirpID      uuid.UUID
+	Reason       string
+	Details      string
+	Status       string
+	Decision     sql.NullString
+	DecisionNote string
+	DecidedBy    uuid.NullUUID
+	DecidedAt    sql.NullTime
+}
+
+type ChirpTranslation struct {
+	ChirpID        uuid.UUID
+	Language       string
+	CreatedAt      time.Time
+	Body           string
+	SourceLanguage string
+	Provider       string
+}
+
+type Conversation struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	UserAID   uuid.UUID
+	UserBID   uuid.UUID
+}
+
+type DataExport struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Status      string
+	Archive     []byte
+	Error       sql.NullString
+	CompletedAt sql.NullTime
+	ExpiresAt   sql.NullTime
+}
+
+type EmailVerificationToken struct {
+	TokenHash string
 	UserID    uuid.UUID
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type EntitlementOverride struct {
+	UserID    uuid.UUID
+	Feature   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Enabled   bool
+}
+
+type ExperimentEvent struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ExperimentKey string
+	Variant       string
+	UserID        uuid.UUID
+	Kind          string
+	Name          string
+}
+
+type FeatureFlag struct {
+	Feature   string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Enabled   bool
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type HashtagFollow struct {
+	UserID    uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type HiddenAuthor struct {
+	ID             uuid.UUID
+	HiddenFromSelf interface{}
+}
+
+type HitCounter struct {
+	Name      string
+	Count     int64
+	UpdatedAt time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	IsPrivate   bool
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Login struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
 	UserID    uuid.UUID
+	IpAddress string
+	Network   string
+	UserAgent string
+}
+
+type MagicLinkToken struct {
+	TokenHash string
+	Email     string
+	CreatedAt time.Time
 	ExpiresAt time.Time
-	RevokedAt sql.NullTime
 }
 
-type User struct {
+type Mention struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+type Message struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ModeratorID uuid.NullUUID
+	Action      string
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	Reason      string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Kind      string
+	Message   string
+	ReadAt    sql.NullTime
+}
+
+type OauthIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Plan struct {
+	ID                 string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	Name               string
+	MaxChirpLength     int32
+	RateLimitPerMinute int32
+	EditWindowSeconds  int32
+	UndoWindowSeconds  int32
+}
+
+type PlanFeature struct {
+	PlanID  string
+	Feature string
+}
+
+type PromoCode struct {
+	Code            string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Plan            string
+	DurationDays    int32
+	MaxRedemptions  int32
+	RedemptionCount int32
+	ExpiresAt       sql.NullTime
+}
+
+type PromoRedemption struct {
+	Code      string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	ExpiresAt  time.Time
+	RevokedAt  sql.NullTime
+	ID         uuid.UUID
+	UserAgent  string
+	IpAddress  string
+	LastUsedAt sql.NullTime
+}
+
+type RevokedAccessToken struct {
+	Jti       string
+	UserID    uuid.UUID
+	RevokedAt time.Time
+	ExpiresAt time.Time
+}
+
+type StripeCustomer struct {
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	CustomerID string
+}
+
+type Subscription struct {
+	UserID        uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Plan          string
+	Status        string
+	ExpiresAt     time.Time
+	GiftAvailable bool
+}
+
+type SubscriptionEvent struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	UserID         uuid.UUID
+	Kind           string
+	Source         string
+	WebhookEventID uuid.NullUUID
+	ActorUserID    uuid.NullUUID
+	PromoCode      sql.NullString
+}
+
+type User struct {
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Email                  string
+	HashedPassword         string
+	IsChirpyRed            bool
+	Plan                   string
+	Recommendations        bool
+	ShareLocation          bool
+	Handle                 sql.NullString
+	DisplayName            string
+	Bio                    string
+	Location               string
+	Website                string
+	AvatarKey              sql.NullString
+	EmailVerified          bool
+	Role                   string
+	TokenVersion           int32
+	FailedLoginCount       int32
+	FailedLoginWindowStart sql.NullTime
+	LockedUntil            sql.NullTime
+	DeactivatedAt          sql.NullTime
+	SuspendedAt            sql.NullTime
+	SuspendedUntil         sql.NullTime
+	SuspensionReason       string
+	PasswordResetRequired  bool
+	ShadowBanned           bool
+}
+
+type WebauthnChallenge struct {
+	Challenge   string
+	Ceremony    string
+	UserID      uuid.NullUUID
+	ExpiresAt   time.Time
+	SessionData string
+}
+
+type WebauthnCredential struct {
+	ID             []byte
+	UserID         uuid.UUID
+	PublicKey      []byte
+	SignCount      int64
+	Name           string
+	CreatedAt      time.Time
+	LastUsedAt     sql.NullTime
+	BackupEligible sql.NullBool
+	BackupState    bool
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	EndpointID    uuid.UUID
+	EventID       uuid.UUID
+	EventType     string
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int32
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	DeliveredAt   sql.NullTime
+}
+
+type WebhookEndpoint struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.NullUUID
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
+}
+
+type WebhookEvent struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Source      string
+	EventID     string
+	EventType   string
+	Payload     json.RawMessage
+	Status      string
+	Error       sql.NullString
+	Attempts    int32
+	ProcessedAt sql.NullTime
 }
@@ -13,19 +13,27 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, NOW(), NOW(), $2, $3, NULL)
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, user_agent, ip_address)
+VALUES ($1, NOW(), NOW(), $2, $3, NULL, $4, $5)
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, id, user_agent, ip_address, last_used_at
 `
 
 type CreateRefreshTokenParams struct {
 	Token     string
 	UserID    uuid.UUID
 	ExpiresAt time.Time
+	UserAgent string
+	IpAddress string
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.Token,
+		arg.UserID,
+		arg.ExpiresAt,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -34,39 +42,191 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.ID,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
+const deleteStaleRefreshTokens = `-- name: DeleteStaleRefreshTokens :execrows
+DELETE FROM refresh_tokens
+WHERE expires_at < NOW() OR revoked_at IS NOT NULL
+`
+
+// Tokens that can no longer be used, whether expired or revoked
+func (q *Queries) DeleteStaleRefreshTokens(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteStaleRefreshTokens)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getActiveSessionsForUser = `-- name: GetActiveSessionsForUser :many
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, id, user_agent, ip_address, last_used_at FROM refresh_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+ORDER BY COALESCE(last_used_at, created_at) DESC
+`
+
+func (q *Queries) GetActiveSessionsForUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveSessionsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RefreshToken
+	for rows.Next() {
+		var i RefreshToken
+		if err := rows.Scan(
+			&i.Token,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.ID,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red FROM users
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.plan, users.recommendations, users.share_location, users.handle, users.display_name, users.bio, users.location, users.website, users.avatar_key, users.email_verified, users.role, users.token_version, users.failed_login_count, users.failed_login_window_start, users.locked_until, users.deactivated_at, users.suspended_at, users.suspended_until, users.suspension_reason, users.password_reset_required, users.shadow_banned, refresh_tokens.created_at AS signed_in_at FROM users
 INNER JOIN refresh_tokens ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = $1
     AND refresh_tokens.revoked_at IS NULL
     AND refresh_tokens.expires_at > NOW()
 `
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (User, error) {
+type GetUserFromRefreshTokenRow struct {
+	User       User
+	SignedInAt time.Time
+}
+
+// signed_in_at is when the session began, which is when the token was made
+func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (GetUserFromRefreshTokenRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
-	var i User
+	var i GetUserFromRefreshTokenRow
 	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Email,
-		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.User.ID,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.Email,
+		&i.User.HashedPassword,
+		&i.User.IsChirpyRed,
+		&i.User.Plan,
+		&i.User.Recommendations,
+		&i.User.ShareLocation,
+		&i.User.Handle,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.Location,
+		&i.User.Website,
+		&i.User.AvatarKey,
+		&i.User.EmailVerified,
+		&i.User.Role,
+		&i.User.TokenVersion,
+		&i.User.FailedLoginCount,
+		&i.User.FailedLoginWindowStart,
+		&i.User.LockedUntil,
+		&i.User.DeactivatedAt,
+		&i.User.SuspendedAt,
+		&i.User.SuspendedUntil,
+		&i.User.SuspensionReason,
+		&i.User.PasswordResetRequired,
+		&i.User.ShadowBanned,
+		&i.SignedInAt,
 	)
 	return i, err
 }
 
-const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
+const revokeRefreshToken = `-- name: RevokeRefreshToken :one
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND revoked_at IS NULL
+RETURNING user_id
+`
+
+// Returns no rows when the token is unknown or already revoked
+func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, revokeRefreshToken, token)
+	var user_id uuid.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const revokeRefreshTokenForUser = `-- name: RevokeRefreshTokenForUser :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenForUserParams struct {
+	Token  string
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeRefreshTokenForUser(ctx context.Context, arg RevokeRefreshTokenForUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeRefreshTokenForUser, arg.Token, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const touchRefreshToken = `-- name: TouchRefreshToken :exec
+UPDATE refresh_tokens
+SET last_used_at = NOW()
 WHERE token = $1
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
-	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+func (q *Queries) TouchRefreshToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, touchRefreshToken, token)
 	return err
 }
@@ -7,29 +7,35 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+ON CONFLICT DO NOTHING
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
+// Returns no rows when the email or handle is already taken
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +44,70 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const deactivateUser = `-- name: DeactivateUser :one
+UPDATE users
+SET deactivated_at = NOW(), token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+// Bumps the token version too, so outstanding access tokens stop working
+func (q *Queries) DeactivateUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, deactivateUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
 	)
 	return i, err
 }
@@ -51,8 +121,84 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE id = $1
+`
+
+// Everything else the user owns goes with them through ON DELETE CASCADE
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const exportUsers = `-- name: ExportUsers :many
+SELECT id, created_at, updated_at, is_chirpy_red, plan FROM users
+WHERE ($1::timestamp IS NULL OR created_at >= $1)
+    AND ($2::timestamp IS NULL OR created_at < $2)
+    AND ($3::timestamp IS NULL
+        OR (created_at, id) > ($3, $4::uuid))
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ExportUsersParams struct {
+	CreatedFrom    sql.NullTime
+	CreatedTo      sql.NullTime
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
+}
+
+type ExportUsersRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	IsChirpyRed bool
+	Plan        string
+}
+
+// One page of the export, after the last row of the page before
+func (q *Queries) ExportUsers(ctx context.Context, arg ExportUsersParams) ([]ExportUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportUsers,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportUsersRow
+	for rows.Next() {
+		var i ExportUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsChirpyRed,
+			&i.Plan,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
 WHERE email = $1
 `
 
@@ -66,15 +212,988 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
 	)
 	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE handle = $1 AND deactivated_at IS NULL
+`
+
+// Deactivated accounts have no public profile
+func (q *Queries) GetUserByHandle(ctx context.Context, handle sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const getUserByIDForUpdate = `-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIDForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const getUsersDeactivatedBefore = `-- name: GetUsersDeactivatedBefore :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE deactivated_at < $1
+ORDER BY deactivated_at
+LIMIT $2
+`
+
+type GetUsersDeactivatedBeforeParams struct {
+	DeactivatedBefore sql.NullTime
+	MaxUsers          int32
+}
+
+func (q *Queries) GetUsersDeactivatedBefore(ctx context.Context, arg GetUsersDeactivatedBeforeParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersDeactivatedBefore, arg.DeactivatedBefore, arg.MaxUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Plan,
+			&i.Recommendations,
+			&i.ShareLocation,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Location,
+			&i.Website,
+			&i.AvatarKey,
+			&i.EmailVerified,
+			&i.Role,
+			&i.TokenVersion,
+			&i.FailedLoginCount,
+			&i.FailedLoginWindowStart,
+			&i.LockedUntil,
+			&i.DeactivatedAt,
+			&i.SuspendedAt,
+			&i.SuspendedUntil,
+			&i.SuspensionReason,
+			&i.PasswordResetRequired,
+			&i.ShadowBanned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const incrementUserTokenVersion = `-- name: IncrementUserTokenVersion :exec
+UPDATE users
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementUserTokenVersion, id)
+	return err
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE ($1::text IS NULL
+        OR email LIKE '%' || $1 || '%'
+        OR handle LIKE '%' || $1 || '%')
+    AND ($2::text IS NULL OR role = $2)
+    AND ($3::text IS NULL OR plan = $3)
+    AND ($4::boolean IS NULL
+        OR (suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > NOW())) = $4)
+    AND ($5::boolean IS NULL OR (deactivated_at IS NOT NULL) = $5)
+    AND ($6::boolean IS NULL OR shadow_banned = $6)
+    AND ($7::timestamp IS NULL OR created_at >= $7)
+    AND ($8::timestamp IS NULL OR created_at < $8)
+    AND ($9::timestamp IS NULL
+        OR (created_at, id) < ($9, $10::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $11
+`
+
+type ListUsersParams struct {
+	Query           sql.NullString
+	Role            sql.NullString
+	Plan            sql.NullString
+	Suspended       sql.NullBool
+	Deactivated     sql.NullBool
+	ShadowBanned    sql.NullBool
+	CreatedFrom     sql.NullTime
+	CreatedTo       sql.NullTime
+	BeforeCreatedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	Limit           int32
+}
+
+// query matches part of the email address or handle. A suspension that has
+// run out doesn't count as suspended.
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers,
+		arg.Query,
+		arg.Role,
+		arg.Plan,
+		arg.Suspended,
+		arg.Deactivated,
+		arg.ShadowBanned,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.Plan,
+			&i.Recommendations,
+			&i.ShareLocation,
+			&i.Handle,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Location,
+			&i.Website,
+			&i.AvatarKey,
+			&i.EmailVerified,
+			&i.Role,
+			&i.TokenVersion,
+			&i.FailedLoginCount,
+			&i.FailedLoginWindowStart,
+			&i.LockedUntil,
+			&i.DeactivatedAt,
+			&i.SuspendedAt,
+			&i.SuspendedUntil,
+			&i.SuspensionReason,
+			&i.PasswordResetRequired,
+			&i.ShadowBanned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUser = `-- name: LockUser :exec
+UPDATE users
+SET locked_until = $2, failed_login_count = 0, failed_login_window_start = NULL
+WHERE id = $1
+`
+
+type LockUserParams struct {
+	ID          uuid.UUID
+	LockedUntil sql.NullTime
+}
+
+func (q *Queries) LockUser(ctx context.Context, arg LockUserParams) error {
+	_, err := q.db.ExecContext(ctx, lockUser, arg.ID, arg.LockedUntil)
+	return err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :one
+UPDATE users
+SET email_verified = TRUE, updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type MarkEmailVerifiedParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+// Matches on email so a token for a previous address has no effect
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, markEmailVerified, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const reactivateUser = `-- name: ReactivateUser :one
+UPDATE users
+SET deactivated_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deactivated_at > $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type ReactivateUserParams struct {
+	ID               uuid.UUID
+	DeactivatedAfter sql.NullTime
+}
+
+// Returns no rows unless the user was deactivated after deactivated_after
+func (q *Queries) ReactivateUser(ctx context.Context, arg ReactivateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, reactivateUser, arg.ID, arg.DeactivatedAfter)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const recordFailedLogin = `-- name: RecordFailedLogin :one
+UPDATE users
+SET failed_login_count = CASE
+        WHEN failed_login_window_start IS NULL OR failed_login_window_start < $1::timestamp THEN 1
+        ELSE failed_login_count + 1
+    END,
+    failed_login_window_start = CASE
+        WHEN failed_login_window_start IS NULL OR failed_login_window_start < $1::timestamp THEN NOW()
+        ELSE failed_login_window_start
+    END
+WHERE id = $2
+RETURNING failed_login_count
+`
+
+type RecordFailedLoginParams struct {
+	WindowStart time.Time
+	ID          uuid.UUID
+}
+
+// Starts a new count when the current window began before window_start
+func (q *Queries) RecordFailedLogin(ctx context.Context, arg RecordFailedLoginParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, recordFailedLogin, arg.WindowStart, arg.ID)
+	var failed_login_count int32
+	err := row.Scan(&failed_login_count)
+	return failed_login_count, err
+}
+
+const requirePasswordReset = `-- name: RequirePasswordReset :one
+UPDATE users
+SET password_reset_required = TRUE, token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+// Bumps the token version too, so outstanding access tokens stop working
+func (q *Queries) RequirePasswordReset(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, requirePasswordReset, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const resetFailedLogins = `-- name: ResetFailedLogins :exec
+UPDATE users
+SET failed_login_count = 0, failed_login_window_start = NULL
+WHERE id = $1 AND failed_login_count <> 0
+`
+
+func (q *Queries) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resetFailedLogins, id)
+	return err
+}
+
+const setRecommendations = `-- name: SetRecommendations :one
+UPDATE users
+SET recommendations = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetRecommendationsParams struct {
+	ID              uuid.UUID
+	Recommendations bool
+}
+
+func (q *Queries) SetRecommendations(ctx context.Context, arg SetRecommendationsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setRecommendations, arg.ID, arg.Recommendations)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const setShareLocation = `-- name: SetShareLocation :one
+UPDATE users
+SET share_location = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetShareLocationParams struct {
+	ID            uuid.UUID
+	ShareLocation bool
+}
+
+func (q *Queries) SetShareLocation(ctx context.Context, arg SetShareLocationParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setShareLocation, arg.ID, arg.ShareLocation)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_key = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetUserAvatarParams struct {
+	ID        uuid.UUID
+	AvatarKey sql.NullString
+}
+
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserAvatar, arg.ID, arg.AvatarKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const setUserHandle = `-- name: SetUserHandle :one
+UPDATE users
+SET handle = $1, updated_at = NOW()
+WHERE users.id = $2
+    AND NOT EXISTS (SELECT 1 FROM users AS other WHERE other.handle = $1 AND other.id <> $2)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetUserHandleParams struct {
+	Handle sql.NullString
+	ID     uuid.UUID
+}
+
+// Returns no rows when another user already holds the handle
+func (q *Queries) SetUserHandle(ctx context.Context, arg SetUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserHandle, arg.Handle, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const setUserPlan = `-- name: SetUserPlan :execrows
+UPDATE users
+SET plan = $1, is_chirpy_red = ($1::text <> 'free'), updated_at = NOW()
+WHERE id = $2
+`
+
+type SetUserPlanParams struct {
+	Plan string
+	ID   uuid.UUID
+}
+
+func (q *Queries) SetUserPlan(ctx context.Context, arg SetUserPlanParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserPlan, arg.Plan, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :one
+UPDATE users
+SET role = $2,
+    token_version = token_version + CASE WHEN role = $2 THEN 0 ELSE 1 END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+// A real change bumps the token version too, so access tokens carrying the
+// old role stop working; refreshing picks up the new one
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserRole, arg.ID, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const setUserShadowBanned = `-- name: SetUserShadowBanned :one
+UPDATE users
+SET shadow_banned = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SetUserShadowBannedParams struct {
+	ID           uuid.UUID
+	ShadowBanned bool
+}
+
+func (q *Queries) SetUserShadowBanned(ctx context.Context, arg SetUserShadowBannedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserShadowBanned, arg.ID, arg.ShadowBanned)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET suspended_at = NOW(),
+    suspended_until = $1,
+    suspension_reason = $2,
+    token_version = token_version + 1,
+    updated_at = NOW()
 WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+type SuspendUserParams struct {
+	SuspendedUntil   sql.NullTime
+	SuspensionReason string
+	ID               uuid.UUID
+}
+
+// Bumps the token version too, so outstanding access tokens stop working
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, arg.SuspendedUntil, arg.SuspensionReason, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const unlockUser = `-- name: UnlockUser :one
+UPDATE users
+SET locked_until = NULL, failed_login_count = 0, failed_login_window_start = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+func (q *Queries) UnlockUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unlockUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_at = NULL, suspended_until = NULL, suspension_reason = '', updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET email = $1,
+    hashed_password = $2,
+    email_verified = email_verified AND email = $1,
+    password_reset_required = FALSE,
+    updated_at = NOW()
+WHERE users.id = $3
+    AND NOT EXISTS (SELECT 1 FROM users AS other WHERE other.email = $1 AND other.id <> $3)
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
 `
 
 type UpdateUserParams struct {
@@ -83,6 +1202,9 @@ type UpdateUserParams struct {
 	ID             uuid.UUID
 }
 
+// Changing the email address clears its verification, and the new password
+// satisfies any forced reset. Returns no rows when another user already has
+// the address.
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
 	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
 	var i User
@@ -93,17 +1215,88 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
 	)
 	return i, err
 }
 
-const upgradeUserToChirpyRed = `-- name: UpgradeUserToChirpyRed :exec
+const updateUserProfile = `-- name: UpdateUserProfile :one
 UPDATE users
-SET is_chirpy_red = TRUE, updated_at = NOW()
-WHERE id = $1
+SET display_name = COALESCE($1, display_name),
+    bio = COALESCE($2, bio),
+    location = COALESCE($3, location),
+    website = COALESCE($4, website),
+    updated_at = NOW()
+WHERE id = $5
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned
 `
 
-func (q *Queries) UpgradeUserToChirpyRed(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, upgradeUserToChirpyRed, id)
-	return err
+type UpdateUserProfileParams struct {
+	DisplayName sql.NullString
+	Bio         sql.NullString
+	Location    sql.NullString
+	Website     sql.NullString
+	ID          uuid.UUID
+}
+
+// Fields left NULL keep their current value
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.Website,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Plan,
+		&i.Recommendations,
+		&i.ShareLocation,
+		&i.Handle,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+		&i.AvatarKey,
+		&i.EmailVerified,
+		&i.Role,
+		&i.TokenVersion,
+		&i.FailedLoginCount,
+		&i.FailedLoginWindowStart,
+		&i.LockedUntil,
+		&i.DeactivatedAt,
+		&i.SuspendedAt,
+		&i.SuspendedUntil,
+		&i.SuspensionReason,
+		&i.PasswordResetRequired,
+		&i.ShadowBanned,
+	)
+	return i, err
 }
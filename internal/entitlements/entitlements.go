@@ -0,0 +1,116 @@
+package entitlements
+
+import (
+	"time"
+)
+
+// Feature names a perk that can be gated per user
+type Feature string
+
+const (
+	// LongChirps allows chirps up to the plan's MaxChirpLength instead of
+	// the base limit
+	LongChirps Feature = "long_chirps"
+	EditChirps Feature = "edit_chirps"
+	Analytics  Feature = "analytics"
+	GiftRed    Feature = "gift_red"
+)
+
+// DefaultMaxChirpLength applies to anyone without the LongChirps feature
+// when no other base limit is configured
+const DefaultMaxChirpLength = 140
+
+// KnownFeatures lists every feature that can be flagged or overridden
+var KnownFeatures = []Feature{LongChirps, EditChirps, Analytics, GiftRed}
+
+// IsKnown reports whether name is a feature the server understands
+func IsKnown(name string) bool {
+	for _, feature := range KnownFeatures {
+		if string(feature) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan carries the limits and features a subscription tier grants
+type Plan struct {
+	ID                 string
+	MaxChirpLength     int
+	RateLimitPerMinute int
+	EditWindow         time.Duration
+	UndoWindow         time.Duration
+	Features           map[Feature]bool
+}
+
+// User is everything needed to decide what a single user may do
+type User struct {
+	Plan Plan
+	// Overrides are admin grants (true) or revocations (false) that take
+	// precedence over the plan
+	Overrides map[Feature]bool
+}
+
+// Resolver answers entitlement questions. Flags are global switches; a
+// feature explicitly flagged off is unavailable to everyone.
+type Resolver struct {
+	Flags map[Feature]bool
+	// BaseMaxChirpLength is the limit without LongChirps; zero means
+	// DefaultMaxChirpLength
+	BaseMaxChirpLength int
+}
+
+// Can reports whether user may use feature. Resolution order is global
+// flag, then admin override, then plan.
+func (r Resolver) Can(user User, feature Feature) bool {
+	if enabled, ok := r.Flags[feature]; ok && !enabled {
+		return false
+	}
+	if enabled, ok := user.Overrides[feature]; ok {
+		return enabled
+	}
+	return user.Plan.Features[feature]
+}
+
+// Withheld reports whether feature has been switched off for user, by a
+// global flag or an admin override, rather than missing from their plan.
+// Upgrading doesn't help a user a feature is withheld from.
+func (r Resolver) Withheld(user User, feature Feature) bool {
+	if enabled, ok := r.Flags[feature]; ok && !enabled {
+		return true
+	}
+	enabled, ok := user.Overrides[feature]
+	return ok && !enabled
+}
+
+// MaxChirpLength returns the longest chirp user may post
+func (r Resolver) MaxChirpLength(user User) int {
+	base := r.BaseMaxChirpLength
+	if base == 0 {
+		base = DefaultMaxChirpLength
+	}
+	if !r.Can(user, LongChirps) || user.Plan.MaxChirpLength < base {
+		return base
+	}
+	return user.Plan.MaxChirpLength
+}
+
+// RateLimitPerMinute returns how many write requests user may make per minute
+func (r Resolver) RateLimitPerMinute(user User) int {
+	return user.Plan.RateLimitPerMinute
+}
+
+// EditWindow returns how long after posting user may edit a chirp, or zero
+// if editing isn't available to them
+func (r Resolver) EditWindow(user User) time.Duration {
+	if !r.Can(user, EditChirps) {
+		return 0
+	}
+	return user.Plan.EditWindow
+}
+
+// UndoWindow returns how long user's new chirps are held back before
+// publishing, during which deleting them cancels the post
+func (r Resolver) UndoWindow(user User) time.Duration {
+	return user.Plan.UndoWindow
+}
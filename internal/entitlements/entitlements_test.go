@@ -0,0 +1,165 @@
+package entitlements
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	freePlan = Plan{
+		ID:                 "free",
+		MaxChirpLength:     140,
+		RateLimitPerMinute: 30,
+		Features:           map[Feature]bool{},
+	}
+	redPlan = Plan{
+		ID:                 "red",
+		MaxChirpLength:     280,
+		RateLimitPerMinute: 60,
+		EditWindow:         5 * time.Minute,
+		UndoWindow:         time.Minute,
+		Features: map[Feature]bool{
+			LongChirps: true,
+			EditChirps: true,
+			Analytics:  true,
+		},
+	}
+)
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[Feature]bool
+		user    User
+		feature Feature
+		want    bool
+	}{
+		{
+			name:    "free plan lacks analytics",
+			user:    User{Plan: freePlan},
+			feature: Analytics,
+			want:    false,
+		},
+		{
+			name:    "red plan has analytics",
+			user:    User{Plan: redPlan},
+			feature: Analytics,
+			want:    true,
+		},
+		{
+			name:    "override grants feature to free user",
+			user:    User{Plan: freePlan, Overrides: map[Feature]bool{Analytics: true}},
+			feature: Analytics,
+			want:    true,
+		},
+		{
+			name:    "override revokes feature from red user",
+			user:    User{Plan: redPlan, Overrides: map[Feature]bool{EditChirps: false}},
+			feature: EditChirps,
+			want:    false,
+		},
+		{
+			name:    "disabled flag beats plan and override",
+			flags:   map[Feature]bool{Analytics: false},
+			user:    User{Plan: redPlan, Overrides: map[Feature]bool{Analytics: true}},
+			feature: Analytics,
+			want:    false,
+		},
+		{
+			name:    "enabled flag does not grant feature",
+			flags:   map[Feature]bool{Analytics: true},
+			user:    User{Plan: freePlan},
+			feature: Analytics,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := Resolver{Flags: tt.flags}
+			if got := resolver.Can(tt.user, tt.feature); got != tt.want {
+				t.Errorf("Expected Can to return %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWithheld(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[Feature]bool
+		user  User
+		want  bool
+	}{
+		{
+			name: "missing from the plan",
+			user: User{Plan: freePlan},
+			want: false,
+		},
+		{
+			name:  "flagged off",
+			flags: map[Feature]bool{EditChirps: false},
+			user:  User{Plan: redPlan},
+			want:  true,
+		},
+		{
+			name: "revoked by an override",
+			user: User{Plan: redPlan, Overrides: map[Feature]bool{EditChirps: false}},
+			want: true,
+		},
+		{
+			name: "granted by an override",
+			user: User{Plan: freePlan, Overrides: map[Feature]bool{EditChirps: true}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := Resolver{Flags: tt.flags}
+			if got := resolver.Withheld(tt.user, EditChirps); got != tt.want {
+				t.Errorf("Expected Withheld to return %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	resolver := Resolver{}
+
+	if got := resolver.MaxChirpLength(User{Plan: freePlan}); got != 140 {
+		t.Errorf("Expected free max chirp length 140, got %d", got)
+	}
+	if got := resolver.MaxChirpLength(User{Plan: redPlan}); got != 280 {
+		t.Errorf("Expected red max chirp length 280, got %d", got)
+	}
+	if got := resolver.EditWindow(User{Plan: freePlan}); got != 0 {
+		t.Errorf("Expected no edit window for free plan, got %v", got)
+	}
+	if got := resolver.EditWindow(User{Plan: redPlan}); got != 5*time.Minute {
+		t.Errorf("Expected 5m edit window for red plan, got %v", got)
+	}
+	if got := resolver.UndoWindow(User{Plan: redPlan}); got != time.Minute {
+		t.Errorf("Expected 1m undo window for red plan, got %v", got)
+	}
+
+	// Revoking long chirps falls back to the default limit
+	revoked := User{Plan: redPlan, Overrides: map[Feature]bool{LongChirps: false}}
+	if got := resolver.MaxChirpLength(revoked); got != DefaultMaxChirpLength {
+		t.Errorf("Expected default max chirp length, got %d", got)
+	}
+
+	// A configured base limit replaces the default, and plans below it
+	// don't shorten it
+	resolver.BaseMaxChirpLength = 200
+	if got := resolver.MaxChirpLength(User{Plan: freePlan}); got != 200 {
+		t.Errorf("Expected base max chirp length 200, got %d", got)
+	}
+	if got := resolver.MaxChirpLength(User{Plan: redPlan}); got != 280 {
+		t.Errorf("Expected red max chirp length 280, got %d", got)
+	}
+	resolver.BaseMaxChirpLength = 300
+	if got := resolver.MaxChirpLength(User{Plan: redPlan}); got != 300 {
+		t.Errorf("Expected base max chirp length 300 for red plan, got %d", got)
+	}
+}
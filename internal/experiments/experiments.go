@@ -0,0 +1,76 @@
+// Package experiments splits users between the variants of A/B
+// experiments. A user's variant is a hash of their ID and the experiment's
+// key, so it never has to be stored, stays the same on every request and
+// is independent of their variant in any other experiment.
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/google/uuid"
+)
+
+// Variant is one arm of an experiment. Weight is its share of users
+// relative to the other variants'.
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Experiment is a test of one or more variants against each other. Changing
+// its variants or weights once it's running moves users between them, so a
+// changed test should get a new key.
+type Experiment struct {
+	Key         string
+	Description string
+	Variants    []Variant
+}
+
+// Assign returns the name of the variant userID is in, or "" when the
+// experiment has no variant with a positive weight
+func (e Experiment) Assign(userID uuid.UUID) string {
+	total := 0
+	for _, variant := range e.Variants {
+		total += max(variant.Weight, 0)
+	}
+	if total == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(e.Key + ":" + userID.String()))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for _, variant := range e.Variants {
+		weight := max(variant.Weight, 0)
+		if bucket < weight {
+			return variant.Name
+		}
+		bucket -= weight
+	}
+	return ""
+}
+
+// Registry is the set of running experiments, looked up by key
+type Registry struct {
+	experiments []Experiment
+}
+
+// NewRegistry holds experiments in the order given
+func NewRegistry(experiments ...Experiment) *Registry {
+	return &Registry{experiments: experiments}
+}
+
+// Get returns the experiment with key
+func (r *Registry) Get(key string) (Experiment, bool) {
+	for _, experiment := range r.experiments {
+		if experiment.Key == key {
+			return experiment, true
+		}
+	}
+	return Experiment{}, false
+}
+
+// All returns every running experiment
+func (r *Registry) All() []Experiment {
+	return r.experiments
+}
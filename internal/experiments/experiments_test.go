@@ -0,0 +1,108 @@
+package experiments
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAssign(t *testing.T) {
+	userID := uuid.MustParse("5c1b2f9e-7a3d-4e8b-9f60-2d4a8c7e1b35")
+	tests := []struct {
+		name       string
+		experiment Experiment
+		want       []string
+	}{
+		{
+			name:       "no variants",
+			experiment: Experiment{Key: "empty"},
+			want:       []string{""},
+		},
+		{
+			name:       "no positive weights",
+			experiment: Experiment{Key: "off", Variants: []Variant{{Name: "a", Weight: 0}, {Name: "b", Weight: -1}}},
+			want:       []string{""},
+		},
+		{
+			name:       "one variant",
+			experiment: Experiment{Key: "only", Variants: []Variant{{Name: "a", Weight: 1}}},
+			want:       []string{"a"},
+		},
+		{
+			name:       "zero weight is never assigned",
+			experiment: Experiment{Key: "skip", Variants: []Variant{{Name: "a", Weight: 0}, {Name: "b", Weight: 3}}},
+			want:       []string{"b"},
+		},
+		{
+			name:       "two variants",
+			experiment: Experiment{Key: "split", Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}},
+			want:       []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.experiment.Assign(userID)
+			found := false
+			for _, want := range tt.want {
+				found = found || got == want
+			}
+			if !found {
+				t.Errorf("Expected one of %q, got %q", tt.want, got)
+			}
+			if again := tt.experiment.Assign(userID); again != got {
+				t.Errorf("Expected the same variant every time, got %q then %q", got, again)
+			}
+		})
+	}
+}
+
+func TestAssignSplitsByWeight(t *testing.T) {
+	experiment := Experiment{Key: "weighted", Variants: []Variant{{Name: "control", Weight: 3}, {Name: "treatment", Weight: 1}}}
+	const users = 20000
+	counts := map[string]int{}
+	for range users {
+		counts[experiment.Assign(uuid.New())]++
+	}
+
+	share := float64(counts["treatment"]) / users
+	if math.Abs(share-0.25) > 0.02 {
+		t.Errorf("Expected about a quarter of users in treatment, got %.3f (%v)", share, counts)
+	}
+}
+
+func TestAssignIsIndependentPerExperiment(t *testing.T) {
+	first := Experiment{Key: "first", Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}}
+	second := Experiment{Key: "second", Variants: []Variant{{Name: "a", Weight: 1}, {Name: "b", Weight: 1}}}
+	const users = 10000
+	same := 0
+	for range users {
+		userID := uuid.New()
+		if first.Assign(userID) == second.Assign(userID) {
+			same++
+		}
+	}
+
+	// Independent 50/50 splits agree for about half of users
+	share := float64(same) / users
+	if math.Abs(share-0.5) > 0.03 {
+		t.Errorf("Expected about half of users in the same variant of both, got %.3f", share)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(
+		Experiment{Key: "first"},
+		Experiment{Key: "second"},
+	)
+	if experiment, ok := registry.Get("second"); !ok || experiment.Key != "second" {
+		t.Errorf("Expected to find second, got %+v (%v)", experiment, ok)
+	}
+	if _, ok := registry.Get("missing"); ok {
+		t.Error("Expected no experiment for an unknown key")
+	}
+	if got := len(registry.All()); got != 2 {
+		t.Errorf("Expected 2 experiments, got %d", got)
+	}
+}
@@ -0,0 +1,120 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Precision is the geohash length stored with chirps, roughly 5m cells
+const Precision = 9
+
+const (
+	base32          = "0123456789bcdefghjkmnpqrstuvwxyz"
+	earthRadiusM    = 6371000.0
+	metersPerDegLat = 111320.0
+)
+
+var ErrInvalidCoordinates = errors.New("latitude must be within ±90 and longitude within ±180")
+
+// Validate reports whether lat and lon are real coordinates
+func Validate(lat, lon float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return ErrInvalidCoordinates
+	}
+	return nil
+}
+
+// Encode returns the geohash of a point with the given number of characters
+func Encode(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for hash.Len() < precision {
+		if even {
+			mid := (lonRange[0] + lonRange[1]) / 2
+			if lon >= mid {
+				ch |= 1 << (4 - bit)
+				lonRange[0] = mid
+			} else {
+				lonRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		even = !even
+		if bit < 4 {
+			bit++
+		} else {
+			hash.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// cellSize returns the height and width in degrees of a geohash cell
+func cellSize(precision int) (latDeg, lonDeg float64) {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(lonBits))
+}
+
+// CoveringCells returns geohash prefixes whose cells together contain every
+// point within radiusMeters of lat/lon: the cell holding the point plus its
+// eight neighbours, at the finest precision whose cells are at least
+// radiusMeters across.
+func CoveringCells(lat, lon, radiusMeters float64) []string {
+	precision := 1
+	for p := Precision; p >= 1; p-- {
+		latDeg, lonDeg := cellSize(p)
+		height := latDeg * metersPerDegLat
+		width := lonDeg * metersPerDegLat * math.Cos(lat*math.Pi/180)
+		if math.Min(height, width) >= radiusMeters {
+			precision = p
+			break
+		}
+	}
+
+	latDeg, lonDeg := cellSize(precision)
+	seen := map[string]bool{}
+	cells := []string{}
+	for _, dLat := range []float64{-1, 0, 1} {
+		for _, dLon := range []float64{-1, 0, 1} {
+			cellLat := math.Max(-90, math.Min(90, lat+dLat*latDeg))
+			cellLon := lon + dLon*lonDeg
+			if cellLon > 180 {
+				cellLon -= 360
+			} else if cellLon < -180 {
+				cellLon += 360
+			}
+			cell := Encode(cellLat, cellLon, precision)
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// Distance returns the great-circle distance in meters between two points
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusM * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,76 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{name: "copenhagen", lat: 57.64911, lon: 10.40744, precision: 11, want: "u4pruydqqvj"},
+		{name: "origin", lat: 0, lon: 0, precision: 5, want: "s0000"},
+		{name: "san francisco", lat: 37.7749, lon: -122.4194, precision: 6, want: "9q8yyk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Encode(tt.lat, tt.lon, tt.precision); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(45, 90); err != nil {
+		t.Errorf("Expected valid coordinates, got %v", err)
+	}
+	if err := Validate(91, 0); err == nil {
+		t.Error("Expected error for latitude 91, got nil")
+	}
+	if err := Validate(0, -181); err == nil {
+		t.Error("Expected error for longitude -181, got nil")
+	}
+	if err := Validate(math.NaN(), 0); err == nil {
+		t.Error("Expected error for NaN latitude, got nil")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// London to Paris is about 344km
+	got := Distance(51.5074, -0.1278, 48.8566, 2.3522)
+	if math.Abs(got-343500) > 2000 {
+		t.Errorf("Expected roughly 343.5km, got %.0fm", got)
+	}
+}
+
+func TestCoveringCellsContainNearbyPoints(t *testing.T) {
+	lat, lon := 37.7749, -122.4194
+	radius := 2000.0
+	cells := CoveringCells(lat, lon, radius)
+
+	// Points on a circle just inside the radius must fall in some cell
+	for bearing := 0.0; bearing < 360; bearing += 30 {
+		rad := bearing * math.Pi / 180
+		pLat := lat + (radius*0.99/metersPerDegLat)*math.Cos(rad)
+		pLon := lon + (radius*0.99/(metersPerDegLat*math.Cos(lat*math.Pi/180)))*math.Sin(rad)
+		hash := Encode(pLat, pLon, Precision)
+
+		covered := false
+		for _, cell := range cells {
+			if strings.HasPrefix(hash, cell) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			t.Errorf("Expected point at bearing %.0f to be covered by %v", bearing, cells)
+		}
+	}
+}
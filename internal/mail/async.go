@@ -0,0 +1,71 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull is returned when an Async sender has no room for a message
+var ErrQueueFull = errors.New("mail queue is full")
+
+// asyncSendTimeout bounds each delivery, since queued messages outlive the
+// request that sent them
+const asyncSendTimeout = 30 * time.Second
+
+// Async queues messages and delivers them through another Sender in the
+// background, so requests don't wait on a mail server. Delivery failures
+// are logged.
+type Async struct {
+	sender Sender
+	queue  chan Message
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewAsync starts workers goroutines delivering through sender, with room
+// for queueSize waiting messages
+func NewAsync(sender Sender, workers, queueSize int) *Async {
+	a := &Async{
+		sender: sender,
+		queue:  make(chan Message, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		a.wg.Add(1)
+		go a.work()
+	}
+	return a
+}
+
+// Send queues msg without blocking. ctx is not used for delivery.
+func (a *Async) Send(ctx context.Context, msg Message) error {
+	select {
+	case a.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits for queued ones to be delivered.
+// Send must not be called after Close.
+func (a *Async) Close() {
+	a.once.Do(func() {
+		close(a.queue)
+	})
+	a.wg.Wait()
+}
+
+func (a *Async) work() {
+	defer a.wg.Done()
+	for msg := range a.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), asyncSendTimeout)
+		err := a.sender.Send(ctx, msg)
+		cancel()
+		if err != nil {
+			log.Printf("Failed to send email %q: %v", msg.Subject, err)
+		}
+	}
+}
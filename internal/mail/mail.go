@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers email
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender returns the sender for kind: "log" (the default) writes emails
+// to the server log, "none" discards them and "smtp" delivers them through
+// the configured server
+func NewSender(kind string, smtpConfig SMTPConfig) (Sender, error) {
+	switch kind {
+	case "", "log":
+		return NewLogSender(nil), nil
+	case "none":
+		return Noop{}, nil
+	case "smtp":
+		return NewSMTP(smtpConfig)
+	default:
+		return nil, fmt.Errorf("unknown mail sender %q (expected log, none or smtp)", kind)
+	}
+}
+
+// LogSender writes messages to a logger instead of delivering them, so
+// links in them can be followed during development
+type LogSender struct {
+	Logger *log.Logger
+}
+
+// NewLogSender returns a LogSender writing to logger, or to the standard
+// logger when logger is nil
+func NewLogSender(logger *log.Logger) *LogSender {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogSender{Logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.Logger.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// Noop discards every message
+type Noop struct{}
+
+func (Noop) Send(ctx context.Context, msg Message) error {
+	return nil
+}
@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewLogSender(log.New(&buf, "", 0))
+
+	err := sender.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "https://chirpy.example/verify?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, want := range []string{"user@example.com", "Verify your email", "token=abc"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected log to contain %q, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		smtp    SMTPConfig
+		wantErr bool
+	}{
+		{name: "default logs", kind: ""},
+		{name: "log", kind: "log"},
+		{name: "none", kind: "none"},
+		{name: "smtp", kind: "smtp", smtp: SMTPConfig{Host: "smtp.example.com", From: "Chirpy <noreply@example.com>"}},
+		{name: "smtp without host", kind: "smtp", smtp: SMTPConfig{From: "noreply@example.com"}, wantErr: true},
+		{name: "smtp with bad from", kind: "smtp", smtp: SMTPConfig{Host: "smtp.example.com", From: "nope"}, wantErr: true},
+		{name: "unknown", kind: "carrier-pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSender(tt.kind, tt.smtp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && sender == nil {
+				t.Error("Expected a sender, got nil")
+			}
+		})
+	}
+}
+
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestAsync(t *testing.T) {
+	recorder := &recordingSender{}
+	async := NewAsync(recorder, 2, 10)
+
+	for i := 0; i < 5; i++ {
+		if err := async.Send(context.Background(), Message{To: "user@example.com"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	async.Close()
+
+	if got := len(recorder.sent); got != 5 {
+		t.Errorf("Expected 5 messages delivered, got %d", got)
+	}
+}
+
+func TestAsyncQueueFull(t *testing.T) {
+	// No workers, so nothing drains the queue
+	async := NewAsync(Noop{}, 0, 1)
+	if err := async.Send(context.Background(), Message{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := async.Send(context.Background(), Message{}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl := MustTemplate("welcome", "Welcome, {{.Name}}", "Hi {{.Name}},\n\nVisit {{.Link}}\n")
+
+	msg, err := tmpl.Render("user@example.com", map[string]string{"Name": "Ada", "Link": "https://example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.To != "user@example.com" {
+		t.Errorf("Expected recipient user@example.com, got %q", msg.To)
+	}
+	if msg.Subject != "Welcome, Ada" {
+		t.Errorf("Expected subject %q, got %q", "Welcome, Ada", msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "https://example.com") {
+		t.Errorf("Expected body to contain link, got %q", msg.Body)
+	}
+
+	// Missing data is an error rather than "<no value>" in someone's inbox
+	if _, err := tmpl.Render("user@example.com", map[string]string{"Name": "Ada"}); err == nil {
+		t.Error("Expected error for missing field, got nil")
+	}
+}
@@ -0,0 +1,119 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	netmail "net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	from := &netmail.Address{Name: "Chirpy", Address: "noreply@example.com"}
+	to := &netmail.Address{Address: "user@example.com"}
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	msg, err := buildMessage(from, to, "Hello", "line one\nline two\n", date)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := string(msg)
+	for _, want := range []string{
+		"From: \"Chirpy\" <noreply@example.com>\r\n",
+		"To: <user@example.com>\r\n",
+		"Subject: Hello\r\n",
+		"Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected message to contain %q, got %q", want, got)
+		}
+	}
+
+	if _, err := buildMessage(from, to, "Hi\r\nBcc: victim@example.com", "", date); err == nil {
+		t.Error("Expected error for multi-line subject, got nil")
+	}
+}
+
+// fakeSMTPServer accepts one message and returns the DATA it received
+func fakeSMTPServer(t *testing.T) (int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch strings.ToUpper(strings.Fields(line)[0]) {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				inData = true
+				reply("354 Go ahead")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPSend(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+
+	sender, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port, From: "noreply@example.com"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = sender.Send(ctx, Message{To: "user@example.com", Subject: "Hello", Body: "Port " + strconv.Itoa(port)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "Subject: Hello") || !strings.Contains(data, "Port "+strconv.Itoa(port)) {
+			t.Errorf("Expected subject and body in data, got %q", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected server to receive a message")
+	}
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// Template renders the subject and body of one kind of email from data
+type Template struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewTemplate parses subject and body as text/template sources
+func NewTemplate(name, subject, body string) (*Template, error) {
+	subjectTmpl, err := template.New(name + "_subject").Option("missingkey=error").Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	bodyTmpl, err := template.New(name + "_body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{subject: subjectTmpl, body: bodyTmpl}, nil
+}
+
+// MustTemplate is NewTemplate for templates known at compile time
+func MustTemplate(name, subject, body string) *Template {
+	t, err := NewTemplate(name, subject, body)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render builds the message to send to to
+func (t *Template) Render(to string, data any) (Message, error) {
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return Message{}, err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: subject.String(), Body: body.String()}, nil
+}
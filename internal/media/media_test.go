@@ -0,0 +1,84 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "avatars/abc.png", want: true},
+		{key: "abc.png", want: true},
+		{key: "", want: false},
+		{key: "/etc/passwd", want: false},
+		{key: "../secret", want: false},
+		{key: "avatars/../../secret", want: false},
+		{key: "avatars//abc.png", want: false},
+		{key: `avatars\abc.png`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := ValidKey(tt.key); got != tt.want {
+				t.Errorf("Expected ValidKey(%q) to be %v, got %v", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+
+	err = store.Put(ctx, "avatars/user.png", "image/png", strings.NewReader("png-bytes"))
+	if err != nil {
+		t.Fatalf("Expected no error storing file, got %v", err)
+	}
+
+	body, contentType, err := store.Get(ctx, "avatars/user.png")
+	if err != nil {
+		t.Fatalf("Expected no error reading file, got %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png-bytes" {
+		t.Errorf("Expected stored bytes back, got %q", data)
+	}
+	if contentType != "image/png" {
+		t.Errorf("Expected image/png, got %q", contentType)
+	}
+
+	err = store.Delete(ctx, "avatars/user.png")
+	if err != nil {
+		t.Fatalf("Expected no error deleting file, got %v", err)
+	}
+	_, _, err = store.Get(ctx, "avatars/user.png")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	// Deleting again is not an error
+	if err := store.Delete(ctx, "avatars/user.png"); err != nil {
+		t.Errorf("Expected no error deleting missing file, got %v", err)
+	}
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+	err = store.Put(context.Background(), "../escape.png", "image/png", strings.NewReader("x"))
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Config describes an S3-compatible bucket (AWS S3, MinIO, R2, ...).
+// Objects are addressed path-style: Endpoint/Bucket/key.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// S3 stores media in an S3-compatible bucket through the AWS SDK
+type S3 struct {
+	bucket string
+	client *s3.Client
+}
+
+// NewS3 creates a store for the configured bucket
+func NewS3(config S3Config) (*S3, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 endpoint, bucket and credentials are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	credentials := aws.Credentials{AccessKeyID: config.AccessKeyID, SecretAccessKey: config.SecretAccessKey}
+	client := s3.New(s3.Options{
+		Region:       config.Region,
+		BaseEndpoint: aws.String(strings.TrimSuffix(config.Endpoint, "/")),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return credentials, nil
+		}),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		// Not every S3-compatible store accepts the checksums the SDK
+		// otherwise adds to each request
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		ResponseChecksumValidation: aws.ResponseChecksumValidationWhenRequired,
+	})
+	return &S3{bucket: config.Bucket, client: client}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, body io.Reader) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	// S3 needs the length up front; media here is small enough to buffer
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(payload),
+		ContentLength: aws.Int64(int64(len(payload))),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err = s.client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("s3 put: %w", err)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	if !ValidKey(key) {
+		return nil, "", ErrInvalidKey
+	}
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return nil, "", ErrNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("s3 get: %w", err)
+	}
+	return output.Body, aws.ToString(output.ContentType), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("s3 delete: %w", err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 from the store, which not every
+// S3-compatible one sends with a NoSuchKey body
+func isNotFound(err error) bool {
+	var responseErr *awshttp.ResponseError
+	return errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,74 @@
+package media
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestS3RoundTrip(t *testing.T) {
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			t.Errorf("Expected a request signed with the configured key, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+		case http.MethodGet:
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(body))
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "chirpy",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating store, got %v", err)
+	}
+	ctx := context.Background()
+
+	err = store.Put(ctx, "avatars/user.png", "image/png", strings.NewReader("png-bytes"))
+	if err != nil {
+		t.Fatalf("Expected no error storing object, got %v", err)
+	}
+	if _, ok := objects["/chirpy/avatars/user.png"]; !ok {
+		t.Errorf("Expected object at /chirpy/avatars/user.png, got %v", objects)
+	}
+
+	body, contentType, err := store.Get(ctx, "avatars/user.png")
+	if err != nil {
+		t.Fatalf("Expected no error reading object, got %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png-bytes" || contentType != "image/png" {
+		t.Errorf("Expected png-bytes as image/png, got %q as %q", data, contentType)
+	}
+
+	err = store.Delete(ctx, "avatars/user.png")
+	if err != nil {
+		t.Fatalf("Expected no error deleting object, got %v", err)
+	}
+	_, _, err = store.Get(ctx, "avatars/user.png")
+	if err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
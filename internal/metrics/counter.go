@@ -0,0 +1,45 @@
+package metrics
+
+import "sync/atomic"
+
+// Counter is a running total whose increments are buffered so they can be
+// persisted in batches, and which can be seeded with the total persisted
+// before a restart
+type Counter struct {
+	total   atomic.Int64
+	pending atomic.Int64
+}
+
+// Add counts n more
+func (c *Counter) Add(n int64) {
+	c.total.Add(n)
+	c.pending.Add(n)
+}
+
+// Load returns the total, including what was seeded
+func (c *Counter) Load() int64 {
+	return c.total.Load()
+}
+
+// Seed adds an already persisted count to the total without marking it
+// pending
+func (c *Counter) Seed(n int64) {
+	c.total.Add(n)
+}
+
+// Drain returns the count added since the last drain and resets it
+func (c *Counter) Drain() int64 {
+	return c.pending.Swap(0)
+}
+
+// Restore adds a drained count back, for when persisting it failed and it
+// should be retried with the next batch
+func (c *Counter) Restore(n int64) {
+	c.pending.Add(n)
+}
+
+// Reset zeroes the total and drops anything pending
+func (c *Counter) Reset() {
+	c.total.Store(0)
+	c.pending.Store(0)
+}
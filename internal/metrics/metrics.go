@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is how many recent latencies each route keeps for its
+// percentiles, bounding memory regardless of traffic
+const latencySamples = 1024
+
+// Registry collects request counts, error counts and latencies per route
+type Registry struct {
+	mu     sync.Mutex
+	routes map[string]*route
+}
+
+type route struct {
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+	// latencies is a ring buffer of the most recent samples
+	latencies []time.Duration
+	next      int
+}
+
+// RouteStats summarizes one route. Percentiles cover its most recent
+// requests rather than its whole history.
+type RouteStats struct {
+	Route        string        `json:"route"`
+	Requests     int64         `json:"requests"`
+	ClientErrors int64         `json:"client_errors"`
+	ServerErrors int64         `json:"server_errors"`
+	P50          time.Duration `json:"-"`
+	P95          time.Duration `json:"-"`
+	P50Millis    float64       `json:"p50_ms"`
+	P95Millis    float64       `json:"p95_ms"`
+}
+
+// New creates an empty registry
+func New() *Registry {
+	return &Registry{routes: map[string]*route{}}
+}
+
+// Observe records one request to routeName that finished with status after
+// taking elapsed
+func (m *Registry) Observe(routeName string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rt, ok := m.routes[routeName]
+	if !ok {
+		rt = &route{}
+		m.routes[routeName] = rt
+	}
+	rt.requests++
+	switch {
+	case status >= 500:
+		rt.serverErrors++
+	case status >= 400:
+		rt.clientErrors++
+	}
+
+	if len(rt.latencies) < latencySamples {
+		rt.latencies = append(rt.latencies, elapsed)
+	} else {
+		rt.latencies[rt.next] = elapsed
+		rt.next = (rt.next + 1) % latencySamples
+	}
+}
+
+// Snapshot returns the stats for every route seen, ordered by route
+func (m *Registry) Snapshot() []RouteStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(m.routes))
+	for name, rt := range m.routes {
+		sorted := append([]time.Duration(nil), rt.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		p50 := percentile(sorted, 50)
+		p95 := percentile(sorted, 95)
+		stats = append(stats, RouteStats{
+			Route:        name,
+			Requests:     rt.requests,
+			ClientErrors: rt.clientErrors,
+			ServerErrors: rt.serverErrors,
+			P50:          p50,
+			P95:          p95,
+			P50Millis:    float64(p50) / float64(time.Millisecond),
+			P95Millis:    float64(p95) / float64(time.Millisecond),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// percentile picks the nearest-rank p-th percentile of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := New()
+	for i := 1; i <= 100; i++ {
+		registry.Observe("GET /api/chirps", 200, time.Duration(i)*time.Millisecond)
+	}
+	registry.Observe("POST /api/chirps", 400, time.Millisecond)
+	registry.Observe("POST /api/chirps", 500, time.Millisecond)
+
+	stats := registry.Snapshot()
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(stats))
+	}
+
+	get := stats[0]
+	if get.Route != "GET /api/chirps" {
+		t.Errorf("Expected routes in order, got %s first", get.Route)
+	}
+	if get.Requests != 100 || get.ClientErrors != 0 || get.ServerErrors != 0 {
+		t.Errorf("Expected 100 requests and no errors, got %+v", get)
+	}
+	if get.P50 != 50*time.Millisecond {
+		t.Errorf("Expected p50 of 50ms, got %v", get.P50)
+	}
+	if get.P95 != 95*time.Millisecond {
+		t.Errorf("Expected p95 of 95ms, got %v", get.P95)
+	}
+	if get.P95Millis != 95 {
+		t.Errorf("Expected p95_ms of 95, got %v", get.P95Millis)
+	}
+
+	post := stats[1]
+	if post.Requests != 2 || post.ClientErrors != 1 || post.ServerErrors != 1 {
+		t.Errorf("Expected one client and one server error, got %+v", post)
+	}
+}
+
+func TestLatencySamplesAreBounded(t *testing.T) {
+	registry := New()
+	// Old slow requests age out of the percentiles
+	for i := 0; i < latencySamples; i++ {
+		registry.Observe("GET /api/healthz", 200, time.Second)
+	}
+	for i := 0; i < latencySamples; i++ {
+		registry.Observe("GET /api/healthz", 200, time.Millisecond)
+	}
+
+	stats := registry.Snapshot()[0]
+	if stats.Requests != 2*latencySamples {
+		t.Errorf("Expected %d requests, got %d", 2*latencySamples, stats.Requests)
+	}
+	if stats.P95 != time.Millisecond {
+		t.Errorf("Expected p95 of 1ms after old samples aged out, got %v", stats.P95)
+	}
+	if got := len(registry.routes["GET /api/healthz"].latencies); got != latencySamples {
+		t.Errorf("Expected %d samples kept, got %d", latencySamples, got)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	var counter Counter
+	counter.Seed(40)
+	counter.Add(1)
+	counter.Add(1)
+
+	if got := counter.Load(); got != 42 {
+		t.Errorf("Expected total of 42, got %d", got)
+	}
+	if got := counter.Drain(); got != 2 {
+		t.Errorf("Expected 2 pending, the seed excluded, got %d", got)
+	}
+	if got := counter.Drain(); got != 0 {
+		t.Errorf("Expected nothing pending after a drain, got %d", got)
+	}
+
+	// A failed flush puts its count back for the next one
+	counter.Add(1)
+	counter.Restore(counter.Drain())
+	if got := counter.Drain(); got != 1 {
+		t.Errorf("Expected restored count of 1, got %d", got)
+	}
+	if got := counter.Load(); got != 43 {
+		t.Errorf("Expected total of 43, got %d", got)
+	}
+
+	counter.Add(1)
+	counter.Reset()
+	if counter.Load() != 0 || counter.Drain() != 0 {
+		t.Errorf("Expected reset to zero the total and pending count")
+	}
+}
@@ -0,0 +1,140 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestAuthCodeURL(t *testing.T) {
+	p := GitHub("client-id", "secret")
+	authURL, err := url.Parse(p.AuthCodeURL("state-123", "https://chirpy.example/api/oauth/github/callback"))
+	if err != nil {
+		t.Fatalf("Expected valid URL, got %v", err)
+	}
+
+	query := authURL.Query()
+	want := map[string]string{
+		"response_type": "code",
+		"client_id":     "client-id",
+		"redirect_uri":  "https://chirpy.example/api/oauth/github/callback",
+		"scope":         "read:user user:email",
+		"state":         "state-123",
+	}
+	for key, value := range want {
+		if got := query.Get(key); got != value {
+			t.Errorf("Expected %s=%q, got %q", key, value, got)
+		}
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "google"},
+		{name: "github"},
+		{name: "myspace", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.name, "id", "secret")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && p.Name != tt.name {
+				t.Errorf("Expected provider %q, got %q", tt.name, p.Name)
+			}
+		})
+	}
+}
+
+func TestGoogleFlow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			r.ParseForm()
+			if r.Form.Get("code") != "the-code" || r.Form.Get("client_secret") != "secret" {
+				w.WriteHeader(400)
+				return
+			}
+			w.Write([]byte(`{"access_token": "the-token"}`))
+		case "/userinfo":
+			if r.Header.Get("Authorization") != "Bearer the-token" {
+				w.WriteHeader(401)
+				return
+			}
+			w.Write([]byte(`{"sub": "1234", "email": "user@example.com", "email_verified": true}`))
+		}
+	}))
+	defer server.Close()
+
+	p := Google("id", "secret")
+	p.TokenURL = server.URL + "/token"
+	p.UserURL = server.URL + "/userinfo"
+
+	token, err := p.Exchange(context.Background(), "the-code", "https://chirpy.example/callback")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	identity, err := p.Identity(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if identity != (Identity{Subject: "1234", Email: "user@example.com", EmailVerified: true}) {
+		t.Errorf("Unexpected identity %+v", identity)
+	}
+
+	if _, err := p.Exchange(context.Background(), "wrong-code", "https://chirpy.example/callback"); err == nil {
+		t.Error("Expected error for rejected code, got nil")
+	}
+}
+
+func TestGitHubIdentity(t *testing.T) {
+	tests := []struct {
+		name   string
+		emails string
+		want   Identity
+	}{
+		{
+			name:   "uses primary email",
+			emails: `[{"email": "old@example.com", "primary": false, "verified": true}, {"email": "main@example.com", "primary": true, "verified": true}]`,
+			want:   Identity{Subject: "42", Email: "main@example.com", EmailVerified: true},
+		},
+		{
+			name:   "unverified primary",
+			emails: `[{"email": "main@example.com", "primary": true, "verified": false}]`,
+			want:   Identity{Subject: "42", Email: "main@example.com", EmailVerified: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					w.Write([]byte(`{"id": 42, "login": "octocat"}`))
+				case "/user/emails":
+					w.Write([]byte(tt.emails))
+				}
+			}))
+			defer server.Close()
+
+			p := GitHub("id", "secret")
+			p.UserURL = server.URL + "/user"
+			p.EmailsURL = server.URL + "/user/emails"
+
+			identity, err := p.Identity(context.Background(), "token")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if identity != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type timestamps struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type author struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type post struct {
+	timestamps
+	Body     string           `json:"body"`
+	Tags     []string         `json:"tags,omitempty"`
+	Author   *author          `json:"author"`
+	Counts   map[string]int32 `json:"counts"`
+	Extra    json.RawMessage  `json:"extra"`
+	Replies  []post           `json:"replies"`
+	Internal string           `json:"-"`
+	Untagged bool
+	hidden   string
+}
+
+func TestSchemaOf(t *testing.T) {
+	doc := New("test", "1")
+
+	if got := doc.SchemaOf(post{}).Ref; got != "#/components/schemas/Post" {
+		t.Fatalf("Expected a reference to Post, got %q", got)
+	}
+	schema := doc.Components.Schemas["Post"]
+
+	tests := []struct {
+		property string
+		want     Schema
+	}{
+		{"created_at", Schema{Type: "string", Format: "date-time"}},
+		{"body", Schema{Type: "string"}},
+		{"tags", Schema{Type: "array", Items: &Schema{Type: "string"}}},
+		{"author", Schema{AllOf: []*Schema{{Ref: "#/components/schemas/Author"}}, Nullable: true}},
+		{"counts", Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}}},
+		{"extra", Schema{}},
+		{"replies", Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/Post"}}},
+		{"Untagged", Schema{Type: "boolean"}},
+	}
+	for _, tt := range tests {
+		got, ok := schema.Properties[tt.property]
+		if !ok {
+			t.Errorf("Expected property %s", tt.property)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Expected %s to be %+v, got %+v", tt.property, tt.want, *got)
+		}
+	}
+	if len(schema.Properties) != len(tests) {
+		t.Errorf("Expected %d properties, got %d", len(tests), len(schema.Properties))
+	}
+
+	wantRequired := []string{"created_at", "body", "author", "counts", "extra", "replies", "Untagged"}
+	if !reflect.DeepEqual(schema.Required, wantRequired) {
+		t.Errorf("Expected required %v, got %v", wantRequired, schema.Required)
+	}
+
+	id := doc.Components.Schemas["Author"].Properties["id"]
+	if id.Type != "string" || id.Format != "uuid" {
+		t.Errorf("Expected a uuid string, got %+v", *id)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	doc := New("test", "1")
+	type errorResponse struct {
+		Error string `json:"error"`
+	}
+
+	if got := doc.Define("Error", errorResponse{}).Ref; got != "#/components/schemas/Error" {
+		t.Errorf("Expected a reference to Error, got %q", got)
+	}
+	// Later uses of the type refer to the given name
+	if got := doc.SchemaOf([]errorResponse{}).Items.Ref; got != "#/components/schemas/Error" {
+		t.Errorf("Expected items to reference Error, got %q", got)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	doc := New("test", "1")
+	doc.Add("GET", "/chirps", &Operation{Summary: "List"})
+	doc.Add("POST", "/chirps", &Operation{Summary: "Create"})
+
+	item := *doc.Paths["/chirps"]
+	if item["get"].Summary != "List" || item["post"].Summary != "Create" {
+		t.Errorf("Expected both operations on /chirps, got %v", item)
+	}
+}
@@ -0,0 +1,81 @@
+package password
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+)
+
+// Bloom is a bloom filter of breached passwords. It answers "definitely not
+// breached" or "probably breached", so a list of millions of passwords fits
+// in a few megabytes.
+type Bloom struct {
+	bits   []uint64
+	m      uint64
+	hashes int
+}
+
+// NewBloom sizes a filter for n passwords with a false positive rate of
+// about falsePositiveRate
+func NewBloom(n int, falsePositiveRate float64) *Bloom {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	hashes := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return &Bloom{
+		bits:   make([]uint64, (m+63)/64),
+		m:      m,
+		hashes: max(hashes, 1),
+	}
+}
+
+// ReadBloom builds a filter from a list of passwords, one per line, sized
+// for the number of lines
+func ReadBloom(r io.Reader, falsePositiveRate float64) (*Bloom, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := NewBloom(bytes.Count(data, []byte("\n"))+1, falsePositiveRate)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line != "" {
+			filter.Add(line)
+		}
+	}
+	return filter, nil
+}
+
+// Add records password as breached
+func (b *Bloom) Add(password string) {
+	h1, h2 := bloomHashes(password)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains reports whether password is probably in the filter
+func (b *Bloom) Contains(password string) bool {
+	h1, h2 := bloomHashes(password)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes derives the two hashes combined into each probe position
+func bloomHashes(password string) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(password))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	// An odd step visits distinct positions even when m is even
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	return h1, h2
+}
@@ -0,0 +1,48 @@
+package password
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMinLength is the minimum password length when none is configured
+const DefaultMinLength = 8
+
+// Violation codes
+const (
+	CodeTooShort = "too_short"
+	CodeBreached = "breached"
+)
+
+// Violation is one way a password fails the policy
+type Violation struct {
+	Code    string
+	Message string
+}
+
+// Policy decides which passwords are acceptable for new or changed
+// credentials
+type Policy struct {
+	// MinLength is counted in characters, not bytes
+	MinLength int
+	// Breached, when set, rejects passwords known from breaches
+	Breached *Bloom
+}
+
+// Check returns every way candidate fails the policy, or nil if it passes
+func (p Policy) Check(candidate string) []Violation {
+	var violations []Violation
+	if utf8.RuneCountInString(candidate) < p.MinLength {
+		violations = append(violations, Violation{
+			Code:    CodeTooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters", p.MinLength),
+		})
+	}
+	if p.Breached != nil && p.Breached.Contains(candidate) {
+		violations = append(violations, Violation{
+			Code:    CodeBreached,
+			Message: "Password has appeared in a data breach, please choose another",
+		})
+	}
+	return violations
+}
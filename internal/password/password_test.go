@@ -0,0 +1,66 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	breached, err := ReadBloom(strings.NewReader("password123\r\nletmein!\n\nqwertyuiop\n"), 0.001)
+	if err != nil {
+		t.Fatalf("Expected no error reading list, got %v", err)
+	}
+	policy := Policy{MinLength: 8, Breached: breached}
+
+	tests := []struct {
+		name      string
+		candidate string
+		wantCodes []string
+	}{
+		{name: "Acceptable", candidate: "correct horse battery", wantCodes: nil},
+		{name: "Too short", candidate: "abc12", wantCodes: []string{CodeTooShort}},
+		{name: "Length counts characters", candidate: "пароль12", wantCodes: nil},
+		{name: "Breached", candidate: "qwertyuiop", wantCodes: []string{CodeBreached}},
+		{name: "Carriage returns are stripped", candidate: "password123", wantCodes: []string{CodeBreached}},
+		{name: "Not in the list", candidate: "letmein!!", wantCodes: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := policy.Check(tt.candidate)
+			var codes []string
+			for _, v := range violations {
+				codes = append(codes, v.Code)
+			}
+			if fmt.Sprint(codes) != fmt.Sprint(tt.wantCodes) {
+				t.Errorf("Expected %v, got %v", tt.wantCodes, codes)
+			}
+		})
+	}
+}
+
+func TestBloom(t *testing.T) {
+	filter := NewBloom(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.Add(fmt.Sprintf("breached-%d", i))
+	}
+
+	// No false negatives
+	for i := 0; i < 1000; i++ {
+		if !filter.Contains(fmt.Sprintf("breached-%d", i)) {
+			t.Fatalf("Expected breached-%d to be in the filter", i)
+		}
+	}
+
+	// False positives stay near the configured rate
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.Contains(fmt.Sprintf("fresh-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 300 {
+		t.Errorf("Expected about 1%% false positives, got %d in 10000", falsePositives)
+	}
+}
@@ -0,0 +1,113 @@
+package profanity
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultWords is the list used until one is loaded
+var DefaultWords = []string{"kerfuffle", "sharbert", "fornax"}
+
+// Mask replaces each banned word
+const Mask = "****"
+
+// Filter masks banned words in text. The word list can be swapped while
+// the filter is in use.
+type Filter struct {
+	mu    sync.RWMutex
+	words map[string]bool
+}
+
+// New creates a filter banning words
+func New(words ...string) *Filter {
+	f := &Filter{}
+	f.Set(words)
+	return f
+}
+
+// NormalizeWord lowercases and trims a word for the list, and reports
+// whether it is a single word of letters and digits, the only thing Clean
+// can match
+func NormalizeWord(raw string) (string, bool) {
+	word := strings.ToLower(strings.TrimSpace(raw))
+	if word == "" || strings.IndexFunc(word, func(r rune) bool { return !isWordRune(r) }) >= 0 {
+		return word, false
+	}
+	return word, true
+}
+
+// isWordRune reports whether r can be part of a word; anything else, such
+// as whitespace or punctuation, separates words
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Set replaces the banned words. Words that aren't valid are skipped.
+func (f *Filter) Set(words []string) {
+	set := make(map[string]bool, len(words))
+	for _, raw := range words {
+		if word, ok := NormalizeWord(raw); ok {
+			set[word] = true
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.words = set
+}
+
+// Words returns the banned words in alphabetical order
+func (f *Filter) Words() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	words := make([]string, 0, len(f.words))
+	for word := range f.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Clean replaces every banned word in text with Mask, matching whole words
+// regardless of case. Punctuation, spacing and the casing of other words
+// are left exactly as they were.
+func (f *Filter) Clean(text string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var cleaned strings.Builder
+	cleaned.Grow(len(text))
+	wordStart := -1
+	endWord := func(end int) {
+		word := text[wordStart:end]
+		if f.words[strings.ToLower(word)] {
+			word = Mask
+		}
+		cleaned.WriteString(word)
+		wordStart = -1
+	}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if isWordRune(r) {
+			if wordStart < 0 {
+				wordStart = i
+			}
+		} else {
+			if wordStart >= 0 {
+				endWord(i)
+			}
+			// Copied byte for byte so invalid UTF-8 survives untouched
+			cleaned.WriteString(text[i : i+size])
+		}
+		i += size
+	}
+	if wordStart >= 0 {
+		endWord(len(text))
+	}
+	return cleaned.String()
+}
@@ -0,0 +1,54 @@
+package profanity
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterSet(t *testing.T) {
+	filter := New(DefaultWords...)
+	if got := filter.Clean("what a Kerfuffle today"); got != "what a **** today" {
+		t.Errorf("Expected default words masked, got %q", got)
+	}
+
+	// Replacing the list normalizes words and drops invalid ones
+	filter.Set([]string{" Bogus ", "", "two words", "f-word", "bogus"})
+	if got := fmt.Sprint(filter.Words()); got != "[bogus]" {
+		t.Errorf("Expected [bogus], got %s", got)
+	}
+	if got := filter.Clean("kerfuffle is bogus"); got != "kerfuffle is ****" {
+		t.Errorf("Expected only the new list masked, got %q", got)
+	}
+}
+
+func TestFilterClean(t *testing.T) {
+	filter := New(DefaultWords...)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "Clean text", text: "I had something interesting for breakfast", want: "I had something interesting for breakfast"},
+		{name: "Lowercase", text: "what a kerfuffle", want: "what a ****"},
+		{name: "Mixed case", text: "I hear Mastodon is better than Chirpy. sharbert I need to migrate", want: "I hear Mastodon is better than Chirpy. **** I need to migrate"},
+		{name: "Uppercase", text: "FORNAX!", want: "****!"},
+		{name: "Trailing punctuation", text: "sharbert! what a Kerfuffle, really", want: "****! what a ****, really"},
+		{name: "Leading punctuation", text: "(fornax) \"kerfuffle\"", want: "(****) \"****\""},
+		{name: "Repeated whitespace", text: "so  much\tkerfuffle\n\nsharbert", want: "so  much\t****\n\n****"},
+		{name: "Joined by punctuation", text: "kerfuffle-sharbert/fornax", want: "****-****/****"},
+		{name: "Part of a longer word", text: "kerfuffles and sharberts", want: "kerfuffles and sharberts"},
+		{name: "Untouched words keep their case", text: "HeLLo Kerfuffle WORLD", want: "HeLLo **** WORLD"},
+		{name: "Non-ASCII neighbours", text: "¡Kerfuffle! café", want: "¡****! café"},
+		{name: "Invalid UTF-8 is kept", text: "fornax\xff", want: "****\xff"},
+		{name: "Empty", text: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Clean(tt.text); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package ranking orders the chirps in a user's For You feed. The feed
+// gathers candidates from the accounts the user follows and recommends
+// others found through their follows and followed hashtags; a FeedRanker
+// decides how the two are blended.
+package ranking
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Source is where a candidate chirp was found
+type Source int
+
+const (
+	// Following is a chirp by an account the user follows
+	Following Source = iota
+	// Network is a chirp by an account followed by one the user follows
+	Network
+	// Hashtag is a chirp tagged with a hashtag the user follows
+	Hashtag
+)
+
+// Candidate is a chirp that could go in the feed, with the signals it's
+// ranked on
+type Candidate struct {
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+	Likes     int
+	Replies   int
+	Source    Source
+}
+
+// FeedRanker orders a user's candidate chirps, best first. It may leave
+// some out, and a chirp found through more than one source appears once.
+type FeedRanker interface {
+	Rank(candidates []Candidate, now time.Time) []Candidate
+}
+
+// Blend scores each candidate by its likes and replies, decaying with its
+// age and scaled by the weight of its source, and orders them by score
+type Blend struct {
+	// HalfLife is the age at which a chirp's score has halved; zero turns
+	// decay off
+	HalfLife time.Duration
+	// Weights scales the scores from each source. Candidates from a source
+	// without a positive weight are left out.
+	Weights map[Source]float64
+	// MaxPerAuthor caps how many recommended chirps one author gets; zero
+	// means no cap. Chirps from accounts the user follows aren't capped.
+	MaxPerAuthor int
+}
+
+// Default is the Blend the For You feed uses. Recommendations need more
+// engagement than chirps from followed accounts to rank alongside them.
+var Default = Blend{
+	HalfLife:     12 * time.Hour,
+	Weights:      map[Source]float64{Following: 1, Network: 0.5, Hashtag: 0.7},
+	MaxPerAuthor: 2,
+}
+
+// Fresh is Default with a shorter half-life, so newer chirps outrank ones
+// that have gathered more engagement
+var Fresh = Blend{
+	HalfLife:     4 * time.Hour,
+	Weights:      Default.Weights,
+	MaxPerAuthor: Default.MaxPerAuthor,
+}
+
+// Score is candidate's score at now
+func (b Blend) Score(candidate Candidate, now time.Time) float64 {
+	engagement := 1 + math.Log1p(float64(max(candidate.Likes, 0)+max(candidate.Replies, 0)))
+	score := b.Weights[candidate.Source] * engagement
+	if b.HalfLife > 0 {
+		age := max(now.Sub(candidate.CreatedAt), 0)
+		score *= math.Exp2(-float64(age) / float64(b.HalfLife))
+	}
+	return score
+}
+
+// Rank orders candidates by score, keeping each chirp under the source it
+// scores best from. Ties go to the newer chirp.
+func (b Blend) Rank(candidates []Candidate, now time.Time) []Candidate {
+	type scored struct {
+		Candidate
+		score float64
+	}
+	best := map[uuid.UUID]scored{}
+	for _, candidate := range candidates {
+		if b.Weights[candidate.Source] <= 0 {
+			continue
+		}
+		s := scored{Candidate: candidate, score: b.Score(candidate, now)}
+		if previous, ok := best[candidate.ChirpID]; !ok || s.score > previous.score {
+			best[candidate.ChirpID] = s
+		}
+	}
+
+	all := make([]scored, 0, len(best))
+	for _, s := range best {
+		all = append(all, s)
+	}
+	slices.SortFunc(all, func(a, b scored) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChirpID.String(), b.ChirpID.String())
+	})
+
+	ranked := make([]Candidate, 0, len(all))
+	perAuthor := map[uuid.UUID]int{}
+	for _, s := range all {
+		if s.Source != Following && b.MaxPerAuthor > 0 {
+			if perAuthor[s.AuthorID] >= b.MaxPerAuthor {
+				continue
+			}
+			perAuthor[s.AuthorID]++
+		}
+		ranked = append(ranked, s.Candidate)
+	}
+	return ranked
+}
@@ -0,0 +1,103 @@
+package ranking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var now = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+func ids(candidates []Candidate) []uuid.UUID {
+	ranked := []uuid.UUID{}
+	for _, candidate := range candidates {
+		ranked = append(ranked, candidate.ChirpID)
+	}
+	return ranked
+}
+
+func TestScore(t *testing.T) {
+	blend := Blend{HalfLife: time.Hour, Weights: map[Source]float64{Following: 1, Network: 0.5}}
+	fresh := Candidate{CreatedAt: now, Source: Following}
+	if got := blend.Score(fresh, now); got != 1 {
+		t.Errorf("Expected a new chirp without engagement to score 1, got %v", got)
+	}
+	old := Candidate{CreatedAt: now.Add(-time.Hour), Source: Following}
+	if got := blend.Score(old, now); got != 0.5 {
+		t.Errorf("Expected a chirp one half-life old to score 0.5, got %v", got)
+	}
+	network := Candidate{CreatedAt: now, Source: Network}
+	if got := blend.Score(network, now); got != 0.5 {
+		t.Errorf("Expected the source's weight to scale the score, got %v", got)
+	}
+	liked := Candidate{CreatedAt: now, Likes: 10, Source: Following}
+	if blend.Score(liked, now) <= blend.Score(fresh, now) {
+		t.Error("Expected likes to raise the score")
+	}
+	future := Candidate{CreatedAt: now.Add(time.Hour), Source: Following}
+	if got := blend.Score(future, now); got != 1 {
+		t.Errorf("Expected no boost for a chirp from the future, got %v", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	newest := Candidate{ChirpID: uuid.New(), AuthorID: alice, CreatedAt: now, Source: Following}
+	older := Candidate{ChirpID: uuid.New(), AuthorID: alice, CreatedAt: now.Add(-6 * time.Hour), Source: Following}
+	popular := Candidate{ChirpID: uuid.New(), AuthorID: bob, CreatedAt: now.Add(-time.Hour), Likes: 50, Source: Network}
+	unliked := Candidate{ChirpID: uuid.New(), AuthorID: bob, CreatedAt: now.Add(-time.Hour), Source: Network}
+
+	ranked := ids(Default.Rank([]Candidate{older, unliked, newest, popular}, now))
+	want := []uuid.UUID{popular.ChirpID, newest.ChirpID, older.ChirpID, unliked.ChirpID}
+	if len(ranked) != len(want) {
+		t.Fatalf("Expected %d chirps, got %d", len(want), len(ranked))
+	}
+	for i := range want {
+		if ranked[i] != want[i] {
+			t.Errorf("Expected %v at %d, got %v", want[i], i, ranked[i])
+		}
+	}
+}
+
+func TestRankKeepsEachChirpOnce(t *testing.T) {
+	id := uuid.New()
+	ranked := Default.Rank([]Candidate{
+		{ChirpID: id, CreatedAt: now, Source: Network},
+		{ChirpID: id, CreatedAt: now, Source: Following},
+		{ChirpID: id, CreatedAt: now, Source: Hashtag},
+	}, now)
+	if len(ranked) != 1 || ranked[0].Source != Following {
+		t.Errorf("Expected the chirp once under its best source, got %+v", ranked)
+	}
+}
+
+func TestRankCapsRecommendationsPerAuthor(t *testing.T) {
+	prolific := uuid.New()
+	candidates := []Candidate{}
+	for i := range 5 {
+		candidates = append(candidates,
+			Candidate{ChirpID: uuid.New(), AuthorID: prolific, CreatedAt: now.Add(-time.Duration(i) * time.Minute), Source: Hashtag},
+			Candidate{ChirpID: uuid.New(), AuthorID: prolific, CreatedAt: now.Add(-time.Duration(i) * time.Minute), Source: Following},
+		)
+	}
+
+	counts := map[Source]int{}
+	for _, candidate := range Default.Rank(candidates, now) {
+		counts[candidate.Source]++
+	}
+	if counts[Hashtag] != Default.MaxPerAuthor || counts[Following] != 5 {
+		t.Errorf("Expected %d recommendations and every followed chirp, got %v", Default.MaxPerAuthor, counts)
+	}
+}
+
+func TestRankLeavesOutUnweightedSources(t *testing.T) {
+	blend := Blend{Weights: map[Source]float64{Following: 1}}
+	ranked := blend.Rank([]Candidate{
+		{ChirpID: uuid.New(), CreatedAt: now, Source: Following},
+		{ChirpID: uuid.New(), CreatedAt: now, Source: Network},
+	}, now)
+	if len(ranked) != 1 || ranked[0].Source != Following {
+		t.Errorf("Expected only the followed chirp, got %+v", ranked)
+	}
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token-bucket limiter: each key holds up to limit tokens,
+// refilled evenly over each period, and every request takes one. Unlike
+// Limiter it allows short bursts without letting a key use its whole
+// allowance again the moment a window rolls over.
+type Bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	// perSecond is the refill rate in tokens per second
+	perSecond float64
+	now       func() time.Time
+	buckets   map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewBucket creates a token-bucket limiter holding limit tokens per key and
+// refilling limit tokens every period of length per
+func NewBucket(limit int, per time.Duration) *Bucket {
+	return &Bucket{
+		capacity:  float64(limit),
+		perSecond: float64(limit) / per.Seconds(),
+		now:       time.Now,
+		buckets:   map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from key's bucket if there is one
+func (l *Bucket) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.updated).Seconds()*l.perSecond)
+	b.updated = now
+
+	result := Result{Limit: int(l.capacity)}
+	if b.tokens < 1 {
+		result.RetryAfter = l.refillTime(1 - b.tokens)
+	} else {
+		b.tokens--
+		result.Allowed = true
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = now.Add(l.refillTime(l.capacity - b.tokens))
+	return result
+}
+
+// Prune forgets keys whose bucket has refilled, since a new bucket starts
+// full anyway
+func (l *Bucket) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.perSecond >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// refillTime is how long the bucket takes to gain tokens
+func (l *Bucket) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / l.perSecond * float64(time.Second))
+}
+
+// Buckets keeps a Bucket per limit, for limits that differ from key to key,
+// like ones set by a user's plan. Every limit refills over the same period,
+// and a key that moves to another limit starts with a full bucket there.
+type Buckets struct {
+	mu      sync.Mutex
+	per     time.Duration
+	now     func() time.Time
+	buckets map[int]*Bucket
+}
+
+// NewBuckets creates a set of buckets that each refill their limit every
+// period of length per
+func NewBuckets(per time.Duration) *Buckets {
+	return &Buckets{per: per, now: time.Now, buckets: map[int]*Bucket{}}
+}
+
+// Allow takes a token from key's bucket for limit if there is one
+func (l *Buckets) Allow(key string, limit int) Result {
+	l.mu.Lock()
+	b, ok := l.buckets[limit]
+	if !ok {
+		b = NewBucket(limit, l.per)
+		b.now = l.now
+		l.buckets[limit] = b
+	}
+	l.mu.Unlock()
+	return b.Allow(key)
+}
+
+// Prune prunes every bucket, as Bucket.Prune does
+func (l *Buckets) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, b := range l.buckets {
+		b.Prune()
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewBucket(3, 3*time.Second)
+	limiter.now = func() time.Time { return now }
+
+	// A new key can burst up to the limit
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("user").Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	result := limiter.Allow("user")
+	if result.Allowed {
+		t.Fatal("Expected request past the burst to be limited")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("Expected retry after %v, got %v", time.Second, result.RetryAfter)
+	}
+	if !result.Reset.Equal(now.Add(3 * time.Second)) {
+		t.Errorf("Expected reset at %v, got %v", now.Add(3*time.Second), result.Reset)
+	}
+
+	// Tokens come back one at a time
+	now = now.Add(time.Second)
+	result = limiter.Allow("user")
+	if !result.Allowed {
+		t.Error("Expected request after a refill to be allowed")
+	}
+	if result.Remaining != 0 {
+		t.Errorf("Expected 0 remaining, got %d", result.Remaining)
+	}
+	if limiter.Allow("user").Allowed {
+		t.Error("Expected request to be limited until the next refill")
+	}
+
+	// Buckets never fill past the limit
+	now = now.Add(time.Hour)
+	if result := limiter.Allow("user"); result.Remaining != 2 {
+		t.Errorf("Expected 2 remaining after a long idle, got %d", result.Remaining)
+	}
+
+	now = now.Add(time.Second)
+	limiter.Prune()
+	if got := len(limiter.buckets); got != 0 {
+		t.Errorf("Expected pruned limiter to be empty, got %d keys", got)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewBuckets(time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	// Each limit is its own budget
+	for i := 0; i < 2; i++ {
+		if !limiter.Allow("free", 2).Allowed {
+			t.Fatalf("Expected request %d at limit 2 to be allowed", i+1)
+		}
+	}
+	if limiter.Allow("free", 2).Allowed {
+		t.Error("Expected request past limit 2 to be limited")
+	}
+	result := limiter.Allow("paid", 5)
+	if !result.Allowed || result.Limit != 5 || result.Remaining != 4 {
+		t.Errorf("Expected a fresh bucket of 5 with 4 remaining, got %+v", result)
+	}
+
+	now = now.Add(time.Minute)
+	limiter.Prune()
+	for limit, b := range limiter.buckets {
+		if got := len(b.buckets); got != 0 {
+			t.Errorf("Expected bucket for limit %d to be empty, got %d keys", limit, got)
+		}
+	}
+}
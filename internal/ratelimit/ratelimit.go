@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter allows each key a fixed number of requests per window. Counts are
+// kept in memory, so each server instance limits on its own.
+type Limiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+	windows map[string]*window
+}
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// Result describes a key's allowance after a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is when the key's full allowance is available again
+	Reset time.Time
+	// RetryAfter is how long a refused request should wait before trying
+	// again
+	RetryAfter time.Duration
+}
+
+// New creates a limiter allowing each key limit requests in every period of
+// length per
+func New(limit int, per time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  per,
+		now:     time.Now,
+		windows: map[string]*window{},
+	}
+}
+
+// Parse reads a rate written as "<limit>/<window>", such as "10/1m"
+func Parse(rate string) (int, time.Duration, error) {
+	limitPart, windowPart, ok := strings.Cut(rate, "/")
+	if !ok {
+		return 0, 0, errors.New("rate must look like <limit>/<window>, e.g. 10/1m")
+	}
+	limit, err := strconv.Atoi(limitPart)
+	if err != nil || limit <= 0 {
+		return 0, 0, errors.New("rate limit must be a positive integer")
+	}
+	window, err := time.ParseDuration(windowPart)
+	if err != nil || window <= 0 {
+		return 0, 0, errors.New("rate window must be a positive duration")
+	}
+	return limit, window, nil
+}
+
+// Allow counts a request against key
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(l.window)}
+		l.windows[key] = w
+	}
+
+	if w.count >= l.limit {
+		return Result{Allowed: false, Limit: l.limit, Remaining: 0, Reset: w.reset, RetryAfter: w.reset.Sub(now)}
+	}
+	w.count++
+	return Result{Allowed: true, Limit: l.limit, Remaining: l.limit - w.count, Reset: w.reset}
+}
+
+// Prune forgets keys whose window has ended, so memory doesn't grow with
+// every address ever seen
+func (l *Limiter) Prune() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, w := range l.windows {
+		if !now.Before(w.reset) {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for i, wantRemaining := range []int{1, 0} {
+		result := limiter.Allow("1.2.3.4")
+		if !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if result.Remaining != wantRemaining {
+			t.Errorf("Expected %d remaining, got %d", wantRemaining, result.Remaining)
+		}
+	}
+
+	result := limiter.Allow("1.2.3.4")
+	if result.Allowed {
+		t.Error("Expected third request to be limited")
+	}
+	if !result.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected reset at %v, got %v", now.Add(time.Minute), result.Reset)
+	}
+	if result.RetryAfter != time.Minute {
+		t.Errorf("Expected retry after %v, got %v", time.Minute, result.RetryAfter)
+	}
+
+	// Keys are limited independently
+	if !limiter.Allow("5.6.7.8").Allowed {
+		t.Error("Expected another key to be allowed")
+	}
+
+	// A new window starts once the old one ends
+	now = now.Add(time.Minute)
+	if !limiter.Allow("1.2.3.4").Allowed {
+		t.Error("Expected request in the next window to be allowed")
+	}
+
+	now = now.Add(time.Minute)
+	limiter.Prune()
+	if got := len(limiter.windows); got != 0 {
+		t.Errorf("Expected pruned limiter to be empty, got %d keys", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       string
+		wantLimit  int
+		wantWindow time.Duration
+		wantErr    bool
+	}{
+		{name: "Per minute", rate: "10/1m", wantLimit: 10, wantWindow: time.Minute},
+		{name: "Per hour", rate: "5/1h", wantLimit: 5, wantWindow: time.Hour},
+		{name: "Missing window", rate: "10", wantErr: true},
+		{name: "Zero limit", rate: "0/1m", wantErr: true},
+		{name: "Bad window", rate: "10/minute", wantErr: true},
+		{name: "Negative window", rate: "10/-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, window, err := Parse(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if limit != tt.wantLimit || window != tt.wantWindow {
+				t.Errorf("Expected %d/%v, got %d/%v", tt.wantLimit, tt.wantWindow, limit, window)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs in their own goroutines until stopped
+type Scheduler struct {
+	mu      sync.Mutex
+	jobs    []Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running bool
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job to run every interval, starting one interval after
+// Start is called. Jobs registered after Start are started immediately.
+func (s *Scheduler) Every(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := Job{Name: name, Interval: interval, Run: run}
+	s.jobs = append(s.jobs, job)
+	if s.running {
+		s.startJob(job)
+	}
+}
+
+// Start launches all registered jobs. The jobs stop when ctx is canceled or
+// Stop is called.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.running = true
+	s.ctx = ctx
+	for _, job := range s.jobs {
+		s.startJob(job)
+	}
+}
+
+// Stop cancels all jobs and waits for any in-flight runs to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.running = false
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) startJob(job Job) {
+	ctx := s.ctx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(job.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := job.Run(ctx)
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Scheduled job %s failed: %s", job.Name, err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	var runs atomic.Int32
+	s := New()
+	s.Every("count", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	s.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	if runs.Load() == 0 {
+		t.Error("Expected job to run at least once")
+	}
+}
+
+func TestSchedulerStopHaltsJobs(t *testing.T) {
+	var runs atomic.Int32
+	s := New()
+	s.Every("count", 5*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	s.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	s.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Errorf("Expected no runs after Stop, got %d more", runs.Load()-stopped)
+	}
+}
@@ -0,0 +1,80 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	payload := []byte(`{"id":"evt_123","event":"user.upgraded"}`)
+	secret := "polka_secret"
+	now := time.Now()
+	// A header signed with an old secret and then the current one
+	_, current, _ := strings.Cut(Sign(payload, secret, now), ",")
+	rotating := Sign(payload, "old_secret", now) + "," + current
+
+	tests := []struct {
+		name    string
+		payload []byte
+		header  string
+		wantErr error
+	}{
+		{
+			name:    "valid signature",
+			header:  Sign(payload, secret, now),
+			wantErr: nil,
+		},
+		{
+			name:    "signed slightly in the future",
+			header:  Sign(payload, secret, now.Add(time.Minute)),
+			wantErr: nil,
+		},
+		{
+			name:    "second signature matches",
+			header:  rotating,
+			wantErr: nil,
+		},
+		{
+			name:    "wrong secret",
+			header:  Sign(payload, "other_secret", now),
+			wantErr: ErrNoValidSignature,
+		},
+		{
+			name:    "tampered payload",
+			payload: []byte(`{"id":"evt_123","event":"user.downgraded"}`),
+			header:  Sign(payload, secret, now),
+			wantErr: ErrNoValidSignature,
+		},
+		{
+			name:    "replayed later",
+			header:  Sign(payload, secret, now.Add(-time.Hour)),
+			wantErr: ErrTimestampExpired,
+		},
+		{
+			name:    "missing signature",
+			header:  fmt.Sprintf("t=%d", now.Unix()),
+			wantErr: ErrInvalidHeader,
+		},
+		{
+			name:    "garbage header",
+			header:  "not-a-signature",
+			wantErr: ErrInvalidHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := payload
+			if tt.payload != nil {
+				body = tt.payload
+			}
+			err := Verify(body, tt.header, secret, DefaultTolerance, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// queryFiles are the SQLite versions of the generated queries that use
+// Postgres syntax, in sqlc's format. Each takes its parameters in the same
+// order as the Postgres query, numbered the same way.
+//
+//go:embed queries/*.sql
+var queryFiles embed.FS
+
+// queryPrefix starts every query sqlc generates, followed by its name
+const queryPrefix = "-- name: "
+
+// queries are the SQLite versions by query name
+var queries = func() map[string]string {
+	queries := map[string]string{}
+	paths, err := fs.Glob(queryFiles, "queries/*.sql")
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range paths {
+		data, err := queryFiles.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		for _, query := range strings.Split(string(data), queryPrefix)[1:] {
+			name := queryName(queryPrefix + query)
+			if _, ok := queries[name]; ok {
+				panic(fmt.Sprintf("sqlite: query %s is defined twice", name))
+			}
+			queries[name] = queryPrefix + strings.TrimSpace(query)
+		}
+	}
+	return queries
+}()
+
+// queryName is the name sqlc gave query, or "" if it has none
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, queryPrefix)
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// castPattern matches the Postgres casts sqlc needs to type parameters,
+// which SQLite has no use for
+var castPattern = regexp.MustCompile(`::(uuid|timestamp|text|integer|bigint|boolean)\b`)
+
+// rewrite is the SQLite version of query: its replacement if it has one,
+// or the query itself without its casts
+func rewrite(query string) string {
+	if replacement, ok := queries[queryName(query)]; ok {
+		return replacement
+	}
+	return castPattern.ReplaceAllString(query, "")
+}
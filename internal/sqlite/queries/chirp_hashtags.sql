@@ -0,0 +1,6 @@
+-- name: CreateChirpHashtags :exec
+-- The WHERE keeps SQLite from reading ON CONFLICT as part of the SELECT
+INSERT INTO chirp_hashtags (chirp_id, tag)
+SELECT $1, tags.value FROM json_each($2) AS tags
+WHERE true
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,48 @@
+-- name: AddChirpViews :exec
+UPDATE chirps
+SET view_count = chirps.view_count + views.count
+FROM (
+    SELECT ids.value AS id, counts.value AS count
+    FROM json_each($1) AS ids
+    JOIN json_each($2) AS counts ON counts.key = ids.key
+) AS views
+WHERE chirps.id = views.id;
+
+-- name: AdjustChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = MAX(reply_count + $1, 0)
+WHERE id = $2;
+
+-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE id IN (SELECT value FROM json_each($1))
+    AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2);
+
+-- name: GetChirpsInCells :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2)
+    AND EXISTS (SELECT 1 FROM json_each($3) AS prefixes WHERE chirps.geohash LIKE prefixes.value)
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: PublishDueChirps :many
+-- SQLite can't update from a WITH clause, so the parents' reply counts are
+-- left to the chirps_count_published_replies trigger
+UPDATE chirps
+SET published_at = now()
+WHERE published_at IS NULL AND deleted_at IS NULL AND publish_at <= now()
+RETURNING id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at;
+
+-- name: SearchChirps :many
+SELECT id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, deleted_at, like_count, parent_chirp_id, reply_count, search_vector, view_count, edited_at FROM chirps
+WHERE websearch_match(body, $1)
+    AND published_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM $2)
+    AND ($3 IS NULL OR user_id = $3)
+    AND ($4 IS NULL
+        OR (created_at, id) < ($4, $5))
+ORDER BY created_at DESC, id DESC
+LIMIT $6;
@@ -0,0 +1,9 @@
+-- name: UpsertConversation :one
+INSERT INTO conversations (id, created_at, updated_at, user_a_id, user_b_id)
+VALUES (
+    gen_random_uuid(), now(), now(),
+    MIN($1, $2),
+    MAX($1, $2)
+)
+ON CONFLICT (user_a_id, user_b_id) DO UPDATE SET updated_at = now()
+RETURNING id, created_at, updated_at, user_a_id, user_b_id;
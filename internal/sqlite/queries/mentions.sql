@@ -0,0 +1,6 @@
+-- name: CreateMentions :exec
+INSERT INTO mentions (chirp_id, user_id)
+SELECT $1, users.id
+FROM users
+WHERE users.handle IN (SELECT value FROM json_each($2))
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+-- name: LockSubscription :one
+SELECT user_id, created_at, updated_at, plan, status, expires_at, gift_available FROM subscriptions
+WHERE user_id = $1;
@@ -0,0 +1,3 @@
+-- name: GetUserByIDForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, plan, recommendations, share_location, handle, display_name, bio, location, website, avatar_key, email_verified, role, token_version, failed_login_count, failed_login_window_start, locked_until, deactivated_at, suspended_at, suspended_until, suspension_reason, password_reset_required, shadow_banned FROM users
+WHERE id = $1;
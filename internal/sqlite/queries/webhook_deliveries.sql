@@ -0,0 +1,7 @@
+-- name: CreateWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (id, created_at, updated_at, endpoint_id, event_id, event_type, payload, next_attempt_at)
+SELECT gen_random_uuid(), now(), now(), webhook_endpoints.id, $1, $2, $3, now()
+FROM webhook_endpoints
+WHERE active
+    AND (user_id IS NULL OR user_id = $4)
+    AND EXISTS (SELECT 1 FROM json_each(events) WHERE value = $2);
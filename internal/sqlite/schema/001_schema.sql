@@ -0,0 +1,444 @@
+-- +goose Up
+-- The Postgres schema in sql/schema as of migration 046, in one step. Columns
+-- are in the order the Postgres migrations left them, since the generated
+-- queries select * into structs field by field. UUIDs are stored as text and
+-- timestamps as UTC text that sorts in time order.
+CREATE TABLE plans (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    name TEXT NOT NULL,
+    max_chirp_length INTEGER NOT NULL,
+    rate_limit_per_minute INTEGER NOT NULL,
+    edit_window_seconds INTEGER NOT NULL,
+    undo_window_seconds INTEGER NOT NULL DEFAULT 20
+);
+
+INSERT INTO plans (id, name, max_chirp_length, rate_limit_per_minute, edit_window_seconds, undo_window_seconds) VALUES
+    ('free', 'Free', 140, 30, 0, 20),
+    ('red', 'Chirpy Red', 280, 60, 300, 60),
+    ('red_plus', 'Chirpy Red+', 1000, 120, 3600, 60);
+
+CREATE TABLE plan_features (
+    plan_id TEXT NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    feature TEXT NOT NULL,
+    PRIMARY KEY (plan_id, feature)
+);
+
+INSERT INTO plan_features (plan_id, feature) VALUES
+    ('red', 'long_chirps'),
+    ('red', 'edit_chirps'),
+    ('red', 'analytics'),
+    ('red', 'gift_red'),
+    ('red_plus', 'long_chirps'),
+    ('red_plus', 'edit_chirps'),
+    ('red_plus', 'analytics'),
+    ('red_plus', 'gift_red');
+
+CREATE TABLE feature_flags (
+    feature TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    enabled BOOLEAN NOT NULL
+);
+
+CREATE TABLE users (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    email TEXT NOT NULL UNIQUE,
+    hashed_password TEXT NOT NULL DEFAULT 'unset',
+    is_chirpy_red BOOLEAN NOT NULL DEFAULT FALSE,
+    plan TEXT NOT NULL DEFAULT 'free' REFERENCES plans(id),
+    recommendations BOOLEAN NOT NULL DEFAULT TRUE,
+    share_location BOOLEAN NOT NULL DEFAULT FALSE,
+    handle TEXT UNIQUE,
+    display_name TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    website TEXT NOT NULL DEFAULT '',
+    avatar_key TEXT,
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+    token_version INTEGER NOT NULL DEFAULT 0,
+    failed_login_count INTEGER NOT NULL DEFAULT 0,
+    failed_login_window_start TIMESTAMP,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE chirps (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    body TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    publish_at TIMESTAMP NOT NULL DEFAULT (now()),
+    published_at TIMESTAMP,
+    latitude REAL,
+    longitude REAL,
+    geohash TEXT,
+    place TEXT,
+    deleted_at TIMESTAMP,
+    like_count INTEGER NOT NULL DEFAULT 0,
+    parent_chirp_id TEXT REFERENCES chirps(id) ON DELETE SET NULL,
+    reply_count INTEGER NOT NULL DEFAULT 0,
+    -- Always NULL; search matches on the body instead
+    search_vector TEXT,
+    view_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX chirps_pending_publish_at_idx ON chirps (publish_at) WHERE published_at IS NULL;
+CREATE INDEX chirps_geohash_idx ON chirps (geohash) WHERE geohash IS NOT NULL;
+CREATE INDEX chirps_published_created_at_id_idx ON chirps (created_at, id) WHERE published_at IS NOT NULL;
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps (user_id, created_at, id) WHERE published_at IS NOT NULL;
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX chirps_parent_chirp_id_idx ON chirps (parent_chirp_id, created_at) WHERE parent_chirp_id IS NOT NULL;
+CREATE INDEX chirps_published_at_id_idx ON chirps (published_at, id) WHERE published_at IS NOT NULL;
+
+-- Counts a scheduled reply towards its parent once it's published, which
+-- PublishDueChirps does in the same statement on Postgres
+-- +goose StatementBegin
+CREATE TRIGGER chirps_count_published_replies
+AFTER UPDATE OF published_at ON chirps
+WHEN OLD.published_at IS NULL AND NEW.published_at IS NOT NULL AND NEW.parent_chirp_id IS NOT NULL
+BEGIN
+    UPDATE chirps SET reply_count = reply_count + 1 WHERE id = NEW.parent_chirp_id;
+END;
+-- +goose StatementEnd
+
+CREATE TABLE refresh_tokens (
+    token TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    id TEXT NOT NULL UNIQUE DEFAULT (gen_random_uuid()),
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+CREATE TABLE webhook_events (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    source TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload BLOB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    processed_at TIMESTAMP,
+    UNIQUE (source, event_id)
+);
+
+CREATE TABLE subscription_events (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    source TEXT NOT NULL,
+    webhook_event_id TEXT REFERENCES webhook_events(id) ON DELETE SET NULL,
+    actor_user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    promo_code TEXT
+);
+
+CREATE INDEX subscription_events_user_id_idx ON subscription_events (user_id, created_at);
+
+CREATE TABLE subscriptions (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    plan TEXT NOT NULL DEFAULT 'red' REFERENCES plans(id),
+    status TEXT NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX subscriptions_active_expires_at_idx ON subscriptions (expires_at) WHERE status = 'active';
+
+CREATE TABLE notifications (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, created_at);
+
+CREATE TABLE promo_codes (
+    code TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    plan TEXT NOT NULL REFERENCES plans(id),
+    duration_days INTEGER NOT NULL,
+    max_redemptions INTEGER NOT NULL DEFAULT 1,
+    redemption_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP
+);
+
+CREATE TABLE promo_redemptions (
+    code TEXT NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (code, user_id)
+);
+
+CREATE TABLE entitlement_overrides (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feature TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, feature)
+);
+
+CREATE TABLE experiment_events (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    experiment_key TEXT NOT NULL,
+    variant TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('exposure', 'conversion')),
+    name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX experiment_events_key_idx ON experiment_events (experiment_key, variant, kind, name);
+
+CREATE TABLE hashtag_follows (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE TABLE chirp_translations (
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    body TEXT NOT NULL,
+    source_language TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, language)
+);
+
+CREATE TABLE chirp_likes (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX chirp_likes_chirp_id_idx ON chirp_likes (chirp_id);
+
+CREATE TABLE chirp_hashtags (
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, tag)
+);
+
+CREATE INDEX chirp_hashtags_tag_idx ON chirp_hashtags (tag, chirp_id);
+
+CREATE TABLE mentions (
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX mentions_user_id_idx ON mentions (user_id);
+
+CREATE TABLE follows (
+    follower_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_idx ON follows (followee_id, follower_id);
+
+CREATE TABLE mutes (
+    muter_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    muted_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+CREATE TABLE bookmarks (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at, chirp_id);
+
+CREATE TABLE lists (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    is_private BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX lists_owner_id_idx ON lists (owner_id, created_at);
+
+CREATE TABLE list_members (
+    list_id TEXT NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE TABLE blocks (
+    blocker_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
+
+CREATE TABLE conversations (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_a_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_a_id, user_b_id),
+    CHECK (user_a_id < user_b_id)
+);
+
+CREATE INDEX conversations_user_a_idx ON conversations (user_a_id, updated_at DESC);
+CREATE INDEX conversations_user_b_idx ON conversations (user_b_id, updated_at DESC);
+
+CREATE TABLE messages (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id TEXT NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX messages_conversation_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+CREATE TABLE email_verification_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
+
+CREATE TABLE oauth_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX oauth_identities_user_id_idx ON oauth_identities (user_id);
+
+CREATE TABLE webauthn_credentials (
+    id BLOB PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    public_key BLOB NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
+
+CREATE TABLE webauthn_challenges (
+    challenge TEXT PRIMARY KEY,
+    ceremony TEXT NOT NULL,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX webauthn_challenges_expires_at_idx ON webauthn_challenges (expires_at);
+
+CREATE TABLE magic_link_tokens (
+    token_hash TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX magic_link_tokens_email_created_at_idx ON magic_link_tokens (email, created_at);
+
+CREATE TABLE revoked_access_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    revoked_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX revoked_access_tokens_expires_at_idx ON revoked_access_tokens (expires_at);
+
+CREATE TABLE banned_words (
+    word TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+INSERT INTO banned_words (word) VALUES
+    ('kerfuffle'),
+    ('sharbert'),
+    ('fornax');
+
+CREATE TABLE hit_counters (
+    name TEXT PRIMARY KEY,
+    count INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+-- +goose Down
+DROP TABLE hit_counters;
+DROP TABLE banned_words;
+DROP TABLE revoked_access_tokens;
+DROP TABLE magic_link_tokens;
+DROP TABLE webauthn_challenges;
+DROP TABLE webauthn_credentials;
+DROP TABLE oauth_identities;
+DROP TABLE email_verification_tokens;
+DROP TABLE messages;
+DROP TABLE conversations;
+DROP TABLE blocks;
+DROP TABLE list_members;
+DROP TABLE lists;
+DROP TABLE bookmarks;
+DROP TABLE mutes;
+DROP TABLE follows;
+DROP TABLE mentions;
+DROP TABLE chirp_hashtags;
+DROP TABLE chirp_likes;
+DROP TABLE chirp_translations;
+DROP TABLE hashtag_follows;
+DROP TABLE experiment_events;
+DROP TABLE entitlement_overrides;
+DROP TABLE promo_redemptions;
+DROP TABLE promo_codes;
+DROP TABLE notifications;
+DROP TABLE subscriptions;
+DROP TABLE subscription_events;
+DROP TABLE webhook_events;
+DROP TABLE refresh_tokens;
+DROP TABLE chirps;
+DROP TABLE users;
+DROP TABLE feature_flags;
+DROP TABLE plan_features;
+DROP TABLE plans;
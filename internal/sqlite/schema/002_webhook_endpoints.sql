@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE webhook_endpoints (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    -- A JSON array, read back as a Postgres array
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE INDEX webhook_endpoints_user_id_idx ON webhook_endpoints (user_id);
+
+-- +goose Down
+DROP TABLE webhook_endpoints;
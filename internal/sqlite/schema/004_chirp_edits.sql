@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN edited_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN edited_at;
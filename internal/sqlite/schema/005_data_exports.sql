@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE data_exports (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    archive BLOB,
+    error TEXT,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id, created_at);
+CREATE INDEX data_exports_status_idx ON data_exports (status, created_at);
+
+-- +goose Down
+DROP TABLE data_exports;
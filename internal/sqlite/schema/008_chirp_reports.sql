@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE chirp_reports (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMP NOT NULL DEFAULT (now()),
+    reporter_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id TEXT NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+        CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'self_harm', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open'
+);
+
+CREATE UNIQUE INDEX chirp_reports_open_idx ON chirp_reports (reporter_id, chirp_id) WHERE status = 'open';
+CREATE INDEX chirp_reports_status_idx ON chirp_reports (status, created_at);
+CREATE INDEX chirp_reports_chirp_id_idx ON chirp_reports (chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_reports;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirp_reports ADD COLUMN decision TEXT
+    CHECK (decision IN ('dismissed', 'chirp_deleted', 'author_suspended'));
+ALTER TABLE chirp_reports ADD COLUMN decision_note TEXT NOT NULL DEFAULT '';
+ALTER TABLE chirp_reports ADD COLUMN decided_by TEXT REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE chirp_reports ADD COLUMN decided_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirp_reports DROP COLUMN decided_at;
+ALTER TABLE chirp_reports DROP COLUMN decided_by;
+ALTER TABLE chirp_reports DROP COLUMN decision_note;
+ALTER TABLE chirp_reports DROP COLUMN decision;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN shadow_banned BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX users_shadow_banned_idx ON users (id) WHERE shadow_banned;
+
+-- +goose Down
+DROP INDEX users_shadow_banned_idx;
+ALTER TABLE users DROP COLUMN shadow_banned;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE moderation_actions (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    moderator_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    chirp_id TEXT NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX moderation_actions_created_at_idx ON moderation_actions (created_at, id);
+CREATE INDEX moderation_actions_user_id_idx ON moderation_actions (user_id, created_at);
+
+-- +goose Down
+DROP TABLE moderation_actions;
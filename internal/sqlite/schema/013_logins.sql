@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE logins (
+    id TEXT PRIMARY KEY DEFAULT (gen_random_uuid()),
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address TEXT NOT NULL,
+    network TEXT NOT NULL,
+    user_agent TEXT NOT NULL
+);
+
+CREATE INDEX logins_user_id_idx ON logins (user_id, created_at, id);
+CREATE INDEX logins_device_idx ON logins (user_id, user_agent, network);
+
+-- +goose Down
+DROP TABLE logins;
@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE VIEW hidden_authors AS
+SELECT id, deactivated_at IS NOT NULL AS hidden_from_self
+FROM users
+WHERE deactivated_at IS NOT NULL OR shadow_banned;
+
+-- +goose Down
+DROP VIEW hidden_authors;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE webauthn_credentials ADD COLUMN backup_eligible BOOLEAN;
+ALTER TABLE webauthn_credentials ADD COLUMN backup_state BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE webauthn_challenges ADD COLUMN session_data TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webauthn_challenges DROP COLUMN session_data;
+ALTER TABLE webauthn_credentials DROP COLUMN backup_state;
+ALTER TABLE webauthn_credentials DROP COLUMN backup_eligible;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN gift_available BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN gift_available;
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE stripe_customers (
+    user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    customer_id TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE stripe_customers;
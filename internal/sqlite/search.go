@@ -0,0 +1,16 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"modernc.org/sqlite"
+)
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("websearch_match", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		body, _ := args[0].(string)
+		query, _ := args[1].(string)
+		return chirptext.MatchesSearch(body, query), nil
+	})
+}
@@ -0,0 +1,364 @@
+// Package sqlite runs the generated queries in internal/database against a
+// SQLite file instead of Postgres, so Chirpy can run as a single binary
+// with nothing else installed.
+//
+// It registers a database/sql driver wrapping modernc.org/sqlite. Queries
+// sqlc generated are swapped by name for their SQLite versions in queries/
+// where Postgres syntax differs; the rest run unchanged, since SQLite
+// binds $1-style parameters by position too. Arguments and results are
+// converted so the generated code sees what lib/pq would give it: times
+// are stored as UTC text that sorts in time order, and arrays as JSON for
+// json_each, in columns declared like TEXT[] so they read back as Postgres
+// arrays.
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+// DriverName is the database/sql driver this package registers
+const DriverName = "chirpy-sqlite"
+
+// busyTimeout is how long a write waits for another connection's
+// transaction before failing
+const busyTimeout = 5 * time.Second
+
+// timeFormat is how times are stored: fixed width, so comparing the text
+// compares the times
+const timeFormat = "2006-01-02 15:04:05.000000"
+
+// Schema holds the goose migrations for a SQLite database, which run with
+// goose's sqlite3 dialect
+//
+//go:embed schema/*.sql
+var Schema embed.FS
+
+func init() {
+	// modernc.org/sqlite only exposes its driver, which carries the
+	// functions registered below, through database/sql
+	db, err := sql.Open("sqlite", "")
+	if err != nil {
+		panic(err)
+	}
+	sql.Register(DriverName, &sqliteDriver{db.Driver()})
+	db.Close()
+
+	// The Postgres functions the schema and queries call
+	sqlite.MustRegisterScalarFunction("now", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return formatTime(time.Now()), nil
+	})
+	sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		id, err := uuid.NewRandomFromReader(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	})
+}
+
+// Memory is the path Open takes for a database kept in memory instead of
+// a file
+const Memory = ":memory:"
+
+// Open opens the SQLite database at path, creating it if needed. Foreign
+// keys are enforced as in Postgres, and the write-ahead log lets readers
+// carry on while a transaction writes.
+//
+// A Memory database is private to the returned pool but shared by all of
+// its connections, and lasts until the last of them closes, so Open stops
+// the pool from expiring them. Callers shouldn't change that.
+func Open(path string) (*sql.DB, error) {
+	if path != Memory {
+		return sql.Open(DriverName, DSN(path))
+	}
+
+	name := make([]byte, 8)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(DriverName, memoryDSN(hex.EncodeToString(name)))
+	if err != nil {
+		return nil, err
+	}
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+	return db, nil
+}
+
+// DSN is the data source name of the database at path
+func DSN(path string) string {
+	query := dsnQuery()
+	query["_pragma"] = append(query["_pragma"], "journal_mode(WAL)")
+	return "file:" + path + "?" + query.Encode()
+}
+
+// memoryDSN is the data source name of the in-memory database called name.
+// The memdb VFS lets connections share it, unlike :memory:, and locks it
+// as it would a file.
+func memoryDSN(name string) string {
+	query := dsnQuery()
+	query.Set("vfs", "memdb")
+	return "file:/" + name + "?" + query.Encode()
+}
+
+func dsnQuery() url.Values {
+	return url.Values{
+		"_pragma": {
+			"foreign_keys(1)",
+			fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()),
+		},
+		// Transactions take the write lock up front, so two of them can't
+		// both read and then deadlock upgrading to write
+		"_txlock": {"immediate"},
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// parseTime is the time s holds if it's exactly in timeFormat
+func parseTime(s string) (time.Time, bool) {
+	if len(s) != len(timeFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeFormat, s)
+	return t, err == nil
+}
+
+// sqliteDriver opens modernc.org/sqlite connections wrapped in conn
+type sqliteDriver struct {
+	base driver.Driver
+}
+
+func (d *sqliteDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c}, nil
+}
+
+// conn rewrites queries and converts arguments and results on their way
+// through a modernc.org/sqlite connection
+type conn struct {
+	driver.Conn
+}
+
+// The interfaces modernc.org/sqlite's connection implements, which conn
+// passes on
+type sqliteConn interface {
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+}
+
+var (
+	_ sqliteConn               = (*conn)(nil)
+	_ driver.NamedValueChecker = (*conn)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+)
+
+func (c *conn) inner() sqliteConn {
+	return c.Conn.(sqliteConn)
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	s, err := c.inner().PrepareContext(ctx, rewrite(query))
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{s}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.inner().BeginTx(ctx, opts)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.inner().ExecContext(ctx, rewrite(query), args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := c.inner().QueryContext(ctx, rewrite(query), args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{r.(sqliteRows)}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	return c.inner().Ping(ctx)
+}
+
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	return checkNamedValue(v)
+}
+
+type stmt struct {
+	driver.Stmt
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.Stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := s.Stmt.(driver.StmtQueryContext).QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{r.(sqliteRows)}, nil
+}
+
+func (s *stmt) CheckNamedValue(v *driver.NamedValue) error {
+	return checkNamedValue(v)
+}
+
+// checkNamedValue stores arrays as JSON and times, including those in
+// sql.NullTime, in timeFormat. Everything else converts as usual.
+func checkNamedValue(v *driver.NamedValue) error {
+	switch value := v.Value.(type) {
+	case *pq.StringArray:
+		return jsonArray(v, []string(*value))
+	case *pq.Int64Array:
+		return jsonArray(v, []int64(*value))
+	case pq.GenericArray:
+		return genericArray(v, value.A)
+	}
+
+	value, err := driver.DefaultParameterConverter.ConvertValue(v.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = formatTime(t)
+	}
+	v.Value = value
+	return nil
+}
+
+func jsonArray(v *driver.NamedValue, elems any) error {
+	data, err := json.Marshal(elems)
+	if err != nil {
+		return err
+	}
+	v.Value = string(data)
+	return nil
+}
+
+// genericArray encodes a slice of anything, using each element's driver
+// value when it has one (as UUIDs do)
+func genericArray(v *driver.NamedValue, a any) error {
+	slice := reflect.ValueOf(a)
+	if slice.Kind() == reflect.Pointer {
+		slice = slice.Elem()
+	}
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("sqlite: can't bind %T as an array", a)
+	}
+	elems := make([]any, slice.Len())
+	for i := range elems {
+		elem := slice.Index(i).Interface()
+		if valuer, ok := elem.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return err
+			}
+			elem = value
+		}
+		elems[i] = elem
+	}
+	return jsonArray(v, elems)
+}
+
+// The interfaces modernc.org/sqlite's rows implement that rows needs
+type sqliteRows interface {
+	driver.Rows
+	driver.RowsColumnTypeDatabaseTypeName
+}
+
+// rows turns times back into time.Time, and the JSON in array columns
+// into the Postgres arrays lib/pq scans. SQLite reports a column's declared
+// type only when it comes straight from a table, so text an expression
+// returns (MAX(created_at), say) is a time if it's in timeFormat.
+type rows struct {
+	sqliteRows
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.sqliteRows.Next(dest)
+	if err != nil {
+		return err
+	}
+	for i, value := range dest {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch declType := r.ColumnTypeDatabaseTypeName(i); {
+		case declType == "":
+			if t, ok := parseTime(s); ok {
+				dest[i] = t
+			}
+		case strings.HasSuffix(declType, "[]"):
+			array, err := postgresArray(s)
+			if err != nil {
+				return err
+			}
+			dest[i] = array
+		}
+	}
+	return nil
+}
+
+// arrayEscaper escapes a quoted element of a Postgres array literal
+var arrayEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// postgresArray is the JSON array s written as a Postgres array literal
+func postgresArray(s string) (string, error) {
+	var elems []any
+	if err := json.Unmarshal([]byte(s), &elems); err != nil {
+		return "", fmt.Errorf("sqlite: array column holds %q: %w", s, err)
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, elem := range elems {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		switch elem := elem.(type) {
+		case nil:
+			b.WriteString("NULL")
+		case string:
+			b.WriteByte('"')
+			b.WriteString(arrayEscaper.Replace(elem))
+			b.WriteByte('"')
+		default:
+			fmt.Fprint(&b, elem)
+		}
+	}
+	b.WriteByte('}')
+	return b.String(), nil
+}
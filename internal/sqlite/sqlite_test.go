@@ -0,0 +1,308 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+// openTestDB opens a migrated database at path
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected no error opening, got %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := fs.Sub(Schema, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatalf("Expected no error migrating, got %v", err)
+	}
+	return db
+}
+
+// generatedQueries are the queries sqlc generated into internal/database
+func generatedQueries(t *testing.T) []string {
+	t.Helper()
+	paths, err := filepath.Glob("../database/*.sql.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	for _, path := range paths {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			lit, ok := node.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			query, err := strconv.Unquote(lit.Value)
+			if err == nil && strings.HasPrefix(query, queryPrefix) {
+				queries = append(queries, query)
+			}
+			return true
+		})
+	}
+	return queries
+}
+
+// parameterCount is the highest parameter number in query
+func parameterCount(query string) int {
+	count := 0
+	for _, match := range parameterPattern.FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(match[1])
+		count = max(count, n)
+	}
+	return count
+}
+
+var parameterPattern = regexp.MustCompile(`[$?](\d+)`)
+
+func TestEveryQueryCompiles(t *testing.T) {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "chirpy.db"))
+	generated := generatedQueries(t)
+	if len(generated) == 0 {
+		t.Fatal("Expected to find the generated queries")
+	}
+
+	names := map[string]bool{}
+	for _, query := range generated {
+		name := queryName(query)
+		names[name] = true
+		// modernc.org/sqlite only compiles a statement when it runs, so the
+		// query is explained instead, with every parameter NULL
+		query = rewrite(query)
+		args := make([]any, parameterCount(query))
+		rows, err := db.Query("EXPLAIN "+query, args...)
+		if err != nil {
+			t.Errorf("Expected %s to compile, got %v", name, err)
+			continue
+		}
+		rows.Close()
+	}
+
+	// A replacement for a query that's been renamed or removed would never
+	// be used
+	for name := range queries {
+		if !names[name] {
+			t.Errorf("Expected a generated query named %s", name)
+		}
+	}
+}
+
+func TestQueries(t *testing.T) {
+	ctx := context.Background()
+	q := database.New(openTestDB(t, Memory))
+
+	author, err := q.CreateUser(ctx, database.CreateUserParams{
+		Email:          "author@example.com",
+		HashedPassword: "hash",
+		Handle:         sql.NullString{String: "author", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating a user, got %v", err)
+	}
+	if author.ID == uuid.Nil || author.CreatedAt.IsZero() || author.Plan != "free" {
+		t.Errorf("Expected the defaults to be filled in, got %+v", author)
+	}
+	if _, err := q.CreateUser(ctx, database.CreateUserParams{Email: "author@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected a taken email to return no rows, got %v", err)
+	}
+	found, err := q.GetUserByEmail(ctx, "author@example.com")
+	if err != nil || found.ID != author.ID {
+		t.Errorf("Expected to find %s, got %+v (%v)", author.ID, found, err)
+	}
+
+	now := time.Now().UTC()
+	chirp, err := q.CreateChirp(ctx, database.CreateChirpParams{
+		Body:        "Hello #sqlite @author",
+		UserID:      author.ID,
+		PublishAt:   now,
+		PublishedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating a chirp, got %v", err)
+	}
+	if !chirp.PublishedAt.Valid || !chirp.PublishedAt.Time.Equal(now.Truncate(time.Microsecond)) {
+		t.Errorf("Expected it published at %s, got %v", now, chirp.PublishedAt)
+	}
+
+	err = q.CreateChirpHashtags(ctx, database.CreateChirpHashtagsParams{ChirpID: chirp.ID, Tags: []string{"sqlite"}})
+	if err != nil {
+		t.Fatalf("Expected no error tagging, got %v", err)
+	}
+	tagged, err := q.GetChirpsByHashtag(ctx, database.GetChirpsByHashtagParams{Tag: "sqlite", Limit: 10})
+	if err != nil || len(tagged) != 1 {
+		t.Errorf("Expected the tagged chirp, got %v (%v)", tagged, err)
+	}
+
+	err = q.CreateMentions(ctx, database.CreateMentionsParams{ChirpID: chirp.ID, Handles: []string{"author", "nobody"}})
+	if err != nil {
+		t.Fatalf("Expected no error mentioning, got %v", err)
+	}
+	mentions, err := q.GetMentionsForUser(ctx, database.GetMentionsForUserParams{UserID: author.ID, Limit: 10})
+	if err != nil || len(mentions) != 1 {
+		t.Errorf("Expected one mention, got %v (%v)", mentions, err)
+	}
+
+	// A scheduled reply counts towards its parent once it's published
+	_, err = q.CreateChirp(ctx, database.CreateChirpParams{
+		Body:          "A reply",
+		UserID:        author.ID,
+		PublishAt:     now.Add(-time.Second),
+		ParentChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating a reply, got %v", err)
+	}
+	published, err := q.PublishDueChirps(ctx)
+	if err != nil || len(published) != 1 {
+		t.Fatalf("Expected the reply to be published, got %v (%v)", published, err)
+	}
+	err = q.AddChirpViews(ctx, database.AddChirpViewsParams{Ids: []uuid.UUID{chirp.ID}, Counts: []int64{3}})
+	if err != nil {
+		t.Fatalf("Expected no error adding views, got %v", err)
+	}
+	chirp, err = q.GetChirpByID(ctx, chirp.ID)
+	if err != nil {
+		t.Fatalf("Expected no error getting the chirp, got %v", err)
+	}
+	if chirp.ReplyCount != 1 || chirp.ViewCount != 3 {
+		t.Errorf("Expected 1 reply and 3 views, got %d and %d", chirp.ReplyCount, chirp.ViewCount)
+	}
+
+	page, err := q.GetChirpsPageDesc(ctx, database.GetChirpsPageDescParams{
+		BeforeCreatedAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		BeforeID:        uuid.NullUUID{UUID: uuid.Nil, Valid: true},
+		Limit:           10,
+	})
+	if err != nil || len(page) != 2 {
+		t.Errorf("Expected both chirps, got %v (%v)", page, err)
+	}
+
+	results, err := q.SearchChirps(ctx, database.SearchChirpsParams{Query: "hello -goodbye", Limit: 10})
+	if err != nil || len(results) != 1 || results[0].ID != chirp.ID {
+		t.Errorf("Expected the chirp to match, got %v (%v)", results, err)
+	}
+
+	// Shadow-banned chirps are only listed for their author
+	_, err = q.SetUserShadowBanned(ctx, database.SetUserShadowBannedParams{ID: author.ID, ShadowBanned: true})
+	if err != nil {
+		t.Fatalf("Expected no error shadow-banning, got %v", err)
+	}
+	for viewer, want := range map[uuid.NullUUID]int{{}: 0, {UUID: uuid.New(), Valid: true}: 0, {UUID: author.ID, Valid: true}: 1} {
+		results, err := q.SearchChirps(ctx, database.SearchChirpsParams{Query: "hello", ViewerID: viewer, Limit: 10})
+		if err != nil || len(results) != want {
+			t.Errorf("Expected %d results for viewer %v, got %v (%v)", want, viewer, results, err)
+		}
+		hidden, err := q.IsChirpAuthorHidden(ctx, database.IsChirpAuthorHiddenParams{AuthorID: author.ID, ViewerID: viewer})
+		if err != nil || hidden != (want == 0) {
+			t.Errorf("Expected the author hidden from viewer %v to be %t, got %t (%v)", viewer, want == 0, hidden, err)
+		}
+	}
+
+	// Arrays are stored as JSON and scanned back as Postgres arrays
+	events := []string{"chirp.created", `quoted "and" \escaped`}
+	endpoint, err := q.CreateWebhookEndpoint(ctx, database.CreateWebhookEndpointParams{
+		UserID: uuid.NullUUID{UUID: author.ID, Valid: true},
+		Url:    "https://example.com/hook",
+		Secret: "secret",
+		Events: events,
+		Active: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error creating an endpoint, got %v", err)
+	}
+	endpoint, err = q.GetWebhookEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		t.Fatalf("Expected no error getting the endpoint, got %v", err)
+	}
+	if got := endpoint.Events; len(got) != 2 || got[0] != events[0] || got[1] != events[1] {
+		t.Errorf("Expected events %q, got %q", events, got)
+	}
+
+	for event, want := range map[string]int64{"chirp.created": 1, "chirp.deleted": 0} {
+		queued, err := q.CreateWebhookDeliveries(ctx, database.CreateWebhookDeliveriesParams{
+			EventID:   uuid.New(),
+			EventType: event,
+			Payload:   []byte(`{}`),
+			UserID:    author.ID,
+		})
+		if err != nil || queued != want {
+			t.Errorf("Expected %s queued for %d endpoints, got %d (%v)", event, want, queued, err)
+		}
+	}
+	claimed, err := q.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil:    time.Now().Add(time.Minute),
+		MaxDeliveries: 10,
+	})
+	if err != nil || len(claimed) != 1 || claimed[0].EndpointID != endpoint.ID {
+		t.Fatalf("Expected to claim the delivery, got %v (%v)", claimed, err)
+	}
+	claimed, err = q.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil:    time.Now().Add(time.Minute),
+		MaxDeliveries: 10,
+	})
+	if err != nil || len(claimed) != 0 {
+		t.Errorf("Expected a claimed delivery not to be claimed again, got %v (%v)", claimed, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, Memory)
+	other := openTestDB(t, Memory)
+
+	// Several connections at once see the same database
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error connecting, got %v", err)
+		}
+		defer conn.Close()
+		conns[i] = conn
+	}
+	_, err := conns[0].ExecContext(ctx, "INSERT INTO banned_words (word) VALUES ('gadzooks')")
+	if err != nil {
+		t.Fatalf("Expected no error inserting, got %v", err)
+	}
+	for _, conn := range conns[1:] {
+		var count int
+		err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM banned_words WHERE word = 'gadzooks'").Scan(&count)
+		if err != nil || count != 1 {
+			t.Errorf("Expected the word on every connection, got %d (%v)", count, err)
+		}
+	}
+
+	var count int
+	err = other.QueryRowContext(ctx, "SELECT COUNT(*) FROM banned_words WHERE word = 'gadzooks'").Scan(&count)
+	if err != nil || count != 0 {
+		t.Errorf("Expected another database not to have the word, got %d (%v)", count, err)
+	}
+}
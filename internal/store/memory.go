@@ -0,0 +1,534 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// ErrForeignKeyViolation is what Memory returns where the database would
+// fail a foreign key: a row referring to one that doesn't exist. Check for
+// it with IsForeignKeyViolation, which knows the databases' errors too.
+var ErrForeignKeyViolation = errors.New("store: foreign key violation")
+
+// ErrUniqueViolation is what Memory returns where the database would fail
+// a unique constraint the query doesn't skip conflicts on
+var ErrUniqueViolation = errors.New("store: unique violation")
+
+// Memory is a Store that keeps everything in maps, for tests and demos that
+// run without a database. Its data is gone when the process exits.
+//
+// Each call holds the lock for as long as it runs. InTx holds the write
+// lock for the whole transaction, so transactions run one at a time and
+// never see each other's changes half done; the Store fn gets mustn't be
+// mixed with calls on the Memory itself, which would wait on the lock.
+type Memory struct {
+	mu     *sync.RWMutex
+	tables *memoryTables
+	inTx   bool
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory is an empty Memory apart from the plans, plan features and
+// banned words the migrations insert
+func NewMemory() *Memory {
+	t := newMemoryTables()
+	now := t.now()
+	for _, plan := range []database.Plan{
+		{ID: "free", Name: "Free", MaxChirpLength: 140, RateLimitPerMinute: 30, EditWindowSeconds: 0, UndoWindowSeconds: 20},
+		{ID: "red", Name: "Chirpy Red", MaxChirpLength: 280, RateLimitPerMinute: 60, EditWindowSeconds: 300, UndoWindowSeconds: 60},
+		{ID: "red_plus", Name: "Chirpy Red+", MaxChirpLength: 1000, RateLimitPerMinute: 120, EditWindowSeconds: 3600, UndoWindowSeconds: 60},
+	} {
+		plan.CreatedAt, plan.UpdatedAt = now, now
+		t.plans[plan.ID] = plan
+	}
+	for _, planID := range []string{"red", "red_plus"} {
+		for _, feature := range []string{"long_chirps", "edit_chirps", "analytics", "gift_red"} {
+			t.planFeatures[namedKey{Name: planID, Key: feature}] = database.PlanFeature{PlanID: planID, Feature: feature}
+		}
+	}
+	for _, word := range []string{"kerfuffle", "sharbert", "fornax"} {
+		t.bannedWords[word] = database.BannedWord{Word: word, CreatedAt: now}
+	}
+	return &Memory{mu: &sync.RWMutex{}, tables: t}
+}
+
+// SetPlan adds or replaces a plan, which a database only gets from its
+// migrations
+func (m *Memory) SetPlan(plan database.Plan) {
+	defer m.write()()
+	m.tables.plans[plan.ID] = plan
+}
+
+func (m *Memory) InTx(ctx context.Context, fn func(Store) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := m.tables.clone()
+	if err := fn(&Memory{mu: m.mu, tables: m.tables, inTx: true}); err != nil {
+		*m.tables = *saved
+		return err
+	}
+	return nil
+}
+
+// read and write lock m for one call, returning the unlock to defer.
+// Inside InTx, which already holds the write lock, they do nothing.
+func (m *Memory) read() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+func (m *Memory) write() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+// pairKey keys a table whose primary key is two IDs
+type pairKey struct {
+	A, B uuid.UUID
+}
+
+// idKey keys a table whose primary key is an ID and a name
+type idKey struct {
+	ID   uuid.UUID
+	Name string
+}
+
+// namedKey keys a table whose primary key is two names
+type namedKey struct {
+	Name, Key string
+}
+
+// memoryTables are the tables of the schema, keyed by primary key
+type memoryTables struct {
+	// lastNow is the latest time now handed out
+	lastNow time.Time
+
+	users                   map[uuid.UUID]database.User
+	oauthIdentities         map[namedKey]database.OauthIdentity
+	webauthnCredentials     map[string]database.WebauthnCredential
+	webauthnChallenges      map[string]database.WebauthnChallenge
+	dataExports             map[uuid.UUID]database.DataExport
+	chirps                  map[uuid.UUID]database.Chirp
+	chirpLikes              map[pairKey]database.ChirpLike
+	chirpHashtags           map[idKey]database.ChirpHashtag
+	hashtagFollows          map[idKey]database.HashtagFollow
+	mentions                map[pairKey]database.Mention
+	chirpTranslations       map[idKey]database.ChirpTranslation
+	bookmarks               map[pairKey]database.Bookmark
+	refreshTokens           map[string]database.RefreshToken
+	revokedAccessTokens     map[string]database.RevokedAccessToken
+	logins                  map[uuid.UUID]database.Login
+	magicLinkTokens         map[string]database.MagicLinkToken
+	emailVerificationTokens map[string]database.EmailVerificationToken
+	follows                 map[pairKey]database.Follow
+	mutes                   map[pairKey]database.Mute
+	blocks                  map[pairKey]database.Block
+	lists                   map[uuid.UUID]database.List
+	listMembers             map[pairKey]database.ListMember
+	conversations           map[uuid.UUID]database.Conversation
+	messages                map[uuid.UUID]database.Message
+	notifications           map[uuid.UUID]database.Notification
+	plans                   map[string]database.Plan
+	planFeatures            map[namedKey]database.PlanFeature
+	subscriptions           map[uuid.UUID]database.Subscription
+	subscriptionEvents      map[uuid.UUID]database.SubscriptionEvent
+	promoCodes              map[string]database.PromoCode
+	promoRedemptions        map[idKey]database.PromoRedemption
+	entitlementOverrides    map[idKey]database.EntitlementOverride
+	featureFlags            map[string]database.FeatureFlag
+	stripeCustomers         map[uuid.UUID]database.StripeCustomer
+	webhookEvents           map[uuid.UUID]database.WebhookEvent
+	bannedWords             map[string]database.BannedWord
+	hitCounters             map[string]database.HitCounter
+	chirpReports            map[uuid.UUID]database.ChirpReport
+	moderationActions       map[uuid.UUID]database.ModerationAction
+	auditEvents             map[uuid.UUID]database.AuditEvent
+	experimentEvents        map[uuid.UUID]database.ExperimentEvent
+	webhookEndpoints        map[uuid.UUID]database.WebhookEndpoint
+	webhookDeliveries       map[uuid.UUID]database.WebhookDelivery
+}
+
+func newMemoryTables() *memoryTables {
+	return &memoryTables{
+		users:                   map[uuid.UUID]database.User{},
+		oauthIdentities:         map[namedKey]database.OauthIdentity{},
+		webauthnCredentials:     map[string]database.WebauthnCredential{},
+		webauthnChallenges:      map[string]database.WebauthnChallenge{},
+		dataExports:             map[uuid.UUID]database.DataExport{},
+		chirps:                  map[uuid.UUID]database.Chirp{},
+		chirpLikes:              map[pairKey]database.ChirpLike{},
+		chirpHashtags:           map[idKey]database.ChirpHashtag{},
+		hashtagFollows:          map[idKey]database.HashtagFollow{},
+		mentions:                map[pairKey]database.Mention{},
+		chirpTranslations:       map[idKey]database.ChirpTranslation{},
+		bookmarks:               map[pairKey]database.Bookmark{},
+		refreshTokens:           map[string]database.RefreshToken{},
+		revokedAccessTokens:     map[string]database.RevokedAccessToken{},
+		logins:                  map[uuid.UUID]database.Login{},
+		magicLinkTokens:         map[string]database.MagicLinkToken{},
+		emailVerificationTokens: map[string]database.EmailVerificationToken{},
+		follows:                 map[pairKey]database.Follow{},
+		mutes:                   map[pairKey]database.Mute{},
+		blocks:                  map[pairKey]database.Block{},
+		lists:                   map[uuid.UUID]database.List{},
+		listMembers:             map[pairKey]database.ListMember{},
+		conversations:           map[uuid.UUID]database.Conversation{},
+		messages:                map[uuid.UUID]database.Message{},
+		notifications:           map[uuid.UUID]database.Notification{},
+		plans:                   map[string]database.Plan{},
+		planFeatures:            map[namedKey]database.PlanFeature{},
+		subscriptions:           map[uuid.UUID]database.Subscription{},
+		subscriptionEvents:      map[uuid.UUID]database.SubscriptionEvent{},
+		promoCodes:              map[string]database.PromoCode{},
+		promoRedemptions:        map[idKey]database.PromoRedemption{},
+		entitlementOverrides:    map[idKey]database.EntitlementOverride{},
+		featureFlags:            map[string]database.FeatureFlag{},
+		stripeCustomers:         map[uuid.UUID]database.StripeCustomer{},
+		webhookEvents:           map[uuid.UUID]database.WebhookEvent{},
+		bannedWords:             map[string]database.BannedWord{},
+		hitCounters:             map[string]database.HitCounter{},
+		chirpReports:            map[uuid.UUID]database.ChirpReport{},
+		moderationActions:       map[uuid.UUID]database.ModerationAction{},
+		auditEvents:             map[uuid.UUID]database.AuditEvent{},
+		experimentEvents:        map[uuid.UUID]database.ExperimentEvent{},
+		webhookEndpoints:        map[uuid.UUID]database.WebhookEndpoint{},
+		webhookDeliveries:       map[uuid.UUID]database.WebhookDelivery{},
+	}
+}
+
+// clone copies every table for InTx to roll back to. Rows are values and
+// never changed in place, so copying the maps is enough.
+func (t *memoryTables) clone() *memoryTables {
+	return &memoryTables{
+		lastNow:                 t.lastNow,
+		users:                   maps.Clone(t.users),
+		oauthIdentities:         maps.Clone(t.oauthIdentities),
+		webauthnCredentials:     maps.Clone(t.webauthnCredentials),
+		webauthnChallenges:      maps.Clone(t.webauthnChallenges),
+		dataExports:             maps.Clone(t.dataExports),
+		chirps:                  maps.Clone(t.chirps),
+		chirpLikes:              maps.Clone(t.chirpLikes),
+		chirpHashtags:           maps.Clone(t.chirpHashtags),
+		hashtagFollows:          maps.Clone(t.hashtagFollows),
+		mentions:                maps.Clone(t.mentions),
+		chirpTranslations:       maps.Clone(t.chirpTranslations),
+		bookmarks:               maps.Clone(t.bookmarks),
+		refreshTokens:           maps.Clone(t.refreshTokens),
+		revokedAccessTokens:     maps.Clone(t.revokedAccessTokens),
+		logins:                  maps.Clone(t.logins),
+		magicLinkTokens:         maps.Clone(t.magicLinkTokens),
+		emailVerificationTokens: maps.Clone(t.emailVerificationTokens),
+		follows:                 maps.Clone(t.follows),
+		mutes:                   maps.Clone(t.mutes),
+		blocks:                  maps.Clone(t.blocks),
+		lists:                   maps.Clone(t.lists),
+		listMembers:             maps.Clone(t.listMembers),
+		conversations:           maps.Clone(t.conversations),
+		messages:                maps.Clone(t.messages),
+		notifications:           maps.Clone(t.notifications),
+		plans:                   maps.Clone(t.plans),
+		planFeatures:            maps.Clone(t.planFeatures),
+		subscriptions:           maps.Clone(t.subscriptions),
+		subscriptionEvents:      maps.Clone(t.subscriptionEvents),
+		promoCodes:              maps.Clone(t.promoCodes),
+		promoRedemptions:        maps.Clone(t.promoRedemptions),
+		entitlementOverrides:    maps.Clone(t.entitlementOverrides),
+		featureFlags:            maps.Clone(t.featureFlags),
+		stripeCustomers:         maps.Clone(t.stripeCustomers),
+		webhookEvents:           maps.Clone(t.webhookEvents),
+		bannedWords:             maps.Clone(t.bannedWords),
+		hitCounters:             maps.Clone(t.hitCounters),
+		chirpReports:            maps.Clone(t.chirpReports),
+		moderationActions:       maps.Clone(t.moderationActions),
+		auditEvents:             maps.Clone(t.auditEvents),
+		experimentEvents:        maps.Clone(t.experimentEvents),
+		webhookEndpoints:        maps.Clone(t.webhookEndpoints),
+		webhookDeliveries:       maps.Clone(t.webhookDeliveries),
+	}
+}
+
+// now stands in for NOW(), to the microsecond like a Postgres timestamp.
+// Each call is later than the last, so rows sort in the order they were
+// written even when the clock hasn't moved on.
+func (t *memoryTables) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(t.lastNow) {
+		now = t.lastNow.Add(time.Microsecond)
+	}
+	t.lastNow = now
+	return now
+}
+
+// hiddenFrom reports whether the author's chirps are kept from viewer, as
+// the hidden_authors view has it: a shadow-banned author still sees their
+// own, a deactivated one doesn't
+func (t *memoryTables) hiddenFrom(authorID uuid.UUID, viewer uuid.NullUUID) bool {
+	author, ok := t.users[authorID]
+	if !ok || (!author.DeactivatedAt.Valid && !author.ShadowBanned) {
+		return false
+	}
+	return author.DeactivatedAt.Valid || !viewer.Valid || viewer.UUID != authorID
+}
+
+// visibleTo reports whether a chirp is published, not deleted and by an
+// author who isn't hidden from viewer
+func (t *memoryTables) visibleTo(chirp database.Chirp, viewer uuid.NullUUID) bool {
+	return chirp.PublishedAt.Valid && !chirp.DeletedAt.Valid && !t.hiddenFrom(chirp.UserID, viewer)
+}
+
+// mutedOrBlocked reports whether viewer has muted the author, or either has
+// blocked the other
+func (t *memoryTables) mutedOrBlocked(viewerID, authorID uuid.UUID) bool {
+	_, muted := t.mutes[pairKey{viewerID, authorID}]
+	_, blocked := t.blocks[pairKey{viewerID, authorID}]
+	_, blockedBy := t.blocks[pairKey{authorID, viewerID}]
+	return muted || blocked || blockedBy
+}
+
+// deleteUser deletes a user along with what ON DELETE CASCADE would, and
+// clears the columns ON DELETE SET NULL would
+func (t *memoryTables) deleteUser(id uuid.UUID) {
+	for chirpID, chirp := range t.chirps {
+		if chirp.UserID == id {
+			t.deleteChirp(chirpID)
+		}
+	}
+	for listID, list := range t.lists {
+		if list.OwnerID == id {
+			t.deleteList(listID)
+		}
+	}
+	for conversationID, conversation := range t.conversations {
+		if conversation.UserAID == id || conversation.UserBID == id {
+			t.deleteConversation(conversationID)
+		}
+	}
+	for endpointID, endpoint := range t.webhookEndpoints {
+		if endpoint.UserID.Valid && endpoint.UserID.UUID == id {
+			t.deleteWebhookEndpoint(endpointID)
+		}
+	}
+	deleteWhere(t.oauthIdentities, func(row database.OauthIdentity) bool { return row.UserID == id })
+	deleteWhere(t.webauthnCredentials, func(row database.WebauthnCredential) bool { return row.UserID == id })
+	deleteWhere(t.webauthnChallenges, func(row database.WebauthnChallenge) bool {
+		return row.UserID.Valid && row.UserID.UUID == id
+	})
+	deleteWhere(t.dataExports, func(row database.DataExport) bool { return row.UserID == id })
+	deleteWhere(t.chirpLikes, func(row database.ChirpLike) bool { return row.UserID == id })
+	deleteWhere(t.hashtagFollows, func(row database.HashtagFollow) bool { return row.UserID == id })
+	deleteWhere(t.mentions, func(row database.Mention) bool { return row.UserID == id })
+	deleteWhere(t.bookmarks, func(row database.Bookmark) bool { return row.UserID == id })
+	deleteWhere(t.refreshTokens, func(row database.RefreshToken) bool { return row.UserID == id })
+	deleteWhere(t.revokedAccessTokens, func(row database.RevokedAccessToken) bool { return row.UserID == id })
+	deleteWhere(t.logins, func(row database.Login) bool { return row.UserID == id })
+	deleteWhere(t.emailVerificationTokens, func(row database.EmailVerificationToken) bool { return row.UserID == id })
+	deleteWhere(t.follows, func(row database.Follow) bool { return row.FollowerID == id || row.FolloweeID == id })
+	deleteWhere(t.mutes, func(row database.Mute) bool { return row.MuterID == id || row.MutedID == id })
+	deleteWhere(t.blocks, func(row database.Block) bool { return row.BlockerID == id || row.BlockedID == id })
+	deleteWhere(t.listMembers, func(row database.ListMember) bool { return row.UserID == id })
+	deleteWhere(t.messages, func(row database.Message) bool { return row.SenderID == id })
+	deleteWhere(t.notifications, func(row database.Notification) bool { return row.UserID == id })
+	deleteWhere(t.subscriptions, func(row database.Subscription) bool { return row.UserID == id })
+	deleteWhere(t.subscriptionEvents, func(row database.SubscriptionEvent) bool { return row.UserID == id })
+	deleteWhere(t.promoRedemptions, func(row database.PromoRedemption) bool { return row.UserID == id })
+	deleteWhere(t.entitlementOverrides, func(row database.EntitlementOverride) bool { return row.UserID == id })
+	deleteWhere(t.stripeCustomers, func(row database.StripeCustomer) bool { return row.UserID == id })
+	deleteWhere(t.chirpReports, func(row database.ChirpReport) bool { return row.ReporterID == id })
+	deleteWhere(t.moderationActions, func(row database.ModerationAction) bool { return row.UserID == id })
+	deleteWhere(t.experimentEvents, func(row database.ExperimentEvent) bool { return row.UserID == id })
+
+	for eventID, event := range t.subscriptionEvents {
+		if event.ActorUserID.Valid && event.ActorUserID.UUID == id {
+			event.ActorUserID = uuid.NullUUID{}
+			t.subscriptionEvents[eventID] = event
+		}
+	}
+	for reportID, report := range t.chirpReports {
+		if report.DecidedBy.Valid && report.DecidedBy.UUID == id {
+			report.DecidedBy = uuid.NullUUID{}
+			t.chirpReports[reportID] = report
+		}
+	}
+	for actionID, action := range t.moderationActions {
+		if action.ModeratorID.Valid && action.ModeratorID.UUID == id {
+			action.ModeratorID = uuid.NullUUID{}
+			t.moderationActions[actionID] = action
+		}
+	}
+	delete(t.users, id)
+}
+
+// deleteChirp deletes a chirp along with what hangs off it, leaving its
+// replies without a parent
+func (t *memoryTables) deleteChirp(id uuid.UUID) {
+	deleteWhere(t.chirpLikes, func(row database.ChirpLike) bool { return row.ChirpID == id })
+	deleteWhere(t.chirpHashtags, func(row database.ChirpHashtag) bool { return row.ChirpID == id })
+	deleteWhere(t.mentions, func(row database.Mention) bool { return row.ChirpID == id })
+	deleteWhere(t.chirpTranslations, func(row database.ChirpTranslation) bool { return row.ChirpID == id })
+	deleteWhere(t.bookmarks, func(row database.Bookmark) bool { return row.ChirpID == id })
+	deleteWhere(t.chirpReports, func(row database.ChirpReport) bool { return row.ChirpID == id })
+	for replyID, reply := range t.chirps {
+		if reply.ParentChirpID.Valid && reply.ParentChirpID.UUID == id {
+			reply.ParentChirpID = uuid.NullUUID{}
+			t.chirps[replyID] = reply
+		}
+	}
+	delete(t.chirps, id)
+}
+
+func (t *memoryTables) deleteList(id uuid.UUID) {
+	deleteWhere(t.listMembers, func(row database.ListMember) bool { return row.ListID == id })
+	delete(t.lists, id)
+}
+
+func (t *memoryTables) deleteConversation(id uuid.UUID) {
+	deleteWhere(t.messages, func(row database.Message) bool { return row.ConversationID == id })
+	delete(t.conversations, id)
+}
+
+func (t *memoryTables) deleteWebhookEndpoint(id uuid.UUID) {
+	deleteWhere(t.webhookDeliveries, func(row database.WebhookDelivery) bool { return row.EndpointID == id })
+	delete(t.webhookEndpoints, id)
+}
+
+// hasUser and hasChirp check a foreign key
+func (t *memoryTables) hasUser(id uuid.UUID) bool {
+	_, ok := t.users[id]
+	return ok
+}
+
+func (t *memoryTables) hasChirp(id uuid.UUID) bool {
+	_, ok := t.chirps[id]
+	return ok
+}
+
+// deleteWhere deletes the rows of table that match, returning how many
+func deleteWhere[K comparable, V any](table map[K]V, match func(V) bool) int64 {
+	var deleted int64
+	for key, row := range table {
+		if match(row) {
+			delete(table, key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// selectWhere is the rows of table that match, sorted by compare
+func selectWhere[K comparable, V any](table map[K]V, match func(V) bool, compare func(a, b V) int) []V {
+	rows := []V{}
+	for _, row := range table {
+		if match(row) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, compare)
+	return rows
+}
+
+// limit is the first n rows, as LIMIT keeps
+func limit[V any](rows []V, n int32) []V {
+	return rows[:min(len(rows), max(int(n), 0))]
+}
+
+// compareRows orders rows by a time then an ID, as ORDER BY created_at, id
+// does. Negate it for DESC.
+func compareRows(aAt time.Time, aID uuid.UUID, bAt time.Time, bID uuid.UUID) int {
+	if c := aAt.Compare(bAt); c != 0 {
+		return c
+	}
+	return bytes.Compare(aID[:], bID[:])
+}
+
+// oldestChirpFirst and newestChirpFirst order chirps by creation
+func oldestChirpFirst(a, b database.Chirp) int {
+	return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+func newestChirpFirst(a, b database.Chirp) int {
+	return -oldestChirpFirst(a, b)
+}
+
+// mostLikedChirpFirst orders chirps by likes, then newest first
+func mostLikedChirpFirst(a, b database.Chirp) int {
+	if a.LikeCount != b.LikeCount {
+		return int(b.LikeCount - a.LikeCount)
+	}
+	return newestChirpFirst(a, b)
+}
+
+// beforeCursor is a keyset pagination filter, (at, id) < (cursorAt,
+// cursorID), which lets everything through when there's no cursor.
+// afterCursor is the same for >.
+func beforeCursor(at time.Time, id uuid.UUID, cursorAt sql.NullTime, cursorID uuid.NullUUID) bool {
+	return !cursorAt.Valid || compareCursor(at, id, cursorAt.Time, cursorID) < 0
+}
+
+func afterCursor(at time.Time, id uuid.UUID, cursorAt sql.NullTime, cursorID uuid.NullUUID) bool {
+	return !cursorAt.Valid || compareCursor(at, id, cursorAt.Time, cursorID) > 0
+}
+
+// compareCursor compares a row against a cursor. Like a row comparison in
+// SQL, a NULL ID only decides anything when the times differ.
+func compareCursor(at time.Time, id uuid.UUID, cursorAt time.Time, cursorID uuid.NullUUID) int {
+	if c := at.Compare(cursorAt); c != 0 || !cursorID.Valid {
+		return c
+	}
+	return bytes.Compare(id[:], cursorID.UUID[:])
+}
+
+// inRange is a created_from/created_to filter, either end of which may be
+// open
+func inRange(at time.Time, from, to sql.NullTime) bool {
+	return (!from.Valid || !at.Before(from.Time)) && (!to.Valid || at.Before(to.Time))
+}
+
+// optional is a sqlc.narg filter, which matches everything when unset
+func optional[T comparable](value T, filter T, set bool) bool {
+	return !set || value == filter
+}
+
+// like matches s against a LIKE pattern, where % is any run of characters
+// and _ any one character
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return s != "" && like(s[1:], pattern[1:])
+	}
+	prefix, _, _ := strings.Cut(pattern, "%")
+	if i := strings.IndexByte(prefix, '_'); i >= 0 {
+		prefix = prefix[:i]
+	}
+	return strings.HasPrefix(s, prefix) && like(s[len(prefix):], pattern[len(prefix):])
+}
@@ -0,0 +1,250 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) AddBannedWord(ctx context.Context, word string) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.bannedWords[word]; ok {
+		return 0, nil
+	}
+	t.bannedWords[word] = database.BannedWord{Word: word, CreatedAt: t.now()}
+	return 1, nil
+}
+
+func (m *Memory) DeleteBannedWord(ctx context.Context, word string) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.bannedWords, word), nil
+}
+
+func (m *Memory) GetBannedWords(ctx context.Context) ([]string, error) {
+	defer m.read()()
+	words := []string{}
+	for word := range m.tables.bannedWords {
+		words = append(words, word)
+	}
+	slices.Sort(words)
+	return words, nil
+}
+
+func (m *Memory) AddHitCount(ctx context.Context, arg database.AddHitCountParams) error {
+	defer m.write()()
+	t := m.tables
+	counter := t.hitCounters[arg.Name]
+	counter.Name = arg.Name
+	counter.Count += arg.Count
+	counter.UpdatedAt = t.now()
+	t.hitCounters[arg.Name] = counter
+	return nil
+}
+
+func (m *Memory) GetHitCounters(ctx context.Context) ([]database.GetHitCountersRow, error) {
+	defer m.read()()
+	counters := []database.GetHitCountersRow{}
+	for _, counter := range m.tables.hitCounters {
+		counters = append(counters, database.GetHitCountersRow{Name: counter.Name, Count: counter.Count})
+	}
+	return counters, nil
+}
+
+func (m *Memory) ResetHitCounters(ctx context.Context) error {
+	defer m.write()()
+	clear(m.tables.hitCounters)
+	return nil
+}
+
+func (m *Memory) CreateChirpReport(ctx context.Context, arg database.CreateChirpReportParams) (database.ChirpReport, error) {
+	defer m.write()()
+	t := m.tables
+	for _, report := range t.chirpReports {
+		if report.ReporterID == arg.ReporterID && report.ChirpID == arg.ChirpID && report.Status == "open" {
+			return database.ChirpReport{}, sql.ErrNoRows
+		}
+	}
+	if !t.hasUser(arg.ReporterID) || !t.hasChirp(arg.ChirpID) {
+		return database.ChirpReport{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	report := database.ChirpReport{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ReporterID: arg.ReporterID,
+		ChirpID:    arg.ChirpID,
+		Reason:     arg.Reason,
+		Details:    arg.Details,
+		Status:     "open",
+	}
+	t.chirpReports[report.ID] = report
+	return report, nil
+}
+
+func (m *Memory) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) || (arg.ModeratorID.Valid && !t.hasUser(arg.ModeratorID.UUID)) {
+		return database.ModerationAction{}, ErrForeignKeyViolation
+	}
+	action := database.ModerationAction{
+		ID:          uuid.New(),
+		CreatedAt:   t.now(),
+		ModeratorID: arg.ModeratorID,
+		Action:      arg.Action,
+		ChirpID:     arg.ChirpID,
+		UserID:      arg.UserID,
+		Reason:      arg.Reason,
+	}
+	t.moderationActions[action.ID] = action
+	return action, nil
+}
+
+func (m *Memory) GetChirpReport(ctx context.Context, id uuid.UUID) (database.ChirpReport, error) {
+	defer m.read()()
+	report, ok := m.tables.chirpReports[id]
+	if !ok {
+		return database.ChirpReport{}, sql.ErrNoRows
+	}
+	return report, nil
+}
+
+// oldestReportFirst orders reports as the queue is worked through
+func oldestReportFirst(a, b database.ChirpReport) int {
+	return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+func (m *Memory) GetChirpReportsForChirp(ctx context.Context, chirpID uuid.UUID) ([]database.ChirpReport, error) {
+	defer m.read()()
+	return selectWhere(m.tables.chirpReports, func(report database.ChirpReport) bool {
+		return report.ChirpID == chirpID
+	}, oldestReportFirst), nil
+}
+
+func (m *Memory) ListChirpReports(ctx context.Context, arg database.ListChirpReportsParams) ([]database.ChirpReport, error) {
+	defer m.read()()
+	reports := selectWhere(m.tables.chirpReports, func(report database.ChirpReport) bool {
+		return optional(report.Status, arg.Status.String, arg.Status.Valid) &&
+			optional(report.Reason, arg.Reason.String, arg.Reason.Valid) &&
+			afterCursor(report.CreatedAt, report.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, oldestReportFirst)
+	return limit(reports, arg.Limit), nil
+}
+
+func (m *Memory) ListModerationActions(ctx context.Context, arg database.ListModerationActionsParams) ([]database.ModerationAction, error) {
+	defer m.read()()
+	actions := selectWhere(m.tables.moderationActions, func(action database.ModerationAction) bool {
+		return optional(action.UserID, arg.UserID.UUID, arg.UserID.Valid) &&
+			optional(action.ModeratorID, arg.ModeratorID, arg.ModeratorID.Valid) &&
+			beforeCursor(action.CreatedAt, action.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.ModerationAction) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(actions, arg.Limit), nil
+}
+
+func (m *Memory) ResolveChirpReports(ctx context.Context, arg database.ResolveChirpReportsParams) ([]database.ChirpReport, error) {
+	defer m.write()()
+	t := m.tables
+	if arg.DecidedBy.Valid && !t.hasUser(arg.DecidedBy.UUID) {
+		return nil, ErrForeignKeyViolation
+	}
+	now := t.now()
+	resolved := selectWhere(t.chirpReports, func(report database.ChirpReport) bool {
+		return report.ChirpID == arg.ChirpID && report.Status == "open"
+	}, oldestReportFirst)
+	for i := range resolved {
+		report := &resolved[i]
+		report.Status = "resolved"
+		report.Decision = sql.NullString{String: arg.Decision, Valid: true}
+		report.DecisionNote = arg.DecisionNote
+		report.DecidedBy = arg.DecidedBy
+		report.DecidedAt = sql.NullTime{Time: now, Valid: true}
+		report.UpdatedAt = now
+		t.chirpReports[report.ID] = *report
+	}
+	return resolved, nil
+}
+
+func (m *Memory) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	defer m.write()()
+	t := m.tables
+	event := database.AuditEvent{
+		ID:        uuid.New(),
+		CreatedAt: t.now(),
+		Action:    arg.Action,
+		UserID:    arg.UserID,
+		ActorID:   arg.ActorID,
+		IpAddress: arg.IpAddress,
+		UserAgent: arg.UserAgent,
+		Details:   arg.Details,
+	}
+	t.auditEvents[event.ID] = event
+	return nil
+}
+
+func (m *Memory) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	defer m.read()()
+	events := selectWhere(m.tables.auditEvents, func(event database.AuditEvent) bool {
+		return optional(event.UserID, arg.UserID, arg.UserID.Valid) &&
+			optional(event.Action, arg.Action.String, arg.Action.Valid) &&
+			inRange(event.CreatedAt, arg.CreatedFrom, arg.CreatedTo) &&
+			beforeCursor(event.CreatedAt, event.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.AuditEvent) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(events, arg.Limit), nil
+}
+
+func (m *Memory) CreateExperimentEvent(ctx context.Context, arg database.CreateExperimentEventParams) error {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	event := database.ExperimentEvent{
+		ID:            uuid.New(),
+		CreatedAt:     t.now(),
+		ExperimentKey: arg.ExperimentKey,
+		Variant:       arg.Variant,
+		UserID:        arg.UserID,
+		Kind:          arg.Kind,
+		Name:          arg.Name,
+	}
+	t.experimentEvents[event.ID] = event
+	return nil
+}
+
+func (m *Memory) GetExperimentResults(ctx context.Context, experimentKey string) ([]database.GetExperimentResultsRow, error) {
+	defer m.read()()
+	type group struct{ variant, kind, name string }
+	results := map[group]*database.GetExperimentResultsRow{}
+	users := map[group]map[uuid.UUID]bool{}
+	for _, event := range m.tables.experimentEvents {
+		if event.ExperimentKey != experimentKey {
+			continue
+		}
+		key := group{event.Variant, event.Kind, event.Name}
+		if results[key] == nil {
+			results[key] = &database.GetExperimentResultsRow{Variant: event.Variant, Kind: event.Kind, Name: event.Name}
+			users[key] = map[uuid.UUID]bool{}
+		}
+		results[key].Events++
+		users[key][event.UserID] = true
+	}
+	rows := make([]database.GetExperimentResultsRow, 0, len(results))
+	for key, row := range results {
+		row.Users = int64(len(users[key]))
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b database.GetExperimentResultsRow) int {
+		return cmp.Or(cmp.Compare(a.Variant, b.Variant), cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return rows, nil
+}
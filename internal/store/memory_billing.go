@@ -0,0 +1,440 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) GetPlanByID(ctx context.Context, id string) (database.Plan, error) {
+	defer m.read()()
+	plan, ok := m.tables.plans[id]
+	if !ok {
+		return database.Plan{}, sql.ErrNoRows
+	}
+	return plan, nil
+}
+
+func (m *Memory) GetPlanForUser(ctx context.Context, id uuid.UUID) (database.Plan, error) {
+	defer m.read()()
+	t := m.tables
+	user, ok := t.users[id]
+	if !ok {
+		return database.Plan{}, sql.ErrNoRows
+	}
+	return t.plans[user.Plan], nil
+}
+
+func (m *Memory) GetPlans(ctx context.Context) ([]database.Plan, error) {
+	defer m.read()()
+	return selectWhere(m.tables.plans, func(database.Plan) bool { return true }, func(a, b database.Plan) int {
+		return cmp.Compare(a.MaxChirpLength, b.MaxChirpLength)
+	}), nil
+}
+
+// updateSubscription changes the user's subscription if it has one that
+// matches
+func (m *Memory) updateSubscription(userID uuid.UUID, match func(database.Subscription) bool, change func(*database.Subscription)) int64 {
+	t := m.tables
+	subscription, ok := t.subscriptions[userID]
+	if !ok || !match(subscription) {
+		return 0
+	}
+	change(&subscription)
+	subscription.UpdatedAt = t.now()
+	t.subscriptions[userID] = subscription
+	return 1
+}
+
+func (m *Memory) CancelSubscription(ctx context.Context, userID uuid.UUID) error {
+	defer m.write()()
+	m.updateSubscription(userID, func(subscription database.Subscription) bool {
+		return subscription.Status == "active"
+	}, func(subscription *database.Subscription) {
+		subscription.Status = "canceled"
+		subscription.ExpiresAt = m.tables.now()
+	})
+	return nil
+}
+
+func (m *Memory) ClaimSubscriptionGift(ctx context.Context, userID uuid.UUID) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return m.updateSubscription(userID, func(subscription database.Subscription) bool {
+		return subscription.GiftAvailable && subscription.Status == "active" && subscription.ExpiresAt.After(now)
+	}, func(subscription *database.Subscription) {
+		subscription.GiftAvailable = false
+	}), nil
+}
+
+func (m *Memory) ExpireSubscription(ctx context.Context, userID uuid.UUID) error {
+	defer m.write()()
+	m.updateSubscription(userID, func(database.Subscription) bool { return true }, func(subscription *database.Subscription) {
+		subscription.Status = "expired"
+		subscription.GiftAvailable = false
+	})
+	return nil
+}
+
+func (m *Memory) GetLapsedSubscriptions(ctx context.Context) ([]uuid.UUID, error) {
+	defer m.read()()
+	now := time.Now()
+	lapsed := selectWhere(m.tables.subscriptions, func(subscription database.Subscription) bool {
+		return subscription.Status == "active" && !subscription.ExpiresAt.After(now)
+	}, func(a, b database.Subscription) int {
+		return compareRows(a.ExpiresAt, a.UserID, b.ExpiresAt, b.UserID)
+	})
+	userIDs := make([]uuid.UUID, 0, len(lapsed))
+	for _, subscription := range lapsed {
+		userIDs = append(userIDs, subscription.UserID)
+	}
+	return userIDs, nil
+}
+
+func (m *Memory) GetSubscriptionByUserID(ctx context.Context, userID uuid.UUID) (database.Subscription, error) {
+	defer m.read()()
+	subscription, ok := m.tables.subscriptions[userID]
+	if !ok {
+		return database.Subscription{}, sql.ErrNoRows
+	}
+	return subscription, nil
+}
+
+// LockSubscription needs no lock of its own: a transaction holds the write
+// lock throughout
+func (m *Memory) LockSubscription(ctx context.Context, userID uuid.UUID) (database.Subscription, error) {
+	return m.GetSubscriptionByUserID(ctx, userID)
+}
+
+func (m *Memory) UpsertSubscription(ctx context.Context, arg database.UpsertSubscriptionParams) (database.Subscription, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.plans[arg.Plan]; !ok || !t.hasUser(arg.UserID) {
+		return database.Subscription{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	subscription, ok := t.subscriptions[arg.UserID]
+	if !ok {
+		subscription = database.Subscription{UserID: arg.UserID, CreatedAt: now}
+	}
+	subscription.UpdatedAt = now
+	subscription.Plan = arg.Plan
+	subscription.Status = "active"
+	subscription.ExpiresAt = arg.ExpiresAt
+	subscription.GiftAvailable = arg.GiftAvailable
+	t.subscriptions[arg.UserID] = subscription
+	return subscription, nil
+}
+
+func (m *Memory) CreateSubscriptionEvent(ctx context.Context, arg database.CreateSubscriptionEventParams) (database.SubscriptionEvent, error) {
+	defer m.write()()
+	t := m.tables
+	_, webhookEvent := t.webhookEvents[arg.WebhookEventID.UUID]
+	if !t.hasUser(arg.UserID) || (arg.ActorUserID.Valid && !t.hasUser(arg.ActorUserID.UUID)) ||
+		(arg.WebhookEventID.Valid && !webhookEvent) {
+		return database.SubscriptionEvent{}, ErrForeignKeyViolation
+	}
+	event := database.SubscriptionEvent{
+		ID:             uuid.New(),
+		CreatedAt:      t.now(),
+		UserID:         arg.UserID,
+		Kind:           arg.Kind,
+		Source:         arg.Source,
+		WebhookEventID: arg.WebhookEventID,
+		ActorUserID:    arg.ActorUserID,
+		PromoCode:      arg.PromoCode,
+	}
+	t.subscriptionEvents[event.ID] = event
+	return event, nil
+}
+
+func (m *Memory) GetSubscriptionEventsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetSubscriptionEventsForUserRow, error) {
+	defer m.read()()
+	t := m.tables
+	events := selectWhere(t.subscriptionEvents, func(event database.SubscriptionEvent) bool {
+		return event.UserID == userID
+	}, func(a, b database.SubscriptionEvent) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	rows := make([]database.GetSubscriptionEventsForUserRow, 0, len(events))
+	for _, event := range events {
+		row := database.GetSubscriptionEventsForUserRow{
+			ID:          event.ID,
+			CreatedAt:   event.CreatedAt,
+			Kind:        event.Kind,
+			Source:      event.Source,
+			ActorUserID: event.ActorUserID,
+			PromoCode:   event.PromoCode,
+		}
+		if webhookEvent, ok := t.webhookEvents[event.WebhookEventID.UUID]; event.WebhookEventID.Valid && ok {
+			row.WebhookEventType = sql.NullString{String: webhookEvent.EventType, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (m *Memory) ClaimPromoCode(ctx context.Context, code string) (database.PromoCode, error) {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	promo, ok := t.promoCodes[code]
+	if !ok || promo.RedemptionCount >= promo.MaxRedemptions || (promo.ExpiresAt.Valid && !promo.ExpiresAt.Time.After(now)) {
+		return database.PromoCode{}, sql.ErrNoRows
+	}
+	promo.RedemptionCount++
+	promo.UpdatedAt = now
+	t.promoCodes[code] = promo
+	return promo, nil
+}
+
+func (m *Memory) CreatePromoCode(ctx context.Context, arg database.CreatePromoCodeParams) (database.PromoCode, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.promoCodes[arg.Code]; ok {
+		return database.PromoCode{}, ErrUniqueViolation
+	}
+	if _, ok := t.plans[arg.Plan]; !ok {
+		return database.PromoCode{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	promo := database.PromoCode{
+		Code:           arg.Code,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Plan:           arg.Plan,
+		DurationDays:   arg.DurationDays,
+		MaxRedemptions: arg.MaxRedemptions,
+		ExpiresAt:      arg.ExpiresAt,
+	}
+	t.promoCodes[promo.Code] = promo
+	return promo, nil
+}
+
+func (m *Memory) CreatePromoRedemption(ctx context.Context, arg database.CreatePromoRedemptionParams) (database.PromoRedemption, error) {
+	defer m.write()()
+	t := m.tables
+	key := idKey{arg.UserID, arg.Code}
+	if _, ok := t.promoRedemptions[key]; ok {
+		return database.PromoRedemption{}, sql.ErrNoRows
+	}
+	if _, ok := t.promoCodes[arg.Code]; !ok || !t.hasUser(arg.UserID) {
+		return database.PromoRedemption{}, ErrForeignKeyViolation
+	}
+	redemption := database.PromoRedemption{Code: arg.Code, UserID: arg.UserID, CreatedAt: t.now()}
+	t.promoRedemptions[key] = redemption
+	return redemption, nil
+}
+
+func (m *Memory) GetPromoCodes(ctx context.Context) ([]database.PromoCode, error) {
+	defer m.read()()
+	return selectWhere(m.tables.promoCodes, func(database.PromoCode) bool { return true }, func(a, b database.PromoCode) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.Code, b.Code))
+	}), nil
+}
+
+func (m *Memory) DeleteEntitlementOverride(ctx context.Context, arg database.DeleteEntitlementOverrideParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.entitlementOverrides, idKey{arg.UserID, arg.Feature}), nil
+}
+
+func (m *Memory) GetEntitlementOverrides(ctx context.Context, userID uuid.UUID) ([]database.EntitlementOverride, error) {
+	defer m.read()()
+	return selectWhere(m.tables.entitlementOverrides, func(override database.EntitlementOverride) bool {
+		return override.UserID == userID
+	}, func(a, b database.EntitlementOverride) int {
+		return cmp.Compare(a.Feature, b.Feature)
+	}), nil
+}
+
+func (m *Memory) GetFeatureFlags(ctx context.Context) ([]database.FeatureFlag, error) {
+	defer m.read()()
+	return selectWhere(m.tables.featureFlags, func(database.FeatureFlag) bool { return true }, func(a, b database.FeatureFlag) int {
+		return cmp.Compare(a.Feature, b.Feature)
+	}), nil
+}
+
+func (m *Memory) GetPlanFeatures(ctx context.Context, planID string) ([]string, error) {
+	defer m.read()()
+	features := []string{}
+	for _, feature := range m.tables.planFeatures {
+		if feature.PlanID == planID {
+			features = append(features, feature.Feature)
+		}
+	}
+	slices.Sort(features)
+	return features, nil
+}
+
+func (m *Memory) UpsertEntitlementOverride(ctx context.Context, arg database.UpsertEntitlementOverrideParams) (database.EntitlementOverride, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return database.EntitlementOverride{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	key := idKey{arg.UserID, arg.Feature}
+	override, ok := t.entitlementOverrides[key]
+	if !ok {
+		override = database.EntitlementOverride{UserID: arg.UserID, Feature: arg.Feature, CreatedAt: now}
+	}
+	override.UpdatedAt = now
+	override.Enabled = arg.Enabled
+	t.entitlementOverrides[key] = override
+	return override, nil
+}
+
+func (m *Memory) UpsertFeatureFlag(ctx context.Context, arg database.UpsertFeatureFlagParams) (database.FeatureFlag, error) {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	flag, ok := t.featureFlags[arg.Feature]
+	if !ok {
+		flag = database.FeatureFlag{Feature: arg.Feature, CreatedAt: now}
+	}
+	flag.UpdatedAt = now
+	flag.Enabled = arg.Enabled
+	t.featureFlags[arg.Feature] = flag
+	return flag, nil
+}
+
+func (m *Memory) GetStripeCustomerID(ctx context.Context, userID uuid.UUID) (string, error) {
+	defer m.read()()
+	customer, ok := m.tables.stripeCustomers[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return customer.CustomerID, nil
+}
+
+func (m *Memory) LinkStripeCustomer(ctx context.Context, arg database.LinkStripeCustomerParams) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return 0, nil
+	}
+	customer, ok := t.stripeCustomers[arg.UserID]
+	if !ok {
+		customer = database.StripeCustomer{UserID: arg.UserID, CreatedAt: t.now()}
+	}
+	customer.CustomerID = arg.CustomerID
+	t.stripeCustomers[arg.UserID] = customer
+	return 1, nil
+}
+
+// updateWebhookEvent changes a webhook event, returning sql.ErrNoRows if
+// there's none that matches
+func (m *Memory) updateWebhookEvent(id uuid.UUID, match func(database.WebhookEvent) bool, change func(*database.WebhookEvent)) (database.WebhookEvent, error) {
+	t := m.tables
+	event, ok := t.webhookEvents[id]
+	if !ok || !match(event) {
+		return database.WebhookEvent{}, sql.ErrNoRows
+	}
+	change(&event)
+	event.UpdatedAt = t.now()
+	t.webhookEvents[id] = event
+	return event, nil
+}
+
+func (m *Memory) ClaimWebhookEvent(ctx context.Context, arg database.ClaimWebhookEventParams) (database.WebhookEvent, error) {
+	defer m.write()()
+	return m.updateWebhookEvent(arg.ID, func(event database.WebhookEvent) bool {
+		return event.Status != "processed" || arg.Reprocess
+	}, func(*database.WebhookEvent) {})
+}
+
+func (m *Memory) CreateWebhookEvent(ctx context.Context, arg database.CreateWebhookEventParams) (database.WebhookEvent, error) {
+	defer m.write()()
+	t := m.tables
+	for _, event := range t.webhookEvents {
+		if event.Source == arg.Source && event.EventID == arg.EventID {
+			return database.WebhookEvent{}, sql.ErrNoRows
+		}
+	}
+	now := t.now()
+	event := database.WebhookEvent{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Source:    arg.Source,
+		EventID:   arg.EventID,
+		EventType: arg.EventType,
+		Payload:   arg.Payload,
+		Status:    "pending",
+	}
+	t.webhookEvents[event.ID] = event
+	return event, nil
+}
+
+func (m *Memory) DeleteProcessedWebhookEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	deleted := deleteWhere(t.webhookEvents, func(event database.WebhookEvent) bool {
+		return event.Status == "processed" && event.ProcessedAt.Valid && event.ProcessedAt.Time.Before(before)
+	})
+	for id, event := range t.subscriptionEvents {
+		if _, ok := t.webhookEvents[event.WebhookEventID.UUID]; event.WebhookEventID.Valid && !ok {
+			event.WebhookEventID = uuid.NullUUID{}
+			t.subscriptionEvents[id] = event
+		}
+	}
+	return deleted, nil
+}
+
+func (m *Memory) GetWebhookEventByID(ctx context.Context, id uuid.UUID) (database.WebhookEvent, error) {
+	defer m.read()()
+	event, ok := m.tables.webhookEvents[id]
+	if !ok {
+		return database.WebhookEvent{}, sql.ErrNoRows
+	}
+	return event, nil
+}
+
+func (m *Memory) GetWebhookEventBySourceAndEventID(ctx context.Context, arg database.GetWebhookEventBySourceAndEventIDParams) (database.WebhookEvent, error) {
+	defer m.read()()
+	for _, event := range m.tables.webhookEvents {
+		if event.Source == arg.Source && event.EventID == arg.EventID {
+			return event, nil
+		}
+	}
+	return database.WebhookEvent{}, sql.ErrNoRows
+}
+
+func (m *Memory) ListWebhookEvents(ctx context.Context, arg database.ListWebhookEventsParams) ([]database.WebhookEvent, error) {
+	defer m.read()()
+	events := selectWhere(m.tables.webhookEvents, func(event database.WebhookEvent) bool {
+		return optional(event.Status, arg.Status.String, arg.Status.Valid) &&
+			optional(event.Source, arg.Source.String, arg.Source.Valid) &&
+			optional(event.EventType, arg.EventType.String, arg.EventType.Valid) &&
+			inRange(event.CreatedAt, arg.CreatedFrom, arg.CreatedTo)
+	}, func(a, b database.WebhookEvent) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(events, arg.Limit), nil
+}
+
+func (m *Memory) MarkWebhookEventFailed(ctx context.Context, arg database.MarkWebhookEventFailedParams) error {
+	defer m.write()()
+	_, err := m.updateWebhookEvent(arg.ID, func(database.WebhookEvent) bool { return true }, func(event *database.WebhookEvent) {
+		event.Status = "failed"
+		event.Error = arg.Error
+		event.Attempts++
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) MarkWebhookEventProcessed(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	_, err := m.updateWebhookEvent(id, func(database.WebhookEvent) bool { return true }, func(event *database.WebhookEvent) {
+		event.Status = "processed"
+		event.Error = sql.NullString{}
+		event.Attempts++
+		event.ProcessedAt = sql.NullTime{Time: m.tables.now(), Valid: true}
+	})
+	return ignoreNoRows(err)
+}
@@ -0,0 +1,555 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) AddChirpViews(ctx context.Context, arg database.AddChirpViewsParams) error {
+	defer m.write()()
+	t := m.tables
+	for i, id := range arg.Ids {
+		chirp, ok := t.chirps[id]
+		if !ok || i >= len(arg.Counts) {
+			continue
+		}
+		chirp.ViewCount += arg.Counts[i]
+		t.chirps[id] = chirp
+	}
+	return nil
+}
+
+// updateChirp changes a chirp with change and returns the changed row
+func (m *Memory) updateChirp(id uuid.UUID, change func(*database.Chirp)) (database.Chirp, error) {
+	t := m.tables
+	chirp, ok := t.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	change(&chirp)
+	t.chirps[id] = chirp
+	return chirp, nil
+}
+
+func (m *Memory) AdjustChirpLikeCount(ctx context.Context, arg database.AdjustChirpLikeCountParams) (database.Chirp, error) {
+	defer m.write()()
+	return m.updateChirp(arg.ID, func(chirp *database.Chirp) { chirp.LikeCount += arg.Delta })
+}
+
+func (m *Memory) AdjustChirpReplyCount(ctx context.Context, arg database.AdjustChirpReplyCountParams) error {
+	defer m.write()()
+	_, err := m.updateChirp(arg.ID, func(chirp *database.Chirp) {
+		chirp.ReplyCount = max(chirp.ReplyCount+arg.Delta, 0)
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) || (arg.ParentChirpID.Valid && !t.hasChirp(arg.ParentChirpID.UUID)) {
+		return database.Chirp{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	chirp := database.Chirp{
+		ID:            uuid.New(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Body:          arg.Body,
+		UserID:        arg.UserID,
+		PublishAt:     arg.PublishAt,
+		PublishedAt:   arg.PublishedAt,
+		Latitude:      arg.Latitude,
+		Longitude:     arg.Longitude,
+		Geohash:       arg.Geohash,
+		Place:         arg.Place,
+		ParentChirpID: arg.ParentChirpID,
+	}
+	t.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (m *Memory) DeleteChirpsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer m.write()()
+	t := m.tables
+	ids := []uuid.UUID{}
+	for id, chirp := range t.chirps {
+		if chirp.UserID == userID {
+			t.deleteChirp(id)
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *Memory) ExportChirps(ctx context.Context, arg database.ExportChirpsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := selectWhere(m.tables.chirps, func(chirp database.Chirp) bool {
+		return m.tables.visibleTo(chirp, uuid.NullUUID{}) && inRange(chirp.CreatedAt, arg.CreatedFrom, arg.CreatedTo) &&
+			afterCursor(chirp.CreatedAt, chirp.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, oldestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+// visibleChirps are the chirps visible to viewer that also match, sorted
+// by compare
+func (m *Memory) visibleChirps(viewer uuid.NullUUID, match func(database.Chirp) bool, compare func(a, b database.Chirp) int) []database.Chirp {
+	return selectWhere(m.tables.chirps, func(chirp database.Chirp) bool {
+		return m.tables.visibleTo(chirp, viewer) && match(chirp)
+	}, compare)
+}
+
+// anyChirp matches every chirp
+func anyChirp(database.Chirp) bool {
+	return true
+}
+
+func (m *Memory) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(viewerID, anyChirp, oldestChirpFirst), nil
+}
+
+func (m *Memory) GetAllChirpsDesc(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(viewerID, anyChirp, newestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	defer m.read()()
+	t := m.tables
+	chirp, ok := t.chirps[id]
+	if !ok || chirp.DeletedAt.Valid || t.users[chirp.UserID].DeactivatedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (m *Memory) GetChirpForModeration(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	defer m.read()()
+	chirp, ok := m.tables.chirps[id]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (m *Memory) GetChirpIDsByAuthor(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer m.read()()
+	ids := []uuid.UUID{}
+	for id, chirp := range m.tables.chirps {
+		if chirp.UserID == userID && !chirp.DeletedAt.Valid {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *Memory) GetChirpReplies(ctx context.Context, arg database.GetChirpRepliesParams) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return arg.ParentChirpID.Valid && chirp.ParentChirpID == arg.ParentChirpID
+	}, oldestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpTotalsForUser(ctx context.Context, userID uuid.UUID) (database.GetChirpTotalsForUserRow, error) {
+	defer m.read()()
+	totals := database.GetChirpTotalsForUserRow{}
+	for _, chirp := range m.tables.chirps {
+		if chirp.UserID != userID || !chirp.PublishedAt.Valid || chirp.DeletedAt.Valid {
+			continue
+		}
+		totals.ChirpCount++
+		totals.ViewCount += chirp.ViewCount
+		totals.LikeCount += int64(chirp.LikeCount)
+		totals.ReplyCount += int64(chirp.ReplyCount)
+	}
+	return totals, nil
+}
+
+func (m *Memory) GetChirpsByAuthor(ctx context.Context, arg database.GetChirpsByAuthorParams) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return chirp.UserID == arg.UserID
+	}, oldestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpsByAuthorDesc(ctx context.Context, arg database.GetChirpsByAuthorDescParams) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return chirp.UserID == arg.UserID
+	}, newestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpsByIDs(ctx context.Context, arg database.GetChirpsByIDsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	return m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return slices.Contains(arg.Ids, chirp.ID)
+	}, oldestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpsForExport(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	defer m.read()()
+	return selectWhere(m.tables.chirps, func(chirp database.Chirp) bool {
+		return chirp.UserID == userID && !chirp.DeletedAt.Valid
+	}, oldestChirpFirst), nil
+}
+
+func (m *Memory) GetChirpsInCells(ctx context.Context, arg database.GetChirpsInCellsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return chirp.Geohash.Valid && slices.ContainsFunc(arg.Prefixes, func(prefix string) bool {
+			return like(chirp.Geohash.String, prefix)
+		})
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) GetChirpsPage(ctx context.Context, arg database.GetChirpsPageParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return optional(chirp.UserID, arg.AuthorID.UUID, arg.AuthorID.Valid) &&
+			afterCursor(chirp.CreatedAt, chirp.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, oldestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) GetChirpsPageDesc(ctx context.Context, arg database.GetChirpsPageDescParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return optional(chirp.UserID, arg.AuthorID.UUID, arg.AuthorID.Valid) &&
+			beforeCursor(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) GetChirpsPublishedAfter(ctx context.Context, arg database.GetChirpsPublishedAfterParams) ([]database.Chirp, error) {
+	defer m.read()()
+	after := sql.NullTime{Time: arg.PublishedAt, Valid: true}
+	chirps := m.visibleChirps(uuid.NullUUID{}, func(chirp database.Chirp) bool {
+		return afterCursor(chirp.PublishedAt.Time, chirp.ID, after, uuid.NullUUID{UUID: arg.ID, Valid: true})
+	}, func(a, b database.Chirp) int {
+		return compareRows(a.PublishedAt.Time, a.ID, b.PublishedAt.Time, b.ID)
+	})
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) IsChirpAuthorHidden(ctx context.Context, arg database.IsChirpAuthorHiddenParams) (bool, error) {
+	defer m.read()()
+	return m.tables.hiddenFrom(arg.AuthorID, arg.ViewerID), nil
+}
+
+func (m *Memory) PublishDueChirps(ctx context.Context) ([]database.PublishDueChirpsRow, error) {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	due := selectWhere(t.chirps, func(chirp database.Chirp) bool {
+		return !chirp.PublishedAt.Valid && !chirp.DeletedAt.Valid && !chirp.PublishAt.After(now)
+	}, oldestChirpFirst)
+	published := make([]database.PublishDueChirpsRow, 0, len(due))
+	for _, chirp := range due {
+		chirp.PublishedAt = sql.NullTime{Time: now, Valid: true}
+		t.chirps[chirp.ID] = chirp
+		published = append(published, database.PublishDueChirpsRow(chirp))
+	}
+	for _, chirp := range due {
+		if parent, ok := t.chirps[chirp.ParentChirpID.UUID]; chirp.ParentChirpID.Valid && ok {
+			parent.ReplyCount++
+			t.chirps[parent.ID] = parent
+		}
+	}
+	return published, nil
+}
+
+func (m *Memory) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	var purged int64
+	for id, chirp := range t.chirps {
+		if chirp.DeletedAt.Valid && (!deletedBefore.Valid || chirp.DeletedAt.Time.Before(deletedBefore.Time)) {
+			t.deleteChirp(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *Memory) ReleaseChirpRepliesByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer m.write()()
+	t := m.tables
+	released := map[uuid.UUID]int32{}
+	for _, reply := range t.chirps {
+		if reply.UserID == userID && reply.PublishedAt.Valid && !reply.DeletedAt.Valid && reply.ParentChirpID.Valid {
+			released[reply.ParentChirpID.UUID]++
+		}
+	}
+	ids := []uuid.UUID{}
+	for id, count := range released {
+		parent, ok := t.chirps[id]
+		if !ok || parent.UserID == userID {
+			continue
+		}
+		parent.ReplyCount -= count
+		t.chirps[id] = parent
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *Memory) SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		return chirptext.MatchesSearch(chirp.Body, arg.Query) &&
+			optional(chirp.UserID, arg.AuthorID.UUID, arg.AuthorID.Valid) &&
+			beforeCursor(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) SoftDeleteChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	defer m.write()()
+	t := m.tables
+	chirp, ok := t.chirps[id]
+	if !ok || chirp.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	now := t.now()
+	chirp.DeletedAt = sql.NullTime{Time: now, Valid: true}
+	chirp.UpdatedAt = now
+	t.chirps[id] = chirp
+	return chirp, nil
+}
+
+func (m *Memory) UpdateChirpBody(ctx context.Context, arg database.UpdateChirpBodyParams) (database.Chirp, error) {
+	defer m.write()()
+	t := m.tables
+	if chirp, ok := t.chirps[arg.ID]; !ok || chirp.DeletedAt.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	now := t.now()
+	return m.updateChirp(arg.ID, func(chirp *database.Chirp) {
+		chirp.Body = arg.Body
+		chirp.EditedAt = sql.NullTime{Time: now, Valid: true}
+		chirp.UpdatedAt = now
+	})
+}
+
+func (m *Memory) CreateChirpLike(ctx context.Context, arg database.CreateChirpLikeParams) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.UserID, arg.ChirpID}
+	if _, ok := t.chirpLikes[key]; ok {
+		return 0, nil
+	}
+	if !t.hasUser(arg.UserID) || !t.hasChirp(arg.ChirpID) {
+		return 0, ErrForeignKeyViolation
+	}
+	t.chirpLikes[key] = database.ChirpLike{UserID: arg.UserID, ChirpID: arg.ChirpID, CreatedAt: t.now()}
+	return 1, nil
+}
+
+func (m *Memory) DeleteChirpLike(ctx context.Context, arg database.DeleteChirpLikeParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.chirpLikes, pairKey{arg.UserID, arg.ChirpID}), nil
+}
+
+func (m *Memory) GetChirpLikesForUser(ctx context.Context, userID uuid.UUID) ([]database.ChirpLike, error) {
+	defer m.read()()
+	return selectWhere(m.tables.chirpLikes, func(like database.ChirpLike) bool {
+		return like.UserID == userID
+	}, func(a, b database.ChirpLike) int {
+		return compareRows(a.CreatedAt, a.ChirpID, b.CreatedAt, b.ChirpID)
+	}), nil
+}
+
+func (m *Memory) ReleaseChirpLikesByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	defer m.write()()
+	t := m.tables
+	ids := []uuid.UUID{}
+	for _, like := range t.chirpLikes {
+		if like.UserID != userID {
+			continue
+		}
+		if _, err := m.updateChirp(like.ChirpID, func(chirp *database.Chirp) { chirp.LikeCount-- }); err == nil {
+			ids = append(ids, like.ChirpID)
+		}
+	}
+	return ids, nil
+}
+
+func (m *Memory) CreateChirpHashtags(ctx context.Context, arg database.CreateChirpHashtagsParams) error {
+	defer m.write()()
+	t := m.tables
+	if len(arg.Tags) > 0 && !t.hasChirp(arg.ChirpID) {
+		return ErrForeignKeyViolation
+	}
+	for _, tag := range arg.Tags {
+		t.chirpHashtags[idKey{arg.ChirpID, tag}] = database.ChirpHashtag{ChirpID: arg.ChirpID, Tag: tag}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteChirpHashtags(ctx context.Context, chirpID uuid.UUID) error {
+	defer m.write()()
+	deleteWhere(m.tables.chirpHashtags, func(row database.ChirpHashtag) bool { return row.ChirpID == chirpID })
+	return nil
+}
+
+func (m *Memory) GetChirpsByHashtag(ctx context.Context, arg database.GetChirpsByHashtagParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		_, tagged := m.tables.chirpHashtags[idKey{chirp.ID, arg.Tag}]
+		return tagged
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) CreateHashtagFollow(ctx context.Context, arg database.CreateHashtagFollowParams) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	key := idKey{arg.UserID, arg.Tag}
+	if _, ok := t.hashtagFollows[key]; ok {
+		return 0, nil
+	}
+	if !t.hasUser(arg.UserID) {
+		return 0, ErrForeignKeyViolation
+	}
+	t.hashtagFollows[key] = database.HashtagFollow{UserID: arg.UserID, Tag: arg.Tag, CreatedAt: t.now()}
+	return 1, nil
+}
+
+func (m *Memory) DeleteHashtagFollow(ctx context.Context, arg database.DeleteHashtagFollowParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.hashtagFollows, idKey{arg.UserID, arg.Tag}), nil
+}
+
+func (m *Memory) GetFollowedHashtags(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	defer m.read()()
+	follows := selectWhere(m.tables.hashtagFollows, func(follow database.HashtagFollow) bool {
+		return follow.UserID == userID
+	}, func(a, b database.HashtagFollow) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	tags := make([]string, 0, len(follows))
+	for _, follow := range follows {
+		tags = append(tags, follow.Tag)
+	}
+	return tags, nil
+}
+
+func (m *Memory) CreateMentions(ctx context.Context, arg database.CreateMentionsParams) error {
+	defer m.write()()
+	t := m.tables
+	for _, user := range t.users {
+		if !user.Handle.Valid || !slices.Contains(arg.Handles, user.Handle.String) {
+			continue
+		}
+		if !t.hasChirp(arg.ChirpID) {
+			return ErrForeignKeyViolation
+		}
+		t.mentions[pairKey{arg.ChirpID, user.ID}] = database.Mention{ChirpID: arg.ChirpID, UserID: user.ID}
+	}
+	return nil
+}
+
+func (m *Memory) DeleteMentions(ctx context.Context, chirpID uuid.UUID) error {
+	defer m.write()()
+	deleteWhere(m.tables.mentions, func(row database.Mention) bool { return row.ChirpID == chirpID })
+	return nil
+}
+
+func (m *Memory) GetMentionsForUser(ctx context.Context, arg database.GetMentionsForUserParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(uuid.NullUUID{UUID: arg.UserID, Valid: true}, func(chirp database.Chirp) bool {
+		_, mentioned := m.tables.mentions[pairKey{chirp.ID, arg.UserID}]
+		return mentioned
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) DeleteChirpTranslations(ctx context.Context, chirpID uuid.UUID) error {
+	defer m.write()()
+	deleteWhere(m.tables.chirpTranslations, func(row database.ChirpTranslation) bool { return row.ChirpID == chirpID })
+	return nil
+}
+
+func (m *Memory) GetChirpTranslation(ctx context.Context, arg database.GetChirpTranslationParams) (database.ChirpTranslation, error) {
+	defer m.read()()
+	translation, ok := m.tables.chirpTranslations[idKey{arg.ChirpID, arg.Language}]
+	if !ok {
+		return database.ChirpTranslation{}, sql.ErrNoRows
+	}
+	return translation, nil
+}
+
+func (m *Memory) UpsertChirpTranslation(ctx context.Context, arg database.UpsertChirpTranslationParams) (database.ChirpTranslation, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasChirp(arg.ChirpID) {
+		return database.ChirpTranslation{}, ErrForeignKeyViolation
+	}
+	translation := database.ChirpTranslation{
+		ChirpID:        arg.ChirpID,
+		Language:       arg.Language,
+		CreatedAt:      t.now(),
+		Body:           arg.Body,
+		SourceLanguage: arg.SourceLanguage,
+		Provider:       arg.Provider,
+	}
+	t.chirpTranslations[idKey{arg.ChirpID, arg.Language}] = translation
+	return translation, nil
+}
+
+func (m *Memory) CreateBookmark(ctx context.Context, arg database.CreateBookmarkParams) error {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.UserID, arg.ChirpID}
+	if _, ok := t.bookmarks[key]; ok {
+		return nil
+	}
+	if !t.hasUser(arg.UserID) || !t.hasChirp(arg.ChirpID) {
+		return ErrForeignKeyViolation
+	}
+	t.bookmarks[key] = database.Bookmark{UserID: arg.UserID, ChirpID: arg.ChirpID, CreatedAt: t.now()}
+	return nil
+}
+
+func (m *Memory) DeleteBookmark(ctx context.Context, arg database.DeleteBookmarkParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.bookmarks, pairKey{arg.UserID, arg.ChirpID}), nil
+}
+
+func (m *Memory) GetBookmarks(ctx context.Context, arg database.GetBookmarksParams) ([]database.GetBookmarksRow, error) {
+	defer m.read()()
+	t := m.tables
+	viewer := uuid.NullUUID{UUID: arg.UserID, Valid: true}
+	bookmarks := selectWhere(t.bookmarks, func(bookmark database.Bookmark) bool {
+		chirp, ok := t.chirps[bookmark.ChirpID]
+		return bookmark.UserID == arg.UserID && ok && t.visibleTo(chirp, viewer) &&
+			beforeCursor(bookmark.CreatedAt, bookmark.ChirpID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.Bookmark) int {
+		return compareRows(b.CreatedAt, b.ChirpID, a.CreatedAt, a.ChirpID)
+	})
+	rows := []database.GetBookmarksRow{}
+	for _, bookmark := range limit(bookmarks, arg.Limit) {
+		rows = append(rows, database.GetBookmarksRow{Chirp: t.chirps[bookmark.ChirpID], BookmarkedAt: bookmark.CreatedAt})
+	}
+	return rows, nil
+}
+
+// deleteKey deletes the row at key, returning how many rows that was
+func deleteKey[K comparable, V any](table map[K]V, key K) int64 {
+	if _, ok := table[key]; !ok {
+		return 0
+	}
+	delete(table, key)
+	return 1
+}
@@ -0,0 +1,428 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.FollowerID, arg.FolloweeID}
+	if _, ok := t.follows[key]; ok {
+		return 0, nil
+	}
+	if !t.hasUser(arg.FollowerID) || !t.hasUser(arg.FolloweeID) {
+		return 0, ErrForeignKeyViolation
+	}
+	t.follows[key] = database.Follow{FollowerID: arg.FollowerID, FolloweeID: arg.FolloweeID, CreatedAt: t.now()}
+	return 1, nil
+}
+
+func (m *Memory) DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.follows, pairKey{arg.FollowerID, arg.FolloweeID}), nil
+}
+
+// usersByLink is the users a link table points at from one side, newest
+// link first. link returns the user a row points at, and whether it's one
+// of the rows wanted.
+func usersByLink[K comparable, V any](t *memoryTables, table map[K]V, link func(V) (uuid.UUID, time.Time, bool)) []database.User {
+	type linked struct {
+		user database.User
+		at   time.Time
+	}
+	rows := []linked{}
+	for _, row := range table {
+		userID, at, ok := link(row)
+		if user, exists := t.users[userID]; ok && exists {
+			rows = append(rows, linked{user, at})
+		}
+	}
+	sortNewestFirst(rows, func(row linked) (time.Time, uuid.UUID) { return row.at, row.user.ID })
+	users := make([]database.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.user)
+	}
+	return users
+}
+
+func (m *Memory) GetFollowers(ctx context.Context, followeeID uuid.UUID) ([]database.User, error) {
+	defer m.read()()
+	t := m.tables
+	return usersByLink(t, t.follows, func(follow database.Follow) (uuid.UUID, time.Time, bool) {
+		return follow.FollowerID, follow.CreatedAt, follow.FolloweeID == followeeID && !t.users[follow.FollowerID].DeactivatedAt.Valid
+	}), nil
+}
+
+func (m *Memory) GetFollowing(ctx context.Context, followerID uuid.UUID) ([]database.User, error) {
+	defer m.read()()
+	t := m.tables
+	return usersByLink(t, t.follows, func(follow database.Follow) (uuid.UUID, time.Time, bool) {
+		return follow.FolloweeID, follow.CreatedAt, follow.FollowerID == followerID && !t.users[follow.FolloweeID].DeactivatedAt.Valid
+	}), nil
+}
+
+func (m *Memory) GetTimeline(ctx context.Context, arg database.GetTimelineParams) ([]database.Chirp, error) {
+	defer m.read()()
+	t := m.tables
+	chirps := m.visibleChirps(uuid.NullUUID{UUID: arg.FollowerID, Valid: true}, func(chirp database.Chirp) bool {
+		_, following := t.follows[pairKey{arg.FollowerID, chirp.UserID}]
+		_, muted := t.mutes[pairKey{arg.FollowerID, chirp.UserID}]
+		return following && !muted && beforeCursor(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+// recommendable reports whether a chirp can be recommended to viewer: a
+// recent top-level chirp by someone else, who isn't hidden, muted or
+// blocked either way
+func (t *memoryTables) recommendable(chirp database.Chirp, viewerID uuid.UUID, since time.Time) bool {
+	return chirp.UserID != viewerID && !chirp.ParentChirpID.Valid && chirp.CreatedAt.After(since) &&
+		t.visibleTo(chirp, uuid.NullUUID{}) && !t.mutedOrBlocked(viewerID, chirp.UserID)
+}
+
+func (m *Memory) GetFollowedHashtagChirps(ctx context.Context, arg database.GetFollowedHashtagChirpsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	t := m.tables
+	tagged := map[uuid.UUID]bool{}
+	for _, hashtag := range t.chirpHashtags {
+		if _, ok := t.hashtagFollows[idKey{arg.ViewerID, hashtag.Tag}]; ok {
+			tagged[hashtag.ChirpID] = true
+		}
+	}
+	chirps := selectWhere(t.chirps, func(chirp database.Chirp) bool {
+		return tagged[chirp.ID] && t.recommendable(chirp, arg.ViewerID, arg.Since)
+	}, mostLikedChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) GetNetworkChirps(ctx context.Context, arg database.GetNetworkChirpsParams) ([]database.Chirp, error) {
+	defer m.read()()
+	t := m.tables
+	network := map[uuid.UUID]bool{}
+	for _, first := range t.follows {
+		if first.FollowerID != arg.ViewerID {
+			continue
+		}
+		for _, second := range t.follows {
+			if second.FollowerID == first.FolloweeID {
+				network[second.FolloweeID] = true
+			}
+		}
+	}
+	chirps := selectWhere(t.chirps, func(chirp database.Chirp) bool {
+		_, following := t.follows[pairKey{arg.ViewerID, chirp.UserID}]
+		return network[chirp.UserID] && !following && t.recommendable(chirp, arg.ViewerID, arg.Since)
+	}, mostLikedChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) CreateMute(ctx context.Context, arg database.CreateMuteParams) error {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.MuterID, arg.MutedID}
+	if _, ok := t.mutes[key]; ok {
+		return nil
+	}
+	if !t.hasUser(arg.MuterID) || !t.hasUser(arg.MutedID) {
+		return ErrForeignKeyViolation
+	}
+	t.mutes[key] = database.Mute{MuterID: arg.MuterID, MutedID: arg.MutedID, CreatedAt: t.now()}
+	return nil
+}
+
+func (m *Memory) DeleteMute(ctx context.Context, arg database.DeleteMuteParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.mutes, pairKey{arg.MuterID, arg.MutedID}), nil
+}
+
+func (m *Memory) GetMutedUsers(ctx context.Context, muterID uuid.UUID) ([]database.User, error) {
+	defer m.read()()
+	t := m.tables
+	return usersByLink(t, t.mutes, func(mute database.Mute) (uuid.UUID, time.Time, bool) {
+		return mute.MutedID, mute.CreatedAt, mute.MuterID == muterID
+	}), nil
+}
+
+func (m *Memory) CreateBlock(ctx context.Context, arg database.CreateBlockParams) error {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.BlockerID, arg.BlockedID}
+	if _, ok := t.blocks[key]; ok {
+		return nil
+	}
+	if !t.hasUser(arg.BlockerID) || !t.hasUser(arg.BlockedID) {
+		return ErrForeignKeyViolation
+	}
+	t.blocks[key] = database.Block{BlockerID: arg.BlockerID, BlockedID: arg.BlockedID, CreatedAt: t.now()}
+	return nil
+}
+
+func (m *Memory) DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.blocks, pairKey{arg.BlockerID, arg.BlockedID}), nil
+}
+
+func (m *Memory) GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]database.User, error) {
+	defer m.read()()
+	t := m.tables
+	return usersByLink(t, t.blocks, func(block database.Block) (uuid.UUID, time.Time, bool) {
+		return block.BlockedID, block.CreatedAt, block.BlockerID == blockerID
+	}), nil
+}
+
+func (m *Memory) IsBlockedEitherWay(ctx context.Context, arg database.IsBlockedEitherWayParams) (bool, error) {
+	defer m.read()()
+	_, blocked := m.tables.blocks[pairKey{arg.UserID, arg.OtherID}]
+	_, blockedBy := m.tables.blocks[pairKey{arg.OtherID, arg.UserID}]
+	return blocked || blockedBy, nil
+}
+
+func (m *Memory) AddListMember(ctx context.Context, arg database.AddListMemberParams) error {
+	defer m.write()()
+	t := m.tables
+	key := pairKey{arg.ListID, arg.UserID}
+	if _, ok := t.listMembers[key]; ok {
+		return nil
+	}
+	if _, ok := t.lists[arg.ListID]; !ok || !t.hasUser(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	t.listMembers[key] = database.ListMember{ListID: arg.ListID, UserID: arg.UserID, CreatedAt: t.now()}
+	return nil
+}
+
+func (m *Memory) CreateList(ctx context.Context, arg database.CreateListParams) (database.List, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.OwnerID) {
+		return database.List{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	list := database.List{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OwnerID:     arg.OwnerID,
+		Name:        arg.Name,
+		Description: arg.Description,
+		IsPrivate:   arg.IsPrivate,
+	}
+	t.lists[list.ID] = list
+	return list, nil
+}
+
+func (m *Memory) DeleteList(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	m.tables.deleteList(id)
+	return nil
+}
+
+func (m *Memory) GetListByID(ctx context.Context, id uuid.UUID) (database.List, error) {
+	defer m.read()()
+	list, ok := m.tables.lists[id]
+	if !ok {
+		return database.List{}, sql.ErrNoRows
+	}
+	return list, nil
+}
+
+func (m *Memory) GetListMembers(ctx context.Context, listID uuid.UUID) ([]database.User, error) {
+	defer m.read()()
+	t := m.tables
+	members := selectWhere(t.listMembers, func(member database.ListMember) bool {
+		return member.ListID == listID
+	}, func(a, b database.ListMember) int {
+		return compareRows(a.CreatedAt, a.UserID, b.CreatedAt, b.UserID)
+	})
+	users := make([]database.User, 0, len(members))
+	for _, member := range members {
+		users = append(users, t.users[member.UserID])
+	}
+	return users, nil
+}
+
+func (m *Memory) GetListTimeline(ctx context.Context, arg database.GetListTimelineParams) ([]database.Chirp, error) {
+	defer m.read()()
+	chirps := m.visibleChirps(arg.ViewerID, func(chirp database.Chirp) bool {
+		_, member := m.tables.listMembers[pairKey{arg.ListID, chirp.UserID}]
+		return member && beforeCursor(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, newestChirpFirst)
+	return limit(chirps, arg.Limit), nil
+}
+
+func (m *Memory) GetListsByOwner(ctx context.Context, ownerID uuid.UUID) ([]database.List, error) {
+	defer m.read()()
+	return selectWhere(m.tables.lists, func(list database.List) bool {
+		return list.OwnerID == ownerID
+	}, func(a, b database.List) int {
+		return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	}), nil
+}
+
+func (m *Memory) RemoveListMember(ctx context.Context, arg database.RemoveListMemberParams) (int64, error) {
+	defer m.write()()
+	return deleteKey(m.tables.listMembers, pairKey{arg.ListID, arg.UserID}), nil
+}
+
+func (m *Memory) UpdateList(ctx context.Context, arg database.UpdateListParams) (database.List, error) {
+	defer m.write()()
+	t := m.tables
+	list, ok := t.lists[arg.ID]
+	if !ok {
+		return database.List{}, sql.ErrNoRows
+	}
+	list.Name = arg.Name
+	list.Description = arg.Description
+	list.IsPrivate = arg.IsPrivate
+	list.UpdatedAt = t.now()
+	t.lists[arg.ID] = list
+	return list, nil
+}
+
+func (m *Memory) CreateMessage(ctx context.Context, arg database.CreateMessageParams) (database.Message, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.conversations[arg.ConversationID]; !ok || !t.hasUser(arg.SenderID) {
+		return database.Message{}, ErrForeignKeyViolation
+	}
+	message := database.Message{
+		ID:             uuid.New(),
+		CreatedAt:      t.now(),
+		ConversationID: arg.ConversationID,
+		SenderID:       arg.SenderID,
+		Body:           arg.Body,
+	}
+	t.messages[message.ID] = message
+	return message, nil
+}
+
+// inConversation reports whether the user is one of the two in it
+func inConversation(conversation database.Conversation, userID uuid.UUID) bool {
+	return conversation.UserAID == userID || conversation.UserBID == userID
+}
+
+func (m *Memory) GetConversationForUser(ctx context.Context, arg database.GetConversationForUserParams) (database.Conversation, error) {
+	defer m.read()()
+	conversation, ok := m.tables.conversations[arg.ID]
+	if !ok || !inConversation(conversation, arg.UserID) {
+		return database.Conversation{}, sql.ErrNoRows
+	}
+	return conversation, nil
+}
+
+func (m *Memory) GetConversationsForUser(ctx context.Context, arg database.GetConversationsForUserParams) ([]database.GetConversationsForUserRow, error) {
+	defer m.read()()
+	t := m.tables
+	conversations := selectWhere(t.conversations, func(conversation database.Conversation) bool {
+		return inConversation(conversation, arg.UserID) &&
+			beforeCursor(conversation.UpdatedAt, conversation.ID, arg.BeforeUpdatedAt, arg.BeforeID)
+	}, func(a, b database.Conversation) int {
+		return compareRows(b.UpdatedAt, b.ID, a.UpdatedAt, a.ID)
+	})
+	rows := []database.GetConversationsForUserRow{}
+	for _, conversation := range limit(conversations, arg.Limit) {
+		otherID := conversation.UserAID
+		if otherID == arg.UserID {
+			otherID = conversation.UserBID
+		}
+		rows = append(rows, database.GetConversationsForUserRow{Conversation: conversation, User: t.users[otherID]})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetMessages(ctx context.Context, arg database.GetMessagesParams) ([]database.Message, error) {
+	defer m.read()()
+	messages := selectWhere(m.tables.messages, func(message database.Message) bool {
+		return message.ConversationID == arg.ConversationID &&
+			beforeCursor(message.CreatedAt, message.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.Message) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(messages, arg.Limit), nil
+}
+
+func (m *Memory) UpsertConversation(ctx context.Context, arg database.UpsertConversationParams) (database.Conversation, error) {
+	defer m.write()()
+	t := m.tables
+	userA, userB := arg.SenderID, arg.RecipientID
+	if bytes.Compare(userA[:], userB[:]) > 0 {
+		userA, userB = userB, userA
+	}
+	now := t.now()
+	for id, conversation := range t.conversations {
+		if conversation.UserAID == userA && conversation.UserBID == userB {
+			conversation.UpdatedAt = now
+			t.conversations[id] = conversation
+			return conversation, nil
+		}
+	}
+	if !t.hasUser(userA) || !t.hasUser(userB) {
+		return database.Conversation{}, ErrForeignKeyViolation
+	}
+	conversation := database.Conversation{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserAID:   userA,
+		UserBID:   userB,
+	}
+	t.conversations[conversation.ID] = conversation
+	return conversation, nil
+}
+
+func (m *Memory) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return database.Notification{}, ErrForeignKeyViolation
+	}
+	notification := database.Notification{
+		ID:        uuid.New(),
+		CreatedAt: t.now(),
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+		Message:   arg.Message,
+	}
+	t.notifications[notification.ID] = notification
+	return notification, nil
+}
+
+func (m *Memory) GetNotificationsForUser(ctx context.Context, arg database.GetNotificationsForUserParams) ([]database.Notification, error) {
+	defer m.read()()
+	notifications := selectWhere(m.tables.notifications, func(notification database.Notification) bool {
+		return notification.UserID == arg.UserID
+	}, func(a, b database.Notification) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(notifications, arg.Limit), nil
+}
+
+func (m *Memory) MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	for id, notification := range t.notifications {
+		if notification.UserID == userID && !notification.ReadAt.Valid {
+			notification.ReadAt = sql.NullTime{Time: now, Valid: true}
+			t.notifications[id] = notification
+		}
+	}
+	return nil
+}
+
+// sortNewestFirst sorts rows by the time and ID key returns, newest first
+func sortNewestFirst[V any](rows []V, key func(V) (time.Time, uuid.UUID)) {
+	slices.SortFunc(rows, func(a, b V) int {
+		aAt, aID := key(a)
+		bAt, bID := key(b)
+		return compareRows(bAt, bID, aAt, aID)
+	})
+}
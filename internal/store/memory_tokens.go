@@ -0,0 +1,294 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.refreshTokens[arg.Token]; ok {
+		return database.RefreshToken{}, ErrUniqueViolation
+	}
+	if !t.hasUser(arg.UserID) {
+		return database.RefreshToken{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	token := database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+		ID:        uuid.New(),
+		UserAgent: arg.UserAgent,
+		IpAddress: arg.IpAddress,
+	}
+	t.refreshTokens[token.Token] = token
+	return token, nil
+}
+
+func (m *Memory) DeleteStaleRefreshTokens(ctx context.Context) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return deleteWhere(m.tables.refreshTokens, func(token database.RefreshToken) bool {
+		return token.ExpiresAt.Before(now) || token.RevokedAt.Valid
+	}), nil
+}
+
+// usable reports whether a refresh token can still be exchanged
+func usable(token database.RefreshToken, now time.Time) bool {
+	return !token.RevokedAt.Valid && token.ExpiresAt.After(now)
+}
+
+func (m *Memory) GetActiveSessionsForUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error) {
+	defer m.read()()
+	now := time.Now()
+	lastUsed := func(token database.RefreshToken) time.Time {
+		if token.LastUsedAt.Valid {
+			return token.LastUsedAt.Time
+		}
+		return token.CreatedAt
+	}
+	return selectWhere(m.tables.refreshTokens, func(token database.RefreshToken) bool {
+		return token.UserID == userID && usable(token, now)
+	}, func(a, b database.RefreshToken) int {
+		return compareRows(lastUsed(b), b.ID, lastUsed(a), a.ID)
+	}), nil
+}
+
+func (m *Memory) GetUserFromRefreshToken(ctx context.Context, token string) (database.GetUserFromRefreshTokenRow, error) {
+	defer m.read()()
+	t := m.tables
+	row, ok := t.refreshTokens[token]
+	if !ok || !usable(row, time.Now()) {
+		return database.GetUserFromRefreshTokenRow{}, sql.ErrNoRows
+	}
+	return database.GetUserFromRefreshTokenRow{User: t.users[row.UserID], SignedInAt: row.CreatedAt}, nil
+}
+
+// revokeRefreshTokens revokes the unrevoked tokens that match, returning
+// them
+func (m *Memory) revokeRefreshTokens(match func(database.RefreshToken) bool) []database.RefreshToken {
+	t := m.tables
+	now := t.now()
+	revoked := []database.RefreshToken{}
+	for key, token := range t.refreshTokens {
+		if token.RevokedAt.Valid || !match(token) {
+			continue
+		}
+		token.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		token.UpdatedAt = now
+		t.refreshTokens[key] = token
+		revoked = append(revoked, token)
+	}
+	return revoked
+}
+
+func (m *Memory) RevokeRefreshToken(ctx context.Context, token string) (uuid.UUID, error) {
+	defer m.write()()
+	revoked := m.revokeRefreshTokens(func(row database.RefreshToken) bool { return row.Token == token })
+	if len(revoked) == 0 {
+		return uuid.UUID{}, sql.ErrNoRows
+	}
+	return revoked[0].UserID, nil
+}
+
+func (m *Memory) RevokeRefreshTokenForUser(ctx context.Context, arg database.RevokeRefreshTokenForUserParams) (int64, error) {
+	defer m.write()()
+	revoked := m.revokeRefreshTokens(func(row database.RefreshToken) bool {
+		return row.Token == arg.Token && row.UserID == arg.UserID
+	})
+	return int64(len(revoked)), nil
+}
+
+func (m *Memory) RevokeSession(ctx context.Context, arg database.RevokeSessionParams) (int64, error) {
+	defer m.write()()
+	revoked := m.revokeRefreshTokens(func(row database.RefreshToken) bool {
+		return row.ID == arg.ID && row.UserID == arg.UserID
+	})
+	return int64(len(revoked)), nil
+}
+
+func (m *Memory) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	defer m.write()()
+	m.revokeRefreshTokens(func(row database.RefreshToken) bool { return row.UserID == userID })
+	return nil
+}
+
+func (m *Memory) TouchRefreshToken(ctx context.Context, token string) error {
+	defer m.write()()
+	t := m.tables
+	row, ok := t.refreshTokens[token]
+	if !ok {
+		return nil
+	}
+	row.LastUsedAt = sql.NullTime{Time: t.now(), Valid: true}
+	t.refreshTokens[token] = row
+	return nil
+}
+
+func (m *Memory) DeleteExpiredRevokedAccessTokens(ctx context.Context) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return deleteWhere(m.tables.revokedAccessTokens, func(token database.RevokedAccessToken) bool {
+		return token.ExpiresAt.Before(now)
+	}), nil
+}
+
+func (m *Memory) GetAccessTokenStatus(ctx context.Context, arg database.GetAccessTokenStatusParams) (database.GetAccessTokenStatusRow, error) {
+	defer m.read()()
+	t := m.tables
+	user, ok := t.users[arg.UserID]
+	if !ok {
+		return database.GetAccessTokenStatusRow{}, sql.ErrNoRows
+	}
+	_, revoked := t.revokedAccessTokens[arg.Jti]
+	return database.GetAccessTokenStatusRow{TokenVersion: user.TokenVersion, Revoked: revoked}, nil
+}
+
+func (m *Memory) RevokeAccessToken(ctx context.Context, arg database.RevokeAccessTokenParams) error {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.revokedAccessTokens[arg.Jti]; ok {
+		return nil
+	}
+	if !t.hasUser(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	t.revokedAccessTokens[arg.Jti] = database.RevokedAccessToken{
+		Jti:       arg.Jti,
+		UserID:    arg.UserID,
+		RevokedAt: t.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (m *Memory) CreateLogin(ctx context.Context, arg database.CreateLoginParams) (database.Login, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return database.Login{}, ErrForeignKeyViolation
+	}
+	login := database.Login{
+		ID:        uuid.New(),
+		CreatedAt: t.now(),
+		UserID:    arg.UserID,
+		IpAddress: arg.IpAddress,
+		Network:   arg.Network,
+		UserAgent: arg.UserAgent,
+	}
+	t.logins[login.ID] = login
+	return login, nil
+}
+
+func (m *Memory) GetLoginDeviceHistory(ctx context.Context, arg database.GetLoginDeviceHistoryParams) (database.GetLoginDeviceHistoryRow, error) {
+	defer m.read()()
+	history := database.GetLoginDeviceHistoryRow{}
+	for _, login := range m.tables.logins {
+		if login.UserID != arg.UserID {
+			continue
+		}
+		history.HasLogins = true
+		if login.UserAgent == arg.UserAgent && login.Network == arg.Network {
+			history.SeenDevice = true
+		}
+	}
+	return history, nil
+}
+
+func (m *Memory) ListLogins(ctx context.Context, arg database.ListLoginsParams) ([]database.Login, error) {
+	defer m.read()()
+	logins := selectWhere(m.tables.logins, func(login database.Login) bool {
+		return login.UserID == arg.UserID && beforeCursor(login.CreatedAt, login.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.Login) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(logins, arg.Limit), nil
+}
+
+func (m *Memory) ConsumeMagicLinkToken(ctx context.Context, tokenHash string) (database.MagicLinkToken, error) {
+	defer m.write()()
+	token, ok := m.tables.magicLinkTokens[tokenHash]
+	if !ok {
+		return database.MagicLinkToken{}, sql.ErrNoRows
+	}
+	delete(m.tables.magicLinkTokens, tokenHash)
+	return token, nil
+}
+
+func (m *Memory) CreateMagicLinkToken(ctx context.Context, arg database.CreateMagicLinkTokenParams) error {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.magicLinkTokens[arg.TokenHash]; ok {
+		return ErrUniqueViolation
+	}
+	t.magicLinkTokens[arg.TokenHash] = database.MagicLinkToken{
+		TokenHash: arg.TokenHash,
+		Email:     arg.Email,
+		CreatedAt: t.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (m *Memory) DeleteExpiredMagicLinkTokens(ctx context.Context) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return deleteWhere(m.tables.magicLinkTokens, func(token database.MagicLinkToken) bool {
+		return token.ExpiresAt.Before(now)
+	}), nil
+}
+
+func (m *Memory) MagicLinkSentSince(ctx context.Context, arg database.MagicLinkSentSinceParams) (bool, error) {
+	defer m.read()()
+	for _, token := range m.tables.magicLinkTokens {
+		if token.Email == arg.Email && token.CreatedAt.After(arg.CreatedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *Memory) ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (database.EmailVerificationToken, error) {
+	defer m.write()()
+	token, ok := m.tables.emailVerificationTokens[tokenHash]
+	if !ok {
+		return database.EmailVerificationToken{}, sql.ErrNoRows
+	}
+	delete(m.tables.emailVerificationTokens, tokenHash)
+	return token, nil
+}
+
+func (m *Memory) CreateEmailVerificationToken(ctx context.Context, arg database.CreateEmailVerificationTokenParams) error {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.emailVerificationTokens[arg.TokenHash]; ok {
+		return ErrUniqueViolation
+	}
+	if !t.hasUser(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	t.emailVerificationTokens[arg.TokenHash] = database.EmailVerificationToken{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		Email:     arg.Email,
+		CreatedAt: t.now(),
+		ExpiresAt: arg.ExpiresAt,
+	}
+	return nil
+}
+
+func (m *Memory) DeleteEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error {
+	defer m.write()()
+	deleteWhere(m.tables.emailVerificationTokens, func(token database.EmailVerificationToken) bool {
+		return token.UserID == userID
+	})
+	return nil
+}
@@ -0,0 +1,615 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	defer m.write()()
+	t := m.tables
+	for _, other := range t.users {
+		if other.Email == arg.Email || (arg.Handle.Valid && other.Handle == arg.Handle) {
+			return database.User{}, sql.ErrNoRows
+		}
+	}
+	now := t.now()
+	user := database.User{
+		ID:              uuid.New(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Email:           arg.Email,
+		HashedPassword:  arg.HashedPassword,
+		Plan:            "free",
+		Recommendations: true,
+		Handle:          arg.Handle,
+		Role:            "user",
+	}
+	t.users[user.ID] = user
+	return user, nil
+}
+
+// updateUser changes a user with change, stamping updated_at unless
+// keepUpdatedAt is set, and returns the changed row
+func (m *Memory) updateUser(id uuid.UUID, keepUpdatedAt bool, change func(*database.User)) (database.User, error) {
+	t := m.tables
+	user, ok := t.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	change(&user)
+	if !keepUpdatedAt {
+		user.UpdatedAt = t.now()
+	}
+	t.users[id] = user
+	return user, nil
+}
+
+func (m *Memory) DeactivateUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return m.updateUser(id, false, func(user *database.User) {
+		user.DeactivatedAt = sql.NullTime{Time: now, Valid: true}
+		user.TokenVersion++
+	})
+}
+
+func (m *Memory) DeleteAllUsers(ctx context.Context) error {
+	defer m.write()()
+	for id := range m.tables.users {
+		m.tables.deleteUser(id)
+	}
+	return nil
+}
+
+func (m *Memory) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	defer m.write()()
+	if !m.tables.hasUser(id) {
+		return 0, nil
+	}
+	m.tables.deleteUser(id)
+	return 1, nil
+}
+
+func (m *Memory) ExportUsers(ctx context.Context, arg database.ExportUsersParams) ([]database.ExportUsersRow, error) {
+	defer m.read()()
+	users := selectWhere(m.tables.users, func(user database.User) bool {
+		return inRange(user.CreatedAt, arg.CreatedFrom, arg.CreatedTo) &&
+			afterCursor(user.CreatedAt, user.ID, arg.AfterCreatedAt, arg.AfterID)
+	}, oldestUserFirst)
+	rows := make([]database.ExportUsersRow, 0, len(users))
+	for _, user := range limit(users, arg.Limit) {
+		rows = append(rows, database.ExportUsersRow{
+			ID:          user.ID,
+			CreatedAt:   user.CreatedAt,
+			UpdatedAt:   user.UpdatedAt,
+			IsChirpyRed: user.IsChirpyRed,
+			Plan:        user.Plan,
+		})
+	}
+	return rows, nil
+}
+
+func oldestUserFirst(a, b database.User) int {
+	return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+// getUser is the one user matching, or no rows
+func (m *Memory) getUser(match func(database.User) bool) (database.User, error) {
+	for _, user := range m.tables.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	defer m.read()()
+	return m.getUser(func(user database.User) bool { return user.Email == email })
+}
+
+func (m *Memory) GetUserByHandle(ctx context.Context, handle sql.NullString) (database.User, error) {
+	defer m.read()()
+	return m.getUser(func(user database.User) bool {
+		return handle.Valid && user.Handle == handle && !user.DeactivatedAt.Valid
+	})
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.read()()
+	user, ok := m.tables.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+// Transactions hold the write lock throughout, so the row is as good as
+// locked already
+func (m *Memory) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (database.User, error) {
+	return m.GetUserByID(ctx, id)
+}
+
+func (m *Memory) GetUsersDeactivatedBefore(ctx context.Context, arg database.GetUsersDeactivatedBeforeParams) ([]database.User, error) {
+	defer m.read()()
+	users := selectWhere(m.tables.users, func(user database.User) bool {
+		return user.DeactivatedAt.Valid && arg.DeactivatedBefore.Valid && user.DeactivatedAt.Time.Before(arg.DeactivatedBefore.Time)
+	}, func(a, b database.User) int {
+		return compareRows(a.DeactivatedAt.Time, a.ID, b.DeactivatedAt.Time, b.ID)
+	})
+	return limit(users, arg.MaxUsers), nil
+}
+
+func (m *Memory) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	_, err := m.updateUser(id, false, func(user *database.User) { user.TokenVersion++ })
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	defer m.read()()
+	now := time.Now()
+	users := selectWhere(m.tables.users, func(user database.User) bool {
+		if arg.Query.Valid {
+			pattern := "%" + arg.Query.String + "%"
+			if !like(user.Email, pattern) && !(user.Handle.Valid && like(user.Handle.String, pattern)) {
+				return false
+			}
+		}
+		suspended := user.SuspendedAt.Valid && (!user.SuspendedUntil.Valid || user.SuspendedUntil.Time.After(now))
+		return optional(user.Role, arg.Role.String, arg.Role.Valid) &&
+			optional(user.Plan, arg.Plan.String, arg.Plan.Valid) &&
+			optional(suspended, arg.Suspended.Bool, arg.Suspended.Valid) &&
+			optional(user.DeactivatedAt.Valid, arg.Deactivated.Bool, arg.Deactivated.Valid) &&
+			optional(user.ShadowBanned, arg.ShadowBanned.Bool, arg.ShadowBanned.Valid) &&
+			inRange(user.CreatedAt, arg.CreatedFrom, arg.CreatedTo) &&
+			beforeCursor(user.CreatedAt, user.ID, arg.BeforeCreatedAt, arg.BeforeID)
+	}, func(a, b database.User) int { return -oldestUserFirst(a, b) })
+	return limit(users, arg.Limit), nil
+}
+
+func (m *Memory) LockUser(ctx context.Context, arg database.LockUserParams) error {
+	defer m.write()()
+	_, err := m.updateUser(arg.ID, true, func(user *database.User) {
+		user.LockedUntil = arg.LockedUntil
+		user.FailedLoginCount = 0
+		user.FailedLoginWindowStart = sql.NullTime{}
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) MarkEmailVerified(ctx context.Context, arg database.MarkEmailVerifiedParams) (database.User, error) {
+	defer m.write()()
+	if user, ok := m.tables.users[arg.ID]; !ok || user.Email != arg.Email {
+		return database.User{}, sql.ErrNoRows
+	}
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.EmailVerified = true })
+}
+
+func (m *Memory) ReactivateUser(ctx context.Context, arg database.ReactivateUserParams) (database.User, error) {
+	defer m.write()()
+	user, ok := m.tables.users[arg.ID]
+	if !ok || !user.DeactivatedAt.Valid || !arg.DeactivatedAfter.Valid || !user.DeactivatedAt.Time.After(arg.DeactivatedAfter.Time) {
+		return database.User{}, sql.ErrNoRows
+	}
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.DeactivatedAt = sql.NullTime{} })
+}
+
+func (m *Memory) RecordFailedLogin(ctx context.Context, arg database.RecordFailedLoginParams) (int32, error) {
+	defer m.write()()
+	now := m.tables.now()
+	user, err := m.updateUser(arg.ID, true, func(user *database.User) {
+		if !user.FailedLoginWindowStart.Valid || user.FailedLoginWindowStart.Time.Before(arg.WindowStart) {
+			user.FailedLoginCount = 1
+			user.FailedLoginWindowStart = sql.NullTime{Time: now, Valid: true}
+		} else {
+			user.FailedLoginCount++
+		}
+	})
+	return user.FailedLoginCount, err
+}
+
+func (m *Memory) RequirePasswordReset(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(id, false, func(user *database.User) {
+		user.PasswordResetRequired = true
+		user.TokenVersion++
+	})
+}
+
+func (m *Memory) ResetFailedLogins(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	_, err := m.updateUser(id, true, func(user *database.User) {
+		user.FailedLoginCount = 0
+		user.FailedLoginWindowStart = sql.NullTime{}
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) SetRecommendations(ctx context.Context, arg database.SetRecommendationsParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.Recommendations = arg.Recommendations })
+}
+
+func (m *Memory) SetShareLocation(ctx context.Context, arg database.SetShareLocationParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.ShareLocation = arg.ShareLocation })
+}
+
+func (m *Memory) SetUserAvatar(ctx context.Context, arg database.SetUserAvatarParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.AvatarKey = arg.AvatarKey })
+}
+
+func (m *Memory) SetUserHandle(ctx context.Context, arg database.SetUserHandleParams) (database.User, error) {
+	defer m.write()()
+	for _, other := range m.tables.users {
+		if arg.Handle.Valid && other.Handle == arg.Handle && other.ID != arg.ID {
+			return database.User{}, sql.ErrNoRows
+		}
+	}
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.Handle = arg.Handle })
+}
+
+func (m *Memory) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	defer m.write()()
+	_, err := m.updateUser(arg.ID, false, func(user *database.User) { user.HashedPassword = arg.HashedPassword })
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) SetUserPlan(ctx context.Context, arg database.SetUserPlanParams) (int64, error) {
+	defer m.write()()
+	if _, ok := m.tables.plans[arg.Plan]; !ok {
+		return 0, ErrForeignKeyViolation
+	}
+	_, err := m.updateUser(arg.ID, false, func(user *database.User) {
+		user.Plan = arg.Plan
+		user.IsChirpyRed = arg.Plan != "free"
+	})
+	return rowsAffected(err)
+}
+
+func (m *Memory) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) {
+		if user.Role != arg.Role {
+			user.TokenVersion++
+		}
+		user.Role = arg.Role
+	})
+}
+
+func (m *Memory) SetUserShadowBanned(ctx context.Context, arg database.SetUserShadowBannedParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) { user.ShadowBanned = arg.ShadowBanned })
+}
+
+func (m *Memory) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return m.updateUser(arg.ID, false, func(user *database.User) {
+		user.SuspendedAt = sql.NullTime{Time: now, Valid: true}
+		user.SuspendedUntil = arg.SuspendedUntil
+		user.SuspensionReason = arg.SuspensionReason
+		user.TokenVersion++
+	})
+}
+
+func (m *Memory) UnlockUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(id, false, func(user *database.User) {
+		user.LockedUntil = sql.NullTime{}
+		user.FailedLoginCount = 0
+		user.FailedLoginWindowStart = sql.NullTime{}
+	})
+}
+
+func (m *Memory) UnsuspendUser(ctx context.Context, id uuid.UUID) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(id, false, func(user *database.User) {
+		user.SuspendedAt = sql.NullTime{}
+		user.SuspendedUntil = sql.NullTime{}
+		user.SuspensionReason = ""
+	})
+}
+
+func (m *Memory) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	defer m.write()()
+	for _, other := range m.tables.users {
+		if other.Email == arg.Email && other.ID != arg.ID {
+			return database.User{}, sql.ErrNoRows
+		}
+	}
+	return m.updateUser(arg.ID, false, func(user *database.User) {
+		user.EmailVerified = user.EmailVerified && user.Email == arg.Email
+		user.Email = arg.Email
+		user.HashedPassword = arg.HashedPassword
+		user.PasswordResetRequired = false
+	})
+}
+
+func (m *Memory) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error) {
+	defer m.write()()
+	return m.updateUser(arg.ID, false, func(user *database.User) {
+		for _, field := range []struct {
+			value sql.NullString
+			dst   *string
+		}{
+			{arg.DisplayName, &user.DisplayName},
+			{arg.Bio, &user.Bio},
+			{arg.Location, &user.Location},
+			{arg.Website, &user.Website},
+		} {
+			if field.value.Valid {
+				*field.dst = field.value.String
+			}
+		}
+	})
+}
+
+func (m *Memory) CreateOAuthIdentity(ctx context.Context, arg database.CreateOAuthIdentityParams) error {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(arg.UserID) {
+		return ErrForeignKeyViolation
+	}
+	key := namedKey{Name: arg.Provider, Key: arg.Subject}
+	if _, ok := t.oauthIdentities[key]; !ok {
+		t.oauthIdentities[key] = database.OauthIdentity{
+			Provider:  arg.Provider,
+			Subject:   arg.Subject,
+			UserID:    arg.UserID,
+			CreatedAt: t.now(),
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetOAuthIdentityUser(ctx context.Context, arg database.GetOAuthIdentityUserParams) (database.User, error) {
+	defer m.read()()
+	identity, ok := m.tables.oauthIdentities[namedKey{Name: arg.Provider, Key: arg.Subject}]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return m.tables.users[identity.UserID], nil
+}
+
+func (m *Memory) ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (database.WebauthnChallenge, error) {
+	defer m.write()()
+	row, ok := m.tables.webauthnChallenges[challenge]
+	if !ok {
+		return database.WebauthnChallenge{}, sql.ErrNoRows
+	}
+	delete(m.tables.webauthnChallenges, challenge)
+	return row, nil
+}
+
+func (m *Memory) CreateWebAuthnChallenge(ctx context.Context, arg database.CreateWebAuthnChallengeParams) error {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.webauthnChallenges[arg.Challenge]; ok {
+		return ErrUniqueViolation
+	}
+	if arg.UserID.Valid && !t.hasUser(arg.UserID.UUID) {
+		return ErrForeignKeyViolation
+	}
+	t.webauthnChallenges[arg.Challenge] = database.WebauthnChallenge{
+		Challenge:   arg.Challenge,
+		Ceremony:    arg.Ceremony,
+		UserID:      arg.UserID,
+		ExpiresAt:   arg.ExpiresAt,
+		SessionData: arg.SessionData,
+	}
+	return nil
+}
+
+func (m *Memory) CreateWebAuthnCredential(ctx context.Context, arg database.CreateWebAuthnCredentialParams) (database.WebauthnCredential, error) {
+	defer m.write()()
+	t := m.tables
+	if _, ok := t.webauthnCredentials[string(arg.ID)]; ok {
+		return database.WebauthnCredential{}, sql.ErrNoRows
+	}
+	if !t.hasUser(arg.UserID) {
+		return database.WebauthnCredential{}, ErrForeignKeyViolation
+	}
+	credential := database.WebauthnCredential{
+		ID:             slices.Clone(arg.ID),
+		UserID:         arg.UserID,
+		PublicKey:      slices.Clone(arg.PublicKey),
+		SignCount:      arg.SignCount,
+		Name:           arg.Name,
+		CreatedAt:      t.now(),
+		BackupEligible: arg.BackupEligible,
+		BackupState:    arg.BackupState,
+	}
+	t.webauthnCredentials[string(arg.ID)] = credential
+	return credential, nil
+}
+
+func (m *Memory) DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return deleteWhere(m.tables.webauthnChallenges, func(row database.WebauthnChallenge) bool {
+		return row.ExpiresAt.Before(now)
+	}), nil
+}
+
+func (m *Memory) DeleteWebAuthnCredential(ctx context.Context, arg database.DeleteWebAuthnCredentialParams) (int64, error) {
+	defer m.write()()
+	credential, ok := m.tables.webauthnCredentials[string(arg.ID)]
+	if !ok || credential.UserID != arg.UserID {
+		return 0, nil
+	}
+	delete(m.tables.webauthnCredentials, string(arg.ID))
+	return 1, nil
+}
+
+func (m *Memory) GetWebAuthnCredential(ctx context.Context, id []byte) (database.WebauthnCredential, error) {
+	defer m.read()()
+	credential, ok := m.tables.webauthnCredentials[string(id)]
+	if !ok {
+		return database.WebauthnCredential{}, sql.ErrNoRows
+	}
+	return credential, nil
+}
+
+func (m *Memory) GetWebAuthnCredentialsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebauthnCredential, error) {
+	defer m.read()()
+	return selectWhere(m.tables.webauthnCredentials, func(row database.WebauthnCredential) bool {
+		return row.UserID == userID
+	}, func(a, b database.WebauthnCredential) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.ID), string(b.ID))
+	}), nil
+}
+
+func (m *Memory) UpdateWebAuthnCredentialUsage(ctx context.Context, arg database.UpdateWebAuthnCredentialUsageParams) error {
+	defer m.write()()
+	t := m.tables
+	credential, ok := t.webauthnCredentials[string(arg.ID)]
+	if !ok {
+		return nil
+	}
+	credential.SignCount = arg.SignCount
+	credential.BackupEligible = arg.BackupEligible
+	credential.BackupState = arg.BackupState
+	credential.LastUsedAt = sql.NullTime{Time: t.now(), Valid: true}
+	t.webauthnCredentials[string(arg.ID)] = credential
+	return nil
+}
+
+func (m *Memory) ClaimDataExports(ctx context.Context, arg database.ClaimDataExportsParams) ([]database.DataExport, error) {
+	defer m.write()()
+	t := m.tables
+	claimable := selectWhere(t.dataExports, func(row database.DataExport) bool {
+		return row.Status == "pending" || (row.Status == "building" && row.UpdatedAt.Before(arg.StaleBefore))
+	}, oldestDataExportFirst)
+	claimed := limit(claimable, arg.MaxExports)
+	now := t.now()
+	for i := range claimed {
+		claimed[i].Status = "building"
+		claimed[i].UpdatedAt = now
+		t.dataExports[claimed[i].ID] = claimed[i]
+	}
+	return claimed, nil
+}
+
+func oldestDataExportFirst(a, b database.DataExport) int {
+	return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+// updateDataExport changes an export with change, if there is one
+func (m *Memory) updateDataExport(id uuid.UUID, change func(*database.DataExport)) {
+	t := m.tables
+	export, ok := t.dataExports[id]
+	if !ok {
+		return
+	}
+	change(&export)
+	t.dataExports[id] = export
+}
+
+func (m *Memory) CompleteDataExport(ctx context.Context, arg database.CompleteDataExportParams) error {
+	defer m.write()()
+	now := m.tables.now()
+	m.updateDataExport(arg.ID, func(export *database.DataExport) {
+		export.Status = "ready"
+		export.Archive = slices.Clone(arg.Archive)
+		export.Error = sql.NullString{}
+		export.CompletedAt = sql.NullTime{Time: now, Valid: true}
+		export.ExpiresAt = arg.ExpiresAt
+		export.UpdatedAt = now
+	})
+	return nil
+}
+
+func (m *Memory) CreateDataExport(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	defer m.write()()
+	t := m.tables
+	if !t.hasUser(userID) {
+		return database.DataExport{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	export := database.DataExport{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    userID,
+		Status:    "pending",
+	}
+	t.dataExports[export.ID] = export
+	return export, nil
+}
+
+func (m *Memory) DeleteExpiredDataExports(ctx context.Context) (int64, error) {
+	defer m.write()()
+	now := m.tables.now()
+	return deleteWhere(m.tables.dataExports, func(row database.DataExport) bool {
+		return row.ExpiresAt.Valid && row.ExpiresAt.Time.Before(now)
+	}), nil
+}
+
+func (m *Memory) FailDataExport(ctx context.Context, arg database.FailDataExportParams) error {
+	defer m.write()()
+	now := m.tables.now()
+	m.updateDataExport(arg.ID, func(export *database.DataExport) {
+		export.Status = "failed"
+		export.Error = arg.Error
+		export.CompletedAt = sql.NullTime{Time: now, Valid: true}
+		export.ExpiresAt = arg.ExpiresAt
+		export.UpdatedAt = now
+	})
+	return nil
+}
+
+func (m *Memory) GetCurrentDataExportForUser(ctx context.Context, userID uuid.UUID) (database.DataExport, error) {
+	defer m.read()()
+	now := time.Now()
+	exports := selectWhere(m.tables.dataExports, func(row database.DataExport) bool {
+		return row.UserID == userID && row.Status != "failed" && (!row.ExpiresAt.Valid || row.ExpiresAt.Time.After(now))
+	}, func(a, b database.DataExport) int { return -oldestDataExportFirst(a, b) })
+	if len(exports) == 0 {
+		return database.DataExport{}, sql.ErrNoRows
+	}
+	return exports[0], nil
+}
+
+func (m *Memory) GetDataExport(ctx context.Context, id uuid.UUID) (database.DataExport, error) {
+	defer m.read()()
+	export, ok := m.tables.dataExports[id]
+	if !ok {
+		return database.DataExport{}, sql.ErrNoRows
+	}
+	return export, nil
+}
+
+// ignoreNoRows is the error of an :exec query, which succeeds whether or
+// not it changed a row
+func ignoreNoRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// rowsAffected is the count of an :execrows query that changes at most one
+// row
+func rowsAffected(err error) (int64, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// dueDelivery reports whether a delivery is waiting for a try that's due
+func dueDelivery(delivery database.WebhookDelivery, now time.Time) bool {
+	return delivery.Status == "pending" && !delivery.NextAttemptAt.After(now)
+}
+
+func (m *Memory) ClaimDueWebhookDeliveries(ctx context.Context, arg database.ClaimDueWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	due := selectWhere(t.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return dueDelivery(delivery, now)
+	}, func(a, b database.WebhookDelivery) int {
+		return compareRows(a.NextAttemptAt, a.ID, b.NextAttemptAt, b.ID)
+	})
+	claimed := limit(due, arg.MaxDeliveries)
+	for i := range claimed {
+		delivery := &claimed[i]
+		delivery.NextAttemptAt = arg.LeaseUntil
+		delivery.UpdatedAt = now
+		t.webhookDeliveries[delivery.ID] = *delivery
+	}
+	return claimed, nil
+}
+
+func (m *Memory) CountWebhookEndpointsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	defer m.read()()
+	var count int64
+	for _, endpoint := range m.tables.webhookEndpoints {
+		if endpoint.UserID.Valid && endpoint.UserID.UUID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) CreateWebhookDeliveries(ctx context.Context, arg database.CreateWebhookDeliveriesParams) (int64, error) {
+	defer m.write()()
+	t := m.tables
+	now := t.now()
+	var created int64
+	for _, endpoint := range t.webhookEndpoints {
+		if !endpoint.Active || (endpoint.UserID.Valid && endpoint.UserID.UUID != arg.UserID) ||
+			!slices.Contains(endpoint.Events, arg.EventType) {
+			continue
+		}
+		delivery := database.WebhookDelivery{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			EndpointID:    endpoint.ID,
+			EventID:       arg.EventID,
+			EventType:     arg.EventType,
+			Payload:       arg.Payload,
+			Status:        "pending",
+			NextAttemptAt: now,
+		}
+		t.webhookDeliveries[delivery.ID] = delivery
+		created++
+	}
+	return created, nil
+}
+
+func (m *Memory) CreateWebhookEndpoint(ctx context.Context, arg database.CreateWebhookEndpointParams) (database.WebhookEndpoint, error) {
+	defer m.write()()
+	t := m.tables
+	if arg.UserID.Valid && !t.hasUser(arg.UserID.UUID) {
+		return database.WebhookEndpoint{}, ErrForeignKeyViolation
+	}
+	now := t.now()
+	endpoint := database.WebhookEndpoint{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    arg.UserID,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		Events:    slices.Clone(arg.Events),
+		Active:    arg.Active,
+	}
+	t.webhookEndpoints[endpoint.ID] = endpoint
+	return endpoint, nil
+}
+
+func (m *Memory) DeleteDeliveredWebhookDeliveriesBefore(ctx context.Context, before time.Time) (int64, error) {
+	defer m.write()()
+	return deleteWhere(m.tables.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return delivery.Status == "delivered" && delivery.DeliveredAt.Valid && delivery.DeliveredAt.Time.Before(before)
+	}), nil
+}
+
+func (m *Memory) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	m.tables.deleteWebhookEndpoint(id)
+	return nil
+}
+
+// oldestEndpointFirst orders endpoints by creation
+func oldestEndpointFirst(a, b database.WebhookEndpoint) int {
+	return compareRows(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+}
+
+func (m *Memory) GetAdminWebhookEndpoints(ctx context.Context) ([]database.WebhookEndpoint, error) {
+	defer m.read()()
+	return selectWhere(m.tables.webhookEndpoints, func(endpoint database.WebhookEndpoint) bool {
+		return !endpoint.UserID.Valid
+	}, oldestEndpointFirst), nil
+}
+
+func (m *Memory) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	defer m.read()()
+	delivery, ok := m.tables.webhookDeliveries[id]
+	if !ok {
+		return database.WebhookDelivery{}, sql.ErrNoRows
+	}
+	return delivery, nil
+}
+
+func (m *Memory) GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (database.WebhookEndpoint, error) {
+	defer m.read()()
+	endpoint, ok := m.tables.webhookEndpoints[id]
+	if !ok {
+		return database.WebhookEndpoint{}, sql.ErrNoRows
+	}
+	return endpoint, nil
+}
+
+func (m *Memory) GetWebhookEndpointsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebhookEndpoint, error) {
+	defer m.read()()
+	return selectWhere(m.tables.webhookEndpoints, func(endpoint database.WebhookEndpoint) bool {
+		return endpoint.UserID.Valid && endpoint.UserID.UUID == userID
+	}, oldestEndpointFirst), nil
+}
+
+func (m *Memory) ListWebhookDeliveries(ctx context.Context, arg database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	defer m.read()()
+	deliveries := selectWhere(m.tables.webhookDeliveries, func(delivery database.WebhookDelivery) bool {
+		return optional(delivery.Status, arg.Status.String, arg.Status.Valid) &&
+			optional(delivery.EndpointID, arg.EndpointID.UUID, arg.EndpointID.Valid)
+	}, func(a, b database.WebhookDelivery) int {
+		return compareRows(b.CreatedAt, b.ID, a.CreatedAt, a.ID)
+	})
+	return limit(deliveries, arg.Limit), nil
+}
+
+// updateWebhookDelivery changes a delivery, returning sql.ErrNoRows if
+// there's no such delivery
+func (m *Memory) updateWebhookDelivery(id uuid.UUID, change func(*database.WebhookDelivery)) (database.WebhookDelivery, error) {
+	t := m.tables
+	delivery, ok := t.webhookDeliveries[id]
+	if !ok {
+		return database.WebhookDelivery{}, sql.ErrNoRows
+	}
+	change(&delivery)
+	delivery.UpdatedAt = t.now()
+	t.webhookDeliveries[id] = delivery
+	return delivery, nil
+}
+
+func (m *Memory) MarkWebhookDeliveryDelivered(ctx context.Context, id uuid.UUID) error {
+	defer m.write()()
+	_, err := m.updateWebhookDelivery(id, func(delivery *database.WebhookDelivery) {
+		delivery.Status = "delivered"
+		delivery.Attempts++
+		delivery.LastError = sql.NullString{}
+		delivery.DeliveredAt = sql.NullTime{Time: m.tables.now(), Valid: true}
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) MarkWebhookDeliveryFailed(ctx context.Context, arg database.MarkWebhookDeliveryFailedParams) error {
+	defer m.write()()
+	_, err := m.updateWebhookDelivery(arg.ID, func(delivery *database.WebhookDelivery) {
+		delivery.Status = arg.Status
+		delivery.Attempts++
+		delivery.LastError = arg.LastError
+		delivery.NextAttemptAt = arg.NextAttemptAt
+	})
+	return ignoreNoRows(err)
+}
+
+func (m *Memory) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error) {
+	defer m.write()()
+	return m.updateWebhookDelivery(id, func(delivery *database.WebhookDelivery) {
+		delivery.Status = "pending"
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = m.tables.now()
+	})
+}
+
+func (m *Memory) UpdateWebhookEndpoint(ctx context.Context, arg database.UpdateWebhookEndpointParams) (database.WebhookEndpoint, error) {
+	defer m.write()()
+	t := m.tables
+	endpoint, ok := t.webhookEndpoints[arg.ID]
+	if !ok {
+		return database.WebhookEndpoint{}, sql.ErrNoRows
+	}
+	endpoint.Url = arg.Url
+	endpoint.Events = slices.Clone(arg.Events)
+	endpoint.Active = arg.Active
+	endpoint.UpdatedAt = t.now()
+	t.webhookEndpoints[arg.ID] = endpoint
+	return endpoint, nil
+}
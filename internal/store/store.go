@@ -0,0 +1,468 @@
+// Package store is the persistence Chirpy's handlers depend on, split by
+// area so code can ask for only the part it uses.
+//
+// SQL implements it with the queries sqlc generates in internal/database,
+// against Postgres or the SQLite driver in internal/sqlite. Memory keeps
+// everything in maps instead, for tests and demos. Tests can also embed
+// Store in a struct of their own and override just the methods they call.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Store is everything Chirpy persists
+type Store interface {
+	UserStore
+	ChirpStore
+	TokenStore
+	SocialStore
+	BillingStore
+	AdminStore
+	ModerationStore
+	AuditStore
+	WebhookStore
+	ExperimentStore
+
+	// InTx runs fn with a Store whose calls all happen in one transaction,
+	// committed if fn returns nil and rolled back otherwise
+	InTx(ctx context.Context, fn func(Store) error) error
+}
+
+// UserStore holds accounts, their profiles and the ways they sign in
+type UserStore interface {
+	// Returns no rows when the email or handle is already taken
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+
+	// Bumps the token version too, so outstanding access tokens stop working
+	DeactivateUser(ctx context.Context, id uuid.UUID) (database.User, error)
+	DeleteAllUsers(ctx context.Context) error
+
+	// Everything else the user owns goes with them through ON DELETE CASCADE
+	DeleteUser(ctx context.Context, id uuid.UUID) (int64, error)
+
+	// One page of the export, after the last row of the page before
+	ExportUsers(ctx context.Context, arg database.ExportUsersParams) ([]database.ExportUsersRow, error)
+	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+
+	// Deactivated accounts have no public profile
+	GetUserByHandle(ctx context.Context, handle sql.NullString) (database.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+
+	// Locks the row until the transaction ends
+	GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetUsersDeactivatedBefore(ctx context.Context, arg database.GetUsersDeactivatedBeforeParams) ([]database.User, error)
+	IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) error
+
+	// query matches part of the email address or handle. A suspension that has
+	// run out doesn't count as suspended.
+	ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error)
+	LockUser(ctx context.Context, arg database.LockUserParams) error
+
+	// Matches on email so a token for a previous address has no effect
+	MarkEmailVerified(ctx context.Context, arg database.MarkEmailVerifiedParams) (database.User, error)
+
+	// Returns no rows unless the user was deactivated after deactivated_after
+	ReactivateUser(ctx context.Context, arg database.ReactivateUserParams) (database.User, error)
+
+	// Starts a new count when the current window began before window_start
+	RecordFailedLogin(ctx context.Context, arg database.RecordFailedLoginParams) (int32, error)
+
+	// Bumps the token version too, so outstanding access tokens stop working
+	RequirePasswordReset(ctx context.Context, id uuid.UUID) (database.User, error)
+	ResetFailedLogins(ctx context.Context, id uuid.UUID) error
+	SetRecommendations(ctx context.Context, arg database.SetRecommendationsParams) (database.User, error)
+	SetShareLocation(ctx context.Context, arg database.SetShareLocationParams) (database.User, error)
+	SetUserAvatar(ctx context.Context, arg database.SetUserAvatarParams) (database.User, error)
+
+	// Returns no rows when another user already holds the handle
+	SetUserHandle(ctx context.Context, arg database.SetUserHandleParams) (database.User, error)
+	SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error
+	SetUserPlan(ctx context.Context, arg database.SetUserPlanParams) (int64, error)
+
+	// A real change bumps the token version too, so access tokens carrying the
+	// old role stop working; refreshing picks up the new one
+	SetUserRole(ctx context.Context, arg database.SetUserRoleParams) (database.User, error)
+	SetUserShadowBanned(ctx context.Context, arg database.SetUserShadowBannedParams) (database.User, error)
+
+	// Bumps the token version too, so outstanding access tokens stop working
+	SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error)
+	UnlockUser(ctx context.Context, id uuid.UUID) (database.User, error)
+	UnsuspendUser(ctx context.Context, id uuid.UUID) (database.User, error)
+
+	// Changing the email address clears its verification, and the new password
+	// satisfies any forced reset. Returns no rows when another user already has
+	// the address.
+	UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error)
+
+	// Fields left NULL keep their current value
+	UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) (database.User, error)
+	CreateOAuthIdentity(ctx context.Context, arg database.CreateOAuthIdentityParams) error
+	GetOAuthIdentityUser(ctx context.Context, arg database.GetOAuthIdentityUserParams) (database.User, error)
+
+	// Challenges are single use
+	ConsumeWebAuthnChallenge(ctx context.Context, challenge string) (database.WebauthnChallenge, error)
+	CreateWebAuthnChallenge(ctx context.Context, arg database.CreateWebAuthnChallengeParams) error
+	CreateWebAuthnCredential(ctx context.Context, arg database.CreateWebAuthnCredentialParams) (database.WebauthnCredential, error)
+	DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error)
+	DeleteWebAuthnCredential(ctx context.Context, arg database.DeleteWebAuthnCredentialParams) (int64, error)
+	GetWebAuthnCredential(ctx context.Context, id []byte) (database.WebauthnCredential, error)
+	GetWebAuthnCredentialsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebauthnCredential, error)
+	UpdateWebAuthnCredentialUsage(ctx context.Context, arg database.UpdateWebAuthnCredentialUsageParams) error
+
+	// Marks pending exports as building, along with any left building since
+	// stale_before by a worker that didn't finish. The outer check skips any
+	// that another worker claimed first.
+	ClaimDataExports(ctx context.Context, arg database.ClaimDataExportsParams) ([]database.DataExport, error)
+	CompleteDataExport(ctx context.Context, arg database.CompleteDataExportParams) error
+	CreateDataExport(ctx context.Context, userID uuid.UUID) (database.DataExport, error)
+	DeleteExpiredDataExports(ctx context.Context) (int64, error)
+	FailDataExport(ctx context.Context, arg database.FailDataExportParams) error
+
+	// The newest export that's under way or ready to download
+	GetCurrentDataExportForUser(ctx context.Context, userID uuid.UUID) (database.DataExport, error)
+	GetDataExport(ctx context.Context, id uuid.UUID) (database.DataExport, error)
+}
+
+// ChirpStore holds chirps and what hangs off them: likes, hashtags,
+// mentions, translations and bookmarks
+type ChirpStore interface {
+	// Applies a batch of buffered view counts in one statement
+	AddChirpViews(ctx context.Context, arg database.AddChirpViewsParams) error
+	AdjustChirpLikeCount(ctx context.Context, arg database.AdjustChirpLikeCountParams) (database.Chirp, error)
+	AdjustChirpReplyCount(ctx context.Context, arg database.AdjustChirpReplyCountParams) error
+	CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error)
+
+	// Deletes every chirp the user has written, including soft-deleted ones,
+	// returning their IDs
+	DeleteChirpsForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+
+	// One page of the export, after the last row of the page before
+	ExportChirps(ctx context.Context, arg database.ExportChirpsParams) ([]database.Chirp, error)
+	GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error)
+	GetAllChirpsDesc(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error)
+	GetChirpByID(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+
+	// Finds the chirp whatever its state, for moderators
+	GetChirpForModeration(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	GetChirpIDsByAuthor(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	GetChirpReplies(ctx context.Context, arg database.GetChirpRepliesParams) ([]database.Chirp, error)
+
+	// Engagement across all of a user's published chirps
+	GetChirpTotalsForUser(ctx context.Context, userID uuid.UUID) (database.GetChirpTotalsForUserRow, error)
+	GetChirpsByAuthor(ctx context.Context, arg database.GetChirpsByAuthorParams) ([]database.Chirp, error)
+	GetChirpsByAuthorDesc(ctx context.Context, arg database.GetChirpsByAuthorDescParams) ([]database.Chirp, error)
+
+	// The listed chirps that are still visible to the viewer, in no particular
+	// order
+	GetChirpsByIDs(ctx context.Context, arg database.GetChirpsByIDsParams) ([]database.Chirp, error)
+
+	// Everything the user hasn't deleted, including chirps still pending
+	GetChirpsForExport(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error)
+	GetChirpsInCells(ctx context.Context, arg database.GetChirpsInCellsParams) ([]database.Chirp, error)
+	GetChirpsPage(ctx context.Context, arg database.GetChirpsPageParams) ([]database.Chirp, error)
+	GetChirpsPageDesc(ctx context.Context, arg database.GetChirpsPageDescParams) ([]database.Chirp, error)
+
+	// Publication order rather than creation order, since held-back chirps are
+	// published after newer ones
+	GetChirpsPublishedAfter(ctx context.Context, arg database.GetChirpsPublishedAfterParams) ([]database.Chirp, error)
+
+	// Whether the author's chirps are kept from the viewer, for reads of a
+	// single chirp, whose cached copy is the same for every viewer
+	IsChirpAuthorHidden(ctx context.Context, arg database.IsChirpAuthorHiddenParams) (bool, error)
+
+	// Replies only count towards their parent once they're published
+	PublishDueChirps(ctx context.Context) ([]database.PublishDueChirpsRow, error)
+	PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error)
+
+	// Takes the user's published replies off the reply counts of other users'
+	// chirps, for when the replies are about to be deleted with the user,
+	// returning the chirps that changed
+	ReleaseChirpRepliesByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	SearchChirps(ctx context.Context, arg database.SearchChirpsParams) ([]database.Chirp, error)
+	// Returns the chirp as deleted, so whether it was published is read under
+	// the same row lock a concurrent PublishDueChirps takes
+	SoftDeleteChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	UpdateChirpBody(ctx context.Context, arg database.UpdateChirpBodyParams) (database.Chirp, error)
+	CreateChirpLike(ctx context.Context, arg database.CreateChirpLikeParams) (int64, error)
+	DeleteChirpLike(ctx context.Context, arg database.DeleteChirpLikeParams) (int64, error)
+	GetChirpLikesForUser(ctx context.Context, userID uuid.UUID) ([]database.ChirpLike, error)
+
+	// Takes the user's likes off the like counts of the chirps they liked, for
+	// when the likes are about to be deleted with the user, returning the
+	// chirps that changed
+	ReleaseChirpLikesByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	CreateChirpHashtags(ctx context.Context, arg database.CreateChirpHashtagsParams) error
+	DeleteChirpHashtags(ctx context.Context, chirpID uuid.UUID) error
+	GetChirpsByHashtag(ctx context.Context, arg database.GetChirpsByHashtagParams) ([]database.Chirp, error)
+
+	// Returns 0 when already following
+	CreateHashtagFollow(ctx context.Context, arg database.CreateHashtagFollowParams) (int64, error)
+	DeleteHashtagFollow(ctx context.Context, arg database.DeleteHashtagFollowParams) (int64, error)
+	GetFollowedHashtags(ctx context.Context, userID uuid.UUID) ([]string, error)
+
+	// Handles that don't belong to anyone are ignored
+	CreateMentions(ctx context.Context, arg database.CreateMentionsParams) error
+	DeleteMentions(ctx context.Context, chirpID uuid.UUID) error
+	GetMentionsForUser(ctx context.Context, arg database.GetMentionsForUserParams) ([]database.Chirp, error)
+	DeleteChirpTranslations(ctx context.Context, chirpID uuid.UUID) error
+	GetChirpTranslation(ctx context.Context, arg database.GetChirpTranslationParams) (database.ChirpTranslation, error)
+	UpsertChirpTranslation(ctx context.Context, arg database.UpsertChirpTranslationParams) (database.ChirpTranslation, error)
+	CreateBookmark(ctx context.Context, arg database.CreateBookmarkParams) error
+	DeleteBookmark(ctx context.Context, arg database.DeleteBookmarkParams) (int64, error)
+	GetBookmarks(ctx context.Context, arg database.GetBookmarksParams) ([]database.GetBookmarksRow, error)
+}
+
+// TokenStore holds the tokens issued to users: refresh tokens, revoked
+// access tokens, login links and email verifications, along with the
+// history of sign-ins
+type TokenStore interface {
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error)
+
+	// Tokens that can no longer be used, whether expired or revoked
+	DeleteStaleRefreshTokens(ctx context.Context) (int64, error)
+	GetActiveSessionsForUser(ctx context.Context, userID uuid.UUID) ([]database.RefreshToken, error)
+
+	// signed_in_at is when the session began, which is when the token was made
+	GetUserFromRefreshToken(ctx context.Context, token string) (database.GetUserFromRefreshTokenRow, error)
+
+	// Returns no rows when the token is unknown or already revoked
+	RevokeRefreshToken(ctx context.Context, token string) (uuid.UUID, error)
+	RevokeRefreshTokenForUser(ctx context.Context, arg database.RevokeRefreshTokenForUserParams) (int64, error)
+	RevokeSession(ctx context.Context, arg database.RevokeSessionParams) (int64, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+	TouchRefreshToken(ctx context.Context, token string) error
+	DeleteExpiredRevokedAccessTokens(ctx context.Context) (int64, error)
+
+	// What's needed to tell whether a token for the user is still good
+	GetAccessTokenStatus(ctx context.Context, arg database.GetAccessTokenStatusParams) (database.GetAccessTokenStatusRow, error)
+	RevokeAccessToken(ctx context.Context, arg database.RevokeAccessTokenParams) error
+
+	CreateLogin(ctx context.Context, arg database.CreateLoginParams) (database.Login, error)
+
+	// Whether the user has signed in before at all, and from this device on
+	// this network
+	GetLoginDeviceHistory(ctx context.Context, arg database.GetLoginDeviceHistoryParams) (database.GetLoginDeviceHistoryRow, error)
+	ListLogins(ctx context.Context, arg database.ListLoginsParams) ([]database.Login, error)
+
+	// Tokens are single use
+	ConsumeMagicLinkToken(ctx context.Context, tokenHash string) (database.MagicLinkToken, error)
+	CreateMagicLinkToken(ctx context.Context, arg database.CreateMagicLinkTokenParams) error
+	DeleteExpiredMagicLinkTokens(ctx context.Context) (int64, error)
+	MagicLinkSentSince(ctx context.Context, arg database.MagicLinkSentSinceParams) (bool, error)
+
+	// Tokens are single use
+	ConsumeEmailVerificationToken(ctx context.Context, tokenHash string) (database.EmailVerificationToken, error)
+	CreateEmailVerificationToken(ctx context.Context, arg database.CreateEmailVerificationTokenParams) error
+	DeleteEmailVerificationTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+// SocialStore holds how users relate to each other: follows, mutes,
+// blocks, lists, direct messages and notifications
+type SocialStore interface {
+	// Returns 0 when already following
+	CreateFollow(ctx context.Context, arg database.CreateFollowParams) (int64, error)
+	DeleteFollow(ctx context.Context, arg database.DeleteFollowParams) (int64, error)
+	GetFollowers(ctx context.Context, followeeID uuid.UUID) ([]database.User, error)
+	GetFollowing(ctx context.Context, followerID uuid.UUID) ([]database.User, error)
+	GetTimeline(ctx context.Context, arg database.GetTimelineParams) ([]database.Chirp, error)
+
+	// The most liked recent chirps tagged with hashtags the viewer follows,
+	// leaving out replies, the viewer's own chirps and any by accounts they've
+	// muted or blocked or been blocked by
+	GetFollowedHashtagChirps(ctx context.Context, arg database.GetFollowedHashtagChirpsParams) ([]database.Chirp, error)
+
+	// The most liked recent chirps by accounts the ones the viewer follows
+	// follow, leaving out replies, accounts the viewer already follows and any
+	// they've muted or blocked or been blocked by
+	GetNetworkChirps(ctx context.Context, arg database.GetNetworkChirpsParams) ([]database.Chirp, error)
+	CreateMute(ctx context.Context, arg database.CreateMuteParams) error
+	DeleteMute(ctx context.Context, arg database.DeleteMuteParams) (int64, error)
+	GetMutedUsers(ctx context.Context, muterID uuid.UUID) ([]database.User, error)
+	CreateBlock(ctx context.Context, arg database.CreateBlockParams) error
+	DeleteBlock(ctx context.Context, arg database.DeleteBlockParams) (int64, error)
+	GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]database.User, error)
+	IsBlockedEitherWay(ctx context.Context, arg database.IsBlockedEitherWayParams) (bool, error)
+	AddListMember(ctx context.Context, arg database.AddListMemberParams) error
+	CreateList(ctx context.Context, arg database.CreateListParams) (database.List, error)
+	DeleteList(ctx context.Context, id uuid.UUID) error
+	GetListByID(ctx context.Context, id uuid.UUID) (database.List, error)
+	GetListMembers(ctx context.Context, listID uuid.UUID) ([]database.User, error)
+	GetListTimeline(ctx context.Context, arg database.GetListTimelineParams) ([]database.Chirp, error)
+	GetListsByOwner(ctx context.Context, ownerID uuid.UUID) ([]database.List, error)
+	RemoveListMember(ctx context.Context, arg database.RemoveListMemberParams) (int64, error)
+	UpdateList(ctx context.Context, arg database.UpdateListParams) (database.List, error)
+	CreateMessage(ctx context.Context, arg database.CreateMessageParams) (database.Message, error)
+	GetConversationForUser(ctx context.Context, arg database.GetConversationForUserParams) (database.Conversation, error)
+	GetConversationsForUser(ctx context.Context, arg database.GetConversationsForUserParams) ([]database.GetConversationsForUserRow, error)
+	GetMessages(ctx context.Context, arg database.GetMessagesParams) ([]database.Message, error)
+
+	// Sending a message bumps updated_at so inboxes sort by latest activity
+	UpsertConversation(ctx context.Context, arg database.UpsertConversationParams) (database.Conversation, error)
+	CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error)
+	GetNotificationsForUser(ctx context.Context, arg database.GetNotificationsForUserParams) ([]database.Notification, error)
+	MarkNotificationsRead(ctx context.Context, userID uuid.UUID) error
+}
+
+// BillingStore holds plans, subscriptions, promo codes, entitlements and
+// the payment webhooks behind them
+type BillingStore interface {
+	GetPlanByID(ctx context.Context, id string) (database.Plan, error)
+	GetPlanForUser(ctx context.Context, id uuid.UUID) (database.Plan, error)
+	GetPlans(ctx context.Context) ([]database.Plan, error)
+	CancelSubscription(ctx context.Context, userID uuid.UUID) error
+	ClaimSubscriptionGift(ctx context.Context, userID uuid.UUID) (int64, error)
+	ExpireSubscription(ctx context.Context, userID uuid.UUID) error
+	GetLapsedSubscriptions(ctx context.Context) ([]uuid.UUID, error)
+	GetSubscriptionByUserID(ctx context.Context, userID uuid.UUID) (database.Subscription, error)
+	LockSubscription(ctx context.Context, userID uuid.UUID) (database.Subscription, error)
+	UpsertSubscription(ctx context.Context, arg database.UpsertSubscriptionParams) (database.Subscription, error)
+	CreateSubscriptionEvent(ctx context.Context, arg database.CreateSubscriptionEventParams) (database.SubscriptionEvent, error)
+	GetSubscriptionEventsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetSubscriptionEventsForUserRow, error)
+	ClaimPromoCode(ctx context.Context, code string) (database.PromoCode, error)
+	CreatePromoCode(ctx context.Context, arg database.CreatePromoCodeParams) (database.PromoCode, error)
+	CreatePromoRedemption(ctx context.Context, arg database.CreatePromoRedemptionParams) (database.PromoRedemption, error)
+	GetPromoCodes(ctx context.Context) ([]database.PromoCode, error)
+	DeleteEntitlementOverride(ctx context.Context, arg database.DeleteEntitlementOverrideParams) (int64, error)
+	GetEntitlementOverrides(ctx context.Context, userID uuid.UUID) ([]database.EntitlementOverride, error)
+	GetFeatureFlags(ctx context.Context) ([]database.FeatureFlag, error)
+	GetPlanFeatures(ctx context.Context, planID string) ([]string, error)
+	UpsertEntitlementOverride(ctx context.Context, arg database.UpsertEntitlementOverrideParams) (database.EntitlementOverride, error)
+	UpsertFeatureFlag(ctx context.Context, arg database.UpsertFeatureFlagParams) (database.FeatureFlag, error)
+	GetStripeCustomerID(ctx context.Context, userID uuid.UUID) (string, error)
+	LinkStripeCustomer(ctx context.Context, arg database.LinkStripeCustomerParams) (int64, error)
+
+	// Run first in the transaction that applies the event: the row lock makes a
+	// concurrent delivery wait, and then find the event processed and get no
+	// rows back. Processed events are only claimed again with reprocess.
+	ClaimWebhookEvent(ctx context.Context, arg database.ClaimWebhookEventParams) (database.WebhookEvent, error)
+	CreateWebhookEvent(ctx context.Context, arg database.CreateWebhookEventParams) (database.WebhookEvent, error)
+	DeleteProcessedWebhookEventsBefore(ctx context.Context, before time.Time) (int64, error)
+	GetWebhookEventByID(ctx context.Context, id uuid.UUID) (database.WebhookEvent, error)
+	GetWebhookEventBySourceAndEventID(ctx context.Context, arg database.GetWebhookEventBySourceAndEventIDParams) (database.WebhookEvent, error)
+	ListWebhookEvents(ctx context.Context, arg database.ListWebhookEventsParams) ([]database.WebhookEvent, error)
+	MarkWebhookEventFailed(ctx context.Context, arg database.MarkWebhookEventFailedParams) error
+	MarkWebhookEventProcessed(ctx context.Context, id uuid.UUID) error
+}
+
+// AdminStore holds the settings and counters admins manage
+type AdminStore interface {
+	AddBannedWord(ctx context.Context, word string) (int64, error)
+	DeleteBannedWord(ctx context.Context, word string) (int64, error)
+	GetBannedWords(ctx context.Context) ([]string, error)
+	AddHitCount(ctx context.Context, arg database.AddHitCountParams) error
+	GetHitCounters(ctx context.Context) ([]database.GetHitCountersRow, error)
+	ResetHitCounters(ctx context.Context) error
+}
+
+// ModerationStore holds users' reports of chirps, what moderators decided
+// about them and the log of what moderators did
+type ModerationStore interface {
+	// Returns no rows when the reporter already has an open report against the
+	// chirp
+	CreateChirpReport(ctx context.Context, arg database.CreateChirpReportParams) (database.ChirpReport, error)
+	CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error)
+	GetChirpReport(ctx context.Context, id uuid.UUID) (database.ChirpReport, error)
+	GetChirpReportsForChirp(ctx context.Context, chirpID uuid.UUID) ([]database.ChirpReport, error)
+
+	// Oldest first, so the queue is worked through in the order reports came in
+	ListChirpReports(ctx context.Context, arg database.ListChirpReportsParams) ([]database.ChirpReport, error)
+	ListModerationActions(ctx context.Context, arg database.ListModerationActionsParams) ([]database.ModerationAction, error)
+
+	// Records one decision on every open report against the chirp
+	ResolveChirpReports(ctx context.Context, arg database.ResolveChirpReportsParams) ([]database.ChirpReport, error)
+}
+
+// AuditStore holds the append-only log of security-relevant events
+type AuditStore interface {
+	CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error
+	ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error)
+}
+
+// ExperimentStore holds the exposures and conversions recorded in A/B
+// experiments
+type ExperimentStore interface {
+	CreateExperimentEvent(ctx context.Context, arg database.CreateExperimentEventParams) error
+
+	// Event and distinct user counts per variant, kind and conversion name
+	GetExperimentResults(ctx context.Context, experimentKey string) ([]database.GetExperimentResultsRow, error)
+}
+
+// WebhookStore holds the endpoints outgoing webhooks are posted to
+type WebhookStore interface {
+	// Leases the pending deliveries that are due by moving their next attempt
+	// to lease_until, so another worker won't pick them up in the meantime.
+	// The outer check skips any that one already has.
+	ClaimDueWebhookDeliveries(ctx context.Context, arg database.ClaimDueWebhookDeliveriesParams) ([]database.WebhookDelivery, error)
+	CountWebhookEndpointsForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// Queues an event for the active endpoints of user_id that take it, along
+	// with every admin endpoint that takes it
+	CreateWebhookDeliveries(ctx context.Context, arg database.CreateWebhookDeliveriesParams) (int64, error)
+	CreateWebhookEndpoint(ctx context.Context, arg database.CreateWebhookEndpointParams) (database.WebhookEndpoint, error)
+	DeleteDeliveredWebhookDeliveriesBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+	GetAdminWebhookEndpoints(ctx context.Context) ([]database.WebhookEndpoint, error)
+	GetWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error)
+	GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (database.WebhookEndpoint, error)
+	GetWebhookEndpointsForUser(ctx context.Context, userID uuid.UUID) ([]database.WebhookEndpoint, error)
+	ListWebhookDeliveries(ctx context.Context, arg database.ListWebhookDeliveriesParams) ([]database.WebhookDelivery, error)
+	MarkWebhookDeliveryDelivered(ctx context.Context, id uuid.UUID) error
+
+	// Records a failed attempt, with status either pending for another try at
+	// next_attempt_at or dead once it's out of tries
+	MarkWebhookDeliveryFailed(ctx context.Context, arg database.MarkWebhookDeliveryFailedParams) error
+
+	// Queues a delivery to be tried again straight away, with its tries reset
+	ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (database.WebhookDelivery, error)
+	UpdateWebhookEndpoint(ctx context.Context, arg database.UpdateWebhookEndpointParams) (database.WebhookEndpoint, error)
+}
+
+// SQL is a Store backed by a database/sql pool
+type SQL struct {
+	*database.Queries
+	db *sql.DB
+}
+
+var _ Store = (*SQL)(nil)
+
+// NewSQL is a Store running the generated queries on db
+func NewSQL(db *sql.DB) *SQL {
+	return &SQL{Queries: database.New(db), db: db}
+}
+
+func (s *SQL) InTx(ctx context.Context, fn func(Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQL{Queries: s.Queries.WithTx(tx), db: s.db}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsForeignKeyViolation reports whether err is a write failing a foreign
+// key, as Postgres, SQLite and Memory each report it
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+	}
+	return errors.Is(err, ErrForeignKeyViolation)
+}
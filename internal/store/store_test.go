@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/sqlite"
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+)
+
+// openTestStore is a store on a migrated in-memory SQLite database
+func openTestStore(t *testing.T) *SQL {
+	t.Helper()
+	db, err := sqlite.Open(sqlite.Memory)
+	if err != nil {
+		t.Fatalf("Expected no error opening, got %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := fs.Sub(sqlite.Schema, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Up(context.Background()); err != nil {
+		t.Fatalf("Expected no error migrating, got %v", err)
+	}
+	return NewSQL(db)
+}
+
+func TestInTx(t *testing.T) {
+	for name, s := range map[string]Store{"SQL": openTestStore(t), "Memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) { testInTx(t, s) })
+	}
+}
+
+func testInTx(t *testing.T, s Store) {
+	ctx := context.Background()
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		email   string
+		err     error
+		wantErr error
+		kept    bool
+	}{
+		{name: "Commits when fn succeeds", email: "kept@example.com", kept: true},
+		{name: "Rolls back when fn fails", email: "dropped@example.com", err: failed, wantErr: failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.InTx(ctx, func(q Store) error {
+				_, err := q.CreateUser(ctx, database.CreateUserParams{Email: tt.email, HashedPassword: "hash"})
+				if err != nil {
+					t.Fatalf("Expected no error creating a user, got %v", err)
+				}
+				return tt.err
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			_, err = s.GetUserByEmail(ctx, tt.email)
+			if kept := err == nil; kept != tt.kept {
+				t.Errorf("Expected the user kept to be %v, got %v (%v)", tt.kept, kept, err)
+			}
+		})
+	}
+}
+
+func TestForeignKeyViolation(t *testing.T) {
+	for name, s := range map[string]Store{"SQL": openTestStore(t), "Memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) { testForeignKeyViolation(t, s) })
+	}
+}
+
+func testForeignKeyViolation(t *testing.T, s Store) {
+	ctx := context.Background()
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("Expected no error creating a user, got %v", err)
+	}
+	if _, err := s.CreatePromoCode(ctx, database.CreatePromoCodeParams{Code: "WELCOME", Plan: "red", DurationDays: 30, MaxRedemptions: 1}); err != nil {
+		t.Fatalf("Expected no error creating a promo code, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		arg     database.CreatePromoRedemptionParams
+		wantErr error
+		wantFK  bool
+	}{
+		{name: "Redeems", arg: database.CreatePromoRedemptionParams{Code: "WELCOME", UserID: user.ID}},
+		{name: "Redeemed already", arg: database.CreatePromoRedemptionParams{Code: "WELCOME", UserID: user.ID}, wantErr: sql.ErrNoRows},
+		{name: "Unknown code", arg: database.CreatePromoRedemptionParams{Code: "MISSING", UserID: user.ID}, wantFK: true},
+		{name: "Unknown user", arg: database.CreatePromoRedemptionParams{Code: "WELCOME", UserID: uuid.New()}, wantFK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreatePromoRedemption(ctx, tt.arg)
+			if fk := IsForeignKeyViolation(err); fk != tt.wantFK {
+				t.Fatalf("Expected a foreign key violation to be %v, got %v (%v)", tt.wantFK, fk, err)
+			}
+			if !tt.wantFK && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSoftDeleteChirp(t *testing.T) {
+	for name, s := range map[string]Store{"SQL": openTestStore(t), "Memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) { testSoftDeleteChirp(t, s) })
+	}
+}
+
+func testSoftDeleteChirp(t *testing.T, s Store) {
+	ctx := context.Background()
+	user, err := s.CreateUser(ctx, database.CreateUserParams{Email: "user@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("Expected no error creating a user, got %v", err)
+	}
+	chirp, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "Scheduled", UserID: user.ID, PublishAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("Expected no error creating a chirp, got %v", err)
+	}
+	// Published after the caller read it
+	if _, err := s.PublishDueChirps(ctx); err != nil {
+		t.Fatalf("Expected no error publishing, got %v", err)
+	}
+
+	deleted, err := s.SoftDeleteChirp(ctx, chirp.ID)
+	if err != nil {
+		t.Fatalf("Expected no error deleting, got %v", err)
+	}
+	if !deleted.PublishedAt.Valid || !deleted.DeletedAt.Valid {
+		t.Errorf("Expected the chirp returned published and deleted, got %+v", deleted)
+	}
+	if _, err := s.SoftDeleteChirp(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows deleting it again, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event is a single real-time update pushed to connected clients
+type Event struct {
+	// ID optionally identifies the event so clients can resume after it
+	ID   string
+	Type string
+	// UserID restricts delivery to one user's connections; uuid.Nil
+	// broadcasts to everyone
+	UserID uuid.UUID
+	Data   any
+}
+
+// Hub fans events out to subscribers. Publishing never blocks: a subscriber
+// whose buffer is full is dropped and its channel closed, so one slow client
+// can't stall the others.
+type Hub struct {
+	mu          sync.Mutex
+	buffer      int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewHub returns a hub giving each subscriber room for buffer pending events
+func NewHub(buffer int) *Hub {
+	return &Hub{
+		buffer:      buffer,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription receives the events addressed to one connected user
+type Subscription struct {
+	UserID uuid.UUID
+	hub    *Hub
+	events chan Event
+}
+
+// Subscribe registers a new subscriber for userID. Callers must Close it
+// when the connection ends.
+func (h *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		UserID: userID,
+		hub:    h,
+		events: make(chan Event, h.buffer),
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Events is closed when the subscription ends, either through Close or
+// because the subscriber fell behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Publish delivers event to every matching subscriber
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if event.UserID != uuid.Nil && event.UserID != sub.UserID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Len returns the number of active subscriptions
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// remove must be called with h.mu held
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPublish(t *testing.T) {
+	alice := uuid.New()
+	bob := uuid.New()
+
+	tests := []struct {
+		name      string
+		event     Event
+		wantAlice bool
+		wantBob   bool
+	}{
+		{
+			name:      "broadcast reaches everyone",
+			event:     Event{Type: "chirp"},
+			wantAlice: true,
+			wantBob:   true,
+		},
+		{
+			name:      "targeted event reaches only its user",
+			event:     Event{Type: "notification", UserID: bob},
+			wantAlice: false,
+			wantBob:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(1)
+			aliceSub := hub.Subscribe(alice)
+			defer aliceSub.Close()
+			bobSub := hub.Subscribe(bob)
+			defer bobSub.Close()
+
+			hub.Publish(tt.event)
+
+			if got := len(aliceSub.Events()); (got == 1) != tt.wantAlice {
+				t.Errorf("Expected alice delivery %v, got %d events", tt.wantAlice, got)
+			}
+			if got := len(bobSub.Events()); (got == 1) != tt.wantBob {
+				t.Errorf("Expected bob delivery %v, got %d events", tt.wantBob, got)
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe(uuid.New())
+
+	hub.Publish(Event{Type: "chirp"})
+	hub.Publish(Event{Type: "chirp"})
+
+	if got := hub.Len(); got != 0 {
+		t.Errorf("Expected slow subscriber to be dropped, got %d subscribers", got)
+	}
+
+	// The buffered event is still delivered before the channel closes
+	if _, ok := <-sub.Events(); !ok {
+		t.Error("Expected buffered event before close")
+	}
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+
+	// Closing after being dropped is a no-op
+	sub.Close()
+}
+
+func TestClose(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe(uuid.New())
+	sub.Close()
+	sub.Close()
+
+	if got := hub.Len(); got != 0 {
+		t.Errorf("Expected 0 subscribers, got %d", got)
+	}
+	hub.Publish(Event{Type: "chirp"})
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+}
@@ -0,0 +1,174 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLanguage = errors.New("language must be an ISO 639-1 code such as \"de\" or \"pt-br\"")
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]{2})?$`)
+
+// Result is a translated text and the language it was translated from
+type Result struct {
+	Text           string
+	SourceLanguage string
+}
+
+// Provider translates text into a target language, detecting the source
+// language itself
+type Provider interface {
+	Name() string
+	Translate(ctx context.Context, text, targetLanguage string) (Result, error)
+}
+
+// NormalizeLanguage lowercases a language code and checks it looks like
+// ISO 639-1 with an optional region
+func NormalizeLanguage(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !languagePattern.MatchString(code) {
+		return "", ErrInvalidLanguage
+	}
+	return code, nil
+}
+
+// NewProvider returns the provider registered under name
+func NewProvider(name, apiKey string) (Provider, error) {
+	switch name {
+	case "deepl":
+		return NewDeepL(apiKey), nil
+	case "google":
+		return NewGoogle(apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q", name)
+	}
+}
+
+// DeepL translates through the DeepL API
+type DeepL struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewDeepL creates a DeepL provider. Free-tier keys (ending in ":fx") are
+// routed to the free API host.
+func NewDeepL(apiKey string) *DeepL {
+	baseURL := "https://api.deepl.com/v2"
+	if strings.HasSuffix(apiKey, ":fx") {
+		baseURL = "https://api-free.deepl.com/v2"
+	}
+	return &DeepL{
+		apiKey:     apiKey,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (d *DeepL) Name() string {
+	return "deepl"
+}
+
+func (d *DeepL) Translate(ctx context.Context, text, targetLanguage string) (Result, error) {
+	form := url.Values{}
+	form.Set("text", text)
+	form.Set("target_lang", strings.ToUpper(targetLanguage))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.baseURL+"/translate", strings.NewReader(form.Encode()))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+d.apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body := struct {
+		Translations []struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		} `json:"translations"`
+	}{}
+	err = doJSON(d.httpClient, req, "deepl", &body)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(body.Translations) == 0 {
+		return Result{}, errors.New("deepl returned no translations")
+	}
+	return Result{
+		Text:           body.Translations[0].Text,
+		SourceLanguage: strings.ToLower(body.Translations[0].DetectedSourceLanguage),
+	}, nil
+}
+
+// Google translates through the Google Cloud Translation v2 API
+type Google struct {
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGoogle creates a Google Cloud Translation provider
+func NewGoogle(apiKey string) *Google {
+	return &Google{
+		apiKey:     apiKey,
+		baseURL:    "https://translation.googleapis.com/language/translate/v2",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *Google) Name() string {
+	return "google"
+}
+
+func (g *Google) Translate(ctx context.Context, text, targetLanguage string) (Result, error) {
+	form := url.Values{}
+	form.Set("q", text)
+	form.Set("target", targetLanguage)
+	form.Set("format", "text")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"?key="+url.QueryEscape(g.apiKey), strings.NewReader(form.Encode()))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body := struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText         string `json:"translatedText"`
+				DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+			} `json:"translations"`
+		} `json:"data"`
+	}{}
+	err = doJSON(g.httpClient, req, "google", &body)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(body.Data.Translations) == 0 {
+		return Result{}, errors.New("google returned no translations")
+	}
+	return Result{
+		Text:           body.Data.Translations[0].TranslatedText,
+		SourceLanguage: strings.ToLower(body.Data.Translations[0].DetectedSourceLanguage),
+	}, nil
+}
+
+func doJSON(client *http.Client, req *http.Request, provider string, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", provider, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,97 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		wantErr error
+	}{
+		{name: "lowercase", code: "de", want: "de"},
+		{name: "uppercase", code: "FR", want: "fr"},
+		{name: "with region", code: "pt-BR", want: "pt-br"},
+		{name: "empty", code: "", wantErr: ErrInvalidLanguage},
+		{name: "too long", code: "german", wantErr: ErrInvalidLanguage},
+		{name: "digits", code: "d3", wantErr: ErrInvalidLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeLanguage(tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDeepLTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "DeepL-Auth-Key key:fx" {
+			t.Errorf("Expected DeepL auth header, got %q", got)
+		}
+		if got := r.FormValue("target_lang"); got != "DE" {
+			t.Errorf("Expected target_lang DE, got %q", got)
+		}
+		w.Write([]byte(`{"translations":[{"detected_source_language":"EN","text":"Hallo Welt"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewDeepL("key:fx")
+	provider.baseURL = server.URL
+	result, err := provider.Translate(context.Background(), "Hello world", "de")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Text != "Hallo Welt" || result.SourceLanguage != "en" {
+		t.Errorf("Expected Hallo Welt from en, got %q from %q", result.Text, result.SourceLanguage)
+	}
+}
+
+func TestGoogleTranslate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("key"); got != "secret" {
+			t.Errorf("Expected API key in query, got %q", got)
+		}
+		if got := r.FormValue("target"); got != "es" {
+			t.Errorf("Expected target es, got %q", got)
+		}
+		w.Write([]byte(`{"data":{"translations":[{"translatedText":"Hola mundo","detectedSourceLanguage":"en"}]}}`))
+	}))
+	defer server.Close()
+
+	provider := NewGoogle("secret")
+	provider.baseURL = server.URL
+	result, err := provider.Translate(context.Background(), "Hello world", "es")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Text != "Hola mundo" || result.SourceLanguage != "en" {
+		t.Errorf("Expected Hola mundo from en, got %q from %q", result.Text, result.SourceLanguage)
+	}
+}
+
+func TestProviderErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	provider := NewDeepL("bad")
+	provider.baseURL = server.URL
+	_, err := provider.Translate(context.Background(), "Hello", "de")
+	if err == nil {
+		t.Error("Expected error for non-200 response, got nil")
+	}
+}
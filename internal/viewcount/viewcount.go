@@ -0,0 +1,48 @@
+package viewcount
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Counter accumulates view increments in memory so they can be written to
+// the database in batches instead of once per read
+type Counter struct {
+	mu      sync.Mutex
+	pending map[uuid.UUID]int64
+}
+
+// New creates an empty counter
+func New() *Counter {
+	return &Counter{pending: map[uuid.UUID]int64{}}
+}
+
+// Add records one view of each id
+func (c *Counter) Add(ids ...uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		c.pending[id]++
+	}
+}
+
+// Drain returns the views recorded since the last drain and resets the
+// counter
+func (c *Counter) Drain() map[uuid.UUID]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	drained := c.pending
+	c.pending = map[uuid.UUID]int64{}
+	return drained
+}
+
+// Restore adds drained views back, for when writing them out failed and
+// they should be retried with the next batch
+func (c *Counter) Restore(counts map[uuid.UUID]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, n := range counts {
+		c.pending[id] += n
+	}
+}
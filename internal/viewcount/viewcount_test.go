@@ -0,0 +1,35 @@
+package viewcount
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCounter(t *testing.T) {
+	a := uuid.New()
+	b := uuid.New()
+
+	counter := New()
+	counter.Add(a, b)
+	counter.Add(a)
+
+	drained := counter.Drain()
+	if drained[a] != 2 {
+		t.Errorf("Expected 2 views of a, got %d", drained[a])
+	}
+	if drained[b] != 1 {
+		t.Errorf("Expected 1 view of b, got %d", drained[b])
+	}
+	if got := len(counter.Drain()); got != 0 {
+		t.Errorf("Expected empty counter after drain, got %d entries", got)
+	}
+
+	// Restored views merge with ones recorded since the drain
+	counter.Add(b)
+	counter.Restore(drained)
+	drained = counter.Drain()
+	if drained[a] != 2 || drained[b] != 2 {
+		t.Errorf("Expected 2 views of each after restore, got %d and %d", drained[a], drained[b])
+	}
+}
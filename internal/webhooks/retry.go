@@ -0,0 +1,31 @@
+package webhooks
+
+import (
+	"math/rand"
+	"time"
+)
+
+// MaxAttempts is how many times a delivery is tried before it's
+// dead-lettered. With Backoff that's about eight and a half hours of
+// retries.
+const MaxAttempts = 10
+
+const (
+	backoffBase = time.Minute
+	backoffMax  = 12 * time.Hour
+)
+
+// Backoff is how long to wait before trying a delivery again after it has
+// failed attempts times: a minute, doubling with each failure up to twelve
+// hours. Up to a tenth more is added at random, so the retries for an
+// endpoint that was down don't all land at once.
+func Backoff(attempts int) time.Duration {
+	wait := backoffMax
+	if attempts < 1 {
+		attempts = 1
+	}
+	if shift := attempts - 1; shift < 32 && backoffBase<<shift < backoffMax {
+		wait = backoffBase << shift
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait)/10+1))
+}
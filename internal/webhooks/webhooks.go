@@ -0,0 +1,143 @@
+// Package webhooks posts Chirpy's events to the URLs integrators register.
+// Each delivery is signed with internal/signature, so a receiver can check
+// it came from Chirpy and turn away replays.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/signature"
+	"github.com/google/uuid"
+)
+
+// The events an endpoint can take
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventUserFollowed = "user.followed"
+)
+
+// Events lists every event, in the order documented
+var Events = []string{EventChirpCreated, EventChirpDeleted, EventUserFollowed}
+
+// ValidEvent reports whether event is one of Events
+func ValidEvent(event string) bool {
+	return slices.Contains(Events, event)
+}
+
+// The headers sent with each delivery besides Content-Type
+const (
+	SignatureHeader = "Chirpy-Signature"
+	EventHeader     = "Chirpy-Event"
+	DeliveryHeader  = "Chirpy-Delivery"
+)
+
+// ErrPrivateAddress is returned for an endpoint that resolves to a
+// loopback, private or link-local address when those aren't allowed
+var ErrPrivateAddress = errors.New("webhook endpoint resolves to a private address")
+
+// deliveryTimeout bounds each attempt, including reading the response
+const deliveryTimeout = 15 * time.Second
+
+// Event is the JSON body of a delivery
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Delivery is one event bound for one endpoint
+type Delivery struct {
+	URL    string
+	Secret string
+	// EventID and EventType repeat what's in Payload, for the headers
+	EventID   uuid.UUID
+	EventType string
+	Payload   []byte
+}
+
+// StatusError is a delivery the endpoint answered without a 2xx status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook endpoint returned %d", e.StatusCode)
+}
+
+// Client posts deliveries. Redirects aren't followed, so a delivery only
+// ever reaches the URL that was registered.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient returns a Client that refuses endpoints on loopback, private
+// and link-local addresses unless allowPrivate is set, so users can't
+// point webhooks at the server's own network
+func NewClient(allowPrivate bool) *Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &Client{httpClient: &http.Client{
+		Transport: transport,
+		Timeout:   deliveryTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Deliver posts d once, signed at the current time
+func (c *Client) Deliver(ctx context.Context, d Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set(EventHeader, d.EventType)
+	req.Header.Set(DeliveryHeader, d.EventID.String())
+	req.Header.Set(SignatureHeader, signature.Sign(d.Payload, d.Secret, time.Now()))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// refusePrivate checks the address actually dialled, after DNS, so a
+// public name can't resolve to an internal address
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
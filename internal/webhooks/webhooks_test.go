@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/signature"
+	"github.com/google/uuid"
+)
+
+func testDelivery(url string) Delivery {
+	return Delivery{
+		URL:       url,
+		Secret:    "secret",
+		EventID:   uuid.New(),
+		EventType: EventChirpCreated,
+		Payload:   []byte(`{"type":"chirp.created"}`),
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := testDelivery(server.URL)
+	err := NewClient(true).Deliver(context.Background(), d)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got.Header.Get(EventHeader) != EventChirpCreated || got.Header.Get(DeliveryHeader) != d.EventID.String() {
+		t.Errorf("Expected the event headers, got %v", got.Header)
+	}
+	err = signature.Verify(body, got.Header.Get(SignatureHeader), d.Secret, signature.DefaultTolerance, time.Now())
+	if err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+}
+
+func TestDeliverFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		client     *Client
+		path       string
+		wantStatus int
+		wantErr    error
+	}{
+		{name: "error status", client: NewClient(true), wantStatus: 500},
+		{name: "redirect not followed", client: NewClient(true), path: "/redirect", wantStatus: 302},
+		{name: "private address", client: NewClient(false), wantErr: ErrPrivateAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.client.Deliver(context.Background(), testDelivery(server.URL+tt.path))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got %v", tt.wantStatus, err)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{MaxAttempts, 512 * time.Minute},
+		{11, 12 * time.Hour},
+		{100, 12 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		got := Backoff(tt.attempts)
+		if got < tt.want || got > tt.want+tt.want/10 {
+			t.Errorf("Expected %d attempts to wait %s plus up to a tenth, got %s", tt.attempts, tt.want, got)
+		}
+	}
+}
+
+func TestValidEvent(t *testing.T) {
+	for _, event := range Events {
+		if !ValidEvent(event) {
+			t.Errorf("Expected %q to be valid", event)
+		}
+	}
+	if ValidEvent("chirp.liked") {
+		t.Error(`Expected "chirp.liked" to be invalid`)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// errTrailingData is returned for a body with more after its JSON value
+var errTrailingData = errors.New("request body must be a single JSON value")
+
+// decodeJSON strictly decodes r's body into dst: fields dst doesn't have
+// are rejected so client typos surface, and so is anything after the
+// value. An empty body is io.EOF, for handlers whose body is optional.
+func decodeJSON(r *http.Request, dst any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err != nil {
+		return err
+	}
+	// Reading on may also run past the size limit, which is still a 413
+	err = decoder.Decode(&struct{}{})
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	if err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// respondWithBodyError answers a request whose body couldn't be read or
+// decoded: 413 when it was over the size limit, otherwise 400, naming the
+// field at fault when there is one
+func respondWithBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondWithError(w, 413, fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
+		return
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		respondWithValidationErrors(w, "Invalid request", []fieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String())),
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for this, only the message
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		respondWithValidationErrors(w, "Invalid request", []fieldError{{
+			Field:   field,
+			Code:    "unknown_field",
+			Message: fmt.Sprintf("%s is not a known field", field),
+		}})
+	case errors.Is(err, errTrailingData):
+		respondWithError(w, 400, "Request body must be a single JSON value")
+	default:
+		respondWithError(w, 400, "Invalid request")
+	}
+}
+
+// jsonTypeName is how a Go kind reads to a JSON client
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "struct", kind == "map", kind == "ptr":
+		return "object"
+	}
+	return kind
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+)
+
+// listenFlags are the serve flags that override the listen address from
+// the environment
+type listenFlags struct {
+	addr      string
+	port      string
+	localhost bool
+}
+
+// listenAddr picks the address the server listens on. The configured
+// address (the TLS one when serving HTTPS) gives the host and port, a port
+// replaces just the port, and localhost keeps the server off every
+// interface but loopback. Each flag takes precedence over its setting.
+func listenAddr(flags listenFlags, conf config.Listen, tlsEnabled bool) (string, error) {
+	addr := conf.Addr
+	if tlsEnabled {
+		addr = conf.TLSAddr
+	}
+	if flags.addr != "" {
+		addr = flags.addr
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("%q isn't host:port or :port", addr)
+	}
+
+	if flags.port != "" {
+		port = flags.port
+	} else if conf.Port != "" {
+		port = conf.Port
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+
+	if flags.localhost || conf.Localhost {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// describeAddr is how the address a listener got is logged, calling out
+// when it's reachable from other machines
+func describeAddr(addr net.Addr) string {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok || !tcpAddr.IP.IsUnspecified() {
+		return addr.String()
+	}
+	return fmt.Sprintf("%s (all interfaces)", addr)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// loginMaxFailures wrong passwords within loginFailureWindow lock the
+	// account for loginLockoutDuration
+	loginMaxFailures     = 5
+	loginFailureWindow   = 15 * time.Minute
+	loginLockoutDuration = 15 * time.Minute
+)
+
+// respondIfLocked answers 423 with a Retry-After header when dbUser's
+// account is locked, and reports whether it did
+func respondIfLocked(w http.ResponseWriter, dbUser database.User) bool {
+	if !dbUser.LockedUntil.Valid {
+		return false
+	}
+	remaining := time.Until(dbUser.LockedUntil.Time)
+	if remaining <= 0 {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+	respondWithError(w, 423, "Account is temporarily locked after too many failed logins")
+	return true
+}
+
+// recordFailedLogin counts a wrong password against dbUser, locking the
+// account once there have been too many. Both go in the audit log.
+func (cfg *apiConfig) recordFailedLogin(r *http.Request, dbUser database.User) error {
+	failures, err := cfg.db.RecordFailedLogin(r.Context(), database.RecordFailedLoginParams{
+		WindowStart: time.Now().Add(-loginFailureWindow),
+		ID:          dbUser.ID,
+	})
+	if err != nil {
+		return err
+	}
+	userID := uuid.NullUUID{UUID: dbUser.ID, Valid: true}
+	cfg.audit(r, auditLoginFailed, userID, "wrong password")
+	if failures < loginMaxFailures {
+		return nil
+	}
+
+	err = cfg.db.LockUser(r.Context(), database.LockUserParams{
+		ID:          dbUser.ID,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(loginLockoutDuration), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	cfg.audit(r, auditAccountLocked, userID, fmt.Sprintf("%d failed logins", failures))
+	return nil
+}
+
+func (cfg *apiConfig) handlerUnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid user ID")
+		return
+	}
+
+	dbUser, err := cfg.db.UnlockUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "User not found")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to unlock user")
+		return
+	}
+
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Login is one successful sign-in from the user's login history
+type Login struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+}
+
+func loginFromDB(dbLogin database.Login) Login {
+	return Login{
+		ID:        dbLogin.ID,
+		CreatedAt: dbLogin.CreatedAt,
+		IPAddress: dbLogin.IpAddress,
+		UserAgent: dbLogin.UserAgent,
+	}
+}
+
+// LoginsPage is one page of the login history. NextCursor is empty on the
+// last page.
+type LoginsPage struct {
+	Logins     []Login `json:"logins"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// loginNetwork is the /24 of an IPv4 address or the /48 of an IPv6 one,
+// which stands in for where a login came from, so a device that's handed
+// a new address on the same network isn't new each time
+func loginNetwork(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+// recordLogin adds a sign-in to dbUser's login history and, if they've
+// never signed in from this device on this network, emails them about it.
+// Their first sign-in ever isn't worth an alert. The sign-in has already
+// succeeded, so failures are logged rather than failing it.
+func (cfg *apiConfig) recordLogin(r *http.Request, dbUser database.User) {
+	ip := clientIP(r)
+	userAgent := sessionUserAgent(r)
+	network := loginNetwork(ip)
+
+	history, err := cfg.db.GetLoginDeviceHistory(r.Context(), database.GetLoginDeviceHistoryParams{
+		UserID:    dbUser.ID,
+		UserAgent: userAgent,
+		Network:   network,
+	})
+	if err != nil {
+		logRequestf(r, "Failed to check login history of user %s: %v", dbUser.ID, err)
+		return
+	}
+	dbLogin, err := cfg.db.CreateLogin(r.Context(), database.CreateLoginParams{
+		UserID:    dbUser.ID,
+		IpAddress: ip,
+		Network:   network,
+		UserAgent: userAgent,
+	})
+	if err != nil {
+		logRequestf(r, "Failed to record login of user %s: %v", dbUser.ID, err)
+		return
+	}
+	if !history.HasLogins || history.SeenDevice {
+		return
+	}
+
+	if userAgent == "" {
+		userAgent = "unknown"
+	}
+	msg, err := newLoginTemplate.Render(dbUser.Email, map[string]string{
+		"Time":      dbLogin.CreatedAt.UTC().Format(time.RFC1123),
+		"IPAddress": ip,
+		"UserAgent": userAgent,
+	})
+	if err == nil {
+		err = cfg.mailer.Send(r.Context(), msg)
+	}
+	if err != nil {
+		logRequestf(r, "Failed to send new login alert to user %s: %v", dbUser.ID, err)
+	}
+}
+
+// handlerGetMyLogins pages through the caller's sign-ins newest first
+func (cfg *apiConfig) handlerGetMyLogins(w http.ResponseWriter, r *http.Request) {
+	limit, cursor, ok := parsePageParams(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.ListLoginsParams{
+		UserID: authUserID(r),
+		Limit:  int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	dbLogins, err := cfg.db.ListLogins(r.Context(), params)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve logins")
+		return
+	}
+
+	dbLogins, nextCursor := pageRows(dbLogins, limit, func(last database.Login) pageCursor {
+		return pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	page := LoginsPage{Logins: []Login{}, NextCursor: nextCursor}
+	for _, dbLogin := range dbLogins {
+		page.Logins = append(page.Logins, loginFromDB(dbLogin))
+	}
+
+	respondWithJSON(w, 200, page)
+}
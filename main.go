@@ -1,54 +1,180 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	netmail "net/mail"
 	"os"
+	"os/signal"
 	"strings"
-	"sort"
 	"sync/atomic"
+	"syscall"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/joho/godotenv"
 	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/cache"
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/compress"
+	"github.com/Utkarsh736/chirpy/internal/config"
 	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/entitlements"
+	"github.com/Utkarsh736/chirpy/internal/experiments"
+	"github.com/Utkarsh736/chirpy/internal/mail"
+	"github.com/Utkarsh736/chirpy/internal/media"
+	"github.com/Utkarsh736/chirpy/internal/metrics"
+	"github.com/Utkarsh736/chirpy/internal/oauth"
+	"github.com/Utkarsh736/chirpy/internal/password"
+	"github.com/Utkarsh736/chirpy/internal/profanity"
+	"github.com/Utkarsh736/chirpy/internal/ranking"
+	"github.com/Utkarsh736/chirpy/internal/ratelimit"
+	"github.com/Utkarsh736/chirpy/internal/scheduler"
+	"github.com/Utkarsh736/chirpy/internal/signature"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/Utkarsh736/chirpy/internal/stream"
+	"github.com/Utkarsh736/chirpy/internal/translate"
+	"github.com/Utkarsh736/chirpy/internal/viewcount"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/stripe/stripe-go/v83"
 )
 
-
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	Email           string    `json:"email"`
+	EmailVerified   bool      `json:"email_verified"`
+	IsChirpyRed     bool      `json:"is_chirpy_red"`
+	Plan            string    `json:"plan"`
+	Role            string    `json:"role"`
+	ShareLocation   bool      `json:"share_location"`
+	Recommendations bool      `json:"recommendations"`
+	Handle          string    `json:"handle,omitempty"`
+	DisplayName     string    `json:"display_name"`
+	Bio             string    `json:"bio"`
+	Location        string    `json:"location"`
+	Website         string    `json:"website"`
+	AvatarURL       string    `json:"avatar_url"`
 }
 
+// userFromDB maps a database user to its public JSON form (without password)
+func userFromDB(dbUser database.User) User {
+	return User{
+		ID:              dbUser.ID,
+		CreatedAt:       dbUser.CreatedAt,
+		UpdatedAt:       dbUser.UpdatedAt,
+		Email:           dbUser.Email,
+		EmailVerified:   dbUser.EmailVerified,
+		IsChirpyRed:     dbUser.IsChirpyRed,
+		Plan:            dbUser.Plan,
+		Role:            dbUser.Role,
+		ShareLocation:   dbUser.ShareLocation,
+		Recommendations: dbUser.Recommendations,
+		Handle:          dbUser.Handle.String,
+		DisplayName:     dbUser.DisplayName,
+		Bio:             dbUser.Bio,
+		Location:        dbUser.Location,
+		Website:         dbUser.Website,
+		AvatarURL:       mediaURL(dbUser.AvatarKey.String),
+	}
+}
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID            uuid.UUID      `json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Body          string         `json:"body"`
+	UserID        uuid.UUID      `json:"user_id"`
+	PublishAt     time.Time      `json:"publish_at"`
+	Pending       bool           `json:"pending"`
+	Location      *ChirpLocation `json:"location,omitempty"`
+	LikeCount     int32          `json:"like_count"`
+	ParentChirpID *uuid.UUID     `json:"parent_chirp_id,omitempty"`
+	ReplyCount    int32          `json:"reply_count"`
+	ViewCount     int64          `json:"view_count"`
+	EditedAt      *time.Time     `json:"edited_at,omitempty"`
 }
 
-
-type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	jwtSecret      string
-	polkaKey       string
+// chirpFromDB maps a database chirp to its JSON form
+func chirpFromDB(dbChirp database.Chirp) Chirp {
+	chirp := Chirp{
+		ID:         dbChirp.ID,
+		CreatedAt:  dbChirp.CreatedAt,
+		UpdatedAt:  dbChirp.UpdatedAt,
+		Body:       dbChirp.Body,
+		UserID:     dbChirp.UserID,
+		PublishAt:  dbChirp.PublishAt,
+		Pending:    !dbChirp.PublishedAt.Valid,
+		Location:   chirpLocationFromDB(dbChirp),
+		LikeCount:  dbChirp.LikeCount,
+		ReplyCount: dbChirp.ReplyCount,
+		ViewCount:  dbChirp.ViewCount,
+	}
+	if dbChirp.ParentChirpID.Valid {
+		chirp.ParentChirpID = &dbChirp.ParentChirpID.UUID
+	}
+	if dbChirp.EditedAt.Valid {
+		chirp.EditedAt = &dbChirp.EditedAt.Time
+	}
+	return chirp
 }
 
+type apiConfig struct {
+	fileserverHits metrics.Counter
+	apiRequests    metrics.Counter
+	db             store.Store
+	// sqlDB is the pool behind db, nil for a demo's in-memory store
+	sqlDB *sql.DB
+	// config holds the settings the server was started with
+	config *config.Config
 
+	stripeClient    *stripe.Client
+	stripePrices    map[string]string
+	translator      translate.Provider
+	mediaStore      media.Store
+	streamHub       *stream.Hub
+	chirpViews      *viewcount.Counter
+	mailer          mail.Sender
+	webhookClient   *webhooks.Client
+	oauthProviders  map[string]*oauth.Provider
+	webauthn        *webauthn.WebAuthn
+	loginLimiter    *ratelimit.Limiter
+	signupLimiter   *ratelimit.Limiter
+	apiReadLimiter  *ratelimit.Bucket
+	apiWriteLimiter *ratelimit.Bucket
+	passwordPolicy  password.Policy
+	profanity       *profanity.Filter
+	// shuttingDown is closed when the server starts shutting down, to end
+	// long-lived streams that would otherwise hold the shutdown up
+	shuttingDown chan struct{}
+	routeMetrics *metrics.Registry
+	// cache holds copies of hot reads; nil when caching is off
+	cache cache.Cache
+	// poolWarm is set once the startup connections to the database are open
+	poolWarm atomic.Bool
+	// planWriteLimiter holds signed-in users to their plan's write limit
+	planWriteLimiter *ratelimit.Buckets
+	// experiments are the A/B experiments users are assigned variants in
+	experiments *experiments.Registry
+	// feedRanker orders each user's For You feed
+	feedRanker ranking.FeedRanker
+}
 
-
+// shutdownTimeout bounds how long in-flight requests get to finish after a
+// shutdown signal
+const shutdownTimeout = 15 * time.Second
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -57,63 +183,148 @@ func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
 	})
 }
 
+// optionalString maps an empty query parameter to SQL NULL
+func optionalString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// withTx runs fn with a store bound to a single transaction, committing only
+// if fn succeeds
+func (cfg *apiConfig) withTx(ctx context.Context, fn func(q store.Store) error) error {
+	return cfg.db.InTx(ctx, fn)
+}
+
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	
+
+	var rows strings.Builder
+	for _, route := range cfg.routeMetrics.Snapshot() {
+		fmt.Fprintf(&rows, "      <tr><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(route.Route), route.Requests, route.ClientErrors, route.ServerErrors,
+			route.P50.Round(time.Microsecond), route.P95.Round(time.Microsecond))
+	}
+
 	hits := cfg.fileserverHits.Load()
-	html := fmt.Sprintf(`<html>
+	page := fmt.Sprintf(`<html>
   <body>
     <h1>Welcome, Chirpy Admin</h1>
     <p>Chirpy has been visited %d times!</p>
+    <p>The API has served %d requests.</p>
+    <h2>Routes</h2>
+    <table>
+      <tr><th>Route</th><th>Requests</th><th>4xx</th><th>5xx</th><th>p50</th><th>p95</th></tr>
+%s    </table>
   </body>
-</html>`, hits)
-	
-	w.Write([]byte(html))
+</html>`, hits, cfg.apiRequests.Load(), rows.String())
+
+	w.Write([]byte(page))
+}
+
+// handlerMetricsJSON is handlerMetrics for scripts and monitoring
+func (cfg *apiConfig) handlerMetricsJSON(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		FileserverHits int64                `json:"fileserver_hits"`
+		APIRequests    int64                `json:"api_requests"`
+		Routes         []metrics.RouteStats `json:"routes"`
+	}
+	respondWithJSON(w, 200, response{
+		FileserverHits: cfg.fileserverHits.Load(),
+		APIRequests:    cfg.apiRequests.Load(),
+		Routes:         cfg.routeMetrics.Snapshot(),
+	})
+}
+
+var errEmailTaken = errors.New("email is taken")
+
+// maxEmailLength is the longest address SMTP allows
+const maxEmailLength = 254
+
+// normalizeEmail trims and lowercases an email address, and reports whether
+// it is a plain address like "user@example.com"
+func normalizeEmail(raw string) (string, bool) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if len(email) > maxEmailLength {
+		return email, false
+	}
+	addr, err := netmail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return email, false
+	}
+	return email, true
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
-	
-	decoder := json.NewDecoder(r.Body)
+
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(r, &params)
 	if err != nil {
-		respondWithError(w, 400, "Invalid request")
+		respondWithBodyError(w, err)
+		return
+	}
+
+	email, ok := normalizeEmail(params.Email)
+	if !ok {
+		respondWithError(w, 400, "Invalid email address")
 		return
 	}
-	
+
+	// Handle is optional at signup
+	handle := ""
+	if params.Handle != "" {
+		var ok bool
+		handle, ok = normalizeHandle(params.Handle)
+		if !ok {
+			respondWithError(w, 400, "Handle must be 3-20 letters, digits or underscores")
+			return
+		}
+	}
+
+	if !cfg.checkPassword(w, params.Password) {
+		return
+	}
+
 	// Hash the password
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, 500, "Failed to hash password")
 		return
 	}
-	
+
 	// Create user in database
 	dbUser, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
-		Email:          params.Email,
+		Email:          email,
 		HashedPassword: hashedPassword,
+		Handle:         optionalString(handle),
 	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either the email or the handle is taken; find out which
+		_, lookupErr := cfg.db.GetUserByEmail(r.Context(), email)
+		if lookupErr == nil {
+			respondWithError(w, 409, "An account with this email already exists")
+			return
+		}
+		respondWithError(w, 409, "Handle is already taken")
+		return
+	}
 	if err != nil {
 		respondWithError(w, 500, "Failed to create user")
 		return
 	}
-	
-	// Map to response struct (without password)
-	user := User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+
+	// The account is usable without verifying, so a mail failure isn't fatal
+	err = cfg.sendVerificationEmail(r.Context(), dbUser)
+	if err != nil {
+		logRequestf(r, "Failed to send verification email to user %s: %v", dbUser.ID, err)
 	}
 
-	
-	respondWithJSON(w, 201, user)
+	// Map to response struct (without password)
+	respondWithJSON(w, 201, userFromDB(dbUser))
 }
 
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
@@ -121,68 +332,102 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 	}
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
-	}
-	
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(r, &params)
 	if err != nil {
-		respondWithError(w, 400, "Invalid request")
+		respondWithBodyError(w, err)
 		return
 	}
-	
+
 	// Get user by email
-	dbUser, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	email, _ := normalizeEmail(params.Email)
+	dbUser, err := cfg.db.GetUserByEmail(r.Context(), email)
 	if err != nil {
+		cfg.audit(r, auditLoginFailed, uuid.NullUUID{}, "unknown email")
 		respondWithError(w, 401, "Incorrect email or password")
 		return
 	}
-	
+
+	// A locked account doesn't even get its password checked
+	if respondIfLocked(w, dbUser) {
+		return
+	}
+
 	// Check password
 	match, err := auth.CheckPasswordHash(params.Password, dbUser.HashedPassword)
 	if err != nil || !match {
+		err = cfg.recordFailedLogin(r, dbUser)
+		if err != nil {
+			respondWithError(w, 500, "Failed to record login attempt")
+			return
+		}
 		respondWithError(w, 401, "Incorrect email or password")
 		return
 	}
-	
+
+	err = cfg.db.ResetFailedLogins(r.Context(), dbUser.ID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to record login attempt")
+		return
+	}
+	if respondIfPasswordResetRequired(w, dbUser) {
+		return
+	}
+
+	cfg.respondWithLogin(w, r, dbUser)
+}
+
+// loginResponse is the user and a fresh token pair, returned by every way of
+// signing in
+type loginResponse struct {
+	User
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// respondWithLogin issues dbUser an access token and a refresh token
+func (cfg *apiConfig) respondWithLogin(w http.ResponseWriter, r *http.Request, dbUser database.User) {
+	// A deactivated account only signs in again through reactivation
+	if dbUser.DeactivatedAt.Valid {
+		respondWithError(w, 403, "Account is deactivated; reactivate it to sign in")
+		return
+	}
+	if respondIfSuspended(w, dbUser) {
+		return
+	}
+
 	// Create JWT (1 hour expiry)
-	accessToken, err := auth.MakeJWT(dbUser.ID, cfg.jwtSecret, time.Hour)
+	accessToken, err := auth.MakeJWT(dbUser.ID, dbUser.Role, dbUser.TokenVersion, cfg.config.JWTSecret, time.Hour)
 	if err != nil {
 		respondWithError(w, 500, "Failed to create access token")
 		return
 	}
-	
+
 	// Create refresh token (60 days expiry)
 	refreshToken, err := auth.MakeRefreshToken()
 	if err != nil {
 		respondWithError(w, 500, "Failed to create refresh token")
 		return
 	}
-	
+
 	// Store refresh token in database
 	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
 		Token:     refreshToken,
 		UserID:    dbUser.ID,
 		ExpiresAt: time.Now().Add(60 * 24 * time.Hour), // 60 days
+		UserAgent: sessionUserAgent(r),
+		IpAddress: clientIP(r),
 	})
 	if err != nil {
 		respondWithError(w, 500, "Failed to store refresh token")
 		return
 	}
-	
+	cfg.audit(r, auditLogin, uuid.NullUUID{UUID: dbUser.ID, Valid: true}, "")
+	cfg.recordLogin(r, dbUser)
+
 	// Return user with tokens
-	respondWithJSON(w, 200, response{
-		User: User{
-			ID:        dbUser.ID,
-			CreatedAt: dbUser.CreatedAt,
-			UpdatedAt: dbUser.UpdatedAt,
-			Email:     dbUser.Email,
-			IsChirpyRed: dbUser.IsChirpyRed,
-		},
+	respondWithJSON(w, 200, loginResponse{
+		User:         userFromDB(dbUser),
 		Token:        accessToken,
 		RefreshToken: refreshToken,
 	})
@@ -192,28 +437,36 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	type response struct {
 		Token string `json:"token"`
 	}
-	
+
 	// Get refresh token from header
 	refreshToken, err := auth.GetBearerToken(r.Header)
 	if err != nil {
 		respondWithError(w, 401, "Unauthorized")
 		return
 	}
-	
+
 	// Get user from refresh token
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	row, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
 	if err != nil {
 		respondWithError(w, 401, "Unauthorized")
 		return
 	}
-	
-	// Create new access token
-	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+
+	// Shows up as the session's last activity
+	err = cfg.db.TouchRefreshToken(r.Context(), refreshToken)
+	if err != nil {
+		respondWithError(w, 500, "Failed to refresh session")
+		return
+	}
+
+	// Create new access token, which still dates from the sign-in
+	user := row.User
+	accessToken, err := auth.ReissueJWT(user.ID, user.Role, user.TokenVersion, cfg.config.JWTSecret, time.Hour, row.SignedInAt)
 	if err != nil {
 		respondWithError(w, 500, "Failed to create access token")
 		return
 	}
-	
+
 	respondWithJSON(w, 200, response{
 		Token: accessToken,
 	})
@@ -226,257 +479,402 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 401, "Unauthorized")
 		return
 	}
-	
-	// Revoke the token
-	err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
-	if err != nil {
+
+	// Revoke the token; one that's already unusable is left as it is
+	userID, err := cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		respondWithError(w, 500, "Failed to revoke token")
 		return
 	}
-	
+	if err == nil {
+		cfg.audit(r, auditRefreshTokenRevoked, uuid.NullUUID{UUID: userID, Valid: true}, "")
+	}
+
 	// 204 No Content response
 	w.WriteHeader(http.StatusNoContent)
 }
 
-
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 	// Check if platform is dev
-	if cfg.platform != "dev" {
+	if cfg.config.Platform != "dev" {
 		respondWithError(w, 403, "Forbidden")
 		return
 	}
-	
-	// Reset hits counter
-	cfg.fileserverHits.Store(0)
-	
+
+	// Reset hit counters
+	err := cfg.db.ResetHitCounters(r.Context())
+	if err != nil {
+		respondWithError(w, 500, "Failed to reset database")
+		return
+	}
+	cfg.fileserverHits.Reset()
+	cfg.apiRequests.Reset()
+	if cfg.cache != nil {
+		err = cfg.cache.Clear(r.Context())
+		if err != nil {
+			respondWithError(w, 500, "Failed to clear cache")
+			return
+		}
+	}
+
 	// Delete all users
-	err := cfg.db.DeleteAllUsers(r.Context())
+	err = cfg.db.DeleteAllUsers(r.Context())
 	if err != nil {
 		respondWithError(w, 500, "Failed to reset database")
 		return
 	}
-	
+
 	w.WriteHeader(http.StatusOK)
 }
 
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body          string     `json:"body"`
+		Latitude      *float64   `json:"lat"`
+		Longitude     *float64   `json:"lon"`
+		Place         string     `json:"place"`
+		ParentChirpID *uuid.UUID `json:"parent_chirp_id"`
 	}
-	
-	// Get and validate JWT
-	token, err := auth.GetBearerToken(r.Header)
+
+	userID := authUserID(r)
+
+	params := parameters{}
+	err := decodeJSON(r, &params)
 	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
+		respondWithBodyError(w, err)
 		return
 	}
-	
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+
+	// Validate chirp length against the author's entitlements
+	resolver, author, err := cfg.loadEntitlements(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
+		respondWithError(w, 500, "Failed to load entitlements")
 		return
 	}
-	
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
-	if err != nil {
-		respondWithError(w, 400, "Invalid request")
+	if !checkChirpLength(w, resolver, author, params.Body) {
 		return
 	}
-	
-	// Validate chirp length
-	if len(params.Body) > 140 {
-		respondWithError(w, 400, "Chirp is too long")
+
+	if cfg.config.RequireEmailVerification {
+		dbUser, err := cfg.authUser(r)
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+		if !dbUser.EmailVerified {
+			respondWithError(w, 403, "Verify your email address before posting")
+			return
+		}
+	}
+
+	// Location is optional and dropped unless the author shares it
+	location, err := cfg.resolveChirpLocation(r.Context(), userID, params.Latitude, params.Longitude, params.Place)
+	if errors.Is(err, errInvalidLocation) {
+		respondWithError(w, 400, "Invalid location")
 		return
 	}
-	
+	if err != nil {
+		respondWithError(w, 500, "Failed to create chirp")
+		return
+	}
+
+	// Replies must point at a visible chirp
+	parentChirpID := uuid.NullUUID{}
+	if params.ParentChirpID != nil {
+		parent, err := cfg.getChirp(r.Context(), *params.ParentChirpID, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil || !parent.PublishedAt.Valid {
+			respondWithError(w, 400, "Parent chirp not found")
+			return
+		}
+		parentChirpID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
 	// Clean profanity
-	cleanedBody := cleanProfanity(params.Body)
-	
-	// Create chirp with authenticated user's ID
-	dbChirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		Body:   cleanedBody,
-		UserID: userID,
+	cleanedBody := cfg.profanity.Clean(params.Body)
+
+	// Hold the chirp back for the author's undo-send window; the publish job
+	// releases it afterwards. A zero window publishes immediately.
+	now := time.Now().UTC()
+	undoWindow := resolver.UndoWindow(author)
+	publishedAt := sql.NullTime{}
+	if undoWindow == 0 {
+		publishedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	// Create chirp with authenticated user's ID along with its hashtags and
+	// mentions.
+	// Replies published straight away count towards their parent now;
+	// pending ones when the publish job releases them.
+	var dbChirp database.Chirp
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		dbChirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			Body:          cleanedBody,
+			UserID:        userID,
+			PublishAt:     now.Add(undoWindow),
+			PublishedAt:   publishedAt,
+			Latitude:      location.Latitude,
+			Longitude:     location.Longitude,
+			Geohash:       location.Geohash,
+			Place:         location.Place,
+			ParentChirpID: parentChirpID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if tags := chirptext.Hashtags(dbChirp.Body); len(tags) > 0 {
+			err = q.CreateChirpHashtags(r.Context(), database.CreateChirpHashtagsParams{
+				ChirpID: dbChirp.ID,
+				Tags:    tags,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if handles := chirptext.Mentions(dbChirp.Body); len(handles) > 0 {
+			err = q.CreateMentions(r.Context(), database.CreateMentionsParams{
+				ChirpID: dbChirp.ID,
+				Handles: handles,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !parentChirpID.Valid || !publishedAt.Valid {
+			return nil
+		}
+		return q.AdjustChirpReplyCount(r.Context(), database.AdjustChirpReplyCountParams{
+			ID:    parentChirpID.UUID,
+			Delta: 1,
+		})
 	})
 	if err != nil {
 		respondWithError(w, 500, "Failed to create chirp")
 		return
 	}
-	
+
 	// Map to response struct
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	if dbChirp.PublishedAt.Valid {
+		cfg.invalidateChirps(r.Context(), parentChirpID.UUID)
+		cfg.publishChirp(r.Context(), dbChirp)
+		cfg.emitWebhook(r.Context(), dbChirp.UserID, webhooks.EventChirpCreated, chirpFromDB(dbChirp))
 	}
-	
-	respondWithJSON(w, 201, chirp)
+	respondWithJSON(w, 201, chirpFromDB(dbChirp))
 }
 
-
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, r *http.Request) {
 	// Get optional query parameters
 	authorIDStr := r.URL.Query().Get("author_id")
 	sortOrder := r.URL.Query().Get("sort")
-	
+
 	// Default to ascending if not specified
 	if sortOrder == "" {
 		sortOrder = "asc"
 	}
-	
-	var dbChirps []database.Chirp
-	var err error
-	
-	if authorIDStr == "" {
-		// No author_id specified, get all chirps
-		dbChirps, err = cfg.db.GetAllChirps(r.Context())
-	} else {
-		// Parse author_id and filter by author
-		authorID, parseErr := uuid.Parse(authorIDStr)
+	if sortOrder != "asc" && sortOrder != "desc" {
+		respondWithError(w, 400, "sort must be asc or desc")
+		return
+	}
+
+	// Parse optional author_id filter
+	authorID := uuid.NullUUID{}
+	if authorIDStr != "" {
+		parsed, parseErr := uuid.Parse(authorIDStr)
 		if parseErr != nil {
 			respondWithError(w, 400, "Invalid author ID")
 			return
 		}
-		dbChirps, err = cfg.db.GetChirpsByAuthor(r.Context(), authorID)
+		authorID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	// Cursor pagination is opt-in so existing clients still get a plain array
+	if r.URL.Query().Has("limit") || r.URL.Query().Has("cursor") {
+		cfg.respondWithChirpsPage(w, r, authorID, sortOrder)
+		return
 	}
-	
+
+	var dbChirps []database.Chirp
+	var err error
+	viewerID := cfg.viewerID(r)
+
+	// Sorting happens in the database
+	switch {
+	case !authorID.Valid && sortOrder == "desc":
+		dbChirps, err = cfg.db.GetAllChirpsDesc(r.Context(), viewerID)
+	case !authorID.Valid:
+		// No author_id specified, get all chirps
+		dbChirps, err = cfg.db.GetAllChirps(r.Context(), viewerID)
+	case sortOrder == "desc":
+		dbChirps, err = cfg.db.GetChirpsByAuthorDesc(r.Context(), database.GetChirpsByAuthorDescParams{
+			UserID:   authorID.UUID,
+			ViewerID: viewerID,
+		})
+	default:
+		// Filter by author
+		dbChirps, err = cfg.db.GetChirpsByAuthor(r.Context(), database.GetChirpsByAuthorParams{
+			UserID:   authorID.UUID,
+			ViewerID: viewerID,
+		})
+	}
+
 	if err != nil {
 		respondWithError(w, 500, "Failed to retrieve chirps")
 		return
 	}
-	
+
 	// Convert to response format
 	chirps := []Chirp{}
 	for _, dbChirp := range dbChirps {
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			Body:      dbChirp.Body,
-			UserID:    dbChirp.UserID,
-		})
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+
+	if respondIfNotModified(w, r, chirpsETag(chirps)) {
+		return
 	}
-	
-	// Sort chirps based on sort parameter
-	sort.Slice(chirps, func(i, j int) bool {
-		if sortOrder == "desc" {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-		}
-		// Default to ascending
-		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-	})
-	
 	respondWithJSON(w, 200, chirps)
 }
 
-
-
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email    string  `json:"email"`
+		Password string  `json:"password"`
+		Handle   *string `json:"handle"`
 	}
-	
-	// Get and validate JWT
-	token, err := auth.GetBearerToken(r.Header)
+
+	userID := authUserID(r)
+
+	// Parse request body
+	params := parameters{}
+	err := decodeJSON(r, &params)
 	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
+		respondWithBodyError(w, err)
 		return
 	}
-	
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
+
+	email, ok := normalizeEmail(params.Email)
+	if !ok {
+		respondWithError(w, 400, "Invalid email address")
 		return
 	}
-	
-	// Parse request body
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
-	if err != nil {
-		respondWithError(w, 400, "Invalid request")
+
+	// Handle is only changed when present
+	handle := ""
+	if params.Handle != nil {
+		var ok bool
+		handle, ok = normalizeHandle(*params.Handle)
+		if !ok {
+			respondWithError(w, 400, "Handle must be 3-20 letters, digits or underscores")
+			return
+		}
+	}
+
+	if !cfg.checkPassword(w, params.Password) {
 		return
 	}
-	
+
 	// Hash the new password
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, 500, "Failed to hash password")
 		return
 	}
-	
+
+	previous, err := cfg.authUser(r)
+	if err != nil {
+		respondWithError(w, 401, "Unauthorized")
+		return
+	}
+	// The password is always set, but only a different one is worth auditing
+	samePassword, err := auth.CheckPasswordHash(params.Password, previous.HashedPassword)
+	passwordChanged := err != nil || !samePassword
+
 	// Update user in database
-	dbUser, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
-		ID:             userID,
+	var dbUser database.User
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		dbUser, err = q.UpdateUser(r.Context(), database.UpdateUserParams{
+			Email:          email,
+			HashedPassword: hashedPassword,
+			ID:             userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errEmailTaken
+		}
+		if err != nil || params.Handle == nil {
+			return err
+		}
+		dbUser, err = q.SetUserHandle(r.Context(), database.SetUserHandleParams{
+			ID:     userID,
+			Handle: optionalString(handle),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errHandleTaken
+		}
+		return err
 	})
+	if errors.Is(err, errEmailTaken) {
+		respondWithError(w, 409, "An account with this email already exists")
+		return
+	}
+	if errors.Is(err, errHandleTaken) {
+		respondWithError(w, 409, "Handle is already taken")
+		return
+	}
 	if err != nil {
 		respondWithError(w, 500, "Failed to update user")
 		return
 	}
-	
-	// Return updated user (without password)
-	user := User{
-		ID:        dbUser.ID,
-		CreatedAt: dbUser.CreatedAt,
-		UpdatedAt: dbUser.UpdatedAt,
-		Email:     dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+	cfg.invalidateProfiles(r.Context(), previous.Handle)
+	if passwordChanged {
+		cfg.audit(r, auditPasswordChanged, uuid.NullUUID{UUID: userID, Valid: true}, "")
+	}
+
+	// A new address has to be verified again
+	if dbUser.Email != previous.Email {
+		err = cfg.sendVerificationEmail(r.Context(), dbUser)
+		if err != nil {
+			logRequestf(r, "Failed to send verification email to user %s: %v", dbUser.ID, err)
+		}
 	}
-	
-	respondWithJSON(w, 200, user)
-}
 
+	// Return updated user (without password)
+	respondWithJSON(w, 200, userFromDB(dbUser))
+}
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 	// Get chirp ID from path parameter
 	chirpIDString := r.PathValue("chirpID")
-	
+
 	// Parse UUID
 	chirpID, err := uuid.Parse(chirpIDString)
 	if err != nil {
 		respondWithError(w, 400, "Invalid chirp ID")
 		return
 	}
-	
-	// Get chirp from database
-	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
-	if err != nil {
+
+	// Get chirp from database; pending chirps aren't visible yet
+	dbChirp, err := cfg.getChirp(r.Context(), chirpID, cfg.viewerID(r))
+	if err != nil || !dbChirp.PublishedAt.Valid {
 		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-	
+
 	// Map to response struct
-	chirp := Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserID:    dbChirp.UserID,
+	chirp := chirpFromDB(dbChirp)
+	// Revalidating a copy the client already has doesn't count as a view
+	if respondIfNotModified(w, r, chirpsETag([]Chirp{chirp})) {
+		return
 	}
-	
+	cfg.recordChirpViews([]Chirp{chirp})
 	respondWithJSON(w, 200, chirp)
 }
 
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
-	// Get and validate JWT
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
-		return
-	}
-	
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, 401, "Unauthorized")
-		return
-	}
-	
+	userID := authUserID(r)
+
 	// Get chirp ID from path parameter
 	chirpIDString := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDString)
@@ -484,37 +882,101 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 400, "Invalid chirp ID")
 		return
 	}
-	
+
 	// Get the chirp to verify ownership
 	dbChirp, err := cfg.db.GetChirpByID(r.Context(), chirpID)
 	if err != nil {
 		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-	
-	// Check if user owns the chirp
+
+	// Check if user owns the chirp; moderators and admins can take down
+	// anyone's, from a full-access token
 	if dbChirp.UserID != userID {
-		respondWithError(w, 403, "Forbidden")
+		claims := requestAuthFrom(r).claims
+		if claims.Scopes != nil || !auth.HasRole(claims.Role, auth.RoleModerator) {
+			respondWithError(w, 403, "Forbidden")
+			return
+		}
+		cfg.handlerTakeDownChirp(w, r, dbChirp)
+		return
+	}
+
+	var deleted database.Chirp
+	err = cfg.withTx(r.Context(), func(q store.Store) error {
+		var err error
+		deleted, err = softDeleteChirp(r.Context(), q, dbChirp.ID)
+		return err
+	})
+	// Deleted again since it was read
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 404, "Chirp not found")
 		return
 	}
-	
-	// Delete the chirp
-	err = cfg.db.DeleteChirp(r.Context(), chirpID)
 	if err != nil {
 		respondWithError(w, 500, "Failed to delete chirp")
 		return
 	}
-	
+	cfg.chirpDeleted(r.Context(), deleted)
+
 	// Return 204 No Content
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// softDeleteChirp soft-deletes a chirp so it can be audited until purged,
+// taking it off its parent's reply count; for pending chirps this also
+// cancels publication. It returns the chirp as deleted, or sql.ErrNoRows if
+// it already was. Once committed, chirpDeleted should follow with it.
+func softDeleteChirp(ctx context.Context, q store.Store, chirpID uuid.UUID) (database.Chirp, error) {
+	// Whether it was published comes from the delete itself, not an earlier
+	// read, or a chirp published in between would stay on the count
+	deleted, err := q.SoftDeleteChirp(ctx, chirpID)
+	if err != nil || !deleted.ParentChirpID.Valid || !deleted.PublishedAt.Valid {
+		return deleted, err
+	}
+	return deleted, q.AdjustChirpReplyCount(ctx, database.AdjustChirpReplyCountParams{
+		ID:    deleted.ParentChirpID.UUID,
+		Delta: -1,
+	})
+}
 
+// chirpDeleted drops the cached copies of a chirp softDeleteChirp removed
+// and tells integrators about it
+func (cfg *apiConfig) chirpDeleted(ctx context.Context, dbChirp database.Chirp) {
+	cfg.invalidateChirps(ctx, dbChirp.ID, dbChirp.ParentChirpID.UUID)
+	// Integrators never heard of a chirp deleted before it was published
+	if dbChirp.PublishedAt.Valid {
+		cfg.emitWebhook(ctx, dbChirp.UserID, webhooks.EventChirpDeleted, chirpDeletedEvent{ChirpID: dbChirp.ID, UserID: dbChirp.UserID})
+	}
+}
+
+// respondWithError sends {"error": msg}, with the request ID set by
+// middlewareRequestID so the failure can be reported and looked up
 func respondWithError(w http.ResponseWriter, code int, msg string) {
 	type errorResponse struct {
-		Error string `json:"error"`
+		Error     string `json:"error"`
+		RequestID string `json:"request_id,omitempty"`
 	}
-	respondWithJSON(w, code, errorResponse{Error: msg})
+	respondWithJSON(w, code, errorResponse{Error: msg, RequestID: w.Header().Get(requestIDHeader)})
+}
+
+// fieldError is one problem with one request field, in a form clients can
+// act on without parsing messages
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// respondWithValidationErrors is respondWithError with the individual
+// problems listed under "details"
+func respondWithValidationErrors(w http.ResponseWriter, msg string, details []fieldError) {
+	type errorResponse struct {
+		Error     string       `json:"error"`
+		Details   []fieldError `json:"details"`
+		RequestID string       `json:"request_id,omitempty"`
+	}
+	respondWithJSON(w, 400, errorResponse{Error: msg, Details: details, RequestID: w.Header().Get(requestIDHeader)})
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -530,183 +992,595 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 }
 
 func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
-	type parameters struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
-		} `json:"data"`
-	}
-	
 	// Get and validate API key
 	apiKey, err := auth.GetAPIKey(r.Header)
 	if err != nil {
 		respondWithError(w, 401, "Unauthorized")
 		return
 	}
-	
-	if apiKey != cfg.polkaKey {
+
+	if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.config.PolkaKey)) != 1 {
 		respondWithError(w, 401, "Unauthorized")
 		return
 	}
-	
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+
+	// Keep the raw body so it can be stored and replayed later
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondWithBodyError(w, err)
+		return
+	}
+
+	// A signature ties the body to when it was sent, so tampered or
+	// replayed deliveries are turned away before anything is recorded
+	if cfg.config.PolkaSigningSecret != "" {
+		err = signature.Verify(payload, r.Header.Get(polkaSignatureHeader), cfg.config.PolkaSigningSecret, cfg.config.PolkaSignatureTolerance, time.Now())
+		if err != nil {
+			respondWithError(w, 401, "Invalid signature")
+			return
+		}
+	}
+
+	params := polkaEvent{}
+	err = json.Unmarshal(payload, &params)
 	if err != nil {
 		respondWithError(w, 400, "Invalid request")
 		return
 	}
-	
-	// Only process user.upgraded events
-	if params.Event != "user.upgraded" {
+
+	// Record the event, skipping deliveries we have already processed
+	dbEvent, err := cfg.recordWebhookEvent(r.Context(), webhookSourcePolka, params.eventID(), params.Event, payload)
+	if err != nil {
+		respondWithError(w, 500, "Failed to record webhook event")
+		return
+	}
+	if dbEvent.Status == webhookStatusProcessed {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	
-	// Upgrade user to Chirpy Red
-	err = cfg.db.UpgradeUserToChirpyRed(r.Context(), params.Data.UserID)
-	if err != nil {
+
+	// Another delivery of the same event may have got there first
+	err = cfg.processWebhookEvent(r.Context(), dbEvent, false)
+	if errors.Is(err, errWebhookEventProcessed) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if errors.Is(err, errUserNotFound) {
 		respondWithError(w, 404, "User not found")
 		return
 	}
-	
+	if errors.Is(err, errUnknownPlan) {
+		respondWithError(w, 400, "Unknown plan")
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "Failed to process webhook event")
+		return
+	}
+
 	// Return 204 No Content on success
 	w.WriteHeader(http.StatusNoContent)
 }
 
-
-
-func handlerValidateChirp(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerValidateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Body string `json:"body"`
 	}
 	type responseBody struct {
 		CleanedBody string `json:"cleaned_body"`
 	}
-	
-	decoder := json.NewDecoder(r.Body)
+
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(r, &params)
 	if err != nil {
 		respondWithError(w, 400, "Something went wrong")
 		return
 	}
-	
+
 	// Validate chirp length
-	if len(params.Body) > 140 {
+	if len(params.Body) > cfg.config.MaxChirpLength {
 		respondWithError(w, 400, "Chirp is too long")
 		return
 	}
-	
+
 	// Clean profanity and respond
-	cleaned := cleanProfanity(params.Body)
+	cleaned := cfg.profanity.Clean(params.Body)
 	respondWithJSON(w, 200, responseBody{CleanedBody: cleaned})
 }
 
+func main() {
+	// Load .env file
+	godotenv.Load()
+
+	// Without a subcommand the binary runs the server, as it always has
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	cmd, ok := commands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		printUsage()
+		os.Exit(2)
+	}
+	cmd.run(args)
+}
+
+// serve runs the HTTP server until it's told to stop
+func serve(args []string) {
+	flags := newFlagSet("serve")
+	var listen listenFlags
+	flags.StringVar(&listen.addr, "addr", "", "address to listen on, like :8080 or 127.0.0.1:8080 (overrides ADDR, or TLS_ADDR with TLS)")
+	flags.StringVar(&listen.port, "port", "", "port to listen on (overrides PORT and the address's port)")
+	flags.BoolVar(&listen.localhost, "localhost", false, "only accept connections from this machine (overrides BIND_LOCALHOST)")
+	flags.Parse(args)
 
-func cleanProfanity(text string) string {
-	badWords := map[string]bool{
-		"kerfuffle": true,
-		"sharbert":  true,
-		"fornax":    true,
+	conf := loadConfig()
+	if err := conf.CheckServe(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
-	
-	words := strings.Split(text, " ")
-	for i, word := range words {
-		lowercaseWord := strings.ToLower(word)
-		if badWords[lowercaseWord] {
-			words[i] = "****"
+
+	var err error
+	var db *sql.DB
+	var dbStore store.Store
+	if conf.DatabaseDriver == config.DatabaseMemory {
+		dbStore = store.NewMemory()
+		err = startDemo(context.Background(), conf, dbStore)
+		if err != nil {
+			log.Fatal("Error starting demo: ", err)
 		}
+	} else {
+		db = openDB(conf)
+		// A database in memory starts out empty every time
+		if conf.MigrateOnStart || conf.SQLitePath == config.SQLiteMemory {
+			err = migrateDB(context.Background(), db, conf.DatabaseDriver)
+			if err != nil {
+				log.Fatal("Error migrating database: ", err)
+			}
+		}
+		dbStore = store.NewSQL(db)
 	}
-	
-	return strings.Join(words, " ")
-}
-
 
-func main() {
-	// Load .env file
-	godotenv.Load()
-	
-	dbURL := os.Getenv("DB_URL")
-	if dbURL == "" {
-		log.Fatal("DB_URL environment variable is not set")
-	}
-	
-	platform := os.Getenv("PLATFORM")
-	if platform == "" {
-		log.Fatal("PLATFORM environment variable is not set")
-	}
-	
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
-	}
-
-	polkaKey := os.Getenv("POLKA_KEY")
-	if polkaKey == "" {
-		log.Fatal("POLKA_KEY environment variable is not set")
-	}	
-	
-	// Open database connection
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatal("Error opening database:", err)
-	}
-	
-	// Create database queries
-	dbQueries := database.New(db)
-	
-	// Initialize config with database and JWT secret
+	// Initialize config with database and settings
 	apiCfg := &apiConfig{
-		db:        dbQueries,
-		platform:  platform,
-		jwtSecret: jwtSecret,
-		polkaKey:  polkaKey,
+		db:           dbStore,
+		sqlDB:        db,
+		config:       conf,
+		streamHub:    stream.NewHub(streamBuffer),
+		chirpViews:   viewcount.New(),
+		routeMetrics: metrics.New(),
 	}
-	
-	mux := http.NewServeMux()
-	
-	// API endpoints
-	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-	
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
-
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhook)
-
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirp)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
-	
-	// Admin endpoints
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
-	
-	// Fileserver
-	fileServer := http.FileServer(http.Dir("."))
-	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", fileServer)))
-	
+
+	// Optional: Stripe billing as an alternative to Polka
+	if conf.Stripe.Enabled() {
+		apiCfg.stripeClient = stripe.NewClient(conf.Stripe.SecretKey)
+
+		// Price IDs per plan; Red+ is only sold when its price is configured
+		apiCfg.stripePrices = map[string]string{planRed: conf.Stripe.PriceID}
+		if conf.Stripe.PriceIDRedPlus != "" {
+			apiCfg.stripePrices[planRedPlus] = conf.Stripe.PriceIDRedPlus
+		}
+	}
+
+	// Uploaded media goes to local disk under MEDIA_DIR by default, or to an
+	// S3-compatible bucket
+	if conf.Media.Store == "s3" {
+		apiCfg.mediaStore, err = media.NewS3(conf.Media.S3)
+	} else {
+		apiCfg.mediaStore, err = media.NewLocal(conf.Media.Dir)
+	}
+	if err != nil {
+		log.Fatal("Error configuring media storage:", err)
+	}
+
+	// Email is written to the log by default; MAIL_SENDER=smtp relays it and
+	// MAIL_SENDER=none drops it. Delivery happens in the background.
+	sender, err := mail.NewSender(conf.Mail.Sender, conf.Mail.SMTP)
+	if err != nil {
+		log.Fatal("Error configuring mail:", err)
+	}
+	mailQueue := mail.NewAsync(sender, mailWorkers, mailQueueSize)
+	apiCfg.mailer = mailQueue
+
+	// Outgoing webhooks are queued in the database and posted by a
+	// background job. WEBHOOK_ALLOW_PRIVATE_NETWORKS lets them reach a local
+	// receiver.
+	apiCfg.webhookClient = webhooks.NewClient(conf.WebhookAllowPrivate)
+
+	// Optional: sign in with Google or GitHub, enabled per provider by its
+	// client ID. Register BASE_URL/api/oauth/<provider>/callback with it.
+	apiCfg.oauthProviders = map[string]*oauth.Provider{}
+	for name, client := range conf.OAuth {
+		apiCfg.oauthProviders[name], err = oauth.NewProvider(name, client.ClientID, client.ClientSecret)
+		if err != nil {
+			log.Fatal("Error configuring OAuth:", err)
+		}
+	}
+
+	// Passkeys are scoped to a domain and checked against the page origin
+	// running the ceremony, both derived from BASE_URL unless overridden
+	apiCfg.webauthn, err = newWebAuthn(conf.WebAuthn)
+	if err != nil {
+		log.Fatal("Error configuring passkeys:", err)
+	}
+
+	// Optional: on-demand chirp translation through deepl or google
+	if conf.Translation.Provider != "" {
+		apiCfg.translator, err = translate.NewProvider(conf.Translation.Provider, conf.Translation.APIKey)
+		if err != nil {
+			log.Fatal("Error configuring translation:", err)
+		}
+	}
+
+	// Optional: a Redis or in-process cache in front of hot reads
+	var redisCache *cache.Redis
+	if conf.Cache.RedisURL != "" {
+		redisCache, err = cache.NewRedis(context.Background(), conf.Cache.RedisURL, cacheKeyPrefix)
+		if err != nil {
+			log.Fatal("Error connecting to Redis:", err)
+		}
+		apiCfg.cache = redisCache
+	} else if conf.Cache.MemorySize > 0 {
+		// Without Redis, an in-process cache of up to this many entries
+		apiCfg.cache = cache.NewLRU(conf.Cache.MemorySize)
+	}
+
+	// Banned words come from the database plus an optional file, one word
+	// per line. The built-in list applies until the first load succeeds.
+	apiCfg.profanity = profanity.New(profanity.DefaultWords...)
+
+	// Password rules for signups and password changes
+	apiCfg.passwordPolicy = password.Policy{MinLength: conf.PasswordMinLength}
+	// Optional: a list of breached passwords, one per line, to refuse
+	if conf.BreachedPasswordsFile != "" {
+		apiCfg.passwordPolicy.Breached, err = loadBreachedPasswords(conf.BreachedPasswordsFile)
+		if err != nil {
+			log.Fatal("Error loading breached passwords:", err)
+		}
+	}
+
+	// Per-IP limits on login and signup
+	apiCfg.loginLimiter = newRateLimiter(conf.RateLimits.Login)
+	apiCfg.signupLimiter = newRateLimiter(conf.RateLimits.Signup)
+
+	// Token-bucket limits on every /api request, per user or per IP
+	apiCfg.apiReadLimiter = newRateBucket(conf.RateLimits.APIRead)
+	apiCfg.apiWriteLimiter = newRateBucket(conf.RateLimits.APIWrite)
+	apiCfg.planWriteLimiter = ratelimit.NewBuckets(time.Minute)
+
+	// Users are split between the variants of the running experiments
+	apiCfg.experiments = experiments.NewRegistry(activeExperiments...)
+	apiCfg.feedRanker = ranking.Default
+
+	// Optional: terminate TLS directly instead of behind a proxy
+	serverTLS := newTLSSetup(conf.TLS)
+	addr, err := listenAddr(listen, conf.Listen, serverTLS.enabled())
+	if err != nil {
+		log.Fatal("Invalid listen address: ", err)
+	}
+
+	limits := conf.Server
 	server := &http.Server{
-		Addr:    ":8080",
-		Handler: mux,
+		Addr:    addr,
+		Handler: apiCfg.routes(),
+	}
+	applyServerLimits(server, limits)
+	apiCfg.shuttingDown = make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		close(apiCfg.shuttingDown)
+	})
+
+	err = apiCfg.reloadBannedWords(context.Background())
+	if err != nil {
+		log.Printf("Loading banned words failed, using the built-in list: %v", err)
+	}
+	err = apiCfg.loadHitCounters(context.Background())
+	if err != nil {
+		log.Printf("Loading hit counters failed, counting from zero: %v", err)
 	}
-	
-	log.Printf("Starting server on %s", server.Addr)
-	server.ListenAndServe()
+
+	// /api/readyz holds traffic off until the pool is warm. A demo has no
+	// pool.
+	if db == nil {
+		apiCfg.poolWarm.Store(true)
+	} else {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), dbConnectTimeout)
+			defer cancel()
+			if err := warmDBPool(ctx, db, conf.DB); err != nil {
+				log.Printf("Warming the database pool failed, connecting on demand: %v", err)
+			}
+			apiCfg.poolWarm.Store(true)
+		}()
+	}
+
+	// Background jobs
+	jobs := scheduler.New()
+	jobs.Every("expire-subscriptions", subscriptionExpiryInterval, apiCfg.expireLapsedSubscriptions)
+	jobs.Every("publish-chirps", chirpPublishInterval, apiCfg.publishDueChirps)
+	jobs.Every("flush-chirp-views", chirpViewFlushInterval, apiCfg.flushChirpViews)
+	jobs.Every("purge-webauthn-challenges", webauthnChallengePurgeInterval, apiCfg.purgeExpiredWebAuthnChallenges)
+	jobs.Every("purge-magic-links", magicLinkPurgeInterval, apiCfg.purgeExpiredMagicLinks)
+	jobs.Every("purge-webhook-events", webhookEventPurgeInterval, apiCfg.purgeProcessedWebhookEvents)
+	jobs.Every("deliver-webhooks", webhookDeliveryInterval, apiCfg.deliverWebhooks)
+	jobs.Every("purge-webhook-deliveries", webhookDeliveryPurgeInterval, apiCfg.purgeDeliveredWebhookDeliveries)
+	jobs.Every("build-data-exports", dataExportInterval, apiCfg.buildDataExports)
+	jobs.Every("purge-data-exports", dataExportPurgeInterval, apiCfg.purgeExpiredDataExports)
+	jobs.Every("delete-deactivated-accounts", deactivatedAccountsInterval, apiCfg.deleteDeactivatedAccounts)
+	jobs.Every("purge-revoked-access-tokens", revokedAccessTokenPurgeInterval, apiCfg.purgeExpiredRevokedAccessTokens)
+	jobs.Every("prune-rate-limits", rateLimitPruneInterval, apiCfg.pruneRateLimits)
+	jobs.Every("reload-banned-words", bannedWordsReloadInterval, apiCfg.reloadBannedWords)
+	jobs.Every("flush-hit-counters", hitCounterFlushInterval, apiCfg.flushHitCounters)
+	jobs.Start(context.Background())
+
+	// SIGINT or SIGTERM begins a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Listening up front logs the address actually bound, which differs
+	// from the configured one for port 0
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatal("Error listening: ", err)
+	}
+	serverErr := make(chan error, 2)
+	go func() {
+		if serverTLS.enabled() {
+			log.Printf("Starting HTTPS server on %s", describeAddr(listener.Addr()))
+			serverErr <- serverTLS.serve(server, listener)
+			return
+		}
+		log.Printf("Starting server on %s", describeAddr(listener.Addr()))
+		serverErr <- server.Serve(listener)
+	}()
+	var redirectServer *http.Server
+	if serverTLS.redirectAddr != "" {
+		redirectServer = serverTLS.redirectServer(listener.Addr().String())
+		applyServerLimits(redirectServer, limits)
+		go func() {
+			log.Printf("Redirecting HTTP on %s to HTTPS", redirectServer.Addr)
+			serverErr <- redirectServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		log.Printf("Server stopped: %v", err)
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	}
+
+	// Stop taking requests and let in-flight ones finish, then stop the
+	// background work they may have queued
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Printf("Requests still running after %s, closing them: %v", shutdownTimeout, err)
+		server.Close()
+	}
+	if redirectServer != nil {
+		redirectServer.Shutdown(shutdownCtx)
+	}
+
+	jobs.Stop()
+	err = apiCfg.flushChirpViews(shutdownCtx)
+	if err != nil {
+		log.Printf("Final chirp view flush failed: %v", err)
+	}
+	err = apiCfg.flushHitCounters(shutdownCtx)
+	if err != nil {
+		log.Printf("Final hit counter flush failed: %v", err)
+	}
+	mailQueue.Close()
+	if redisCache != nil {
+		redisCache.Close()
+	}
+	if db != nil {
+		db.Close()
+	}
+	log.Printf("Shutdown complete")
 }
 
+// routes registers every endpoint and wraps them in the middleware every
+// request goes through, returning the handler the server runs
+func (cfg *apiConfig) routes() http.Handler {
+	conf := cfg.config
+
+	mux := http.NewServeMux()
+
+	// Health probes stay unversioned, since orchestrators poll them directly
+	mux.HandleFunc("GET /api/healthz", cfg.handlerHealthz)
+	mux.HandleFunc("GET /api/readyz", cfg.handlerReadyz)
+
+	// Versioned API, also served at the unversioned /api paths
+	v1 := newAPIVersion(1)
+	v1.HandleFunc("GET /config", cfg.handlerGetConfig)
+
+	v1.HandleFunc("POST /users", rateLimit(cfg.signupLimiter, cfg.handlerCreateUser))
+	v1.HandleFunc("PUT /users", cfg.middlewareAuth(cfg.handlerUpdateUser))
+	v1.HandleFunc("DELETE /users/me", cfg.middlewareAuth(cfg.handlerDeleteAccount))
+	v1.HandleFunc("POST /users/me/deactivate", cfg.middlewareAuth(cfg.handlerDeactivateAccount))
+	v1.HandleFunc("POST /users/reactivate", rateLimit(cfg.loginLimiter, cfg.handlerReactivateAccount))
+	v1.HandleFunc("GET /verify-email", cfg.handlerVerifyEmail)
+	v1.HandleFunc("POST /verify-email/resend", cfg.middlewareAuth(cfg.handlerResendVerificationEmail))
+	v1.HandleFunc("POST /login", rateLimit(cfg.loginLimiter, cfg.handlerLogin))
+	v1.HandleFunc("POST /login/magic", cfg.handlerRequestMagicLink)
+	v1.HandleFunc("GET /login/magic/verify", cfg.handlerVerifyMagicLink)
+	v1.HandleFunc("GET /oauth/{provider}/login", cfg.handlerOAuthLogin)
+	v1.HandleFunc("GET /oauth/{provider}/callback", cfg.handlerOAuthCallback)
+	v1.HandleFunc("POST /webauthn/register/begin", cfg.middlewareAuth(cfg.handlerWebAuthnRegisterBegin))
+	v1.HandleFunc("POST /webauthn/register/finish", cfg.middlewareAuth(cfg.handlerWebAuthnRegisterFinish))
+	v1.HandleFunc("POST /webauthn/login/begin", cfg.handlerWebAuthnLoginBegin)
+	v1.HandleFunc("POST /webauthn/login/finish", cfg.handlerWebAuthnLoginFinish)
+	v1.HandleFunc("GET /webauthn/credentials", cfg.middlewareAuth(cfg.handlerGetPasskeys))
+	v1.HandleFunc("DELETE /webauthn/credentials/{credentialID}", cfg.middlewareAuth(cfg.handlerDeletePasskey))
+
+	v1.HandleFunc("POST /refresh", cfg.handlerRefresh)
+	v1.HandleFunc("POST /revoke", cfg.handlerRevoke)
+	v1.HandleFunc("POST /logout", cfg.handlerLogout)
+	v1.HandleFunc("POST /users/me/revoke-all", cfg.middlewareAuth(cfg.handlerRevokeAllSessions))
+	v1.HandleFunc("GET /sessions", cfg.middlewareAuth(cfg.handlerGetSessions))
+	v1.HandleFunc("GET /users/me/logins", cfg.middlewareAuth(cfg.handlerGetMyLogins))
+	v1.HandleFunc("DELETE /sessions/{sessionID}", cfg.middlewareAuth(cfg.handlerDeleteSession))
+	v1.HandleFunc("POST /tokens", cfg.middlewareAuth(cfg.handlerCreateScopedToken))
+	v1.HandleFunc("POST /polka/webhooks", cfg.handlerWebhook)
+	v1.HandleFunc("POST /stripe/webhooks", cfg.handlerStripeWebhook)
+	v1.HandleFunc("POST /stripe/checkout", cfg.middlewareAuth(cfg.handlerStripeCheckout))
+	v1.HandleFunc("GET /plans", cfg.handlerGetPlans)
+	v1.HandleFunc("POST /redeem", cfg.middlewareAuth(cfg.handlerRedeemPromoCode))
+	// Exports hold the user's sessions and email, so scoped tokens can't
+	// fetch them
+	v1.HandleFunc("GET /users/me/export", cfg.middlewareAuth(cfg.handlerDataExport))
+	v1.HandleFunc("GET /users/me/export/{exportID}", cfg.middlewareAuth(cfg.handlerGetDataExport))
+	v1.HandleFunc("GET /users/me/export/{exportID}/download", cfg.middlewareAuth(cfg.handlerDownloadDataExport))
+	v1.HandleFunc("GET /users/me/subscription", cfg.middlewareAuth(cfg.handlerGetMySubscription, auth.ScopeUsersRead))
+	v1.HandleFunc("GET /users/me/analytics", cfg.middlewareAuth(cfg.requireFeature(entitlements.Analytics, cfg.handlerGetMyAnalytics), auth.ScopeChirpsRead))
+	v1.HandleFunc("PUT /users/me/settings", cfg.middlewareAuth(cfg.handlerUpdateSettings, auth.ScopeUsersWrite))
+	v1.HandleFunc("PATCH /users/me/profile", cfg.middlewareAuth(cfg.handlerUpdateProfile, auth.ScopeUsersWrite))
+	v1.HandleFunc("POST /users/me/avatar", cfg.middlewareAuth(cfg.handlerUploadAvatar, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /users/me/mentions", cfg.middlewareAuth(cfg.handlerGetMyMentions, auth.ScopeChirpsRead))
+	v1.HandleFunc("GET /users/me/mutes", cfg.middlewareAuth(cfg.handlerGetMutedUsers, auth.ScopeUsersRead))
+	v1.HandleFunc("GET /users/me/blocks", cfg.middlewareAuth(cfg.handlerGetBlockedUsers, auth.ScopeUsersRead))
+	v1.HandleFunc("GET /users/me/hashtags", cfg.middlewareAuth(cfg.handlerGetFollowedHashtags, auth.ScopeUsersRead))
+	v1.HandleFunc("POST /users/{userID}/gift", cfg.middlewareAuth(cfg.handlerGiftChirpyRed))
+	v1.HandleFunc("POST /users/{userID}/follow", cfg.middlewareAuth(cfg.handlerFollowUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /users/{userID}/follow", cfg.middlewareAuth(cfg.handlerUnfollowUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("POST /users/{userID}/mute", cfg.middlewareAuth(cfg.handlerMuteUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /users/{userID}/mute", cfg.middlewareAuth(cfg.handlerUnmuteUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("POST /users/{userID}/block", cfg.middlewareAuth(cfg.handlerBlockUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /users/{userID}/block", cfg.middlewareAuth(cfg.handlerUnblockUser, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /users/{userID}/{relation}", cfg.handlerGetFollowList)
+	v1.HandleFunc("GET /users/by-handle/{handle}", cfg.handlerGetUserByHandle)
 
+	v1.HandleFunc("POST /chirps", cfg.middlewareAuth(cfg.handlerCreateChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("GET /chirps", cfg.handlerGetChirps)
+	v1.HandleFunc("GET /chirps/stream", cfg.handlerChirpsStream)
+	v1.HandleFunc("GET /chirps/nearby", cfg.handlerGetNearbyChirps)
+	v1.HandleFunc("GET /chirps/{chirpID}", cfg.handlerGetChirp)
+	v1.HandleFunc("PUT /chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerEditChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("DELETE /chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerDeleteChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("POST /chirps/{chirpID}/translate", cfg.middlewareAuth(cfg.handlerTranslateChirp, auth.ScopeChirpsRead))
+	v1.HandleFunc("GET /chirps/{chirpID}/replies", cfg.handlerGetChirpReplies)
+	v1.HandleFunc("GET /chirps/{chirpID}/stats", cfg.middlewareAuth(cfg.requireFeature(entitlements.Analytics, cfg.handlerGetChirpStats), auth.ScopeChirpsRead))
+	v1.HandleFunc("POST /chirps/{chirpID}/like", cfg.middlewareAuth(cfg.handlerLikeChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("DELETE /chirps/{chirpID}/like", cfg.middlewareAuth(cfg.handlerUnlikeChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("POST /chirps/{chirpID}/bookmark", cfg.middlewareAuth(cfg.handlerBookmarkChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("DELETE /chirps/{chirpID}/bookmark", cfg.middlewareAuth(cfg.handlerUnbookmarkChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("POST /chirps/{chirpID}/report", cfg.middlewareAuth(cfg.handlerReportChirp, auth.ScopeChirpsWrite))
+	v1.HandleFunc("GET /bookmarks", cfg.middlewareAuth(cfg.handlerGetBookmarks, auth.ScopeChirpsRead))
 
+	v1.HandleFunc("POST /lists", cfg.middlewareAuth(cfg.handlerCreateList, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /lists", cfg.middlewareAuth(cfg.handlerGetMyLists, auth.ScopeUsersRead))
+	v1.HandleFunc("GET /lists/{listID}", cfg.middlewareAuth(cfg.handlerGetList, auth.ScopeUsersRead))
+	v1.HandleFunc("PUT /lists/{listID}", cfg.middlewareAuth(cfg.handlerUpdateList, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /lists/{listID}", cfg.middlewareAuth(cfg.handlerDeleteList, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /lists/{listID}/members", cfg.middlewareAuth(cfg.handlerGetListMembers, auth.ScopeUsersRead))
+	v1.HandleFunc("PUT /lists/{listID}/members/{userID}", cfg.middlewareAuth(cfg.handlerAddListMember, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /lists/{listID}/members/{userID}", cfg.middlewareAuth(cfg.handlerRemoveListMember, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /lists/{listID}/chirps", cfg.middlewareAuth(cfg.handlerGetListChirps, auth.ScopeUsersRead))
 
+	v1.HandleFunc("POST /dm/{userID}", cfg.middlewareAuth(cfg.handlerSendDM, auth.ScopeMessagesWrite))
+	v1.HandleFunc("GET /dm/conversations", cfg.middlewareAuth(cfg.handlerGetConversations, auth.ScopeMessagesRead))
+	v1.HandleFunc("GET /dm/conversations/{conversationID}/messages", cfg.middlewareAuth(cfg.handlerGetMessages, auth.ScopeMessagesRead))
 
+	v1.HandleFunc("GET /hashtags/{tag}/chirps", cfg.handlerGetHashtagChirps)
+	v1.HandleFunc("POST /hashtags/{tag}/follow", cfg.middlewareAuth(cfg.handlerFollowHashtag, auth.ScopeUsersWrite))
+	v1.HandleFunc("DELETE /hashtags/{tag}/follow", cfg.middlewareAuth(cfg.handlerUnfollowHashtag, auth.ScopeUsersWrite))
+	v1.HandleFunc("GET /search", cfg.handlerSearchChirps)
+	v1.HandleFunc("GET /timeline", cfg.middlewareAuth(cfg.handlerGetTimeline, auth.ScopeChirpsRead))
+	v1.HandleFunc("GET /feed/for-you", cfg.middlewareAuth(cfg.handlerGetForYou, auth.ScopeChirpsRead))
+	v1.HandleFunc("GET /stream", acceptQueryToken(cfg.middlewareAuth(cfg.handlerStream, auth.ScopeChirpsRead, auth.ScopeUsersRead)))
 
+	v1.HandleFunc("GET /experiments", cfg.middlewareAuth(cfg.handlerGetExperiments, auth.ScopeUsersRead))
+	v1.HandleFunc("POST /experiments/{key}/events", cfg.middlewareAuth(cfg.handlerRecordExperimentEvent, auth.ScopeUsersWrite))
 
+	v1.HandleFunc("GET /notifications", cfg.middlewareAuth(cfg.handlerGetNotifications, auth.ScopeUsersRead))
+	v1.HandleFunc("POST /notifications/read", cfg.middlewareAuth(cfg.handlerMarkNotificationsRead, auth.ScopeUsersWrite))
 
+	// Webhook endpoints hold signing secrets, so scoped tokens can't manage them
+	userWebhooks := cfg.userWebhookEndpoints()
+	v1.HandleFunc("POST /webhooks", cfg.middlewareAuth(userWebhooks.handlerCreate))
+	v1.HandleFunc("GET /webhooks", cfg.middlewareAuth(userWebhooks.handlerList))
+	v1.HandleFunc("GET /webhooks/{endpointID}", cfg.middlewareAuth(userWebhooks.handlerGet))
+	v1.HandleFunc("PUT /webhooks/{endpointID}", cfg.middlewareAuth(userWebhooks.handlerUpdate))
+	v1.HandleFunc("DELETE /webhooks/{endpointID}", cfg.middlewareAuth(userWebhooks.handlerDelete))
+	v1.HandleFunc("GET /webhooks/{endpointID}/deliveries", cfg.middlewareAuth(userWebhooks.handlerListDeliveries))
+
+	// Sub-requests run through the same middleware as any other request,
+	// which is only built once every route is registered
+	var batchRouter http.Handler
+	v1.HandleFunc("POST /batch", handlerBatch(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batchRouter.ServeHTTP(w, r)
+	})))
+	v1.HandleFunc("GET /openapi.json", handlerOpenAPI(v1))
+	mountAPI(mux, v1)
+	mux.HandleFunc("GET /api/docs", handlerSwaggerUI)
+
+	// Admin endpoints
+	mux.HandleFunc("GET /admin/metrics", cfg.requireRole(auth.RoleAdmin, cfg.handlerMetrics))
+	mux.HandleFunc("GET /admin/metrics.json", cfg.requireRole(auth.RoleAdmin, cfg.handlerMetricsJSON))
+	mux.HandleFunc("POST /admin/reset", cfg.requireRole(auth.RoleAdmin, cfg.handlerReset))
+	mux.HandleFunc("GET /admin/export/chirps.csv", cfg.requireRole(auth.RoleAdmin, cfg.handlerExportChirps))
+	mux.HandleFunc("POST /admin/chirps/purge", cfg.requireRole(auth.RoleAdmin, cfg.handlerPurgeDeletedChirps))
+	mux.HandleFunc("GET /admin/export/users.csv", cfg.requireRole(auth.RoleAdmin, cfg.handlerExportUsers))
+	mux.HandleFunc("GET /admin/promo-codes", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetPromoCodes))
+	mux.HandleFunc("POST /admin/promo-codes", cfg.requireRole(auth.RoleAdmin, cfg.handlerCreatePromoCode))
+	mux.HandleFunc("GET /admin/feature-flags", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetFeatureFlags))
+	mux.HandleFunc("PUT /admin/feature-flags/{feature}", cfg.requireRole(auth.RoleAdmin, cfg.handlerSetFeatureFlag))
+	mux.HandleFunc("GET /admin/users/{userID}/entitlements", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetUserEntitlements))
+	mux.HandleFunc("PUT /admin/users/{userID}/entitlements/{feature}", cfg.requireRole(auth.RoleAdmin, cfg.handlerSetEntitlementOverride))
+	mux.HandleFunc("DELETE /admin/users/{userID}/entitlements/{feature}", cfg.requireRole(auth.RoleAdmin, cfg.handlerDeleteEntitlementOverride))
+	mux.HandleFunc("GET /admin/webhook-events", cfg.requireRole(auth.RoleAdmin, cfg.handlerListWebhookEvents))
+	mux.HandleFunc("GET /admin/webhook-events/{eventID}", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetWebhookEvent))
+	mux.HandleFunc("POST /admin/webhook-events/{eventID}/replay", cfg.requireRole(auth.RoleAdmin, cfg.handlerReplayWebhookEvent))
+	adminWebhooks := cfg.adminWebhookEndpoints()
+	mux.HandleFunc("POST /admin/webhooks", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerCreate))
+	mux.HandleFunc("GET /admin/webhooks", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerList))
+	mux.HandleFunc("GET /admin/webhooks/{endpointID}", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerGet))
+	mux.HandleFunc("PUT /admin/webhooks/{endpointID}", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerUpdate))
+	mux.HandleFunc("DELETE /admin/webhooks/{endpointID}", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerDelete))
+	mux.HandleFunc("GET /admin/webhooks/{endpointID}/deliveries", cfg.requireRole(auth.RoleAdmin, adminWebhooks.handlerListDeliveries))
+	mux.HandleFunc("GET /admin/webhook-deliveries", cfg.requireRole(auth.RoleAdmin, cfg.handlerListWebhookDeliveries))
+	mux.HandleFunc("GET /admin/webhook-deliveries/{deliveryID}", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetWebhookDelivery))
+	mux.HandleFunc("POST /admin/webhook-deliveries/{deliveryID}/replay", cfg.requireRole(auth.RoleAdmin, cfg.handlerReplayWebhookDelivery))
+	mux.HandleFunc("PUT /admin/users/{userID}/role", cfg.requireRole(auth.RoleAdmin, cfg.handlerSetUserRole))
+	mux.HandleFunc("POST /admin/users/{userID}/unlock", cfg.requireRole(auth.RoleAdmin, cfg.handlerUnlockUser))
+	mux.HandleFunc("GET /admin/users", cfg.requireRole(auth.RoleAdmin, cfg.handlerListUsers))
+	mux.HandleFunc("GET /admin/users/{userID}", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetUser))
+	mux.HandleFunc("POST /admin/users/{userID}/suspend", cfg.requireRole(auth.RoleAdmin, cfg.handlerSuspendUser))
+	mux.HandleFunc("POST /admin/users/{userID}/unsuspend", cfg.requireRole(auth.RoleAdmin, cfg.handlerUnsuspendUser))
+	mux.HandleFunc("POST /admin/users/{userID}/password-reset", cfg.requireRole(auth.RoleAdmin, cfg.handlerForcePasswordReset))
+	mux.HandleFunc("PUT /admin/users/{userID}/shadow-ban", cfg.requireRole(auth.RoleAdmin, cfg.handlerSetShadowBan))
+	mux.HandleFunc("GET /admin/reports", cfg.requireRole(auth.RoleModerator, cfg.handlerListReports))
+	mux.HandleFunc("GET /admin/reports/{reportID}", cfg.requireRole(auth.RoleModerator, cfg.handlerGetReport))
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", cfg.requireRole(auth.RoleModerator, cfg.handlerResolveReport))
+	mux.HandleFunc("GET /admin/moderation-log", cfg.requireRole(auth.RoleModerator, cfg.handlerListModerationActions))
+	mux.HandleFunc("GET /admin/experiments/{key}/results", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetExperimentResults))
+	mux.HandleFunc("GET /admin/audit-log", cfg.requireRole(auth.RoleAdmin, cfg.handlerListAuditEvents))
+	mux.HandleFunc("GET /admin/banned-words", cfg.requireRole(auth.RoleAdmin, cfg.handlerGetBannedWords))
+	mux.HandleFunc("POST /admin/banned-words", cfg.requireRole(auth.RoleAdmin, cfg.handlerAddBannedWord))
+	mux.HandleFunc("POST /admin/banned-words/reload", cfg.requireRole(auth.RoleAdmin, cfg.handlerReloadBannedWords))
+	mux.HandleFunc("DELETE /admin/banned-words/{word}", cfg.requireRole(auth.RoleAdmin, cfg.handlerDeleteBannedWord))
+
+	// Uploaded media
+	mux.HandleFunc("GET /media/{key...}", cfg.handlerGetMedia)
+
+	// Fileserver
+	fileServer := http.FileServer(http.Dir("."))
+	staticCache := staticCachePolicy{
+		maxAge:       conf.Static.MaxAge,
+		hashedMaxAge: conf.Static.HashedMaxAge,
+	}
+	mux.Handle("/app/", cfg.middlewareMetricsInc(staticCache.middlewareStaticCache(http.StripPrefix("/app", fileServer))))
+
+	// Gzip compressible responses of at least GZIP_MIN_SIZE bytes
+	var handler http.Handler = mux
+	if conf.GzipMinSize != config.GzipOff {
+		handler = compress.Handler(conf.GzipMinSize, mux)
+	}
+
+	limits := conf.Server
+	batchRouter = cfg.middlewareRouteMetrics(mux, middlewareTimeout(mux, limits.RequestTimeout, cfg.middlewareRateLimit(middlewareMaxBodySize(mux, limits.MaxBodyBytes, handler))))
+	return middlewareRequestID(batchRouter)
+}
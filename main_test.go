@@ -0,0 +1,535 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/experiments"
+	"github.com/Utkarsh736/chirpy/internal/mail"
+	"github.com/Utkarsh736/chirpy/internal/media"
+	"github.com/Utkarsh736/chirpy/internal/metrics"
+	"github.com/Utkarsh736/chirpy/internal/oauth"
+	"github.com/Utkarsh736/chirpy/internal/password"
+	"github.com/Utkarsh736/chirpy/internal/profanity"
+	"github.com/Utkarsh736/chirpy/internal/ranking"
+	"github.com/Utkarsh736/chirpy/internal/ratelimit"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/Utkarsh736/chirpy/internal/stream"
+	"github.com/Utkarsh736/chirpy/internal/viewcount"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	testPolkaKey    = "test-polka-key"
+	testPassword    = "Str0ng!Passw0rd#x"
+	testRemoteAddr  = "192.0.2.1:1234"
+	testChirpLength = 140
+)
+
+// testAPI is the API's full handler over a fresh store.Memory
+type testAPI struct {
+	t       *testing.T
+	cfg     *apiConfig
+	handler http.Handler
+}
+
+// testUser is a signed-up user and the tokens from their sign-in
+type testUser struct {
+	ID           uuid.UUID
+	Token        string
+	RefreshToken string
+}
+
+// newTestAPI sets the API up as serve does for a dev server, but on a
+// store.Memory, with env on top of the test settings. DB_URL only has to
+// pass the configuration checks. The undo window is dropped so new chirps
+// publish straight away.
+func newTestAPI(t *testing.T, env map[string]string) *testAPI {
+	t.Helper()
+	settings := map[string]string{
+		"DB_URL":            "sqlite::memory:",
+		"PLATFORM":          "dev",
+		"JWT_SECRET":        "test-secret",
+		"POLKA_KEY":         testPolkaKey,
+		"MAIL_SENDER":       "none",
+		"SIGNUP_RATE_LIMIT": "off",
+		"LOGIN_RATE_LIMIT":  "off",
+	}
+	maps.Copy(settings, env)
+	conf, err := config.LoadFrom(func(key string) string { return settings[key] })
+	if err != nil {
+		t.Fatalf("Expected no error loading the configuration, got %v", err)
+	}
+
+	db := store.NewMemory()
+	plans, err := db.GetPlans(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plan := range plans {
+		plan.UndoWindowSeconds = 0
+		db.SetPlan(plan)
+	}
+
+	mediaStore, err := media.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	relyingParty, err := newWebAuthn(conf.WebAuthn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &apiConfig{
+		db:               db,
+		config:           conf,
+		mediaStore:       mediaStore,
+		streamHub:        stream.NewHub(streamBuffer),
+		chirpViews:       viewcount.New(),
+		mailer:           mail.Noop{},
+		webhookClient:    webhooks.NewClient(conf.WebhookAllowPrivate),
+		oauthProviders:   map[string]*oauth.Provider{},
+		webauthn:         relyingParty,
+		loginLimiter:     newRateLimiter(conf.RateLimits.Login),
+		signupLimiter:    newRateLimiter(conf.RateLimits.Signup),
+		apiReadLimiter:   newRateBucket(conf.RateLimits.APIRead),
+		apiWriteLimiter:  newRateBucket(conf.RateLimits.APIWrite),
+		passwordPolicy:   password.Policy{MinLength: conf.PasswordMinLength},
+		profanity:        profanity.New(profanity.DefaultWords...),
+		shuttingDown:     make(chan struct{}),
+		routeMetrics:     metrics.New(),
+		planWriteLimiter: ratelimit.NewBuckets(time.Minute),
+		experiments:      experiments.NewRegistry(activeExperiments...),
+		feedRanker:       ranking.Default,
+	}
+	return &testAPI{t: t, cfg: cfg, handler: cfg.routes()}
+}
+
+// do sends a request through the API's middleware, encoding body as JSON
+// unless it's nil or already a string. authorization is the whole
+// Authorization header, if any.
+func (api *testAPI) do(method, path, authorization string, body any) *httptest.ResponseRecorder {
+	api.t.Helper()
+	var payload []byte
+	switch body := body.(type) {
+	case nil:
+	case string:
+		payload = []byte(body)
+	default:
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			api.t.Fatal(err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.RemoteAddr = testRemoteAddr
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	api.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// signUp creates a user with handle and signs them in
+func (api *testAPI) signUp(handle string) testUser {
+	api.t.Helper()
+	credentials := map[string]string{
+		"email":    handle + "@example.com",
+		"password": testPassword,
+		"handle":   handle,
+	}
+	rec := api.do("POST", "/api/users", "", credentials)
+	if rec.Code != 201 {
+		api.t.Fatalf("Expected 201 signing up %s, got %d: %s", handle, rec.Code, rec.Body)
+	}
+
+	delete(credentials, "handle")
+	rec = api.do("POST", "/api/login", "", credentials)
+	if rec.Code != 200 {
+		api.t.Fatalf("Expected 200 signing in %s, got %d: %s", handle, rec.Code, rec.Body)
+	}
+	login := decodeResponse[struct {
+		ID           uuid.UUID `json:"id"`
+		Token        string    `json:"token"`
+		RefreshToken string    `json:"refresh_token"`
+	}](api.t, rec)
+	return testUser{ID: login.ID, Token: login.Token, RefreshToken: login.RefreshToken}
+}
+
+// createChirp posts body as user, expecting it to be published
+func (api *testAPI) createChirp(user testUser, body string) Chirp {
+	api.t.Helper()
+	rec := api.do("POST", "/api/chirps", bearer(user.Token), map[string]string{"body": body})
+	if rec.Code != 201 {
+		api.t.Fatalf("Expected 201 creating a chirp, got %d: %s", rec.Code, rec.Body)
+	}
+	return decodeResponse[Chirp](api.t, rec)
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
+
+func decodeResponse[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	err := json.Unmarshal(rec.Body.Bytes(), &value)
+	if err != nil {
+		t.Fatalf("Expected a JSON response, got %q (%v)", rec.Body, err)
+	}
+	return value
+}
+
+func TestSignUpAndLogin(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	tests := []struct {
+		name       string
+		path       string
+		body       map[string]string
+		wantStatus int
+	}{
+		{
+			name:       "duplicate email",
+			path:       "/api/users",
+			body:       map[string]string{"email": "alice@example.com", "password": testPassword, "handle": "alice2"},
+			wantStatus: 409,
+		},
+		{
+			name:       "invalid email",
+			path:       "/api/users",
+			body:       map[string]string{"email": "not-an-email", "password": testPassword, "handle": "bobby"},
+			wantStatus: 400,
+		},
+		{
+			name:       "wrong password",
+			path:       "/api/login",
+			body:       map[string]string{"email": "alice@example.com", "password": "wrong"},
+			wantStatus: 401,
+		},
+		{
+			name:       "unknown email",
+			path:       "/api/login",
+			body:       map[string]string{"email": "nobody@example.com", "password": testPassword},
+			wantStatus: 401,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := api.do("POST", tt.path, "", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	rec := api.do("GET", "/api/sessions", bearer(alice.Token), nil)
+	if rec.Code != 200 {
+		t.Errorf("Expected the access token to work, got %d: %s", rec.Code, rec.Body)
+	}
+	rec = api.do("GET", "/api/sessions", "", nil)
+	if rec.Code != 401 {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+	rec = api.do("GET", "/api/sessions", bearer("not-a-token"), nil)
+	if rec.Code != 401 {
+		t.Errorf("Expected 401 with a bad token, got %d", rec.Code)
+	}
+}
+
+func TestRefreshAndRevoke(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	rec := api.do("POST", "/api/refresh", bearer(alice.RefreshToken), nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 refreshing, got %d: %s", rec.Code, rec.Body)
+	}
+	refreshed := decodeResponse[struct {
+		Token string `json:"token"`
+	}](t, rec)
+	rec = api.do("GET", "/api/sessions", bearer(refreshed.Token), nil)
+	if rec.Code != 200 {
+		t.Errorf("Expected the refreshed token to work, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = api.do("POST", "/api/revoke", bearer(alice.RefreshToken), nil)
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 revoking, got %d: %s", rec.Code, rec.Body)
+	}
+	rec = api.do("POST", "/api/refresh", bearer(alice.RefreshToken), nil)
+	if rec.Code != 401 {
+		t.Errorf("Expected 401 refreshing a revoked token, got %d", rec.Code)
+	}
+	// An access token isn't a refresh token
+	rec = api.do("POST", "/api/refresh", bearer(alice.Token), nil)
+	if rec.Code != 401 {
+		t.Errorf("Expected 401 refreshing with an access token, got %d", rec.Code)
+	}
+}
+
+func TestChirpCRUD(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	bobby := api.signUp("bobby")
+
+	chirp := api.createChirp(alice, "Hello, world!")
+	if chirp.UserID != alice.ID || chirp.Body != "Hello, world!" {
+		t.Errorf("Expected alice's chirp, got %+v", chirp)
+	}
+
+	rec := api.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 getting the chirp, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := decodeResponse[Chirp](t, rec); got.ID != chirp.ID {
+		t.Errorf("Expected chirp %s, got %s", chirp.ID, got.ID)
+	}
+
+	rec = api.do("GET", "/api/chirps?author_id="+alice.ID.String(), "", nil)
+	if got := decodeResponse[[]Chirp](t, rec); len(got) != 1 || got[0].ID != chirp.ID {
+		t.Errorf("Expected only alice's chirp listed, got %+v", got)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		body          any
+		wantStatus    int
+	}{
+		{"create without a token", "POST", "/api/chirps", "", map[string]string{"body": "hi"}, 401},
+		{"create too long for the free plan", "POST", "/api/chirps", bearer(alice.Token), map[string]string{"body": strings.Repeat("a", testChirpLength+1)}, 402},
+		{"get with a bad ID", "GET", "/api/chirps/not-a-uuid", "", nil, 400},
+		{"get a missing chirp", "GET", "/api/chirps/" + uuid.NewString(), "", nil, 404},
+		{"edit on the free plan", "PUT", "/api/chirps/" + chirp.ID.String(), bearer(alice.Token), map[string]string{"body": "Hello again"}, 402},
+		{"delete someone else's", "DELETE", "/api/chirps/" + chirp.ID.String(), bearer(bobby.Token), nil, 403},
+		{"delete", "DELETE", "/api/chirps/" + chirp.ID.String(), bearer(alice.Token), nil, 204},
+		{"get after deleting", "GET", "/api/chirps/" + chirp.ID.String(), "", nil, 404},
+		{"delete again", "DELETE", "/api/chirps/" + chirp.ID.String(), bearer(alice.Token), nil, 404},
+	}
+	for _, tt := range tests {
+		rec := api.do(tt.method, tt.path, tt.authorization, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestDeactivateAndReactivate(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	chirp := api.createChirp(alice, "Hello, world!")
+	credentials := map[string]string{"email": "alice@example.com", "password": testPassword}
+	visible := func() bool {
+		return api.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil).Code == 200
+	}
+
+	rec := api.do("POST", "/api/users/me/deactivate", bearer(alice.Token), map[string]string{"password": testPassword})
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 deactivating, got %d: %s", rec.Code, rec.Body)
+	}
+	if visible() {
+		t.Error("Expected the chirp hidden once deactivated")
+	}
+	if rec := api.do("POST", "/api/login", "", credentials); rec.Code != 403 {
+		t.Errorf("Expected 403 signing in while deactivated, got %d", rec.Code)
+	}
+
+	rec = api.do("POST", "/api/users/reactivate", "", credentials)
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 reactivating, got %d: %s", rec.Code, rec.Body)
+	}
+	if !visible() {
+		t.Error("Expected the chirp shown again once reactivated")
+	}
+	if rec := api.do("POST", "/api/users/reactivate", "", credentials); rec.Code != 409 {
+		t.Errorf("Expected 409 reactivating an active account, got %d", rec.Code)
+	}
+}
+
+func TestReactivateSuspended(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	chirp := api.createChirp(alice, "Hello, world!")
+
+	rec := api.do("POST", "/api/users/me/deactivate", bearer(alice.Token), map[string]string{"password": testPassword})
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 deactivating, got %d: %s", rec.Code, rec.Body)
+	}
+	_, err := api.cfg.db.SuspendUser(context.Background(), database.SuspendUserParams{ID: alice.ID, SuspensionReason: "Spam"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec = api.do("POST", "/api/users/reactivate", "", map[string]string{"email": "alice@example.com", "password": testPassword})
+	if rec.Code != 403 {
+		t.Errorf("Expected 403 reactivating while suspended, got %d: %s", rec.Code, rec.Body)
+	}
+	dbUser, err := api.cfg.db.GetUserByID(context.Background(), alice.ID)
+	if err != nil || !dbUser.DeactivatedAt.Valid {
+		t.Errorf("Expected the account left deactivated, got %+v (%v)", dbUser, err)
+	}
+	if rec := api.do("GET", "/api/chirps/"+chirp.ID.String(), "", nil); rec.Code != 404 {
+		t.Errorf("Expected the chirp still hidden, got %d", rec.Code)
+	}
+}
+
+func TestWriteRateLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		writeLimit    string
+		wantAnonymous int
+	}{
+		{"API_WRITE_RATE_LIMIT set", "60/1m", 429},
+		{"API_WRITE_RATE_LIMIT off", "off", 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI(t, map[string]string{"API_WRITE_RATE_LIMIT": tt.writeLimit})
+			alice := api.signUp("alice")
+
+			// The free plan allows 30 writes a minute either way
+			for i := range 30 {
+				api.createChirp(alice, "Chirp "+strconv.Itoa(i))
+			}
+			rec := api.do("POST", "/api/chirps", bearer(alice.Token), map[string]string{"body": "One too many"})
+			if rec.Code != 429 {
+				t.Errorf("Expected 429 past the plan's limit, got %d: %s", rec.Code, rec.Body)
+			}
+
+			for range 60 {
+				api.do("POST", "/api/chirps", "", map[string]string{"body": "Anonymous"})
+			}
+			rec = api.do("POST", "/api/chirps", "", map[string]string{"body": "Anonymous"})
+			if rec.Code != tt.wantAnonymous {
+				t.Errorf("Expected %d signed out, got %d: %s", tt.wantAnonymous, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRequestBodyErrors(t *testing.T) {
+	api := newTestAPI(t, map[string]string{"MAX_BODY_BYTES": "100"})
+	alice := api.signUp("alice")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"over the limit", `{"body": "` + strings.Repeat("a", 100) + `"}`, 413},
+		{"over the limit after the value", `{"body": "hi"}` + strings.Repeat(" ", 100) + `{}`, 413},
+		{"trailing data", `{"body": "hi"} {}`, 400},
+		{"unknown field", `{"body": "hi", "bdy": "hi"}`, 400},
+	}
+	for _, tt := range tests {
+		rec := api.do("POST", "/api/chirps", bearer(alice.Token), tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	login := func(password string) *httptest.ResponseRecorder {
+		return api.do("POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": password})
+	}
+	failLogins := func(n int) {
+		t.Helper()
+		for range n {
+			if rec := login("wrong"); rec.Code != 401 {
+				t.Fatalf("Expected 401 for a wrong password, got %d: %s", rec.Code, rec.Body)
+			}
+		}
+	}
+
+	// A sign-in in between starts the count again
+	failLogins(loginMaxFailures - 1)
+	if rec := login(testPassword); rec.Code != 200 {
+		t.Fatalf("Expected 200 before the limit, got %d: %s", rec.Code, rec.Body)
+	}
+	failLogins(loginMaxFailures - 1)
+	if rec := login(testPassword); rec.Code != 200 {
+		t.Fatalf("Expected the count reset by signing in, got %d: %s", rec.Code, rec.Body)
+	}
+
+	failLogins(loginMaxFailures)
+	rec := login(testPassword)
+	if rec.Code != 423 {
+		t.Fatalf("Expected 423 once locked, even with the right password, got %d: %s", rec.Code, rec.Body)
+	}
+	if retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retryAfter <= 0 || retryAfter > int(loginLockoutDuration.Seconds()) {
+		t.Errorf("Expected a Retry-After within the lockout, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// The lock lapses on its own
+	err := api.cfg.db.LockUser(context.Background(), database.LockUserParams{
+		ID:          alice.ID,
+		LockedUntil: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := login(testPassword); rec.Code != 200 {
+		t.Errorf("Expected 200 once the lock expired, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestAccessTokenRevocation(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	authorized := func(token string) bool {
+		return api.do("GET", "/api/sessions", bearer(token), nil).Code == 200
+	}
+	signIn := func() string {
+		rec := api.do("POST", "/api/login", "", map[string]string{"email": "alice@example.com", "password": testPassword})
+		return decodeResponse[struct {
+			Token string `json:"token"`
+		}](t, rec).Token
+	}
+	other := signIn()
+
+	// Logging out revokes just that token's jti
+	if rec := api.do("POST", "/api/logout", bearer(alice.Token), nil); rec.Code != 204 {
+		t.Fatalf("Expected 204 logging out, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := api.do("GET", "/api/sessions", bearer(alice.Token), nil); rec.Code != 401 {
+		t.Errorf("Expected 401 with the revoked token, got %d", rec.Code)
+	}
+	if !authorized(other) {
+		t.Error("Expected the other session's token to keep working")
+	}
+
+	// Revoking every session bumps the token version past them all
+	if rec := api.do("POST", "/api/users/me/revoke-all", bearer(other), nil); rec.Code != 204 {
+		t.Fatalf("Expected 204 revoking every session, got %d: %s", rec.Code, rec.Body)
+	}
+	if authorized(other) {
+		t.Error("Expected 401 for every token issued before")
+	}
+	if !authorized(signIn()) {
+		t.Error("Expected a new sign-in to work")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const (
+	requestAuthKey contextKey = iota
+	requestIDKey
+)
+
+// requestAuth is the caller middlewareAuth authenticated
+type requestAuth struct {
+	claims *auth.Claims
+	userID uuid.UUID
+	// user is loaded the first time a handler asks for it
+	user *database.User
+}
+
+// middlewareAuth only lets through requests carrying a valid, unrevoked
+// access token with every one of scopes, and makes the caller available to
+// next through authUserID and cfg.authUser. With no scopes, only
+// full-access tokens are accepted.
+func (cfg *apiConfig) middlewareAuth(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+
+		claims, err := cfg.parseJWT(r.Context(), token)
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+		err = claims.RequireScopes(scopes...)
+		if errors.Is(err, auth.ErrInsufficientScope) {
+			respondWithError(w, 403, "Token lacks the required scope")
+			return
+		}
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			respondWithError(w, 401, "Unauthorized")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), requestAuthKey, &requestAuth{
+			claims: claims,
+			userID: userID,
+		})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// acceptQueryToken lets a request pass its access token as ?access_token=
+// instead of a header, for WebSocket clients in browsers that can't set
+// headers. It goes outside middlewareAuth.
+func acceptQueryToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		if token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next(w, r)
+	}
+}
+
+// viewerID is the caller of a public route when the request carries a
+// valid access token of any scope, so listings can include what only they
+// may see. A missing or bad token just means an anonymous viewer.
+func (cfg *apiConfig) viewerID(r *http.Request) uuid.NullUUID {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	claims, err := cfg.parseJWT(r.Context(), token)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: userID, Valid: true}
+}
+
+func requestAuthFrom(r *http.Request) *requestAuth {
+	ra, ok := r.Context().Value(requestAuthKey).(*requestAuth)
+	if !ok {
+		// Only reachable if a route is missing middlewareAuth
+		panic("request was not authenticated by middlewareAuth")
+	}
+	return ra
+}
+
+// authUserID is the ID of the user middlewareAuth authenticated
+func authUserID(r *http.Request) uuid.UUID {
+	return requestAuthFrom(r).userID
+}
+
+// callerID is the user middlewareAuth or requireRole authenticated, or
+// none on routes without them and for the admin API key
+func callerID(r *http.Request) uuid.NullUUID {
+	ra, ok := r.Context().Value(requestAuthKey).(*requestAuth)
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: ra.userID, Valid: true}
+}
+
+// authUser loads the row of the user middlewareAuth authenticated, at most
+// once per request
+func (cfg *apiConfig) authUser(r *http.Request) (database.User, error) {
+	ra := requestAuthFrom(r)
+	if ra.user == nil {
+		dbUser, err := cfg.db.GetUserByID(r.Context(), ra.userID)
+		if err != nil {
+			return database.User{}, err
+		}
+		ra.user = &dbUser
+	}
+	return *ra.user, nil
+}
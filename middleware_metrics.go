@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// unmatchedRoute labels requests no route pattern matched, so scanners
+// probing random paths share one row instead of one each
+const unmatchedRoute = "unmatched"
+
+// middlewareRouteMetrics records the status and latency of every request
+// under the mux pattern that serves it, for the admin metrics page, and
+// counts requests to the API
+func (cfg *apiConfig) middlewareRouteMetrics(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			cfg.apiRequests.Add(1)
+		}
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		cfg.routeMetrics.Observe(route, recorder.Status(), time.Since(start))
+	})
+}
+
+// statusRecorder remembers the status code written through it. It passes
+// flushing and hijacking through so streams and WebSockets keep working.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = 200
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Status is the code sent, 200 if the handler wrote nothing
+func (rec *statusRecorder) Status() int {
+	if rec.status == 0 {
+		return 200
+	}
+	return rec.status
+}
+
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	// A hijacked connection reports as switching protocols
+	rec.status = 101
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
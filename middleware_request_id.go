@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// Incoming IDs are only honored if they're short and safe to put in logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// middlewareRequestID gives every request an ID, taken from an incoming
+// X-Request-ID when it looks sane and generated otherwise. The ID is echoed
+// in the response headers and error bodies and prefixed to log lines, so a
+// user reporting a failure can be matched to what the server saw.
+func middlewareRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestID is the ID middlewareRequestID gave the request ctx belongs to,
+// or "" outside a request
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// logRequestf is log.Printf tagged with the request's ID
+func logRequestf(r *http.Request, format string, args ...any) {
+	log.Printf("[%s] "+format, append([]any{requestID(r.Context())}, args...)...)
+}
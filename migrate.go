@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/sqlite"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// migrations are the goose migrations in sql/schema, built into the binary
+// so the schema always matches the code
+//
+//go:embed sql/schema/*.sql
+var migrations embed.FS
+
+// schemaFor is the migrations for the database driver names. SQLite has
+// its own, since its schema is written differently.
+func schemaFor(driver string) (fs.FS, error) {
+	if driver == config.DatabaseSQLite {
+		return fs.Sub(sqlite.Schema, "schema")
+	}
+	return fs.Sub(migrations, "sql/schema")
+}
+
+// latestMigration is the version of the newest migration shipped with this
+// build for driver, which the database has to be at before the server is
+// ready
+func latestMigration(driver string) (int64, error) {
+	schema, err := schemaFor(driver)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := fs.ReadDir(schema, ".")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err == nil && version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
+
+// migrateDB applies the migrations the database doesn't have yet. On
+// Postgres, an advisory lock keeps instances that start together from
+// racing each other; a SQLite file only has the one.
+func migrateDB(ctx context.Context, db *sql.DB, driver string) error {
+	schema, err := schemaFor(driver)
+	if err != nil {
+		return err
+	}
+	dialect := goose.DialectSQLite3
+	var opts []goose.ProviderOption
+	if driver != config.DatabaseSQLite {
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return err
+		}
+		dialect = goose.DialectPostgres
+		opts = append(opts, goose.WithSessionLocker(locker))
+	}
+	provider, err := goose.NewProvider(dialect, db, schema, opts...)
+	if err != nil {
+		return err
+	}
+
+	results, err := provider.Up(ctx)
+	for _, result := range results {
+		if result.Error == nil {
+			log.Printf("Applied migration %s in %s", result.Source.Path, result.Duration)
+		}
+	}
+	return err
+}
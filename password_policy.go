@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/Utkarsh736/chirpy/internal/password"
+)
+
+// breachedPasswordFalsePositiveRate is how often a password that isn't in
+// the breach list gets refused anyway
+const breachedPasswordFalsePositiveRate = 0.001
+
+// loadBreachedPasswords reads a breach list into a bloom filter, so the
+// list itself isn't kept in memory
+func loadBreachedPasswords(path string) (*password.Bloom, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return password.ReadBloom(file, breachedPasswordFalsePositiveRate)
+}
+
+// checkPassword answers 400 listing what's wrong with candidate if it
+// fails the password policy, and reports whether it passed
+func (cfg *apiConfig) checkPassword(w http.ResponseWriter, candidate string) bool {
+	violations := cfg.passwordPolicy.Check(candidate)
+	if len(violations) == 0 {
+		return true
+	}
+
+	details := make([]fieldError, 0, len(violations))
+	for _, violation := range violations {
+		details = append(details, fieldError{
+			Field:   "password",
+			Code:    violation.Code,
+			Message: violation.Message,
+		})
+	}
+	respondWithValidationErrors(w, "Password does not meet the requirements", details)
+	return false
+}
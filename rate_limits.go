@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"github.com/Utkarsh736/chirpy/internal/ratelimit"
+	"github.com/google/uuid"
+)
+
+const rateLimitPruneInterval = 10 * time.Minute
+
+// newRateLimiter builds a limiter allowing rate, or returns nil when the
+// limit is off
+func newRateLimiter(rate *config.Rate) *ratelimit.Limiter {
+	if rate == nil {
+		return nil
+	}
+	return ratelimit.New(rate.Limit, rate.Window)
+}
+
+// newRateBucket is newRateLimiter for token-bucket limits
+func newRateBucket(rate *config.Rate) *ratelimit.Bucket {
+	if rate == nil {
+		return nil
+	}
+	return ratelimit.NewBucket(rate.Limit, rate.Window)
+}
+
+// respondIfRateLimited reports result in X-RateLimit-* headers and answers
+// 429 with Retry-After when the request was refused, reporting whether it
+// did
+func respondIfRateLimited(w http.ResponseWriter, result ratelimit.Result) bool {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+	if result.Allowed {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	respondWithError(w, 429, "Too many requests, please try again later")
+	return true
+}
+
+// rateLimit throttles next per client IP. A nil limiter lets everything
+// through.
+func rateLimit(limiter *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if respondIfRateLimited(w, limiter.Allow(clientIP(r))) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// providerWebhookPath matches the billing providers' webhook receivers,
+// under a version prefix or not
+var providerWebhookPath = regexp.MustCompile(`^/api(/v[0-9]+)?/(polka|stripe)/webhooks$`)
+
+// middlewareRateLimit applies the read or write limit to every /api
+// request, counted per user for requests with a valid access token and per
+// client IP otherwise. Signed-in users' writes are held to their plan's
+// rate_limit_per_minute instead of API_WRITE_RATE_LIMIT, even with that
+// turned off. Provider webhooks are left alone: they come from a handful of
+// addresses and retry on their own.
+func (cfg *apiConfig) middlewareRateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || providerWebhookPath.MatchString(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, limited := cfg.allowAPIRequest(r)
+		if limited && respondIfRateLimited(w, result) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowAPIRequest takes a token for r from the bucket it counts against,
+// reporting false when no limit applies
+func (cfg *apiConfig) allowAPIRequest(r *http.Request) (ratelimit.Result, bool) {
+	userID, signedIn := rateLimitUser(r, cfg.config.JWTSecret)
+	key := "ip:" + clientIP(r)
+	if signedIn {
+		key = "user:" + userID.String()
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		if cfg.apiReadLimiter == nil {
+			return ratelimit.Result{}, false
+		}
+		return cfg.apiReadLimiter.Allow(key), true
+	}
+	// Plans keep their limits with API_WRITE_RATE_LIMIT off
+	if signedIn {
+		if limit, ok := cfg.planRateLimit(r.Context(), userID); ok {
+			return cfg.planWriteLimiter.Allow(key, limit), true
+		}
+	}
+	if cfg.apiWriteLimiter == nil {
+		return ratelimit.Result{}, false
+	}
+	return cfg.apiWriteLimiter.Allow(key), true
+}
+
+// planRateLimit is the write requests per minute userID's plan allows. A
+// user whose plan can't be loaded falls back to API_WRITE_RATE_LIMIT, and
+// so does a plan without a limit of its own.
+func (cfg *apiConfig) planRateLimit(ctx context.Context, userID uuid.UUID) (int, bool) {
+	dbPlan, err := cfg.db.GetPlanForUser(ctx, userID)
+	if err != nil || dbPlan.RateLimitPerMinute <= 0 {
+		return 0, false
+	}
+	return int(dbPlan.RateLimitPerMinute), true
+}
+
+// rateLimitUser is who a request with an access token counts against. Only
+// the token's signature is checked: a revoked token still belongs to the
+// same user, and reads shouldn't need the database to be counted.
+func rateLimitUser(r *http.Request, jwtSecret string) (uuid.UUID, bool) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	claims, err := auth.ParseJWT(token, jwtSecret)
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return uuid.UUID{}, false
+	}
+	return userID, true
+}
+
+// pruneRateLimits forgets clients whose rate limit window has ended
+func (cfg *apiConfig) pruneRateLimits(ctx context.Context) error {
+	for _, limiter := range []*ratelimit.Limiter{cfg.loginLimiter, cfg.signupLimiter} {
+		if limiter != nil {
+			limiter.Prune()
+		}
+	}
+	for _, limiter := range []*ratelimit.Bucket{cfg.apiReadLimiter, cfg.apiWriteLimiter} {
+		if limiter != nil {
+			limiter.Prune()
+		}
+	}
+	cfg.planWriteLimiter.Prune()
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	mathrand "math/rand"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/auth"
+	"github.com/Utkarsh736/chirpy/internal/chirptext"
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+// seedPassword is the password of every seeded user
+const seedPassword = "chirpy-seed-password"
+
+// seedBodies are the chirps seeded users post, with hashtags so search and
+// trending have something to show
+var seedBodies = []string{
+	"Just setting up my chirpy",
+	"Coffee first, code second #morning",
+	"Shipped it! #golang",
+	"Anyone else watching the game tonight? #sports",
+	"Reading a great book about databases #postgres",
+	"Rainy day, perfect for refactoring #golang #weekend",
+	"Hot take: tabs are better than spaces",
+	"Lunch break #food",
+	"Deploy on a Friday? Never again #ops",
+	"Trying out a new recipe tonight #food #cooking",
+}
+
+// seedOptions size the data seedDB creates
+type seedOptions struct {
+	users int
+	// follows and chirps are per user
+	follows int
+	chirps  int
+}
+
+// seedDB fills the database with fake users, follows and chirps for local
+// development and load testing. Users are named seed_<run>_<n> with a run
+// ID unique to each call, so seeding twice adds to the data instead of
+// colliding with it.
+func seedDB(ctx context.Context, db store.Store, opts seedOptions) error {
+	runBytes := make([]byte, 3)
+	if _, err := rand.Read(runBytes); err != nil {
+		return err
+	}
+	run := hex.EncodeToString(runBytes)
+
+	// Hashed once, since hashing is deliberately slow
+	hashedPassword, err := auth.HashPassword(seedPassword)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uuid.UUID, 0, opts.users)
+	for n := range opts.users {
+		handle := fmt.Sprintf("seed_%s_%d", run, n)
+		user, err := db.CreateUser(ctx, database.CreateUserParams{
+			Email:          handle + "@example.com",
+			HashedPassword: hashedPassword,
+			Handle:         optionalString(handle),
+		})
+		if err != nil {
+			return fmt.Errorf("creating user %s: %w", handle, err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	follows := 0
+	for i, followerID := range userIDs {
+		// The first few other users in a random order, so follow graphs
+		// differ per user
+		followed := 0
+		for _, j := range mathrand.Perm(len(userIDs)) {
+			if followed == opts.follows {
+				break
+			}
+			if j == i {
+				continue
+			}
+			followed++
+			_, err := db.CreateFollow(ctx, database.CreateFollowParams{
+				FollowerID: followerID,
+				FolloweeID: userIDs[j],
+			})
+			if err != nil {
+				return fmt.Errorf("creating follow: %w", err)
+			}
+			follows++
+		}
+	}
+
+	chirps := 0
+	for _, userID := range userIDs {
+		for range opts.chirps {
+			// Spread over the last month, so timelines and date filters
+			// have some range
+			now := time.Now().UTC().Add(-time.Duration(mathrand.Int63n(int64(30 * 24 * time.Hour))))
+			chirp, err := db.CreateChirp(ctx, database.CreateChirpParams{
+				Body:        seedBodies[mathrand.Intn(len(seedBodies))],
+				UserID:      userID,
+				PublishAt:   now,
+				PublishedAt: sql.NullTime{Time: now, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("creating chirp: %w", err)
+			}
+			if tags := chirptext.Hashtags(chirp.Body); len(tags) > 0 {
+				err = db.CreateChirpHashtags(ctx, database.CreateChirpHashtagsParams{
+					ChirpID: chirp.ID,
+					Tags:    tags,
+				})
+				if err != nil {
+					return fmt.Errorf("tagging chirp: %w", err)
+				}
+			}
+			chirps++
+		}
+	}
+
+	log.Printf("Seeded %d users, %d follows and %d chirps; every user's password is %q", len(userIDs), follows, chirps, seedPassword)
+	return nil
+}
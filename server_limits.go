@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+)
+
+// exportTimeout is how long CSV exports get to stream every row
+const exportTimeout = 5 * time.Minute
+
+// routeTimeouts are the deadlines of routes that legitimately outlast
+// REQUEST_TIMEOUT, keyed by unversioned mux pattern. 0 means no deadline,
+// for streams that stay open until the client leaves.
+var routeTimeouts = map[string]time.Duration{
+	"GET /api/chirps/stream":       0,
+	"GET /api/stream":              0,
+	"GET /admin/export/chirps.csv": exportTimeout,
+	"GET /admin/export/users.csv":  exportTimeout,
+}
+
+// streamingRoute reports whether a request for method and urlPath reaches
+// one of the streams in routeTimeouts
+func streamingRoute(method, urlPath string) bool {
+	// The mux serves HEAD from GET routes
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	timeout, ok := routeTimeouts[method+" "+unversionedRoute(path.Clean(urlPath))]
+	return ok && timeout == 0
+}
+
+// routeBodyLimits are the routes whose handlers bound their own bodies,
+// keyed by unversioned mux pattern, because they take more than
+// MAX_BODY_BYTES
+var routeBodyLimits = map[string]bool{
+	"POST /api/users/me/avatar": true,
+}
+
+// applyServerLimits sets the connection limits on server, so slow clients
+// can't tie it up
+func applyServerLimits(server *http.Server, limits config.Server) {
+	server.ReadHeaderTimeout = limits.ReadHeaderTimeout
+	server.ReadTimeout = limits.ReadTimeout
+	server.WriteTimeout = limits.WriteTimeout
+	server.IdleTimeout = limits.IdleTimeout
+	server.MaxHeaderBytes = limits.MaxHeaderBytes
+}
+
+// middlewareTimeout puts a deadline on every request's context, so
+// database calls and outgoing requests are abandoned once the client can
+// no longer get an answer, and an error caused by the deadline is reported
+// as a 504. Routes in routeTimeouts get their own deadline, and
+// their write deadline is moved to match.
+func middlewareTimeout(mux *http.ServeMux, requestTimeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := requestTimeout
+		_, route := mux.Handler(r)
+		if routeTimeout, ok := routeTimeouts[unversionedRoute(route)]; ok {
+			timeout = routeTimeout
+			var writeDeadline time.Time
+			if timeout > 0 {
+				writeDeadline = time.Now().Add(timeout)
+			}
+			// Not every writer supports deadlines, and then there's none to move
+			http.NewResponseController(w).SetWriteDeadline(writeDeadline)
+		}
+
+		if timeout == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(&timeoutWriter{ResponseWriter: w, ctx: ctx}, r.WithContext(ctx))
+	})
+}
+
+// timeoutWriter replaces an error written after the request's deadline
+// passed with a 504. Handlers report a failed lookup as whatever fits it,
+// like a 404 for a missing chirp, but past the deadline the cause was the
+// database or an upstream being too slow.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+	// timedOut is set once the 504 has been sent in place of the handler's
+	// response, whose body is then dropped
+	timedOut bool
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	if status >= 400 && errors.Is(tw.ctx.Err(), context.DeadlineExceeded) {
+		tw.timedOut = true
+		respondWithError(tw.ResponseWriter, 504, "Request timed out")
+		return
+	}
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(200)
+	}
+	if tw.timedOut {
+		return len(b), nil
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// middlewareMaxBodySize stops reading request bodies after maxBytes, so a
+// huge upload fails fast instead of being buffered by a JSON decoder
+func middlewareMaxBodySize(mux *http.ServeMux, maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		if !routeBodyLimits[unversionedRoute(route)] {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
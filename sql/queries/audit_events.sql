@@ -0,0 +1,14 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, action, user_id, actor_id, ip_address, user_agent, details)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5, $6);
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+    AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,12 @@
+-- name: GetBannedWords :many
+SELECT word FROM banned_words
+ORDER BY word ASC;
+
+-- name: AddBannedWord :execrows
+INSERT INTO banned_words (word, created_at)
+VALUES ($1, NOW())
+ON CONFLICT (word) DO NOTHING;
+
+-- name: DeleteBannedWord :execrows
+DELETE FROM banned_words
+WHERE word = $1;
@@ -0,0 +1,21 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: DeleteBlock :execrows
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlockedUsers :many
+SELECT users.* FROM users
+JOIN blocks ON blocks.blocked_id = users.id
+WHERE blocks.blocker_id = $1
+ORDER BY blocks.created_at DESC;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_id))
+        OR (blocker_id = sqlc.arg(other_id) AND blocked_id = sqlc.arg(user_id))
+);
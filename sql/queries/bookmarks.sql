@@ -0,0 +1,21 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.arg(user_id))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,18 @@
+-- name: CreateChirpHashtags :exec
+INSERT INTO chirp_hashtags (chirp_id, tag)
+SELECT sqlc.arg(chirp_id)::uuid, unnest(sqlc.arg(tags)::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+WHERE chirp_hashtags.tag = $1
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+ORDER BY chirps.created_at DESC
+LIMIT $2;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = $1;
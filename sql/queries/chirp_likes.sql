@@ -0,0 +1,22 @@
+-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteChirpLike :execrows
+DELETE FROM chirp_likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetChirpLikesForUser :many
+SELECT * FROM chirp_likes
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ReleaseChirpLikesByUser :many
+-- Takes the user's likes off the like counts of the chirps they liked, for
+-- when the likes are about to be deleted with the user, returning the
+-- chirps that changed
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id IN (SELECT chirp_id FROM chirp_likes WHERE chirp_likes.user_id = $1)
+RETURNING id;
@@ -0,0 +1,38 @@
+-- name: CreateChirpReport :one
+-- Returns no rows when the reporter already has an open report against the
+-- chirp
+INSERT INTO chirp_reports (id, created_at, updated_at, reporter_id, chirp_id, reason, details)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+ON CONFLICT (reporter_id, chirp_id) WHERE status = 'open' DO NOTHING
+RETURNING *;
+
+-- name: GetChirpReport :one
+SELECT * FROM chirp_reports
+WHERE id = $1;
+
+-- name: GetChirpReportsForChirp :many
+SELECT * FROM chirp_reports
+WHERE chirp_id = $1
+ORDER BY created_at, id;
+
+-- name: ListChirpReports :many
+-- Oldest first, so the queue is worked through in the order reports came in
+SELECT * FROM chirp_reports
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('reason')::text IS NULL OR reason = sqlc.narg('reason'))
+    AND (sqlc.narg('after_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ResolveChirpReports :many
+-- Records one decision on every open report against the chirp
+UPDATE chirp_reports
+SET status = 'resolved',
+    decision = sqlc.arg(decision)::text,
+    decision_note = sqlc.arg(decision_note),
+    decided_by = sqlc.narg(decided_by),
+    decided_at = NOW(),
+    updated_at = NOW()
+WHERE chirp_id = sqlc.arg(chirp_id) AND status = 'open'
+RETURNING *;
@@ -0,0 +1,17 @@
+-- name: GetChirpTranslation :one
+SELECT * FROM chirp_translations
+WHERE chirp_id = $1 AND language = $2;
+
+-- name: UpsertChirpTranslation :one
+INSERT INTO chirp_translations (chirp_id, language, created_at, body, source_language, provider)
+VALUES ($1, $2, NOW(), $3, $4, $5)
+ON CONFLICT (chirp_id, language) DO UPDATE
+SET body = EXCLUDED.body,
+    source_language = EXCLUDED.source_language,
+    provider = EXCLUDED.provider,
+    created_at = EXCLUDED.created_at
+RETURNING *;
+
+-- name: DeleteChirpTranslations :exec
+DELETE FROM chirp_translations
+WHERE chirp_id = $1;
@@ -1,27 +1,238 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, publish_at, published_at, latitude, longitude, geohash, place, parent_chirp_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
 SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
 ORDER BY created_at ASC;
 
 -- name: GetChirpsByAuthor :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC, id ASC;
+
+-- name: GetChirpsForExport :many
+-- Everything the user hasn't deleted, including chirps still pending
+SELECT * FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC;
+
+-- name: GetAllChirpsDesc :many
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC;
+
+-- name: GetChirpsByAuthorDesc :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetChirpsPage :many
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('after_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsPageDesc :many
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
+WHERE chirps.id = $1 AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self);
+
+-- name: IsChirpAuthorHidden :one
+-- Whether the author's chirps are kept from the viewer, for reads of a
+-- single chirp, whose cached copy is the same for every viewer
+SELECT EXISTS (
+    SELECT 1 FROM hidden_authors
+    WHERE id = sqlc.arg(author_id)::uuid
+        AND (hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+);
+
+-- name: GetChirpForModeration :one
+-- Finds the chirp whatever its state, for moderators
+SELECT * FROM chirps
 WHERE id = $1;
 
--- name: DeleteChirp :exec
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteChirp :one
+-- Returns the chirp as deleted, so whether it was published is read under
+-- the same row lock a concurrent PublishDueChirps takes
+UPDATE chirps
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
 DELETE FROM chirps
-WHERE id = $1;
+WHERE deleted_at IS NOT NULL
+    AND (sqlc.narg('deleted_before')::timestamp IS NULL OR deleted_at < sqlc.narg('deleted_before'));
+
+-- name: ExportChirps :many
+-- One page of the export, after the last row of the page before
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors)
+    AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('after_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: PublishDueChirps :many
+-- Replies only count towards their parent once they're published
+WITH published AS (
+    UPDATE chirps
+    SET published_at = NOW()
+    WHERE published_at IS NULL AND deleted_at IS NULL AND publish_at <= NOW()
+    RETURNING *
+), counted AS (
+    UPDATE chirps
+    SET reply_count = chirps.reply_count + replies.count
+    FROM (
+        SELECT parent_chirp_id, COUNT(*)::integer AS count
+        FROM published
+        WHERE parent_chirp_id IS NOT NULL
+        GROUP BY parent_chirp_id
+    ) AS replies
+    WHERE chirps.id = replies.parent_chirp_id
+)
+SELECT * FROM published;
+
+-- name: GetChirpsInCells :many
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+    AND geohash LIKE ANY(sqlc.arg(prefixes)::text[])
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: GetChirpsByIDs :many
+-- The listed chirps that are still visible to the viewer, in no particular
+-- order
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+    AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid);
+
+-- name: AdjustChirpLikeCount :one
+UPDATE chirps
+SET like_count = like_count + sqlc.arg(delta)::integer
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: AdjustChirpReplyCount :exec
+UPDATE chirps
+SET reply_count = GREATEST(reply_count + sqlc.arg(delta)::integer, 0)
+WHERE id = sqlc.arg(id);
+
+-- name: GetChirpReplies :many
+SELECT * FROM chirps
+WHERE parent_chirp_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+ORDER BY created_at ASC;
+
+-- name: GetChirpsPublishedAfter :many
+-- Publication order rather than creation order, since held-back chirps are
+-- published after newer ones
+SELECT * FROM chirps
+WHERE published_at IS NOT NULL AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors)
+    AND (published_at, id) > (sqlc.arg(published_at)::timestamp, sqlc.arg(id)::uuid)
+ORDER BY published_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchChirps :many
+SELECT * FROM chirps
+WHERE search_vector @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND published_at IS NOT NULL
+    AND deleted_at IS NULL
+    AND user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: AddChirpViews :exec
+-- Applies a batch of buffered view counts in one statement
+UPDATE chirps
+SET view_count = chirps.view_count + views.count
+FROM (
+    SELECT unnest(sqlc.arg(ids)::uuid[]) AS id, unnest(sqlc.arg(counts)::bigint[]) AS count
+) AS views
+WHERE chirps.id = views.id;
+
+-- name: GetChirpTotalsForUser :one
+-- Engagement across all of a user's published chirps
+SELECT
+    COUNT(*) AS chirp_count,
+    COALESCE(SUM(view_count), 0)::bigint AS view_count,
+    COALESCE(SUM(like_count), 0)::bigint AS like_count,
+    COALESCE(SUM(reply_count), 0)::bigint AS reply_count
+FROM chirps
+WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL;
+
+-- name: ReleaseChirpRepliesByUser :many
+-- Takes the user's published replies off the reply counts of other users'
+-- chirps, for when the replies are about to be deleted with the user,
+-- returning the chirps that changed
+UPDATE chirps
+SET reply_count = reply_count - (
+    SELECT COUNT(*) FROM chirps AS replies
+    WHERE replies.parent_chirp_id = chirps.id AND replies.user_id = $1
+        AND replies.published_at IS NOT NULL AND replies.deleted_at IS NULL
+)
+WHERE user_id <> $1 AND id IN (
+    SELECT parent_chirp_id FROM chirps
+    WHERE user_id = $1 AND published_at IS NOT NULL AND deleted_at IS NULL
+)
+RETURNING id;
+
+-- name: GetChirpIDsByAuthor :many
+SELECT id FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL;
+
+-- name: DeleteChirpsForUser :many
+-- Deletes every chirp the user has written, including soft-deleted ones,
+-- returning their IDs
+DELETE FROM chirps
+WHERE user_id = $1
+RETURNING id;
@@ -0,0 +1,47 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1)
+RETURNING *;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports
+WHERE id = $1;
+
+-- name: GetCurrentDataExportForUser :one
+-- The newest export that's under way or ready to download
+SELECT * FROM data_exports
+WHERE user_id = $1
+    AND status <> 'failed'
+    AND (expires_at IS NULL OR expires_at > NOW())
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ClaimDataExports :many
+-- Marks pending exports as building, along with any left building since
+-- stale_before by a worker that didn't finish. The outer check skips any
+-- that another worker claimed first.
+UPDATE data_exports
+SET status = 'building', updated_at = NOW()
+WHERE (status = 'pending' OR (status = 'building' AND updated_at < sqlc.arg(stale_before)::timestamp))
+    AND id IN (
+        SELECT id FROM data_exports
+        WHERE status = 'pending'
+            OR (status = 'building' AND updated_at < sqlc.arg(stale_before)::timestamp)
+        ORDER BY created_at
+        LIMIT sqlc.arg(max_exports)
+    )
+RETURNING *;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'ready', archive = $2, error = NULL, completed_at = NOW(), expires_at = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports
+SET status = 'failed', error = $2, completed_at = NOW(), expires_at = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteExpiredDataExports :execrows
+DELETE FROM data_exports
+WHERE expires_at < NOW();
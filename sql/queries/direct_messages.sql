@@ -0,0 +1,41 @@
+-- name: UpsertConversation :one
+-- Sending a message bumps updated_at so inboxes sort by latest activity
+INSERT INTO conversations (id, created_at, updated_at, user_a_id, user_b_id)
+VALUES (
+    gen_random_uuid(), NOW(), NOW(),
+    LEAST(sqlc.arg(sender_id)::uuid, sqlc.arg(recipient_id)::uuid),
+    GREATEST(sqlc.arg(sender_id)::uuid, sqlc.arg(recipient_id)::uuid)
+)
+ON CONFLICT (user_a_id, user_b_id) DO UPDATE SET updated_at = NOW()
+RETURNING *;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3)
+RETURNING *;
+
+-- name: GetConversationForUser :one
+SELECT * FROM conversations
+WHERE id = sqlc.arg(id)
+    AND (user_a_id = sqlc.arg(user_id) OR user_b_id = sqlc.arg(user_id));
+
+-- name: GetConversationsForUser :many
+SELECT sqlc.embed(conversations), sqlc.embed(users)
+FROM conversations
+JOIN users ON users.id = CASE
+    WHEN conversations.user_a_id = sqlc.arg(user_id) THEN conversations.user_b_id
+    ELSE conversations.user_a_id
+END
+WHERE (conversations.user_a_id = sqlc.arg(user_id) OR conversations.user_b_id = sqlc.arg(user_id))
+    AND (sqlc.narg('before_updated_at')::timestamp IS NULL
+        OR (conversations.updated_at, conversations.id) < (sqlc.narg('before_updated_at'), sqlc.narg('before_id')::uuid))
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
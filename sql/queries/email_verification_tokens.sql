@@ -0,0 +1,13 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at)
+VALUES ($1, $2, $3, NOW(), $4);
+
+-- name: ConsumeEmailVerificationToken :one
+-- Tokens are single use
+DELETE FROM email_verification_tokens
+WHERE token_hash = $1
+RETURNING *;
+
+-- name: DeleteEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens
+WHERE user_id = $1;
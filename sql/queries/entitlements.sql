@@ -0,0 +1,30 @@
+-- name: GetPlanFeatures :many
+SELECT feature FROM plan_features
+WHERE plan_id = $1;
+
+-- name: GetFeatureFlags :many
+SELECT * FROM feature_flags
+ORDER BY feature ASC;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (feature, created_at, updated_at, enabled)
+VALUES ($1, NOW(), NOW(), $2)
+ON CONFLICT (feature) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = NOW()
+RETURNING *;
+
+-- name: GetEntitlementOverrides :many
+SELECT * FROM entitlement_overrides
+WHERE user_id = $1
+ORDER BY feature ASC;
+
+-- name: UpsertEntitlementOverride :one
+INSERT INTO entitlement_overrides (user_id, feature, created_at, updated_at, enabled)
+VALUES ($1, $2, NOW(), NOW(), $3)
+ON CONFLICT (user_id, feature) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteEntitlementOverride :execrows
+DELETE FROM entitlement_overrides
+WHERE user_id = $1 AND feature = $2;
@@ -0,0 +1,11 @@
+-- name: CreateExperimentEvent :exec
+INSERT INTO experiment_events (id, created_at, experiment_key, variant, user_id, kind, name)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5);
+
+-- name: GetExperimentResults :many
+-- Event and distinct user counts per variant, kind and conversion name
+SELECT variant, kind, name, COUNT(*) AS events, COUNT(DISTINCT user_id) AS users
+FROM experiment_events
+WHERE experiment_key = $1
+GROUP BY variant, kind, name
+ORDER BY variant, kind, name;
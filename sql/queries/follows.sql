@@ -0,0 +1,37 @@
+-- name: CreateFollow :execrows
+-- Returns 0 when already following
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT users.* FROM users
+JOIN follows ON follows.follower_id = users.id
+WHERE follows.followee_id = $1 AND users.deactivated_at IS NULL
+ORDER BY follows.created_at DESC;
+
+-- name: GetFollowing :many
+SELECT users.* FROM users
+JOIN follows ON follows.followee_id = users.id
+WHERE follows.follower_id = $1 AND users.deactivated_at IS NULL
+ORDER BY follows.created_at DESC;
+
+-- name: GetTimeline :many
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = sqlc.arg(follower_id)
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.arg(follower_id))
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = follows.follower_id AND mutes.muted_id = chirps.user_id
+    )
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,56 @@
+-- name: GetNetworkChirps :many
+-- The most liked recent chirps by accounts the ones the viewer follows
+-- follow, leaving out replies, accounts the viewer already follows and any
+-- they've muted or blocked or been blocked by
+SELECT chirps.* FROM chirps
+WHERE chirps.user_id IN (
+        SELECT second.followee_id FROM follows AS first
+        JOIN follows AS second ON second.follower_id = first.followee_id
+        WHERE first.follower_id = sqlc.arg(viewer_id)
+    )
+    AND chirps.user_id <> sqlc.arg(viewer_id)
+    AND chirps.user_id NOT IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(viewer_id))
+    AND chirps.parent_chirp_id IS NULL
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.created_at > sqlc.arg(since)::timestamp
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors)
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(viewer_id) AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+            OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(viewer_id))
+    )
+ORDER BY chirps.like_count DESC, chirps.created_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowedHashtagChirps :many
+-- The most liked recent chirps tagged with hashtags the viewer follows,
+-- leaving out replies, the viewer's own chirps and any by accounts they've
+-- muted or blocked or been blocked by
+SELECT chirps.* FROM chirps
+WHERE chirps.id IN (
+        SELECT chirp_hashtags.chirp_id FROM chirp_hashtags
+        JOIN hashtag_follows ON hashtag_follows.tag = chirp_hashtags.tag
+        WHERE hashtag_follows.user_id = sqlc.arg(viewer_id)
+    )
+    AND chirps.user_id <> sqlc.arg(viewer_id)
+    AND chirps.parent_chirp_id IS NULL
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.created_at > sqlc.arg(since)::timestamp
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors)
+    AND NOT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = sqlc.arg(viewer_id) AND mutes.muted_id = chirps.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM blocks
+        WHERE (blocks.blocker_id = sqlc.arg(viewer_id) AND blocks.blocked_id = chirps.user_id)
+            OR (blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.arg(viewer_id))
+    )
+ORDER BY chirps.like_count DESC, chirps.created_at DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,14 @@
+-- name: CreateHashtagFollow :execrows
+-- Returns 0 when already following
+INSERT INTO hashtag_follows (user_id, tag, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id, tag) DO NOTHING;
+
+-- name: DeleteHashtagFollow :execrows
+DELETE FROM hashtag_follows
+WHERE user_id = $1 AND tag = $2;
+
+-- name: GetFollowedHashtags :many
+SELECT tag FROM hashtag_follows
+WHERE user_id = $1
+ORDER BY created_at DESC, tag;
@@ -0,0 +1,11 @@
+-- name: GetHitCounters :many
+SELECT name, count FROM hit_counters;
+
+-- name: AddHitCount :exec
+INSERT INTO hit_counters (name, count, updated_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (name) DO UPDATE
+SET count = hit_counters.count + EXCLUDED.count, updated_at = NOW();
+
+-- name: ResetHitCounters :exec
+DELETE FROM hit_counters;
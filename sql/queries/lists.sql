@@ -0,0 +1,50 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, description, is_private)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetListByID :one
+SELECT * FROM lists
+WHERE id = $1;
+
+-- name: GetListsByOwner :many
+SELECT * FROM lists
+WHERE owner_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $2, description = $3, is_private = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: AddListMember :exec
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (list_id, user_id) DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: GetListMembers :many
+SELECT users.* FROM users
+JOIN list_members ON list_members.user_id = users.id
+WHERE list_members.list_id = $1
+ORDER BY list_members.created_at ASC;
+
+-- name: GetListTimeline :many
+SELECT chirps.* FROM chirps
+JOIN list_members ON list_members.user_id = chirps.user_id
+WHERE list_members.list_id = sqlc.arg(list_id)
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM sqlc.narg('viewer_id')::uuid)
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('limit');
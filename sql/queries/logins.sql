@@ -0,0 +1,23 @@
+-- name: CreateLogin :one
+INSERT INTO logins (id, created_at, user_id, ip_address, network, user_agent)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetLoginDeviceHistory :one
+-- Whether the user has signed in before at all, and from this device on
+-- this network
+SELECT EXISTS (SELECT 1 FROM logins WHERE logins.user_id = sqlc.arg('user_id')) AS has_logins,
+    EXISTS (
+        SELECT 1 FROM logins
+        WHERE logins.user_id = sqlc.arg('user_id')
+            AND logins.user_agent = sqlc.arg('user_agent')
+            AND logins.network = sqlc.arg('network')
+    ) AS seen_device;
+
+-- name: ListLogins :many
+SELECT * FROM logins
+WHERE user_id = sqlc.arg('user_id')
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
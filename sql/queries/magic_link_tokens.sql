@@ -0,0 +1,19 @@
+-- name: CreateMagicLinkToken :exec
+INSERT INTO magic_link_tokens (token_hash, email, created_at, expires_at)
+VALUES ($1, $2, NOW(), $3);
+
+-- name: MagicLinkSentSince :one
+SELECT EXISTS (
+    SELECT 1 FROM magic_link_tokens
+    WHERE email = $1 AND created_at > $2
+);
+
+-- name: ConsumeMagicLinkToken :one
+-- Tokens are single use
+DELETE FROM magic_link_tokens
+WHERE token_hash = $1
+RETURNING *;
+
+-- name: DeleteExpiredMagicLinkTokens :execrows
+DELETE FROM magic_link_tokens
+WHERE expires_at < NOW();
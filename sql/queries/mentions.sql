@@ -0,0 +1,21 @@
+-- name: CreateMentions :exec
+-- Handles that don't belong to anyone are ignored
+INSERT INTO mentions (chirp_id, user_id)
+SELECT sqlc.arg(chirp_id)::uuid, users.id
+FROM users
+WHERE users.handle = ANY(sqlc.arg(handles)::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetMentionsForUser :many
+SELECT chirps.* FROM chirps
+JOIN mentions ON mentions.chirp_id = chirps.id
+WHERE mentions.user_id = $1
+    AND chirps.published_at IS NOT NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM mentions.user_id)
+ORDER BY chirps.created_at DESC
+LIMIT $2;
+
+-- name: DeleteMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
@@ -0,0 +1,13 @@
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, action, chirp_id, user_id, reason)
+VALUES (gen_random_uuid(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+    AND (sqlc.narg('moderator_id')::uuid IS NULL OR moderator_id = sqlc.narg('moderator_id'))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,14 @@
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: DeleteMute :execrows
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT users.* FROM users
+JOIN mutes ON mutes.muted_id = users.id
+WHERE mutes.muter_id = $1
+ORDER BY mutes.created_at DESC;
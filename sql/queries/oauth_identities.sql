@@ -0,0 +1,9 @@
+-- name: GetOAuthIdentityUser :one
+SELECT users.* FROM users
+JOIN oauth_identities ON oauth_identities.user_id = users.id
+WHERE oauth_identities.provider = $1 AND oauth_identities.subject = $2;
+
+-- name: CreateOAuthIdentity :exec
+INSERT INTO oauth_identities (provider, subject, user_id, created_at)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (provider, subject) DO NOTHING;
@@ -0,0 +1,12 @@
+-- name: GetPlans :many
+SELECT * FROM plans
+ORDER BY max_chirp_length ASC;
+
+-- name: GetPlanByID :one
+SELECT * FROM plans
+WHERE id = $1;
+
+-- name: GetPlanForUser :one
+SELECT plans.* FROM plans
+INNER JOIN users ON users.plan = plans.id
+WHERE users.id = $1;
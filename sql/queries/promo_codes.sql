@@ -0,0 +1,22 @@
+-- name: CreatePromoCode :one
+INSERT INTO promo_codes (code, created_at, updated_at, plan, duration_days, max_redemptions, expires_at)
+VALUES ($1, NOW(), NOW(), $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetPromoCodes :many
+SELECT * FROM promo_codes
+ORDER BY created_at DESC;
+
+-- name: ClaimPromoCode :one
+UPDATE promo_codes
+SET redemption_count = redemption_count + 1, updated_at = NOW()
+WHERE code = $1
+    AND redemption_count < max_redemptions
+    AND (expires_at IS NULL OR expires_at > NOW())
+RETURNING *;
+
+-- name: CreatePromoRedemption :one
+INSERT INTO promo_redemptions (code, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (code, user_id) DO NOTHING
+RETURNING *;
@@ -1,17 +1,52 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
-VALUES ($1, NOW(), NOW(), $2, $3, NULL)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at, user_agent, ip_address)
+VALUES ($1, NOW(), NOW(), $2, $3, NULL, $4, $5)
 RETURNING *;
 
 -- name: GetUserFromRefreshToken :one
-SELECT users.* FROM users
+-- signed_in_at is when the session began, which is when the token was made
+SELECT sqlc.embed(users), refresh_tokens.created_at AS signed_in_at FROM users
 INNER JOIN refresh_tokens ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = $1
     AND refresh_tokens.revoked_at IS NULL
     AND refresh_tokens.expires_at > NOW();
 
--- name: RevokeRefreshToken :exec
+-- name: RevokeRefreshToken :one
+-- Returns no rows when the token is unknown or already revoked
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND revoked_at IS NULL
+RETURNING user_id;
+
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenForUser :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE token = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: TouchRefreshToken :exec
+UPDATE refresh_tokens
+SET last_used_at = NOW()
 WHERE token = $1;
 
+-- name: GetActiveSessionsForUser :many
+SELECT * FROM refresh_tokens
+WHERE user_id = $1
+    AND revoked_at IS NULL
+    AND expires_at > NOW()
+ORDER BY COALESCE(last_used_at, created_at) DESC;
+
+-- name: RevokeSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: DeleteStaleRefreshTokens :execrows
+-- Tokens that can no longer be used, whether expired or revoked
+DELETE FROM refresh_tokens
+WHERE expires_at < NOW() OR revoked_at IS NOT NULL;
@@ -0,0 +1,19 @@
+-- name: RevokeAccessToken :exec
+INSERT INTO revoked_access_tokens (jti, user_id, revoked_at, expires_at)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: GetAccessTokenStatus :one
+-- What's needed to tell whether a token for the user is still good
+SELECT
+    users.token_version,
+    EXISTS (
+        SELECT 1 FROM revoked_access_tokens
+        WHERE revoked_access_tokens.jti = sqlc.arg(jti)::text
+    ) AS revoked
+FROM users
+WHERE users.id = sqlc.arg(user_id);
+
+-- name: DeleteExpiredRevokedAccessTokens :execrows
+DELETE FROM revoked_access_tokens
+WHERE expires_at < NOW();
@@ -0,0 +1,10 @@
+-- name: LinkStripeCustomer :execrows
+INSERT INTO stripe_customers (user_id, created_at, customer_id)
+SELECT id, NOW(), sqlc.arg(customer_id) FROM users
+WHERE id = sqlc.arg(user_id)
+ON CONFLICT (user_id) DO UPDATE
+SET customer_id = EXCLUDED.customer_id;
+
+-- name: GetStripeCustomerID :one
+SELECT customer_id FROM stripe_customers
+WHERE user_id = $1;
@@ -0,0 +1,27 @@
+-- name: CreateSubscriptionEvent :one
+INSERT INTO subscription_events (id, created_at, user_id, kind, source, webhook_event_id, actor_user_id, promo_code)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetSubscriptionEventsForUser :many
+SELECT
+    subscription_events.id,
+    subscription_events.created_at,
+    subscription_events.kind,
+    subscription_events.source,
+    subscription_events.actor_user_id,
+    subscription_events.promo_code,
+    webhook_events.event_type AS webhook_event_type
+FROM subscription_events
+LEFT JOIN webhook_events ON webhook_events.id = subscription_events.webhook_event_id
+WHERE subscription_events.user_id = $1
+ORDER BY subscription_events.created_at DESC;
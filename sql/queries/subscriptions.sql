@@ -0,0 +1,35 @@
+-- name: GetSubscriptionByUserID :one
+SELECT * FROM subscriptions
+WHERE user_id = $1;
+
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions (user_id, created_at, updated_at, plan, status, expires_at, gift_available)
+VALUES ($1, NOW(), NOW(), $2, 'active', $3, $4)
+ON CONFLICT (user_id) DO UPDATE
+SET plan = EXCLUDED.plan, status = 'active', expires_at = EXCLUDED.expires_at, gift_available = EXCLUDED.gift_available, updated_at = NOW()
+RETURNING *;
+
+-- name: ClaimSubscriptionGift :execrows
+UPDATE subscriptions
+SET gift_available = FALSE, updated_at = NOW()
+WHERE user_id = $1 AND gift_available AND status = 'active' AND expires_at > NOW();
+
+-- name: CancelSubscription :exec
+UPDATE subscriptions
+SET status = 'canceled', expires_at = NOW(), updated_at = NOW()
+WHERE user_id = $1 AND status = 'active';
+
+-- name: GetLapsedSubscriptions :many
+SELECT user_id FROM subscriptions
+WHERE status = 'active' AND expires_at <= NOW()
+ORDER BY expires_at;
+
+-- name: LockSubscription :one
+SELECT * FROM subscriptions
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: ExpireSubscription :exec
+UPDATE subscriptions
+SET status = 'expired', gift_available = FALSE, updated_at = NOW()
+WHERE user_id = $1;
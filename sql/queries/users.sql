@@ -1,28 +1,232 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+-- Returns no rows when the email or handle is already taken
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
+ON CONFLICT DO NOTHING
 RETURNING *;
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
 
+-- name: DeleteUser :execrows
+-- Everything else the user owns goes with them through ON DELETE CASCADE
+DELETE FROM users
+WHERE id = $1;
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1;
 
 -- name: UpdateUser :one
+-- Changing the email address clears its verification, and the new password
+-- satisfies any forced reset. Returns no rows when another user already has
+-- the address.
+UPDATE users
+SET email = $1,
+    hashed_password = $2,
+    email_verified = email_verified AND email = $1,
+    password_reset_required = FALSE,
+    updated_at = NOW()
+WHERE users.id = $3
+    AND NOT EXISTS (SELECT 1 FROM users AS other WHERE other.email = $1 AND other.id <> $3)
+RETURNING *;
+
+-- name: SetUserPlan :execrows
+UPDATE users
+SET plan = sqlc.arg(plan), is_chirpy_red = (sqlc.arg(plan)::text <> 'free'), updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: ExportUsers :many
+-- One page of the export, after the last row of the page before
+SELECT id, created_at, updated_at, is_chirpy_red, plan FROM users
+WHERE (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('after_created_at')::timestamp IS NULL
+        OR (created_at, id) > (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetShareLocation :one
+UPDATE users
+SET share_location = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetRecommendations :one
+UPDATE users
+SET recommendations = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserHandle :one
+-- Returns no rows when another user already holds the handle
+UPDATE users
+SET handle = sqlc.arg(handle), updated_at = NOW()
+WHERE users.id = sqlc.arg(id)
+    AND NOT EXISTS (SELECT 1 FROM users AS other WHERE other.handle = sqlc.arg(handle) AND other.id <> sqlc.arg(id))
+RETURNING *;
+
+-- name: GetUserByHandle :one
+-- Deactivated accounts have no public profile
+SELECT * FROM users
+WHERE handle = $1 AND deactivated_at IS NULL;
+
+-- name: UpdateUserProfile :one
+-- Fields left NULL keep their current value
+UPDATE users
+SET display_name = COALESCE(sqlc.narg(display_name), display_name),
+    bio = COALESCE(sqlc.narg(bio), bio),
+    location = COALESCE(sqlc.narg(location), location),
+    website = COALESCE(sqlc.narg(website), website),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetUserAvatar :one
+UPDATE users
+SET avatar_key = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkEmailVerified :one
+-- Matches on email so a token for a previous address has no effect
+UPDATE users
+SET email_verified = TRUE, updated_at = NOW()
+WHERE id = $1 AND email = $2
+RETURNING *;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :one
+-- A real change bumps the token version too, so access tokens carrying the
+-- old role stop working; refreshing picks up the new one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
-WHERE id = $3
+SET role = $2,
+    token_version = token_version + CASE WHEN role = $2 THEN 0 ELSE 1 END,
+    updated_at = NOW()
+WHERE id = $1
 RETURNING *;
 
--- name: UpgradeUserToChirpyRed :exec
+-- name: IncrementUserTokenVersion :exec
 UPDATE users
-SET is_chirpy_red = TRUE, updated_at = NOW()
+SET token_version = token_version + 1, updated_at = NOW()
 WHERE id = $1;
+
+-- name: RecordFailedLogin :one
+-- Starts a new count when the current window began before window_start
+UPDATE users
+SET failed_login_count = CASE
+        WHEN failed_login_window_start IS NULL OR failed_login_window_start < sqlc.arg(window_start)::timestamp THEN 1
+        ELSE failed_login_count + 1
+    END,
+    failed_login_window_start = CASE
+        WHEN failed_login_window_start IS NULL OR failed_login_window_start < sqlc.arg(window_start)::timestamp THEN NOW()
+        ELSE failed_login_window_start
+    END
+WHERE id = sqlc.arg(id)
+RETURNING failed_login_count;
+
+-- name: LockUser :exec
+UPDATE users
+SET locked_until = $2, failed_login_count = 0, failed_login_window_start = NULL
+WHERE id = $1;
+
+-- name: ResetFailedLogins :exec
+UPDATE users
+SET failed_login_count = 0, failed_login_window_start = NULL
+WHERE id = $1 AND failed_login_count <> 0;
+
+-- name: UnlockUser :one
+UPDATE users
+SET locked_until = NULL, failed_login_count = 0, failed_login_window_start = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeactivateUser :one
+-- Bumps the token version too, so outstanding access tokens stop working
+UPDATE users
+SET deactivated_at = NOW(), token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ReactivateUser :one
+-- Returns no rows unless the user was deactivated after deactivated_after
+UPDATE users
+SET deactivated_at = NULL, updated_at = NOW()
+WHERE id = sqlc.arg(id) AND deactivated_at > sqlc.arg(deactivated_after)
+RETURNING *;
+
+-- name: GetUsersDeactivatedBefore :many
+SELECT * FROM users
+WHERE deactivated_at < sqlc.arg(deactivated_before)
+ORDER BY deactivated_at
+LIMIT sqlc.arg(max_users);
+
+-- name: ListUsers :many
+-- query matches part of the email address or handle. A suspension that has
+-- run out doesn't count as suspended.
+SELECT * FROM users
+WHERE (sqlc.narg('query')::text IS NULL
+        OR email LIKE '%' || sqlc.narg('query') || '%'
+        OR handle LIKE '%' || sqlc.narg('query') || '%')
+    AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role'))
+    AND (sqlc.narg('plan')::text IS NULL OR plan = sqlc.narg('plan'))
+    AND (sqlc.narg('suspended')::boolean IS NULL
+        OR (suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > NOW())) = sqlc.narg('suspended'))
+    AND (sqlc.narg('deactivated')::boolean IS NULL OR (deactivated_at IS NOT NULL) = sqlc.narg('deactivated'))
+    AND (sqlc.narg('shadow_banned')::boolean IS NULL OR shadow_banned = sqlc.narg('shadow_banned'))
+    AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+    AND (sqlc.narg('before_created_at')::timestamp IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at'), sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SuspendUser :one
+-- Bumps the token version too, so outstanding access tokens stop working
+UPDATE users
+SET suspended_at = NOW(),
+    suspended_until = sqlc.narg(suspended_until),
+    suspension_reason = sqlc.arg(suspension_reason),
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_at = NULL, suspended_until = NULL, suspension_reason = '', updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserShadowBanned :one
+UPDATE users
+SET shadow_banned = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RequirePasswordReset :one
+-- Bumps the token version too, so outstanding access tokens stop working
+UPDATE users
+SET password_reset_required = TRUE, token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,37 @@
+-- name: CreateWebAuthnChallenge :exec
+INSERT INTO webauthn_challenges (challenge, ceremony, user_id, expires_at, session_data)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeWebAuthnChallenge :one
+-- Challenges are single use
+DELETE FROM webauthn_challenges
+WHERE challenge = $1
+RETURNING *;
+
+-- name: DeleteExpiredWebAuthnChallenges :execrows
+DELETE FROM webauthn_challenges
+WHERE expires_at < NOW();
+
+-- name: CreateWebAuthnCredential :one
+INSERT INTO webauthn_credentials (id, user_id, public_key, sign_count, name, backup_eligible, backup_state, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebAuthnCredential :one
+SELECT * FROM webauthn_credentials
+WHERE id = $1;
+
+-- name: GetWebAuthnCredentialsForUser :many
+SELECT * FROM webauthn_credentials
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: UpdateWebAuthnCredentialUsage :exec
+UPDATE webauthn_credentials
+SET sign_count = $2, backup_eligible = $3, backup_state = $4, last_used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteWebAuthnCredential :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2;
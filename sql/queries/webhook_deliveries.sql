@@ -0,0 +1,58 @@
+-- name: CreateWebhookDeliveries :execrows
+-- Queues an event for the active endpoints of user_id that take it, along
+-- with every admin endpoint that takes it
+INSERT INTO webhook_deliveries (id, created_at, updated_at, endpoint_id, event_id, event_type, payload, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), webhook_endpoints.id, sqlc.arg(event_id)::uuid, sqlc.arg(event_type)::text, sqlc.arg(payload)::jsonb, NOW()
+FROM webhook_endpoints
+WHERE active
+    AND (user_id IS NULL OR user_id = sqlc.arg(user_id)::uuid)
+    AND sqlc.arg(event_type)::text = ANY(events);
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases the pending deliveries that are due by moving their next attempt
+-- to lease_until, so another worker won't pick them up in the meantime.
+-- The outer check skips any that one already has.
+UPDATE webhook_deliveries
+SET next_attempt_at = sqlc.arg(lease_until)::timestamp, updated_at = NOW()
+WHERE status = 'pending' AND next_attempt_at <= NOW()
+    AND id IN (
+        SELECT id FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= NOW()
+        ORDER BY next_attempt_at
+        LIMIT sqlc.arg(max_deliveries)
+    )
+RETURNING *;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- Records a failed attempt, with status either pending for another try at
+-- next_attempt_at or dead once it's out of tries
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('endpoint_id')::uuid IS NULL OR endpoint_id = sqlc.narg('endpoint_id'))
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: ReplayWebhookDelivery :one
+-- Queues a delivery to be tried again straight away, with its tries reset
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDeliveredWebhookDeliveriesBefore :execrows
+DELETE FROM webhook_deliveries
+WHERE status = 'delivered' AND delivered_at < sqlc.arg(before)::timestamp;
@@ -0,0 +1,32 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, created_at, updated_at, user_id, url, secret, events, active)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: GetWebhookEndpointsForUser :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = sqlc.arg(user_id)::uuid
+ORDER BY created_at;
+
+-- name: GetAdminWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+WHERE user_id IS NULL
+ORDER BY created_at;
+
+-- name: CountWebhookEndpointsForUser :one
+SELECT COUNT(*) FROM webhook_endpoints
+WHERE user_id = sqlc.arg(user_id)::uuid;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = $2, events = $3, active = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints
+WHERE id = $1;
@@ -0,0 +1,54 @@
+-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (id, created_at, updated_at, source, event_id, event_type, payload)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (source, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEventByID :one
+SELECT * FROM webhook_events
+WHERE id = $1;
+
+-- name: GetWebhookEventBySourceAndEventID :one
+SELECT * FROM webhook_events
+WHERE source = $1 AND event_id = $2;
+
+-- name: ListWebhookEvents :many
+SELECT * FROM webhook_events
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+    AND (sqlc.narg('source')::text IS NULL OR source = sqlc.narg('source'))
+    AND (sqlc.narg('event_type')::text IS NULL OR event_type = sqlc.narg('event_type'))
+    AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from'))
+    AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to'))
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: ClaimWebhookEvent :one
+-- Run first in the transaction that applies the event: the row lock makes a
+-- concurrent delivery wait, and then find the event processed and get no
+-- rows back. Processed events are only claimed again with reprocess.
+UPDATE webhook_events
+SET updated_at = NOW()
+WHERE id = sqlc.arg(id) AND (status <> 'processed' OR sqlc.arg(reprocess)::boolean)
+RETURNING *;
+
+-- name: MarkWebhookEventProcessed :exec
+UPDATE webhook_events
+SET status = 'processed', error = NULL, attempts = attempts + 1, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookEventFailed :exec
+UPDATE webhook_events
+SET status = 'failed', error = $2, attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteProcessedWebhookEventsBefore :execrows
+DELETE FROM webhook_events
+WHERE status = 'processed' AND processed_at < sqlc.arg(before)::timestamp;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE subscription_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    source TEXT NOT NULL,
+    webhook_event_id UUID REFERENCES webhook_events(id) ON DELETE SET NULL
+);
+
+CREATE INDEX subscription_events_user_id_idx ON subscription_events (user_id, created_at);
+
+-- +goose Down
+DROP TABLE subscription_events;
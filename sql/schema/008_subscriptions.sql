@@ -0,0 +1,31 @@
+-- +goose Up
+-- subscriptions is the source of truth for Chirpy Red; users.is_chirpy_red is
+-- kept as a cached flag so user reads don't need a join.
+CREATE TABLE subscriptions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    plan TEXT NOT NULL DEFAULT 'red',
+    status TEXT NOT NULL DEFAULT 'active',
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX subscriptions_active_expires_at_idx ON subscriptions (expires_at) WHERE status = 'active';
+
+INSERT INTO subscriptions (user_id, expires_at)
+SELECT id, NOW() + INTERVAL '30 days' FROM users WHERE is_chirpy_red;
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_idx ON notifications (user_id, created_at);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE subscriptions;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE plans (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    name TEXT NOT NULL,
+    max_chirp_length INTEGER NOT NULL,
+    rate_limit_per_minute INTEGER NOT NULL,
+    edit_window_seconds INTEGER NOT NULL
+);
+
+INSERT INTO plans (id, name, max_chirp_length, rate_limit_per_minute, edit_window_seconds) VALUES
+    ('free', 'Free', 140, 30, 0),
+    ('red', 'Chirpy Red', 280, 60, 300),
+    ('red_plus', 'Chirpy Red+', 1000, 120, 3600);
+
+ALTER TABLE users ADD COLUMN plan TEXT NOT NULL DEFAULT 'free' REFERENCES plans(id);
+UPDATE users SET plan = 'red' WHERE is_chirpy_red;
+
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_plan_fkey FOREIGN KEY (plan) REFERENCES plans(id);
+
+-- +goose Down
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_plan_fkey;
+ALTER TABLE users DROP COLUMN plan;
+DROP TABLE plans;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE promo_codes (
+    code TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    plan TEXT NOT NULL REFERENCES plans(id),
+    duration_days INTEGER NOT NULL,
+    max_redemptions INTEGER NOT NULL DEFAULT 1,
+    redemption_count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP
+);
+
+CREATE TABLE promo_redemptions (
+    code TEXT NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code, user_id)
+);
+
+ALTER TABLE subscription_events ADD COLUMN actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE subscription_events ADD COLUMN promo_code TEXT;
+
+-- +goose Down
+ALTER TABLE subscription_events DROP COLUMN promo_code;
+ALTER TABLE subscription_events DROP COLUMN actor_user_id;
+DROP TABLE promo_redemptions;
+DROP TABLE promo_codes;
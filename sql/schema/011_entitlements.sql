@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE plan_features (
+    plan_id TEXT NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    feature TEXT NOT NULL,
+    PRIMARY KEY (plan_id, feature)
+);
+
+INSERT INTO plan_features (plan_id, feature) VALUES
+    ('red', 'long_chirps'),
+    ('red', 'edit_chirps'),
+    ('red', 'analytics'),
+    ('red', 'gift_red'),
+    ('red_plus', 'long_chirps'),
+    ('red_plus', 'edit_chirps'),
+    ('red_plus', 'analytics'),
+    ('red_plus', 'gift_red');
+
+-- Global switches; a feature with no row is enabled
+CREATE TABLE feature_flags (
+    feature TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    enabled BOOLEAN NOT NULL
+);
+
+-- Per-user grants or revocations set by admins
+CREATE TABLE entitlement_overrides (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    feature TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, feature)
+);
+
+-- +goose Down
+DROP TABLE entitlement_overrides;
+DROP TABLE feature_flags;
+DROP TABLE plan_features;
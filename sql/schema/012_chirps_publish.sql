@@ -0,0 +1,17 @@
+-- +goose Up
+-- Chirps are created pending and published by a background job once their
+-- undo-send window has passed
+ALTER TABLE chirps ADD COLUMN publish_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE chirps ADD COLUMN published_at TIMESTAMP;
+UPDATE chirps SET publish_at = created_at, published_at = created_at;
+
+CREATE INDEX chirps_pending_publish_at_idx ON chirps (publish_at) WHERE published_at IS NULL;
+
+ALTER TABLE plans ADD COLUMN undo_window_seconds INTEGER NOT NULL DEFAULT 20;
+UPDATE plans SET undo_window_seconds = 60 WHERE id IN ('red', 'red_plus');
+
+-- +goose Down
+ALTER TABLE plans DROP COLUMN undo_window_seconds;
+DROP INDEX chirps_pending_publish_at_idx;
+ALTER TABLE chirps DROP COLUMN published_at;
+ALTER TABLE chirps DROP COLUMN publish_at;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Exposures and conversions in A/B experiments. Variants are assigned by
+-- hashing rather than stored, so each event records the one the user was
+-- in at the time.
+CREATE TABLE experiment_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    experiment_key TEXT NOT NULL,
+    variant TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('exposure', 'conversion')),
+    name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX experiment_events_key_idx ON experiment_events (experiment_key, variant, kind, name);
+
+-- +goose Down
+DROP TABLE experiment_events;
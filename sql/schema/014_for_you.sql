@@ -0,0 +1,16 @@
+-- +goose Up
+-- Hashtags a user follows, which the For You feed recommends chirps from
+CREATE TABLE hashtag_follows (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, tag)
+);
+
+-- Users who turn recommendations off get only the accounts they follow in
+-- their For You feed
+ALTER TABLE users ADD COLUMN recommendations BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN recommendations;
+DROP TABLE hashtag_follows;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE chirp_translations (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    body TEXT NOT NULL,
+    source_language TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    PRIMARY KEY (chirp_id, language)
+);
+
+-- +goose Down
+DROP TABLE chirp_translations;
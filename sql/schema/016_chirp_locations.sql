@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps
+    ADD COLUMN latitude DOUBLE PRECISION,
+    ADD COLUMN longitude DOUBLE PRECISION,
+    ADD COLUMN geohash TEXT,
+    ADD COLUMN place TEXT;
+
+-- Nearby search matches geohash prefixes
+CREATE INDEX chirps_geohash_idx ON chirps (geohash text_pattern_ops) WHERE geohash IS NOT NULL;
+
+-- Location is never attached unless the author opts in
+ALTER TABLE users ADD COLUMN share_location BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN share_location;
+DROP INDEX chirps_geohash_idx;
+ALTER TABLE chirps
+    DROP COLUMN place,
+    DROP COLUMN geohash,
+    DROP COLUMN longitude,
+    DROP COLUMN latitude;
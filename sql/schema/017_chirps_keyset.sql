@@ -0,0 +1,6 @@
+-- +goose Up
+-- Keyset pagination walks published chirps in (created_at, id) order
+CREATE INDEX chirps_published_created_at_id_idx ON chirps (created_at, id) WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_published_created_at_id_idx;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Backs GetChirpsByAuthor and author-filtered pages without scanning every chirp
+CREATE INDEX chirps_user_id_created_at_id_idx ON chirps (user_id, created_at, id) WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_created_at_id_idx;
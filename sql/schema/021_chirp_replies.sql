@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN parent_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+-- Cached count of published, undeleted replies
+ALTER TABLE chirps ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX chirps_parent_chirp_id_idx ON chirps (parent_chirp_id, created_at) WHERE parent_chirp_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_parent_chirp_id_idx;
+ALTER TABLE chirps DROP COLUMN reply_count;
+ALTER TABLE chirps DROP COLUMN parent_chirp_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Handles are stored lowercase so @mentions match case-insensitively
+ALTER TABLE users ADD COLUMN handle TEXT UNIQUE;
+
+CREATE TABLE mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX mentions_user_id_idx ON mentions (user_id);
+
+-- +goose Down
+DROP TABLE mentions;
+ALTER TABLE users DROP COLUMN handle;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Conversations are one-to-one. Participants are stored in a canonical order
+-- so each pair of users has exactly one conversation.
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_a_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_b_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_a_id, user_b_id),
+    CHECK (user_a_id < user_b_id)
+);
+
+CREATE INDEX conversations_user_a_idx ON conversations (user_a_id, updated_at DESC);
+CREATE INDEX conversations_user_b_idx ON conversations (user_b_id, updated_at DESC);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX messages_conversation_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversations;
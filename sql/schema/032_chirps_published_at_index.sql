@@ -0,0 +1,6 @@
+-- +goose Up
+-- Supports resuming the live chirp stream from a Last-Event-ID
+CREATE INDEX chirps_published_at_id_idx ON chirps (published_at, id) WHERE published_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_published_at_id_idx;
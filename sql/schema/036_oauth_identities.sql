@@ -0,0 +1,14 @@
+-- +goose Up
+-- Links a provider account (e.g. a Google or GitHub user) to a local user
+CREATE TABLE oauth_identities (
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX oauth_identities_user_id_idx ON oauth_identities (user_id);
+
+-- +goose Down
+DROP TABLE oauth_identities;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Only a hash of each token is stored. Tokens name an address rather than
+-- a user so they can sign up someone who doesn't have an account yet.
+CREATE TABLE magic_link_tokens (
+    token_hash TEXT PRIMARY KEY,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX magic_link_tokens_email_created_at_idx ON magic_link_tokens (email, created_at);
+
+-- +goose Down
+DROP TABLE magic_link_tokens;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Copied into each access token; bumping it invalidates every token issued
+-- before
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN token_version;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Each refresh token is a session the user can see and revoke. The token
+-- itself is a secret, so sessions are identified by a separate ID.
+ALTER TABLE refresh_tokens
+    ADD COLUMN id UUID NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_used_at TIMESTAMP;
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_user_id_idx;
+ALTER TABLE refresh_tokens
+    DROP COLUMN last_used_at,
+    DROP COLUMN ip_address,
+    DROP COLUMN user_agent,
+    DROP COLUMN id;
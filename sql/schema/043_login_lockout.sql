@@ -0,0 +1,11 @@
+-- +goose Up
+-- Failed password logins are counted per account within a window; too many
+-- lock the account until locked_until
+ALTER TABLE users ADD COLUMN failed_login_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN failed_login_window_start TIMESTAMP;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN locked_until;
+ALTER TABLE users DROP COLUMN failed_login_window_start;
+ALTER TABLE users DROP COLUMN failed_login_count;
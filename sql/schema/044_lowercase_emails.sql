@@ -0,0 +1,11 @@
+-- +goose Up
+-- Emails are now lowercased on the way in. Existing mixed-case addresses are
+-- lowercased too, except where that would collide with another account;
+-- those are left for an admin to sort out.
+UPDATE users
+SET email = LOWER(email), updated_at = NOW()
+WHERE email <> LOWER(email)
+    AND (SELECT COUNT(*) FROM users AS other WHERE LOWER(other.email) = LOWER(users.email)) = 1;
+
+-- +goose Down
+-- The original casing isn't kept, so there's nothing to undo
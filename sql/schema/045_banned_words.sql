@@ -0,0 +1,14 @@
+-- +goose Up
+-- Words the profanity filter masks in chirps, managed from the admin API
+CREATE TABLE banned_words (
+    word TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO banned_words (word) VALUES
+    ('kerfuffle'),
+    ('sharbert'),
+    ('fornax');
+
+-- +goose Down
+DROP TABLE banned_words;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Running totals for the admin metrics page, so they survive restarts
+CREATE TABLE hit_counters (
+    name TEXT PRIMARY KEY,
+    count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE hit_counters;
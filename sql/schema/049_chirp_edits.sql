@@ -0,0 +1,6 @@
+-- +goose Up
+-- Set when a Chirpy Red member edits a chirp after posting it
+ALTER TABLE chirps ADD COLUMN edited_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirps DROP COLUMN edited_at;
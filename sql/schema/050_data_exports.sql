@@ -0,0 +1,21 @@
+-- +goose Up
+-- Archives of everything a user has stored, built in the background when
+-- they ask for one and kept until expires_at
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- pending, building, ready or failed
+    status TEXT NOT NULL DEFAULT 'pending',
+    archive BYTEA,
+    error TEXT,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_user_id_idx ON data_exports (user_id, created_at);
+CREATE INDEX data_exports_status_idx ON data_exports (status, created_at);
+
+-- +goose Down
+DROP TABLE data_exports;
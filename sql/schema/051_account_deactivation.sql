@@ -0,0 +1,10 @@
+-- +goose Up
+-- Set while a user has deactivated their account: their profile and chirps
+-- are hidden until they reactivate, or the account is deleted once the
+-- grace period runs out
+ALTER TABLE users ADD COLUMN deactivated_at TIMESTAMP;
+CREATE INDEX users_deactivated_at_idx ON users (deactivated_at) WHERE deactivated_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX users_deactivated_at_idx;
+ALTER TABLE users DROP COLUMN deactivated_at;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- Users flagging chirps for moderators to look at
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+        CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'self_harm', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    -- open until a moderator deals with it
+    status TEXT NOT NULL DEFAULT 'open'
+);
+
+-- A user can have one open report against a chirp at a time
+CREATE UNIQUE INDEX chirp_reports_open_idx ON chirp_reports (reporter_id, chirp_id) WHERE status = 'open';
+CREATE INDEX chirp_reports_status_idx ON chirp_reports (status, created_at);
+CREATE INDEX chirp_reports_chirp_id_idx ON chirp_reports (chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_reports;
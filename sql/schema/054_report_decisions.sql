@@ -0,0 +1,16 @@
+-- +goose Up
+-- What the moderator who resolved a report did about it. decided_by is
+-- NULL for decisions made with the admin API key.
+ALTER TABLE chirp_reports
+    ADD COLUMN decision TEXT
+        CHECK (decision IN ('dismissed', 'chirp_deleted', 'author_suspended')),
+    ADD COLUMN decision_note TEXT NOT NULL DEFAULT '',
+    ADD COLUMN decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN decided_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE chirp_reports
+    DROP COLUMN decided_at,
+    DROP COLUMN decided_by,
+    DROP COLUMN decision_note,
+    DROP COLUMN decision;
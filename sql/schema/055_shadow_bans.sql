@@ -0,0 +1,9 @@
+-- +goose Up
+-- Shadow-banned users' chirps are left out of everyone else's listings,
+-- search results and timelines without the author being told
+ALTER TABLE users ADD COLUMN shadow_banned BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX users_shadow_banned_idx ON users (id) WHERE shadow_banned;
+
+-- +goose Down
+DROP INDEX users_shadow_banned_idx;
+ALTER TABLE users DROP COLUMN shadow_banned;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- What moderators did to other users' content, and why. moderator_id is
+-- NULL for actions taken with the admin API key; chirp_id has no foreign
+-- key so entries outlive purged chirps.
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- chirp_deleted
+    action TEXT NOT NULL,
+    chirp_id UUID NOT NULL,
+    -- The author of the chirp
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX moderation_actions_created_at_idx ON moderation_actions (created_at, id);
+CREATE INDEX moderation_actions_user_id_idx ON moderation_actions (user_id, created_at);
+
+-- +goose Down
+DROP TABLE moderation_actions;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Every successful sign-in. network is the IP address's /24 (or /48 for
+-- IPv6), so a device that moves around one network isn't new each time.
+CREATE TABLE logins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip_address TEXT NOT NULL,
+    network TEXT NOT NULL,
+    user_agent TEXT NOT NULL
+);
+
+CREATE INDEX logins_user_id_idx ON logins (user_id, created_at, id);
+CREATE INDEX logins_device_idx ON logins (user_id, user_agent, network);
+
+-- +goose Down
+DROP TABLE logins;
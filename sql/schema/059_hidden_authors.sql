@@ -0,0 +1,12 @@
+-- +goose Up
+-- The users whose chirps are kept out of other people's reads: deactivated
+-- accounts from everyone, and shadow-banned ones from everyone but
+-- themselves. Queries leave a viewer's hidden authors out with
+--   user_id NOT IN (SELECT id FROM hidden_authors WHERE hidden_from_self OR id IS DISTINCT FROM <viewer>)
+CREATE VIEW hidden_authors AS
+SELECT id, deactivated_at IS NOT NULL AS hidden_from_self
+FROM users
+WHERE deactivated_at IS NOT NULL OR shadow_banned;
+
+-- +goose Down
+DROP VIEW hidden_authors;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- The backup flags a passkey registered with. A passkey can't become
+-- syncable or stop being so, so logins are checked against BE; BS is what
+-- it reported last. BE is NULL for passkeys registered before it was kept
+-- and is filled in by their next login.
+ALTER TABLE webauthn_credentials ADD COLUMN backup_eligible BOOLEAN;
+ALTER TABLE webauthn_credentials ADD COLUMN backup_state BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The session go-webauthn verifies the ceremony's response against, as JSON
+ALTER TABLE webauthn_challenges ADD COLUMN session_data TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE webauthn_challenges DROP COLUMN session_data;
+ALTER TABLE webauthn_credentials DROP COLUMN backup_state;
+ALTER TABLE webauthn_credentials DROP COLUMN backup_eligible;
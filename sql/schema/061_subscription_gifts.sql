@@ -0,0 +1,8 @@
+-- +goose Up
+-- Each paid billing period comes with one gift of Chirpy Red for another
+-- user. Gifts and promo codes don't grant one, so subscribers can't keep
+-- gifting each other.
+ALTER TABLE subscriptions ADD COLUMN gift_available BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN gift_available;
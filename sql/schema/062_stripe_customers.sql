@@ -0,0 +1,11 @@
+-- +goose Up
+-- The Stripe customer a user became at their first checkout, so later
+-- checkouts reuse it instead of creating another
+CREATE TABLE stripe_customers (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    customer_id TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE stripe_customers;
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Filenames like app.3f9a1c2e.js carry a content hash, so a changed file
+// gets a new name and the old one can be cached forever
+var hashedFilenamePattern = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[A-Za-z0-9]+$`)
+
+// staticCachePolicy is how long browsers may reuse fileserver responses
+type staticCachePolicy struct {
+	maxAge       time.Duration
+	hashedMaxAge time.Duration
+}
+
+// cacheControl picks the Cache-Control for a request path, and how long
+// it lets the response be reused. Pages are always revalidated so a deploy
+// shows up at once; the assets they link to are cached.
+func (policy staticCachePolicy) cacheControl(urlPath string) (string, time.Duration) {
+	name := path.Base(urlPath)
+	switch {
+	case strings.HasSuffix(urlPath, "/") || strings.HasSuffix(name, ".html"):
+		return "no-cache", 0
+	case hashedFilenamePattern.MatchString(name):
+		return fmt.Sprintf("public, max-age=%d, immutable", int(policy.hashedMaxAge.Seconds())), policy.hashedMaxAge
+	case policy.maxAge == 0:
+		return "no-cache", 0
+	default:
+		return fmt.Sprintf("public, max-age=%d", int(policy.maxAge.Seconds())), policy.maxAge
+	}
+}
+
+// middlewareStaticCache adds Cache-Control and Expires to successful
+// fileserver responses. Errors aren't cached, so a missing file that gets
+// deployed later isn't stuck as a 404.
+func (policy staticCachePolicy) middlewareStaticCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cacheControl, maxAge := policy.cacheControl(r.URL.Path)
+		next.ServeHTTP(&cacheHeaderWriter{
+			ResponseWriter: w,
+			cacheControl:   cacheControl,
+			maxAge:         maxAge,
+		}, r)
+	})
+}
+
+// cacheHeaderWriter sets the caching headers once the status is known
+type cacheHeaderWriter struct {
+	http.ResponseWriter
+	cacheControl string
+	maxAge       time.Duration
+	wroteHeader  bool
+}
+
+func (cw *cacheHeaderWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if status == 200 || status == 206 || status == 304 {
+			cw.Header().Set("Cache-Control", cw.cacheControl)
+			if cw.maxAge > 0 {
+				cw.Header().Set("Expires", time.Now().Add(cw.maxAge).UTC().Format(http.TimeFormat))
+			}
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *cacheHeaderWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(200)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (cw *cacheHeaderWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	// chirpyRedPeriod is how long one upgrade event extends a subscription
+	// when the provider doesn't send an explicit expiry
+	chirpyRedPeriod = 30 * 24 * time.Hour
+
+	// subscriptionExpiryInterval is how often lapsed subscriptions are swept
+	subscriptionExpiryInterval = time.Minute
+
+	subscriptionEventUpgraded   = "upgraded"
+	subscriptionEventRenewed    = "renewed"
+	subscriptionEventDowngraded = "downgraded"
+	subscriptionEventExpired    = "expired"
+	subscriptionEventGifted     = "gifted"
+	subscriptionEventPromo      = "promo"
+
+	subscriptionSourceSystem = "system"
+	subscriptionSourceGift   = "gift"
+	subscriptionSourcePromo  = "promo"
+
+	notificationSubscriptionExpired = "subscription.expired"
+	notificationSubscriptionGifted  = "subscription.gifted"
+)
+
+// errUnknownPlan is returned when a billing event names a plan we don't sell
+var errUnknownPlan = errors.New("unknown subscription plan")
+
+// subscriptionChange describes a move onto a paid plan
+type subscriptionChange struct {
+	UserID uuid.UUID
+	// Plan defaults to Chirpy Red when empty
+	Plan string
+	// ExpiresAt is an explicit expiry from the billing provider. When nil the
+	// subscription is extended by Period instead.
+	ExpiresAt *time.Time
+	Period    time.Duration
+	// KeepHigherPlan stops grants such as gifts from moving a user onto a
+	// lower tier than the one they already pay for
+	KeepHigherPlan bool
+
+	Kind           string
+	Source         string
+	WebhookEventID uuid.NullUUID
+	ActorUserID    uuid.NullUUID
+	PromoCode      sql.NullString
+}
+
+// upgradeChirpyRed moves a user onto a paid plan and records the transition.
+// It is shared by every billing provider, promo codes and gifts so they all
+// map onto the same subscription model.
+func upgradeChirpyRed(ctx context.Context, q store.Store, change subscriptionChange) error {
+	if change.Plan == "" {
+		change.Plan = planRed
+	}
+	if change.Plan == planFree {
+		return errUnknownPlan
+	}
+	if change.Period == 0 {
+		change.Period = chirpyRedPeriod
+	}
+	if change.Kind == "" {
+		change.Kind = subscriptionEventUpgraded
+	}
+
+	_, err := q.GetPlanByID(ctx, change.Plan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errUnknownPlan
+	}
+	if err != nil {
+		return err
+	}
+
+	// Extend from the current expiry when the subscription is still running
+	newExpiry := time.Now().UTC().Add(change.Period)
+	existing, err := q.GetSubscriptionByUserID(ctx, change.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	active := err == nil && existing.Status == "active" && existing.ExpiresAt.After(time.Now().UTC())
+	if active {
+		newExpiry = existing.ExpiresAt.Add(change.Period)
+		if change.KeepHigherPlan && planRank[existing.Plan] > planRank[change.Plan] {
+			change.Plan = existing.Plan
+		}
+	}
+	if change.ExpiresAt != nil {
+		newExpiry = change.ExpiresAt.UTC()
+	}
+
+	// A billing provider paying for a new period grants that period's gift;
+	// anything else leaves an unused one in place
+	giftAvailable := active && existing.GiftAvailable
+	if change.WebhookEventID.Valid && (!active || newExpiry.After(existing.ExpiresAt)) {
+		giftAvailable = true
+	}
+
+	updated, err := q.SetUserPlan(ctx, database.SetUserPlanParams{
+		ID:   change.UserID,
+		Plan: change.Plan,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errUserNotFound
+	}
+
+	_, err = q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+		UserID:        change.UserID,
+		Plan:          change.Plan,
+		ExpiresAt:     newExpiry,
+		GiftAvailable: giftAvailable,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+		UserID:         change.UserID,
+		Kind:           change.Kind,
+		Source:         change.Source,
+		WebhookEventID: change.WebhookEventID,
+		ActorUserID:    change.ActorUserID,
+		PromoCode:      change.PromoCode,
+	})
+	if err != nil || !change.WebhookEventID.Valid {
+		return err
+	}
+
+	// A billing provider's say-so is enough to hand out a paid plan, so
+	// those go in the audit log too
+	return q.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		Action:  auditWebhookUpgrade,
+		UserID:  uuid.NullUUID{UUID: change.UserID, Valid: true},
+		Details: fmt.Sprintf("%s %s to %s, event %s", change.Source, change.Kind, change.Plan, change.WebhookEventID.UUID),
+	})
+}
+
+// downgradeChirpyRed revokes paid plans immediately and records the transition
+func downgradeChirpyRed(ctx context.Context, q store.Store, userID uuid.UUID, source string, webhookEventID uuid.NullUUID) error {
+	updated, err := q.SetUserPlan(ctx, database.SetUserPlanParams{
+		ID:   userID,
+		Plan: planFree,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errUserNotFound
+	}
+
+	err = q.CancelSubscription(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+		UserID:         userID,
+		Kind:           subscriptionEventDowngraded,
+		Source:         source,
+		WebhookEventID: webhookEventID,
+	})
+	return err
+}
+
+// expireLapsedSubscriptions downgrades every user whose subscription has run
+// past its expiry and lets them know. Each is expired in a transaction of
+// its own that re-checks the subscription under a row lock, so a renewal
+// that lands after the sweep found it isn't undone.
+func (cfg *apiConfig) expireLapsedSubscriptions(ctx context.Context) error {
+	lapsed, err := cfg.db.GetLapsedSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	expired := 0
+	for _, userID := range lapsed {
+		var dbNotification *database.Notification
+		err := cfg.withTx(ctx, func(q store.Store) error {
+			subscription, err := q.LockSubscription(ctx, userID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if subscription.Status != "active" || subscription.ExpiresAt.After(time.Now().UTC()) {
+				return nil
+			}
+
+			err = q.ExpireSubscription(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.SetUserPlan(ctx, database.SetUserPlanParams{
+				ID:   userID,
+				Plan: planFree,
+			})
+			if err != nil {
+				return err
+			}
+
+			_, err = q.CreateSubscriptionEvent(ctx, database.CreateSubscriptionEventParams{
+				UserID: userID,
+				Kind:   subscriptionEventExpired,
+				Source: subscriptionSourceSystem,
+			})
+			if err != nil {
+				return err
+			}
+
+			notification, err := q.CreateNotification(ctx, database.CreateNotificationParams{
+				UserID:  userID,
+				Kind:    notificationSubscriptionExpired,
+				Message: "Your Chirpy Red membership has expired.",
+			})
+			if err != nil {
+				return err
+			}
+			dbNotification = &notification
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if dbNotification != nil {
+			cfg.publishNotification(*dbNotification)
+			expired++
+		}
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d Chirpy Red subscriptions", expired)
+	}
+	return nil
+}
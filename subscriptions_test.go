@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+)
+
+func TestExpireLapsedSubscriptions(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+	bob := api.signUp("bob")
+
+	upgrades := map[testUser]time.Time{
+		alice: time.Now().Add(-time.Hour),
+		bob:   time.Now().Add(time.Hour),
+	}
+	for user, expiresAt := range upgrades {
+		body := fmt.Sprintf(`{"event":"user.upgraded","data":{"user_id":%q,"expires_at":%q}}`, user.ID, expiresAt.UTC().Format(time.RFC3339))
+		rec := api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, body)
+		if rec.Code != 204 {
+			t.Fatalf("Expected 204 upgrading, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	// A second sweep finds nothing left to expire
+	for range 2 {
+		err := api.cfg.expireLapsedSubscriptions(context.Background())
+		if err != nil {
+			t.Fatalf("Expected the sweep to succeed, got %v", err)
+		}
+	}
+
+	for user, wantRed := range map[testUser]bool{alice: false, bob: true} {
+		dbUser, err := api.cfg.db.GetUserByID(context.Background(), user.ID)
+		if err != nil || dbUser.IsChirpyRed != wantRed {
+			t.Errorf("Expected Chirpy Red to be %v for %s, got %v (%v)", wantRed, user.ID, dbUser.IsChirpyRed, err)
+		}
+	}
+	history, err := api.cfg.db.GetSubscriptionEventsForUser(context.Background(), alice.ID)
+	if err != nil || len(history) != 2 || !slices.ContainsFunc(history, func(event database.GetSubscriptionEventsForUserRow) bool {
+		return event.Kind == subscriptionEventExpired
+	}) {
+		t.Errorf("Expected the upgrade and one expiry, got %v (%v)", history, err)
+	}
+}
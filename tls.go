@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/Utkarsh736/chirpy/internal/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsSetup is how the server terminates TLS: from a certificate on disk,
+// from Let's Encrypt through autocert, or not at all
+type tlsSetup struct {
+	certFile string
+	keyFile  string
+	autocert *autocert.Manager
+	// redirectAddr is where plain HTTP is redirected to HTTPS, "" for
+	// nowhere
+	redirectAddr string
+}
+
+// newTLSSetup prepares the TLS configured by conf
+func newTLSSetup(conf config.TLS) tlsSetup {
+	setup := tlsSetup{
+		certFile:     conf.CertFile,
+		keyFile:      conf.KeyFile,
+		redirectAddr: conf.RedirectAddr,
+	}
+	if len(conf.AutocertDomains) > 0 {
+		setup.autocert = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(conf.AutocertDomains...),
+			Cache:      autocert.DirCache(conf.AutocertCacheDir),
+			Email:      conf.AutocertEmail,
+		}
+	}
+	return setup
+}
+
+func (setup tlsSetup) enabled() bool {
+	return setup.certFile != "" || setup.autocert != nil
+}
+
+// serve serves HTTPS on listener
+func (setup tlsSetup) serve(server *http.Server, listener net.Listener) error {
+	if setup.autocert != nil {
+		server.TLSConfig = setup.autocert.TLSConfig()
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.ServeTLS(listener, setup.certFile, setup.keyFile)
+}
+
+// redirectServer is the plain HTTP listener sending clients to the HTTPS
+// server on tlsAddr. With autocert it also answers Let's Encrypt's HTTP
+// challenges.
+func (setup tlsSetup) redirectServer(tlsAddr string) *http.Server {
+	var handler http.Handler = redirectToHTTPS(tlsAddr)
+	if setup.autocert != nil {
+		handler = setup.autocert.HTTPHandler(handler)
+	}
+	return &http.Server{
+		Addr:    setup.redirectAddr,
+		Handler: handler,
+	}
+}
+
+// redirectToHTTPS permanently redirects to the same URL over HTTPS on the
+// port tlsAddr listens on
+func redirectToHTTPS(tlsAddr string) http.HandlerFunc {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		// 308 rather than 301 so clients repeat the method and body
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), 308)
+	}
+}
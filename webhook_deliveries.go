@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookDeliveryPending   = "pending"
+	webhookDeliveryDelivered = "delivered"
+	webhookDeliveryDead      = "dead"
+)
+
+const (
+	webhookDeliveryInterval = time.Second
+	webhookDeliveryWorkers  = 4
+	webhookDeliveryBatch    = 20
+	// webhookDeliveryLease is how long a claimed batch has before another
+	// instance may take it over, well past the workers getting through a
+	// batch of attempts that all time out
+	webhookDeliveryLease = 5 * time.Minute
+
+	// webhookDeliveryRetention is how long a delivered webhook is kept for
+	// inspection. Dead ones are kept until they're replayed or their
+	// endpoint is deleted.
+	webhookDeliveryRetention     = 7 * 24 * time.Hour
+	webhookDeliveryPurgeInterval = time.Hour
+)
+
+type WebhookDelivery struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	EndpointID uuid.UUID       `json:"endpoint_id"`
+	EventID    uuid.UUID       `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     string          `json:"status"`
+	Attempts   int32           `json:"attempts"`
+	// NextAttemptAt is only set while the delivery is pending
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+func webhookDeliveryFromDB(dbDelivery database.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:         dbDelivery.ID,
+		CreatedAt:  dbDelivery.CreatedAt,
+		UpdatedAt:  dbDelivery.UpdatedAt,
+		EndpointID: dbDelivery.EndpointID,
+		EventID:    dbDelivery.EventID,
+		EventType:  dbDelivery.EventType,
+		Payload:    dbDelivery.Payload,
+		Status:     dbDelivery.Status,
+		Attempts:   dbDelivery.Attempts,
+		LastError:  dbDelivery.LastError.String,
+	}
+	if dbDelivery.Status == webhookDeliveryPending {
+		delivery.NextAttemptAt = &dbDelivery.NextAttemptAt
+	}
+	if dbDelivery.DeliveredAt.Valid {
+		delivery.DeliveredAt = &dbDelivery.DeliveredAt.Time
+	}
+	return delivery
+}
+
+// deliverWebhooks claims the deliveries that are due and attempts them,
+// recording each outcome. Failures are retried with webhooks.Backoff until
+// webhooks.MaxAttempts, then dead-lettered.
+func (cfg *apiConfig) deliverWebhooks(ctx context.Context) error {
+	due, err := cfg.db.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil:    time.Now().Add(webhookDeliveryLease),
+		MaxDeliveries: webhookDeliveryBatch,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The client bounds each attempt, and attempts under way at shutdown
+	// finish rather than being counted as failures
+	ctx = context.WithoutCancel(ctx)
+
+	endpoints := map[uuid.UUID]database.WebhookEndpoint{}
+	sem := make(chan struct{}, webhookDeliveryWorkers)
+	var wg sync.WaitGroup
+	for _, dbDelivery := range due {
+		endpoint, ok := endpoints[dbDelivery.EndpointID]
+		if !ok {
+			endpoint, err = cfg.db.GetWebhookEndpoint(ctx, dbDelivery.EndpointID)
+			if err != nil {
+				// Deleting the endpoint deletes its deliveries, so this is
+				// transient; the lease running out retries it
+				log.Printf("Failed to look up webhook endpoint %s: %v", dbDelivery.EndpointID, err)
+				continue
+			}
+			endpoints[endpoint.ID] = endpoint
+		}
+		if !endpoint.Active {
+			cfg.recordWebhookDelivery(ctx, dbDelivery, errors.New("webhook endpoint is inactive"), true)
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(dbDelivery database.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := cfg.webhookClient.Deliver(ctx, webhooks.Delivery{
+				URL:       endpoint.Url,
+				Secret:    endpoint.Secret,
+				EventID:   dbDelivery.EventID,
+				EventType: dbDelivery.EventType,
+				Payload:   dbDelivery.Payload,
+			})
+			cfg.recordWebhookDelivery(ctx, dbDelivery, err, false)
+		}(dbDelivery)
+	}
+	wg.Wait()
+	return nil
+}
+
+// recordWebhookDelivery stores the outcome of an attempt: delivered if
+// deliveryErr is nil, otherwise scheduled for a retry, or dead once it's
+// out of attempts or dead is set
+func (cfg *apiConfig) recordWebhookDelivery(ctx context.Context, dbDelivery database.WebhookDelivery, deliveryErr error, dead bool) {
+	if deliveryErr == nil {
+		err := cfg.db.MarkWebhookDeliveryDelivered(ctx, dbDelivery.ID)
+		if err != nil {
+			log.Printf("Failed to record webhook delivery %s: %v", dbDelivery.ID, err)
+		}
+		return
+	}
+
+	attempts := int(dbDelivery.Attempts) + 1
+	status := webhookDeliveryPending
+	nextAttempt := time.Now().Add(webhooks.Backoff(attempts))
+	if dead || attempts >= webhooks.MaxAttempts {
+		status = webhookDeliveryDead
+		nextAttempt = time.Now()
+		log.Printf("Webhook delivery %s to endpoint %s dead-lettered after %d attempts: %v", dbDelivery.ID, dbDelivery.EndpointID, attempts, deliveryErr)
+	}
+	err := cfg.db.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+		ID:            dbDelivery.ID,
+		Status:        status,
+		LastError:     sql.NullString{String: deliveryErr.Error(), Valid: true},
+		NextAttemptAt: nextAttempt,
+	})
+	if err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", dbDelivery.ID, err)
+	}
+}
+
+// purgeDeliveredWebhookDeliveries drops delivered webhooks past their
+// retention
+func (cfg *apiConfig) purgeDeliveredWebhookDeliveries(ctx context.Context) error {
+	_, err := cfg.db.DeleteDeliveredWebhookDeliveriesBefore(ctx, time.Now().Add(-webhookDeliveryRetention))
+	return err
+}
+
+// respondWithWebhookDeliveries lists the newest deliveries, filtered by
+// ?status= and endpointID when it's set, up to ?limit=
+func (cfg *apiConfig) respondWithWebhookDeliveries(w http.ResponseWriter, r *http.Request, endpointID uuid.NullUUID) {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 500 {
+			respondWithError(w, 400, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	dbDeliveries, err := cfg.db.ListWebhookDeliveries(r.Context(), database.ListWebhookDeliveriesParams{
+		Limit:      int32(limit),
+		Status:     optionalString(r.URL.Query().Get("status")),
+		EndpointID: endpointID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve webhook deliveries")
+		return
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, dbDelivery := range dbDeliveries {
+		deliveries = append(deliveries, webhookDeliveryFromDB(dbDelivery))
+	}
+
+	respondWithJSON(w, 200, deliveries)
+}
+
+func (cfg *apiConfig) handlerListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	endpointID := uuid.NullUUID{}
+	if endpointStr := r.URL.Query().Get("endpoint_id"); endpointStr != "" {
+		parsed, err := uuid.Parse(endpointStr)
+		if err != nil {
+			respondWithError(w, 400, "Invalid webhook endpoint ID")
+			return
+		}
+		endpointID = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+
+	cfg.respondWithWebhookDeliveries(w, r, endpointID)
+}
+
+func (cfg *apiConfig) handlerGetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid delivery ID")
+		return
+	}
+
+	dbDelivery, err := cfg.db.GetWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		respondWithError(w, 404, "Webhook delivery not found")
+		return
+	}
+
+	respondWithJSON(w, 200, webhookDeliveryFromDB(dbDelivery))
+}
+
+// handlerReplayWebhookDelivery queues a dead delivery to be tried again,
+// with a full set of attempts. The delivery job picks it up within a
+// second or so.
+func (cfg *apiConfig) handlerReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid delivery ID")
+		return
+	}
+
+	dbDelivery, err := cfg.db.GetWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		respondWithError(w, 404, "Webhook delivery not found")
+		return
+	}
+
+	switch {
+	case dbDelivery.Status == webhookDeliveryPending:
+		respondWithError(w, 409, "Webhook delivery already pending")
+		return
+	// Delivered ones are only sent again on request, e.g. for a receiver
+	// that lost what it was sent
+	case dbDelivery.Status == webhookDeliveryDelivered && r.URL.Query().Get("force") != "true":
+		respondWithError(w, 409, "Webhook delivery already delivered")
+		return
+	}
+
+	dbDelivery, err = cfg.db.ReplayWebhookDelivery(r.Context(), deliveryID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to replay webhook delivery")
+		return
+	}
+
+	respondWithJSON(w, 200, webhookDeliveryFromDB(dbDelivery))
+}
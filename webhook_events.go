@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Utkarsh736/chirpy/internal/database"
+	"github.com/Utkarsh736/chirpy/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSourcePolka  = "polka"
+	webhookSourceStripe = "stripe"
+
+	webhookStatusPending   = "pending"
+	webhookStatusProcessed = "processed"
+	webhookStatusFailed    = "failed"
+)
+
+const (
+	// webhookEventRetention is how long a processed event is kept, and so
+	// how long a redelivery of it is recognised. Failed events are kept
+	// until they're replayed.
+	webhookEventRetention     = 30 * 24 * time.Hour
+	webhookEventPurgeInterval = time.Hour
+)
+
+// errUserNotFound is returned when a subscription change targets an unknown user
+var errUserNotFound = errors.New("webhook user not found")
+
+// errWebhookEventProcessed is returned when another delivery of an event
+// has already applied it
+var errWebhookEventProcessed = errors.New("webhook event already processed")
+
+// polkaSignatureHeader carries Polka's HMAC of the body when
+// POLKA_SIGNING_SECRET is set
+const polkaSignatureHeader = "Polka-Signature"
+
+// polkaEvent is the payload Polka sends to POST /api/polka/webhooks
+type polkaEvent struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  struct {
+		UserID    uuid.UUID  `json:"user_id"`
+		Plan      string     `json:"plan"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	} `json:"data"`
+}
+
+// eventID returns Polka's event ID. Without one a redelivery can't be told
+// apart from a new event with the same payload, like a second upgrade after
+// a downgrade, so each delivery gets an ID of its own and is applied.
+func (e polkaEvent) eventID() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return "delivery:" + uuid.NewString()
+}
+
+type WebhookEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Source      string          `json:"source"`
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+func webhookEventFromDB(dbEvent database.WebhookEvent) WebhookEvent {
+	event := WebhookEvent{
+		ID:        dbEvent.ID,
+		CreatedAt: dbEvent.CreatedAt,
+		UpdatedAt: dbEvent.UpdatedAt,
+		Source:    dbEvent.Source,
+		EventID:   dbEvent.EventID,
+		EventType: dbEvent.EventType,
+		Payload:   dbEvent.Payload,
+		Status:    dbEvent.Status,
+		Error:     dbEvent.Error.String,
+		Attempts:  dbEvent.Attempts,
+	}
+	if dbEvent.ProcessedAt.Valid {
+		event.ProcessedAt = &dbEvent.ProcessedAt.Time
+	}
+	return event
+}
+
+// recordWebhookEvent stores an incoming event, or returns the existing row if
+// this (source, event ID) pair has been delivered before
+func (cfg *apiConfig) recordWebhookEvent(ctx context.Context, source, eventID, eventType string, payload []byte) (database.WebhookEvent, error) {
+	dbEvent, err := cfg.db.CreateWebhookEvent(ctx, database.CreateWebhookEventParams{
+		Source:    source,
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// ON CONFLICT DO NOTHING returns no row for duplicates
+		return cfg.db.GetWebhookEventBySourceAndEventID(ctx, database.GetWebhookEventBySourceAndEventIDParams{
+			Source:  source,
+			EventID: eventID,
+		})
+	}
+	return dbEvent, err
+}
+
+// purgeProcessedWebhookEvents drops processed events past their retention
+func (cfg *apiConfig) purgeProcessedWebhookEvents(ctx context.Context) error {
+	_, err := cfg.db.DeleteProcessedWebhookEventsBefore(ctx, time.Now().Add(-webhookEventRetention))
+	return err
+}
+
+// processWebhookEvent applies a stored event in a transaction and records the
+// outcome on it. The event is claimed in the same transaction, so it's
+// applied once however many deliveries of it arrive together; the others
+// get errWebhookEventProcessed. reprocess applies a processed event again.
+func (cfg *apiConfig) processWebhookEvent(ctx context.Context, dbEvent database.WebhookEvent, reprocess bool) error {
+	err := cfg.withTx(ctx, func(q store.Store) error {
+		_, err := q.ClaimWebhookEvent(ctx, database.ClaimWebhookEventParams{
+			ID:        dbEvent.ID,
+			Reprocess: reprocess,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return errWebhookEventProcessed
+		}
+		if err != nil {
+			return err
+		}
+
+		switch dbEvent.Source {
+		case webhookSourcePolka:
+			err = applyPolkaEvent(ctx, q, dbEvent)
+		case webhookSourceStripe:
+			err = applyStripeEvent(ctx, q, dbEvent)
+		default:
+			err = fmt.Errorf("unknown webhook source %q", dbEvent.Source)
+		}
+		if err != nil {
+			return err
+		}
+		return q.MarkWebhookEventProcessed(ctx, dbEvent.ID)
+	})
+	if errors.Is(err, errWebhookEventProcessed) {
+		return err
+	}
+
+	if err != nil {
+		markErr := cfg.db.MarkWebhookEventFailed(ctx, database.MarkWebhookEventFailedParams{
+			ID:    dbEvent.ID,
+			Error: sql.NullString{String: err.Error(), Valid: true},
+		})
+		if markErr != nil {
+			return markErr
+		}
+		return err
+	}
+	return nil
+}
+
+func applyPolkaEvent(ctx context.Context, q store.Store, dbEvent database.WebhookEvent) error {
+	event := polkaEvent{}
+	err := json.Unmarshal(dbEvent.Payload, &event)
+	if err != nil {
+		return err
+	}
+
+	webhookEventID := uuid.NullUUID{UUID: dbEvent.ID, Valid: true}
+	switch event.Event {
+	case "user.upgraded", "user.renewed":
+		// A renewal extends the subscription just as an upgrade does, and is
+		// only told apart in the history
+		kind := subscriptionEventUpgraded
+		if event.Event == "user.renewed" {
+			kind = subscriptionEventRenewed
+		}
+		return upgradeChirpyRed(ctx, q, subscriptionChange{
+			UserID:         event.Data.UserID,
+			Plan:           event.Data.Plan,
+			ExpiresAt:      event.Data.ExpiresAt,
+			Kind:           kind,
+			Source:         dbEvent.Source,
+			WebhookEventID: webhookEventID,
+		})
+	case "user.downgraded":
+		return downgradeChirpyRed(ctx, q, event.Data.UserID, dbEvent.Source, webhookEventID)
+	}
+	// Other event types are acknowledged and ignored
+	return nil
+}
+
+func (cfg *apiConfig) handlerListWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 500 {
+			respondWithError(w, 400, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondWithError(w, 400, "Invalid date range")
+		return
+	}
+
+	query := r.URL.Query()
+	dbEvents, err := cfg.db.ListWebhookEvents(r.Context(), database.ListWebhookEventsParams{
+		Limit:       int32(limit),
+		Status:      optionalString(query.Get("status")),
+		Source:      optionalString(query.Get("source")),
+		EventType:   optionalString(query.Get("type")),
+		CreatedFrom: from,
+		CreatedTo:   to,
+	})
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve webhook events")
+		return
+	}
+
+	events := []WebhookEvent{}
+	for _, dbEvent := range dbEvents {
+		events = append(events, webhookEventFromDB(dbEvent))
+	}
+
+	respondWithJSON(w, 200, events)
+}
+
+func (cfg *apiConfig) handlerGetWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid event ID")
+		return
+	}
+
+	dbEvent, err := cfg.db.GetWebhookEventByID(r.Context(), eventID)
+	if err != nil {
+		respondWithError(w, 404, "Webhook event not found")
+		return
+	}
+
+	respondWithJSON(w, 200, webhookEventFromDB(dbEvent))
+}
+
+func (cfg *apiConfig) handlerReplayWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(r.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, 400, "Invalid event ID")
+		return
+	}
+
+	dbEvent, err := cfg.db.GetWebhookEventByID(r.Context(), eventID)
+	if err != nil {
+		respondWithError(w, 404, "Webhook event not found")
+		return
+	}
+
+	// Processed events are only replayed on request, e.g. after fixing a
+	// handler bug that applied them incorrectly
+	force := r.URL.Query().Get("force") == "true"
+	if dbEvent.Status == webhookStatusProcessed && !force {
+		respondWithError(w, 409, "Webhook event already processed")
+		return
+	}
+
+	err = cfg.processWebhookEvent(r.Context(), dbEvent, force)
+	if errors.Is(err, errWebhookEventProcessed) {
+		respondWithError(w, 409, "Webhook event already processed")
+		return
+	}
+	if err != nil && !errors.Is(err, errUserNotFound) {
+		respondWithError(w, 500, "Failed to process webhook event")
+		return
+	}
+
+	// Return the event with its new status, failed or processed
+	dbEvent, err = cfg.db.GetWebhookEventByID(r.Context(), eventID)
+	if err != nil {
+		respondWithError(w, 500, "Failed to retrieve webhook event")
+		return
+	}
+
+	respondWithJSON(w, 200, webhookEventFromDB(dbEvent))
+}
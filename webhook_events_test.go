@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func polkaUpgrade(eventID string, userID uuid.UUID) string {
+	return fmt.Sprintf(`{"id":%q,"event":"user.upgraded","data":{"user_id":%q}}`, eventID, userID)
+}
+
+func TestPolkaWebhook(t *testing.T) {
+	api := newTestAPI(t, nil)
+	alice := api.signUp("alice")
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		wantStatus    int
+	}{
+		{"no API key", "", polkaUpgrade("evt_1", alice.ID), 401},
+		{"wrong API key", "ApiKey wrong", polkaUpgrade("evt_1", alice.ID), 401},
+		{"malformed body", "ApiKey " + testPolkaKey, "{", 400},
+		{"unknown user", "ApiKey " + testPolkaKey, polkaUpgrade("evt_2", uuid.New()), 404},
+		{"ignored event", "ApiKey " + testPolkaKey, `{"id":"evt_3","event":"user.payment_failed","data":{}}`, 204},
+	}
+	for _, tt := range tests {
+		rec := api.do("POST", "/api/polka/webhooks", tt.authorization, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.wantStatus, rec.Code, rec.Body)
+		}
+	}
+	user, err := api.cfg.db.GetUserByID(context.Background(), alice.ID)
+	if err != nil || user.IsChirpyRed {
+		t.Fatalf("Expected alice still on the free plan, got %v (%v)", user.IsChirpyRed, err)
+	}
+
+	rec := api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, polkaUpgrade("evt_4", alice.ID))
+	if rec.Code != 204 {
+		t.Fatalf("Expected 204 upgrading, got %d: %s", rec.Code, rec.Body)
+	}
+	user, err = api.cfg.db.GetUserByID(context.Background(), alice.ID)
+	if err != nil || !user.IsChirpyRed {
+		t.Fatalf("Expected alice upgraded, got %v (%v)", user.IsChirpyRed, err)
+	}
+
+	// Red lifts the free plan's edit window
+	chirp := api.createChirp(alice, "Hello, world!")
+	rec = api.do("PUT", "/api/chirps/"+chirp.ID.String(), bearer(alice.Token), map[string]string{"body": "Hello again"})
+	if rec.Code != 200 {
+		t.Errorf("Expected 200 editing on Chirpy Red, got %d: %s", rec.Code, rec.Body)
+	}
+
+	// A redelivery of the same event is acknowledged without applying it
+	// again, while a new event without an ID is applied
+	history, err := api.cfg.db.GetSubscriptionEventsForUser(context.Background(), alice.ID)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("Expected one subscription event, got %v (%v)", history, err)
+	}
+	deliveries := []string{
+		polkaUpgrade("evt_4", alice.ID),
+		fmt.Sprintf(`{"event":"user.renewed","data":{"user_id":%q}}`, alice.ID),
+	}
+	for _, body := range deliveries {
+		rec = api.do("POST", "/api/polka/webhooks", "ApiKey "+testPolkaKey, body)
+		if rec.Code != 204 {
+			t.Errorf("Expected 204 for %s, got %d: %s", body, rec.Code, rec.Body)
+		}
+	}
+	history, err = api.cfg.db.GetSubscriptionEventsForUser(context.Background(), alice.ID)
+	if err != nil || len(history) != 2 {
+		t.Errorf("Expected the renewal and not the redelivery to be recorded, got %v (%v)", history, err)
+	}
+}